@@ -0,0 +1,63 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// ContentLibrarySyncStatusAnnotation is the annotation on a ContentLibraryProvider whose value is the JSON
+	// encoded ContentLibrarySyncStatus of the last time its images were synced.
+	ContentLibrarySyncStatusAnnotation = "vmoperator.vmware.com/sync-status"
+)
+
+const (
+	// ContentLibrarySyncedCondition documents whether the VirtualMachineImages of the content library are in sync
+	// with its library items.
+	ContentLibrarySyncedCondition vmopv1alpha1.ConditionType = "ContentLibrarySynced"
+
+	// ContentLibraryListFailedReason (Severity=Error) documents that the library items could not be listed, so no
+	// VirtualMachineImages were synced.
+	ContentLibraryListFailedReason = "ListFailed"
+	// ContentLibraryItemsFailedReason (Severity=Warning) documents that some library items could not be converted
+	// to VirtualMachineImages. The other items were synced.
+	ContentLibraryItemsFailedReason = "ItemsFailed"
+	// ContentLibraryImagesFailedReason (Severity=Error) documents that some VirtualMachineImages could not be
+	// created, updated or deleted.
+	ContentLibraryImagesFailedReason = "ImagesFailed"
+)
+
+// ContentLibraryItemSyncError describes a library item that could not be synced.
+type ContentLibraryItemSyncError struct {
+	// ItemID is the identifier of the library item.
+	ItemID string `json:"itemID"`
+
+	// ItemName is the name of the library item.
+	// +optional
+	ItemName string `json:"itemName,omitempty"`
+
+	// Message describes why the library item could not be synced.
+	Message string `json:"message"`
+}
+
+// ContentLibrarySyncStatus describes the last sync of the VirtualMachineImages of a content library. It is
+// recorded on the ContentLibraryProvider in the ContentLibrarySyncStatusAnnotation annotation.
+type ContentLibrarySyncStatus struct {
+	// LastSyncTime is when the images were last synced.
+	LastSyncTime metav1.Time `json:"lastSyncTime"`
+
+	// ItemCount is the number of library items that were synced to VirtualMachineImages.
+	ItemCount int32 `json:"itemCount"`
+
+	// ItemErrors are the library items that could not be synced.
+	// +optional
+	ItemErrors []ContentLibraryItemSyncError `json:"itemErrors,omitempty"`
+
+	// Conditions describes the result of the sync.
+	// +optional
+	Conditions vmopv1alpha1.Conditions `json:"conditions,omitempty"`
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// VirtualMachineClassAvailabilityAnnotation is the annotation on a VirtualMachineClass whose value is the JSON
+	// encoded VirtualMachineClassAvailability of the last time the class was checked.
+	VirtualMachineClassAvailabilityAnnotation = "vmoperator.vmware.com/class-availability"
+)
+
+const (
+	// VirtualMachineClassSchedulableCondition documents whether VMs of the class can be realized. On the
+	// VirtualMachineClassAvailability it is true when VMs can be realized in at least one availability zone.
+	VirtualMachineClassSchedulableCondition vmopv1alpha1.ConditionType = "VirtualMachineClassSchedulable"
+
+	// VirtualMachineClassVGPUProfileNotFoundReason (Severity=Error) documents that no host of the cluster has
+	// a vGPU profile of the class.
+	VirtualMachineClassVGPUProfileNotFoundReason = "VGPUProfileNotFound"
+	// VirtualMachineClassDirectPathDeviceNotFoundReason (Severity=Error) documents that no host of the cluster
+	// has a Dynamic DirectPath I/O device of the class.
+	VirtualMachineClassDirectPathDeviceNotFoundReason = "DirectPathDeviceNotFound"
+	// VirtualMachineClassHardwareVersionNotSupportedReason (Severity=Error) documents that the cluster cannot
+	// create VMs with the virtual hardware version that the devices of the class require.
+	VirtualMachineClassHardwareVersionNotSupportedReason = "HardwareVersionNotSupported"
+	// VirtualMachineClassInsufficientCapacityReason (Severity=Warning) documents that no namespace bound to the
+	// class has enough unreserved CPU and memory in its resource pool for another VM of the class.
+	VirtualMachineClassInsufficientCapacityReason = "InsufficientCapacity"
+	// VirtualMachineClassNotSchedulableReason (Severity=Error) documents that VMs of the class cannot be realized
+	// in any availability zone.
+	VirtualMachineClassNotSchedulableReason = "NotSchedulable"
+)
+
+// VirtualMachineClassNamespaceCapacity describes how many more VMs of the class fit in the resource pool of a
+// namespace bound to the class.
+type VirtualMachineClassNamespaceCapacity struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+
+	// AvailableVMs is the number of VMs of the class whose CPU and memory reservations fit in the unreserved
+	// capacity of the namespace's resource pool. It is not set when the class has no reservations, so the
+	// number of VMs is not limited by reservations.
+	// +optional
+	AvailableVMs *int32 `json:"availableVMs,omitempty"`
+}
+
+// VirtualMachineClassZoneAvailability describes whether VMs of the class can be realized in an availability zone.
+type VirtualMachineClassZoneAvailability struct {
+	// Zone is the name of the availability zone.
+	Zone string `json:"zone"`
+
+	// Namespaces are the capacities of the namespaces in the zone that are bound to the class.
+	// +optional
+	Namespaces []VirtualMachineClassNamespaceCapacity `json:"namespaces,omitempty"`
+
+	// Conditions describes whether VMs of the class can be realized in the zone.
+	// +optional
+	Conditions vmopv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+// VirtualMachineClassAvailability describes the last check of whether VMs of a class can be realized. It is
+// recorded on the VirtualMachineClass in the VirtualMachineClassAvailabilityAnnotation annotation.
+type VirtualMachineClassAvailability struct {
+	// LastCheckTime is when the class was last checked and found to have a different availability than
+	// recorded. Checks that find the same availability are not recorded.
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
+
+	// Zones are the availability of the class in each availability zone.
+	// +optional
+	Zones []VirtualMachineClassZoneAvailability `json:"zones,omitempty"`
+
+	// Conditions summarizes the availability of the class across the availability zones.
+	// +optional
+	Conditions vmopv1alpha1.Conditions `json:"conditions,omitempty"`
+}
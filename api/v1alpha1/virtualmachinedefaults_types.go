@@ -0,0 +1,106 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// VirtualMachineDefaultsName is the name of the VirtualMachineDefaults that is applied to the VirtualMachines of
+	// its namespace.
+	VirtualMachineDefaultsName = "default"
+
+	// DefaultedFieldsAnnotation is the annotation of a VirtualMachine that lists, separated by commas, the fields
+	// that were set from the VirtualMachineDefaults of its namespace when it was created.
+	DefaultedFieldsAnnotation = "vmoperator.vmware.com/defaulted-fields"
+)
+
+// VirtualMachineDefaultsSpec defines the defaults of the VirtualMachines of a namespace. A default is only applied
+// to a field that is not set.
+type VirtualMachineDefaultsSpec struct {
+	// ClassName is the default name of the VirtualMachineClass.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// ImageName is the default name of the VirtualMachineImage.
+	// +optional
+	ImageName string `json:"imageName,omitempty"`
+
+	// PowerState is the default desired power state.
+	// +optional
+	PowerState vmopv1alpha1.VirtualMachinePowerState `json:"powerState,omitempty"`
+
+	// StorageClass is the default name of the StorageClass.
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// NetworkInterfaces are the default network interfaces, that are applied when the VirtualMachine has none.
+	// +optional
+	NetworkInterfaces []vmopv1alpha1.VirtualMachineNetworkInterface `json:"networkInterfaces,omitempty"`
+
+	// MetadataTransport is the default transport of the VmMetadata, that is applied when the VirtualMachine has
+	// VmMetadata without a transport.
+	// +optional
+	MetadataTransport vmopv1alpha1.VirtualMachineMetadataTransport `json:"metadataTransport,omitempty"`
+
+	// ReadinessProbe is the default readiness probe.
+	// +optional
+	ReadinessProbe *vmopv1alpha1.Probe `json:"readinessProbe,omitempty"`
+
+	// ExtraConfig is the ExtraConfig of the VirtualMachines of the namespace. The ExtraConfig of the
+	// VirtualMachineClass and of the VirtualMachine take precedence over it. The keys that VM Operator manages are
+	// ignored. Only administrators can set it.
+	// +optional
+	ExtraConfig map[string]string `json:"extraConfig,omitempty"`
+
+	// ExtraConfigPolicy restricts the ExtraConfig keys that non-administrators can set on the VirtualMachines of the
+	// namespace. Only administrators can set it.
+	// +optional
+	ExtraConfigPolicy *ExtraConfigPolicy `json:"extraConfigPolicy,omitempty"`
+}
+
+// ExtraConfigPolicy restricts the ExtraConfig keys that non-administrators can set on a VirtualMachine. A key is a
+// pattern that can contain the * wildcard, for example "pciPassthru*", and is matched regardless of case. The keys
+// that VM Operator manages can never be set by non-administrators.
+type ExtraConfigPolicy struct {
+	// AllowedKeys are the only keys that can be set. All keys that are not denied can be set when it is empty.
+	// +optional
+	AllowedKeys []string `json:"allowedKeys,omitempty"`
+
+	// DeniedKeys are the keys that cannot be set.
+	// +optional
+	DeniedKeys []string `json:"deniedKeys,omitempty"`
+}
+
+// VirtualMachineDefaults supplies the defaults of the VirtualMachines that are created in its namespace. Only the
+// VirtualMachineDefaults named "default" is applied.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vmdefaults
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Class",type="string",JSONPath=".spec.className"
+// +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.imageName"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type VirtualMachineDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualMachineDefaultsSpec `json:"spec,omitempty"`
+}
+
+// VirtualMachineDefaultsList contains a list of VirtualMachineDefaults resources.
+//
+// +kubebuilder:object:root=true
+type VirtualMachineDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualMachineDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualMachineDefaults{}, &VirtualMachineDefaultsList{})
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+const (
+	// VirtualMachineImageDeprecatedAnnotation is the annotation on a VirtualMachineImage whose content library item
+	// was removed while VirtualMachines still use the image. Its value is the RFC3339 time the item was found to be
+	// removed. A deprecated image is deleted once no VirtualMachines use it.
+	VirtualMachineImageDeprecatedAnnotation = "vmoperator.vmware.com/deprecated"
+
+	// VirtualMachineImageInUseByAnnotation is the annotation on a VirtualMachineImage whose value is the sorted,
+	// comma separated list of the "namespace/name" of the VirtualMachines that use the image.
+	VirtualMachineImageInUseByAnnotation = "vmoperator.vmware.com/in-use-by"
+)
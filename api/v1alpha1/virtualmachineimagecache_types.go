@@ -0,0 +1,15 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// VirtualMachineLinkedCloneCondition documents that the VM is a linked clone of the template VM that caches
+	// its image on its datastore, so its disks are children of the template's disks. The condition is not set on
+	// VMs that were fully deployed or cloned.
+	VirtualMachineLinkedCloneCondition vmopv1alpha1.ConditionType = "VirtualMachineLinkedClone"
+)
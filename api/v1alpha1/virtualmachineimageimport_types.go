@@ -0,0 +1,159 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+// VirtualMachineImageImportChecksumAlgorithm is the hash algorithm of a checksum.
+type VirtualMachineImageImportChecksumAlgorithm string
+
+const (
+	// SHA1ChecksumAlgorithm is the SHA-1 hash algorithm.
+	SHA1ChecksumAlgorithm VirtualMachineImageImportChecksumAlgorithm = "SHA1"
+	// SHA256ChecksumAlgorithm is the SHA-256 hash algorithm.
+	SHA256ChecksumAlgorithm VirtualMachineImageImportChecksumAlgorithm = "SHA256"
+	// SHA512ChecksumAlgorithm is the SHA-512 hash algorithm.
+	SHA512ChecksumAlgorithm VirtualMachineImageImportChecksumAlgorithm = "SHA512"
+)
+
+// VirtualMachineImageImportPhase is the phase of a VirtualMachineImageImport.
+type VirtualMachineImageImportPhase string
+
+const (
+	// VirtualMachineImageImportImporting is the phase of an import that is in progress.
+	VirtualMachineImageImportImporting VirtualMachineImageImportPhase = "Importing"
+	// VirtualMachineImageImportSucceeded is the phase of an import whose library item was created.
+	VirtualMachineImageImportSucceeded VirtualMachineImageImportPhase = "Succeeded"
+	// VirtualMachineImageImportFailed is the phase of an import that failed. A failed import is not retried.
+	VirtualMachineImageImportFailed VirtualMachineImageImportPhase = "Failed"
+)
+
+const (
+	// VirtualMachineImageImportChecksumVerifiedCondition documents whether the checksum of the downloaded file
+	// matched the Spec.Checksum. It is only set when the Spec.Checksum is set.
+	VirtualMachineImageImportChecksumVerifiedCondition vmopv1alpha1.ConditionType = "ChecksumVerified"
+
+	// VirtualMachineImageImportChecksumMismatchReason (Severity=Error) documents that the checksum of the
+	// downloaded file did not match the Spec.Checksum.
+	VirtualMachineImageImportChecksumMismatchReason = "ChecksumMismatch"
+
+	// VirtualMachineImageImportInvalidSpecReason (Severity=Error) documents that the spec of the import is invalid.
+	VirtualMachineImageImportInvalidSpecReason = "InvalidSpec"
+	// VirtualMachineImageImportLibraryNotFoundReason (Severity=Error) documents that the ContentLibraryProvider
+	// named by Spec.LibraryName does not exist.
+	VirtualMachineImageImportLibraryNotFoundReason = "LibraryNotFound"
+	// VirtualMachineImageImportItemExistsReason (Severity=Error) documents that the library already has an item
+	// with the name of the import.
+	VirtualMachineImageImportItemExistsReason = "ItemExists"
+	// VirtualMachineImageImportDownloadFailedReason (Severity=Error) documents that the file could not be downloaded.
+	VirtualMachineImageImportDownloadFailedReason = "DownloadFailed"
+	// VirtualMachineImageImportUploadFailedReason (Severity=Error) documents that the file could not be uploaded to
+	// the content library.
+	VirtualMachineImageImportUploadFailedReason = "UploadFailed"
+)
+
+// VirtualMachineImageImportChecksum is the expected checksum of the downloaded file.
+type VirtualMachineImageImportChecksum struct {
+	// Algorithm is the hash algorithm of the checksum.
+	// +kubebuilder:validation:Enum=SHA1;SHA256;SHA512
+	Algorithm VirtualMachineImageImportChecksumAlgorithm `json:"algorithm"`
+
+	// Value is the hex encoded checksum.
+	Value string `json:"value"`
+}
+
+// VirtualMachineImageImportSpec defines the desired state of VirtualMachineImageImport.
+type VirtualMachineImageImportSpec struct {
+	// URL is the HTTP or HTTPS URL of the OVA, OVF or ISO file to import. The type of the file is determined by
+	// the extension of the URL's path. The files referenced by an OVF descriptor are downloaded relative to its URL.
+	URL string `json:"url"`
+
+	// Checksum is the expected checksum of the file at the URL. For an OVF, it is the checksum of the descriptor.
+	// +optional
+	Checksum *VirtualMachineImageImportChecksum `json:"checksum,omitempty"`
+
+	// LibraryName is the name of the ContentLibraryProvider of the library the file is imported into.
+	LibraryName string `json:"libraryName"`
+
+	// ItemName is the name of the created library item. Defaults to the name of the file at the URL, without
+	// its extension.
+	// +optional
+	ItemName string `json:"itemName,omitempty"`
+}
+
+// VirtualMachineImageImportStatus defines the observed state of VirtualMachineImageImport.
+type VirtualMachineImageImportStatus struct {
+	// Phase is the phase of the import.
+	// +optional
+	Phase VirtualMachineImageImportPhase `json:"phase,omitempty"`
+
+	// ItemName is the name of the library item the file is imported into.
+	// +optional
+	ItemName string `json:"itemName,omitempty"`
+
+	// ItemID is the identifier of the library item, once the import has succeeded.
+	// +optional
+	ItemID string `json:"itemID,omitempty"`
+
+	// BytesTotal is the size of the files being imported, when it is known.
+	// +optional
+	BytesTotal int64 `json:"bytesTotal,omitempty"`
+
+	// BytesTransferred is the number of bytes that have been imported so far.
+	// +optional
+	BytesTransferred int64 `json:"bytesTransferred,omitempty"`
+
+	// CompletionTime is when the import succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Conditions describes the checksum verification and the result of the import.
+	// +optional
+	Conditions vmopv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+// VirtualMachineImageImport imports an image from an HTTP or HTTPS URL into a content library. The content
+// library is then synced to a VirtualMachineImage like any other library item.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=vmimport
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Library",type="string",JSONPath=".spec.libraryName"
+// +kubebuilder:printcolumn:name="Item",type="string",JSONPath=".status.itemName"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Transferred",type="integer",JSONPath=".status.bytesTransferred"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type VirtualMachineImageImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualMachineImageImportSpec   `json:"spec,omitempty"`
+	Status VirtualMachineImageImportStatus `json:"status,omitempty"`
+}
+
+func (i *VirtualMachineImageImport) GetConditions() vmopv1alpha1.Conditions {
+	return i.Status.Conditions
+}
+
+func (i *VirtualMachineImageImport) SetConditions(conditions vmopv1alpha1.Conditions) {
+	i.Status.Conditions = conditions
+}
+
+// VirtualMachineImageImportList contains a list of VirtualMachineImageImport resources.
+//
+// +kubebuilder:object:root=true
+type VirtualMachineImageImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualMachineImageImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualMachineImageImport{}, &VirtualMachineImageImportList{})
+}
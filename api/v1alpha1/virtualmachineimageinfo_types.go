@@ -0,0 +1,128 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// VirtualMachineImageInfoAnnotation is the annotation on a VirtualMachineImage whose value is the JSON
+	// encoded VirtualMachineImageInfo inspected from the image's OVF descriptor. It is an annotation because the
+	// VirtualMachineImage type is defined by vm-operator-api, whose status cannot be extended here.
+	VirtualMachineImageInfoAnnotation = "vmoperator.vmware.com/image-info"
+
+	// VirtualMachineImageInfoVersionAnnotation is the annotation on a VirtualMachineImage that records the
+	// version of the content library item that the VirtualMachineImageInfo was inspected from.
+	VirtualMachineImageInfoVersionAnnotation = "vmoperator.vmware.com/image-info-version"
+
+	// The following labels are set on an inspected VirtualMachineImage so images can be selected by their
+	// capabilities, e.g. "kubectl get vmimage -l image.vmoperator.vmware.com/firmware=efi".
+
+	// VirtualMachineImageGuestOSIDLabel is the label for the vSphere guest OS identifier of the image.
+	VirtualMachineImageGuestOSIDLabel = "image.vmoperator.vmware.com/guest-os-id"
+	// VirtualMachineImageFirmwareLabel is the label for the firmware of the image, either "bios" or "efi".
+	VirtualMachineImageFirmwareLabel = "image.vmoperator.vmware.com/firmware"
+	// VirtualMachineImageHardwareVersionLabel is the label for the virtual hardware version of the image.
+	VirtualMachineImageHardwareVersionLabel = "image.vmoperator.vmware.com/hardware-version"
+	// VirtualMachineImageDiskCountLabel is the label for the number of disks in the image.
+	VirtualMachineImageDiskCountLabel = "image.vmoperator.vmware.com/disk-count"
+	// VirtualMachineImageNICCountLabel is the label for the number of network interfaces in the image.
+	VirtualMachineImageNICCountLabel = "image.vmoperator.vmware.com/nic-count"
+)
+
+const (
+	// VirtualMachineImageOVFInspectedCondition documents whether the OVF descriptor of the image was
+	// successfully inspected.
+	VirtualMachineImageOVFInspectedCondition vmopv1alpha1.ConditionType = "VirtualMachineImageOVFInspected"
+
+	// VirtualMachineImageUnsupportedReason documents that the image is not of a type that can be inspected.
+	VirtualMachineImageUnsupportedReason = "ImageUnsupported"
+	// VirtualMachineImageCorruptReason documents that the OVF descriptor of the image could not be parsed.
+	VirtualMachineImageCorruptReason = "ImageCorrupt"
+)
+
+const (
+	// VirtualMachineImageFirmwareBIOS is the firmware of an image that does not specify one.
+	VirtualMachineImageFirmwareBIOS = "bios"
+	// VirtualMachineImageFirmwareEFI is the firmware of an image that boots with EFI.
+	VirtualMachineImageFirmwareEFI = "efi"
+)
+
+// VirtualMachineImageDiskInfo describes a disk in the image.
+type VirtualMachineImageDiskInfo struct {
+	// ID is the identifier of the disk in the OVF descriptor.
+	ID string `json:"id"`
+
+	// Capacity is the provisioned capacity of the disk.
+	// +optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+
+	// PopulatedSize is the amount of data in the disk, if the OVF descriptor specifies it.
+	// +optional
+	PopulatedSize *resource.Quantity `json:"populatedSize,omitempty"`
+}
+
+// VirtualMachineImageOVFProperty describes a vApp property of the image.
+type VirtualMachineImageOVFProperty struct {
+	// Key is the key of the property.
+	Key string `json:"key"`
+
+	// Type is the OVF type of the property, e.g. "string", "boolean" or "int".
+	Type string `json:"type"`
+
+	// Qualifiers further restrict the values of the property, e.g. "MinLen(1)" or "ValueMap{"a","b"}".
+	// +optional
+	Qualifiers string `json:"qualifiers,omitempty"`
+
+	// Default is the default value of the property.
+	// +optional
+	Default *string `json:"default,omitempty"`
+
+	// UserConfigurable is true when the value of the property may be set when a VM is deployed.
+	// +optional
+	UserConfigurable bool `json:"userConfigurable,omitempty"`
+
+	// Password is true when the value of the property is a password.
+	// +optional
+	Password bool `json:"password,omitempty"`
+
+	// Label is the display label of the property.
+	// +optional
+	Label string `json:"label,omitempty"`
+
+	// Description is the description of the property.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// VirtualMachineImageInfo describes the contents of a VirtualMachineImage as inspected from its OVF
+// descriptor. It is recorded on the image in the VirtualMachineImageInfoAnnotation annotation.
+type VirtualMachineImageInfo struct {
+	// GuestOSID is the vSphere guest OS identifier of the image, e.g. "ubuntu64Guest".
+	// +optional
+	GuestOSID string `json:"guestOSID,omitempty"`
+
+	// Firmware is the firmware of the image, either "bios" or "efi".
+	// +optional
+	Firmware string `json:"firmware,omitempty"`
+
+	// HardwareVersion is the virtual hardware version of the image.
+	// +optional
+	HardwareVersion int32 `json:"hardwareVersion,omitempty"`
+
+	// Disks are the disks in the image.
+	// +optional
+	Disks []VirtualMachineImageDiskInfo `json:"disks,omitempty"`
+
+	// NICCount is the number of network interfaces in the image.
+	NICCount int32 `json:"nicCount"`
+
+	// OVFProperties are all of the vApp properties of the image, including those that are not user
+	// configurable.
+	// +optional
+	OVFProperties []VirtualMachineImageOVFProperty `json:"ovfProperties,omitempty"`
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// VirtualMachineImageSignerAnnotation is the annotation on a VirtualMachineImage whose value is the subject
+	// of the certificate that signed the image's OVF manifest, once the signature has been verified.
+	VirtualMachineImageSignerAnnotation = "vmoperator.vmware.com/image-signer"
+
+	// VirtualMachineImageTrustVersionAnnotation is the annotation on a VirtualMachineImage that records the
+	// version of the content library item that the manifest and signature were verified for.
+	VirtualMachineImageTrustVersionAnnotation = "vmoperator.vmware.com/image-trust-version"
+
+	// VirtualMachineImageTrustPolicyAnnotation is the annotation on a Namespace that sets the policy for
+	// deploying VMs in the namespace from images that are unsigned or not trusted. The stricter of this and the
+	// cluster wide policy applies.
+	VirtualMachineImageTrustPolicyAnnotation = "vmoperator.vmware.com/image-trust-policy"
+)
+
+const (
+	// VirtualMachineImageManifestVerifiedCondition documents whether the digests in the OVF manifest of the
+	// image match its files.
+	VirtualMachineImageManifestVerifiedCondition vmopv1alpha1.ConditionType = "VirtualMachineImageManifestVerified"
+
+	// VirtualMachineImageSignatureVerifiedCondition documents whether the OVF manifest of the image is signed by
+	// a certificate that chains to the trust bundle.
+	VirtualMachineImageSignatureVerifiedCondition vmopv1alpha1.ConditionType = "VirtualMachineImageSignatureVerified"
+
+	// VirtualMachineImageManifestMissingReason (Severity=Info) documents that the image has no OVF manifest.
+	VirtualMachineImageManifestMissingReason = "ManifestMissing"
+	// VirtualMachineImageDigestMismatchReason (Severity=Error) documents that a file of the image does not
+	// match its digest in the OVF manifest, or the manifest cannot be parsed.
+	VirtualMachineImageDigestMismatchReason = "DigestMismatch"
+	// VirtualMachineImageUnsignedReason (Severity=Info) documents that the image has no signing certificate.
+	VirtualMachineImageUnsignedReason = "Unsigned"
+	// VirtualMachineImageInvalidSignatureReason (Severity=Error) documents that the signature of the OVF manifest
+	// is not valid for the signing certificate, or the signing certificate cannot be parsed.
+	VirtualMachineImageInvalidSignatureReason = "InvalidSignature"
+	// VirtualMachineImageUntrustedCertificateReason (Severity=Error) documents that the signing certificate does
+	// not chain to the trust bundle.
+	VirtualMachineImageUntrustedCertificateReason = "UntrustedCertificate"
+)
+
+// VirtualMachineImageTrustPolicy is the policy for deploying VMs from images that are unsigned or not trusted.
+type VirtualMachineImageTrustPolicy string
+
+const (
+	// AllowVirtualMachineImageTrustPolicy allows VMs to be deployed from any image. This is the default.
+	AllowVirtualMachineImageTrustPolicy VirtualMachineImageTrustPolicy = "Allow"
+
+	// RejectUntrustedVirtualMachineImageTrustPolicy refuses VMs whose image does not match its OVF manifest, or
+	// whose image is signed with an invalid signature or by a certificate that is not trusted. Unsigned images
+	// are allowed.
+	RejectUntrustedVirtualMachineImageTrustPolicy VirtualMachineImageTrustPolicy = "RejectUntrusted"
+
+	// RequireTrustedVirtualMachineImageTrustPolicy only allows VMs whose image matches its OVF manifest and is
+	// signed by a trusted certificate.
+	RequireTrustedVirtualMachineImageTrustPolicy VirtualMachineImageTrustPolicy = "RequireTrusted"
+)
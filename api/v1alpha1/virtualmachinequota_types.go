@@ -0,0 +1,86 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// VirtualMachineQuotaVirtualMachines is the number of VirtualMachines.
+	VirtualMachineQuotaVirtualMachines corev1.ResourceName = "virtualmachines"
+	// VirtualMachineQuotaCPU is the number of vCPUs of the classes of the VirtualMachines.
+	VirtualMachineQuotaCPU corev1.ResourceName = "cpu"
+	// VirtualMachineQuotaMemory is the memory of the classes of the VirtualMachines.
+	VirtualMachineQuotaMemory corev1.ResourceName = "memory"
+	// VirtualMachineQuotaRequestsCPU is the CPU reservation of the classes of the VirtualMachines.
+	VirtualMachineQuotaRequestsCPU corev1.ResourceName = "requests.cpu"
+	// VirtualMachineQuotaRequestsMemory is the memory reservation of the classes of the VirtualMachines.
+	VirtualMachineQuotaRequestsMemory corev1.ResourceName = "requests.memory"
+	// VirtualMachineQuotaLimitsCPU is the CPU limit of the classes of the VirtualMachines.
+	VirtualMachineQuotaLimitsCPU corev1.ResourceName = "limits.cpu"
+	// VirtualMachineQuotaLimitsMemory is the memory limit of the classes of the VirtualMachines.
+	VirtualMachineQuotaLimitsMemory corev1.ResourceName = "limits.memory"
+	// VirtualMachineQuotaGPUs is the number of vGPU and Dynamic DirectPath I/O devices of the classes of the
+	// VirtualMachines.
+	VirtualMachineQuotaGPUs corev1.ResourceName = "gpus"
+	// VirtualMachineQuotaInstanceStorage is the size of the instance storage volumes of the classes of the
+	// VirtualMachines.
+	VirtualMachineQuotaInstanceStorage corev1.ResourceName = "instancestorage"
+
+	// VirtualMachineQuotaClassSuffix is the suffix of the resource that is the number of VirtualMachines of a
+	// VirtualMachineClass, that is named <class name>.virtualmachineclass.vmoperator.vmware.com/virtualmachines.
+	VirtualMachineQuotaClassSuffix = ".virtualmachineclass.vmoperator.vmware.com/virtualmachines"
+)
+
+// VirtualMachineQuotaSpec defines the desired state of VirtualMachineQuota.
+type VirtualMachineQuotaSpec struct {
+	// Hard is the set of limits on the VirtualMachines of the namespace. A VirtualMachine cannot be created
+	// when it would exceed a limit. The resources are virtualmachines, cpu, memory, requests.cpu,
+	// requests.memory, limits.cpu, limits.memory, gpus, instancestorage and, for the number of VirtualMachines
+	// of a class, <class name>.virtualmachineclass.vmoperator.vmware.com/virtualmachines.
+	// +optional
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+}
+
+// VirtualMachineQuotaStatus defines the observed state of VirtualMachineQuota.
+type VirtualMachineQuotaStatus struct {
+	// Hard is the set of limits that the usage was last observed for.
+	// +optional
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+
+	// Used is the usage of the limited resources by the VirtualMachines of the namespace.
+	// +optional
+	Used corev1.ResourceList `json:"used,omitempty"`
+}
+
+// VirtualMachineQuota limits the VirtualMachines of a namespace by the number of VirtualMachines and by the
+// resources of their classes, that a ResourceQuota cannot account for.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vmquota
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type VirtualMachineQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualMachineQuotaSpec   `json:"spec,omitempty"`
+	Status VirtualMachineQuotaStatus `json:"status,omitempty"`
+}
+
+// VirtualMachineQuotaList contains a list of VirtualMachineQuota resources.
+//
+// +kubebuilder:object:root=true
+type VirtualMachineQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualMachineQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualMachineQuota{}, &VirtualMachineQuotaList{})
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+// VirtualMachineReplicaSetStrategyType describes how VirtualMachines are replaced when the template of a
+// VirtualMachineReplicaSet changes.
+type VirtualMachineReplicaSetStrategyType string
+
+const (
+	// OnDeleteVirtualMachineReplicaSetStrategyType only replaces a VirtualMachine with one created from the
+	// current template after the VirtualMachine has been deleted by the user.
+	OnDeleteVirtualMachineReplicaSetStrategyType VirtualMachineReplicaSetStrategyType = "OnDelete"
+
+	// RollingUpdateVirtualMachineReplicaSetStrategyType replaces VirtualMachines created from an older template
+	// a few at a time, keeping the number of unavailable replicas within RollingUpdate.MaxUnavailable.
+	RollingUpdateVirtualMachineReplicaSetStrategyType VirtualMachineReplicaSetStrategyType = "RollingUpdate"
+)
+
+const (
+	// VirtualMachineReplicaSetNameLabel is set on the VirtualMachines of a VirtualMachineReplicaSet to the
+	// name of the VirtualMachineReplicaSet.
+	VirtualMachineReplicaSetNameLabel = "vmoperator.vmware.com/replicaset-name"
+
+	// VirtualMachineReplicaSetTemplateHashLabel is set on the VirtualMachines of a VirtualMachineReplicaSet to
+	// the hash of the template they were created from.
+	VirtualMachineReplicaSetTemplateHashLabel = "vmoperator.vmware.com/replicaset-template-hash"
+)
+
+// VirtualMachineTemplateSpec describes the VirtualMachines created by a VirtualMachineReplicaSet.
+type VirtualMachineTemplateSpec struct {
+	// ObjectMeta contains the labels and annotations of the created VirtualMachines. The labels must
+	// match the VirtualMachineReplicaSet's Selector.
+	// +optional
+	ObjectMeta metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the spec of the created VirtualMachines.
+	Spec vmopv1alpha1.VirtualMachineSpec `json:"spec"`
+}
+
+// RollingUpdateVirtualMachineReplicaSetStrategy controls the pace of a rolling update.
+type RollingUpdateVirtualMachineReplicaSetStrategy struct {
+	// MaxUnavailable is the maximum number of replicas, either an absolute number or a percentage of
+	// the desired replicas rounded down, that may be unavailable during the update. A VirtualMachine is
+	// available when its Ready condition is true, or, if the template has no ReadinessProbe, when it is
+	// powered on. Defaults to 1, and a value that resolves to 0 is treated as 1 so the update can progress.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// VirtualMachineReplicaSetStrategy describes how VirtualMachines are replaced when the template changes.
+type VirtualMachineReplicaSetStrategy struct {
+	// Type is the strategy type. Defaults to RollingUpdate.
+	// +optional
+	// +kubebuilder:validation:Enum=OnDelete;RollingUpdate
+	Type VirtualMachineReplicaSetStrategyType `json:"type,omitempty"`
+
+	// RollingUpdate is only used when Type is RollingUpdate.
+	// +optional
+	RollingUpdate *RollingUpdateVirtualMachineReplicaSetStrategy `json:"rollingUpdate,omitempty"`
+}
+
+// VirtualMachineReplicaSetSpec defines the desired state of VirtualMachineReplicaSet.
+type VirtualMachineReplicaSetSpec struct {
+	// Replicas is the desired number of VirtualMachines. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Selector is a label query over the VirtualMachines owned by this VirtualMachineReplicaSet. It must
+	// match the labels of the Template.
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// Template describes the VirtualMachines that are created.
+	Template VirtualMachineTemplateSpec `json:"template"`
+
+	// Strategy describes how VirtualMachines are replaced when the Template changes.
+	// +optional
+	Strategy VirtualMachineReplicaSetStrategy `json:"strategy,omitempty"`
+
+	// ResourcePolicyName is the name of a VirtualMachineSetResourcePolicy shared by all the VirtualMachines.
+	// When set it overrides the Template's ResourcePolicyName.
+	// +optional
+	ResourcePolicyName string `json:"resourcePolicyName,omitempty"`
+
+	// ClusterModuleGroupName is the name of a ClusterModule of the ResourcePolicyName policy. The
+	// VirtualMachines are added to the ClusterModule so that vSphere places them on different hosts.
+	// +optional
+	ClusterModuleGroupName string `json:"clusterModuleGroupName,omitempty"`
+}
+
+// VirtualMachineReplicaSetStatus defines the observed state of VirtualMachineReplicaSet.
+type VirtualMachineReplicaSetStatus struct {
+	// Replicas is the number of VirtualMachines that are not being deleted.
+	// +optional
+	Replicas int32 `json:"replicas"`
+
+	// ReadyReplicas is the number of VirtualMachines that are available.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// UpdatedReplicas is the number of VirtualMachines created from the current Template.
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// Selector is the serialized Spec.Selector, used by the scale subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// VirtualMachineReplicaSet maintains a number of identical VirtualMachines.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vmrs
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".spec.replicas"
+// +kubebuilder:printcolumn:name="Current",type="integer",JSONPath=".status.replicas"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type VirtualMachineReplicaSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualMachineReplicaSetSpec   `json:"spec,omitempty"`
+	Status VirtualMachineReplicaSetStatus `json:"status,omitempty"`
+}
+
+// NamespacedName returns the namespaced name of this VirtualMachineReplicaSet.
+func (rs *VirtualMachineReplicaSet) NamespacedName() string {
+	return rs.Namespace + "/" + rs.Name
+}
+
+// VirtualMachineReplicaSetList contains a list of VirtualMachineReplicaSet resources.
+//
+// +kubebuilder:object:root=true
+type VirtualMachineReplicaSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualMachineReplicaSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualMachineReplicaSet{}, &VirtualMachineReplicaSetList{})
+}
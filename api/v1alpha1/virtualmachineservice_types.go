@@ -0,0 +1,10 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+const (
+	// VirtualMachineServiceConditionsAnnotation is the annotation on a VirtualMachineService whose value is the JSON
+	// encoded list of its conditions, since its status has no conditions.
+	VirtualMachineServiceConditionsAnnotation = "virtualmachineservice.vmoperator.vmware.com/conditions"
+)
@@ -0,0 +1,27 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package v1alpha2 contains the v1alpha2 API Schema definitions for the VM Operator APIs. The v1alpha2 APIs are
+// served through the conversion webhook from the v1alpha1 storage version, that the controllers operate on.
+// +kubebuilder:object:generate=true
+// +groupName=vmoperator.vmware.com
+package v1alpha2
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{
+		Group:   "vmoperator.vmware.com",
+		Version: "v1alpha2",
+	}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
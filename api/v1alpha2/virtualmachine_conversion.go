@@ -0,0 +1,450 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+// ConvertFromV1alpha1 converts the v1alpha1 src VirtualMachine to this v1alpha2 VirtualMachine. The v1alpha2 spec that
+// was saved in the V1alpha2SpecAnnotation of src is restored, and the v1alpha1 spec of src is saved in the
+// V1alpha1SpecAnnotation when it cannot be represented in v1alpha2.
+func (dst *VirtualMachine) ConvertFromV1alpha1(src *vmopv1alpha1.VirtualMachine) error {
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = convertSpecFromV1alpha1(&src.Spec)
+	dst.Status = convertStatusFromV1alpha1(&src.Status)
+
+	if data, ok := dst.Annotations[V1alpha2SpecAnnotation]; ok {
+		restored := VirtualMachineSpec{}
+		if err := json.Unmarshal([]byte(data), &restored); err != nil {
+			return err
+		}
+
+		if spec := convertSpecToV1alpha1(&restored); equality.Semantic.DeepEqual(spec, src.Spec) {
+			dst.Spec = restored
+		} else {
+			// The v1alpha1 spec was changed since the annotation was saved, so only restore what v1alpha1 cannot
+			// represent and that still applies.
+			restoreIPConfigs(&dst.Spec, &restored)
+		}
+	}
+
+	delete(dst.Annotations, V1alpha1SpecAnnotation)
+	delete(dst.Annotations, V1alpha2SpecAnnotation)
+
+	if spec := convertSpecToV1alpha1(&dst.Spec); !equality.Semantic.DeepEqual(spec, src.Spec) {
+		data, err := json.Marshal(src.Spec)
+		if err != nil {
+			return err
+		}
+		setAnnotation(&dst.ObjectMeta.Annotations, V1alpha1SpecAnnotation, string(data))
+	}
+
+	return nil
+}
+
+// ConvertToV1alpha1 converts this v1alpha2 VirtualMachine to the v1alpha1 dst VirtualMachine. The v1alpha1 spec that
+// was saved in the V1alpha1SpecAnnotation of this VirtualMachine is restored, and the v1alpha2 spec of this
+// VirtualMachine is saved in the V1alpha2SpecAnnotation when it cannot be represented in v1alpha1.
+func (src *VirtualMachine) ConvertToV1alpha1(dst *vmopv1alpha1.VirtualMachine) error {
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = convertSpecToV1alpha1(&src.Spec)
+	dst.Status = convertStatusToV1alpha1(&src.Status)
+
+	if data, ok := dst.Annotations[V1alpha1SpecAnnotation]; ok {
+		restored := vmopv1alpha1.VirtualMachineSpec{}
+		if err := json.Unmarshal([]byte(data), &restored); err != nil {
+			return err
+		}
+
+		if spec := convertSpecFromV1alpha1(&restored); equality.Semantic.DeepEqual(spec, src.Spec) {
+			dst.Spec = restored
+		} else {
+			// The v1alpha2 spec was changed since the annotation was saved, so only restore what v1alpha2 cannot
+			// represent.
+			dst.Spec.Ports = restored.Ports
+		}
+	}
+
+	delete(dst.Annotations, V1alpha1SpecAnnotation)
+	delete(dst.Annotations, V1alpha2SpecAnnotation)
+
+	if spec := convertSpecFromV1alpha1(&dst.Spec); !equality.Semantic.DeepEqual(spec, src.Spec) {
+		data, err := json.Marshal(src.Spec)
+		if err != nil {
+			return err
+		}
+		setAnnotation(&dst.ObjectMeta.Annotations, V1alpha2SpecAnnotation, string(data))
+	}
+
+	return nil
+}
+
+// restoreIPConfigs restores the IP configuration of the network interfaces of spec from the interfaces of restored
+// that are on the same network.
+func restoreIPConfigs(spec, restored *VirtualMachineSpec) {
+	if spec.Network == nil || restored.Network == nil {
+		return
+	}
+
+	for i := range spec.Network.Interfaces {
+		if i >= len(restored.Network.Interfaces) {
+			break
+		}
+
+		nif, restoredNif := &spec.Network.Interfaces[i], &restored.Network.Interfaces[i]
+		if nif.NetworkType == restoredNif.NetworkType && nif.NetworkName == restoredNif.NetworkName {
+			nif.IPConfig = restoredNif.IPConfig.DeepCopy()
+		}
+	}
+}
+
+func setAnnotation(annotations *map[string]string, key, value string) {
+	if *annotations == nil {
+		*annotations = map[string]string{}
+	}
+	(*annotations)[key] = value
+}
+
+func convertSpecFromV1alpha1(in *vmopv1alpha1.VirtualMachineSpec) VirtualMachineSpec {
+	out := VirtualMachineSpec{
+		ClassName:          in.ClassName,
+		PowerState:         VirtualMachinePowerState(in.PowerState),
+		StorageClass:       in.StorageClass,
+		ResourcePolicyName: in.ResourcePolicyName,
+	}
+
+	if in.ImageName != "" {
+		out.Image = &VirtualMachineImageRef{
+			Kind: VirtualMachineImageKind,
+			Name: in.ImageName,
+		}
+	}
+
+	if md := in.VmMetadata; md != nil {
+		source := VirtualMachineBootstrapDataSource{
+			ConfigMapName: md.ConfigMapName,
+			SecretName:    md.SecretName,
+		}
+
+		out.Bootstrap = &VirtualMachineBootstrapSpec{}
+		switch md.Transport {
+		case vmopv1alpha1.VirtualMachineMetadataCloudInitTransport:
+			out.Bootstrap.CloudInit = &VirtualMachineBootstrapCloudInitSpec{VirtualMachineBootstrapDataSource: source}
+		case vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport:
+			out.Bootstrap.VAppConfig = &VirtualMachineBootstrapVAppConfigSpec{VirtualMachineBootstrapDataSource: source}
+		default:
+			out.Bootstrap.ExtraConfig = &VirtualMachineBootstrapExtraConfigSpec{VirtualMachineBootstrapDataSource: source}
+		}
+	}
+
+	if in.NetworkInterfaces != nil {
+		out.Network = &VirtualMachineNetworkSpec{
+			Interfaces: make([]VirtualMachineNetworkInterfaceSpec, 0, len(in.NetworkInterfaces)),
+		}
+		for _, nif := range in.NetworkInterfaces {
+			outNif := VirtualMachineNetworkInterfaceSpec{
+				NetworkType:      nif.NetworkType,
+				NetworkName:      nif.NetworkName,
+				EthernetCardType: nif.EthernetCardType,
+			}
+			if ref := nif.ProviderRef; ref != nil {
+				outNif.ProviderRef = &NetworkInterfaceProviderReference{
+					APIGroup:   ref.APIGroup,
+					Kind:       ref.Kind,
+					Name:       ref.Name,
+					APIVersion: ref.APIVersion,
+				}
+			}
+			out.Network.Interfaces = append(out.Network.Interfaces, outNif)
+		}
+	}
+
+	if in.Volumes != nil {
+		out.Volumes = make([]VirtualMachineVolume, 0, len(in.Volumes))
+		for _, vol := range in.Volumes {
+			outVol := VirtualMachineVolume{
+				Name: vol.Name,
+			}
+			if pvc := vol.PersistentVolumeClaim; pvc != nil {
+				outVol.PersistentVolumeClaim = &PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: pvc.PersistentVolumeClaimVolumeSource,
+				}
+				if ivc := pvc.InstanceVolumeClaim; ivc != nil {
+					outVol.PersistentVolumeClaim.InstanceVolumeClaim = &InstanceVolumeClaimVolumeSource{
+						StorageClass: ivc.StorageClass,
+						Size:         ivc.Size.DeepCopy(),
+					}
+				}
+			}
+			if vsv := vol.VsphereVolume; vsv != nil {
+				outVol.VsphereVolume = &VsphereVolumeSource{}
+				if vsv.Capacity != nil {
+					outVol.VsphereVolume.Capacity = vsv.Capacity.DeepCopy()
+				}
+				if vsv.DeviceKey != nil {
+					deviceKey := *vsv.DeviceKey
+					outVol.VsphereVolume.DeviceKey = &deviceKey
+				}
+			}
+			out.Volumes = append(out.Volumes, outVol)
+		}
+	}
+
+	if probe := in.ReadinessProbe; probe != nil {
+		out.ReadinessProbe = &Probe{
+			TimeoutSeconds: probe.TimeoutSeconds,
+			PeriodSeconds:  probe.PeriodSeconds,
+		}
+		if tcp := probe.TCPSocket; tcp != nil {
+			out.ReadinessProbe.TCPSocket = &TCPSocketAction{
+				Port: tcp.Port,
+				Host: tcp.Host,
+			}
+		}
+		if hb := probe.GuestHeartbeat; hb != nil {
+			out.ReadinessProbe.GuestHeartbeat = &GuestHeartbeatAction{
+				ThresholdStatus: GuestHeartbeatStatus(hb.ThresholdStatus),
+			}
+		}
+	}
+
+	if opts := in.AdvancedOptions; opts != nil {
+		out.AdvancedOptions = &VirtualMachineAdvancedOptions{
+			ChangeBlockTracking: copyBool(opts.ChangeBlockTracking),
+		}
+		if prov := opts.DefaultVolumeProvisioningOptions; prov != nil {
+			out.AdvancedOptions.DefaultVolumeProvisioningOptions = &VirtualMachineVolumeProvisioningOptions{
+				ThinProvisioned: copyBool(prov.ThinProvisioned),
+				EagerZeroed:     copyBool(prov.EagerZeroed),
+			}
+		}
+	}
+
+	return out
+}
+
+func convertSpecToV1alpha1(in *VirtualMachineSpec) vmopv1alpha1.VirtualMachineSpec {
+	out := vmopv1alpha1.VirtualMachineSpec{
+		ClassName:          in.ClassName,
+		PowerState:         vmopv1alpha1.VirtualMachinePowerState(in.PowerState),
+		StorageClass:       in.StorageClass,
+		ResourcePolicyName: in.ResourcePolicyName,
+	}
+
+	if in.Image != nil {
+		out.ImageName = in.Image.Name
+	}
+
+	if bs := in.Bootstrap; bs != nil {
+		var (
+			source    VirtualMachineBootstrapDataSource
+			transport vmopv1alpha1.VirtualMachineMetadataTransport
+		)
+
+		switch {
+		case bs.CloudInit != nil:
+			source, transport = bs.CloudInit.VirtualMachineBootstrapDataSource, vmopv1alpha1.VirtualMachineMetadataCloudInitTransport
+		case bs.VAppConfig != nil:
+			source, transport = bs.VAppConfig.VirtualMachineBootstrapDataSource, vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport
+		case bs.ExtraConfig != nil:
+			source, transport = bs.ExtraConfig.VirtualMachineBootstrapDataSource, vmopv1alpha1.VirtualMachineMetadataExtraConfigTransport
+		}
+
+		if transport != "" {
+			out.VmMetadata = &vmopv1alpha1.VirtualMachineMetadata{
+				ConfigMapName: source.ConfigMapName,
+				SecretName:    source.SecretName,
+				Transport:     transport,
+			}
+		}
+	}
+
+	if in.Network != nil && in.Network.Interfaces != nil {
+		out.NetworkInterfaces = make([]vmopv1alpha1.VirtualMachineNetworkInterface, 0, len(in.Network.Interfaces))
+		for _, nif := range in.Network.Interfaces {
+			outNif := vmopv1alpha1.VirtualMachineNetworkInterface{
+				NetworkType:      nif.NetworkType,
+				NetworkName:      nif.NetworkName,
+				EthernetCardType: nif.EthernetCardType,
+			}
+			if ref := nif.ProviderRef; ref != nil {
+				outNif.ProviderRef = &vmopv1alpha1.NetworkInterfaceProviderReference{
+					APIGroup:   ref.APIGroup,
+					Kind:       ref.Kind,
+					Name:       ref.Name,
+					APIVersion: ref.APIVersion,
+				}
+			}
+			out.NetworkInterfaces = append(out.NetworkInterfaces, outNif)
+		}
+	}
+
+	if in.Volumes != nil {
+		out.Volumes = make([]vmopv1alpha1.VirtualMachineVolume, 0, len(in.Volumes))
+		for _, vol := range in.Volumes {
+			outVol := vmopv1alpha1.VirtualMachineVolume{
+				Name: vol.Name,
+			}
+			if pvc := vol.PersistentVolumeClaim; pvc != nil {
+				outVol.PersistentVolumeClaim = &vmopv1alpha1.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: pvc.PersistentVolumeClaimVolumeSource,
+				}
+				if ivc := pvc.InstanceVolumeClaim; ivc != nil {
+					outVol.PersistentVolumeClaim.InstanceVolumeClaim = &vmopv1alpha1.InstanceVolumeClaimVolumeSource{
+						StorageClass: ivc.StorageClass,
+						Size:         ivc.Size.DeepCopy(),
+					}
+				}
+			}
+			if vsv := vol.VsphereVolume; vsv != nil {
+				outVol.VsphereVolume = &vmopv1alpha1.VsphereVolumeSource{}
+				if vsv.Capacity != nil {
+					outVol.VsphereVolume.Capacity = vsv.Capacity.DeepCopy()
+				}
+				if vsv.DeviceKey != nil {
+					deviceKey := *vsv.DeviceKey
+					outVol.VsphereVolume.DeviceKey = &deviceKey
+				}
+			}
+			out.Volumes = append(out.Volumes, outVol)
+		}
+	}
+
+	if probe := in.ReadinessProbe; probe != nil {
+		out.ReadinessProbe = &vmopv1alpha1.Probe{
+			TimeoutSeconds: probe.TimeoutSeconds,
+			PeriodSeconds:  probe.PeriodSeconds,
+		}
+		if tcp := probe.TCPSocket; tcp != nil {
+			out.ReadinessProbe.TCPSocket = &vmopv1alpha1.TCPSocketAction{
+				Port: tcp.Port,
+				Host: tcp.Host,
+			}
+		}
+		if hb := probe.GuestHeartbeat; hb != nil {
+			out.ReadinessProbe.GuestHeartbeat = &vmopv1alpha1.GuestHeartbeatAction{
+				ThresholdStatus: vmopv1alpha1.GuestHeartbeatStatus(hb.ThresholdStatus),
+			}
+		}
+	}
+
+	if opts := in.AdvancedOptions; opts != nil {
+		out.AdvancedOptions = &vmopv1alpha1.VirtualMachineAdvancedOptions{
+			ChangeBlockTracking: copyBool(opts.ChangeBlockTracking),
+		}
+		if prov := opts.DefaultVolumeProvisioningOptions; prov != nil {
+			out.AdvancedOptions.DefaultVolumeProvisioningOptions = &vmopv1alpha1.VirtualMachineVolumeProvisioningOptions{
+				ThinProvisioned: copyBool(prov.ThinProvisioned),
+				EagerZeroed:     copyBool(prov.EagerZeroed),
+			}
+		}
+	}
+
+	return out
+}
+
+func convertStatusFromV1alpha1(in *vmopv1alpha1.VirtualMachineStatus) VirtualMachineStatus {
+	out := VirtualMachineStatus{
+		Host:                in.Host,
+		PowerState:          VirtualMachinePowerState(in.PowerState),
+		Phase:               VMStatusPhase(in.Phase),
+		VMIP:                in.VmIp,
+		UniqueID:            in.UniqueID,
+		BiosUUID:            in.BiosUUID,
+		InstanceUUID:        in.InstanceUUID,
+		ChangeBlockTracking: copyBool(in.ChangeBlockTracking),
+		Zone:                in.Zone,
+	}
+
+	if in.Conditions != nil {
+		out.Conditions = make([]vmopv1alpha1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+
+	if in.Volumes != nil {
+		out.Volumes = make([]VirtualMachineVolumeStatus, 0, len(in.Volumes))
+		for _, vol := range in.Volumes {
+			out.Volumes = append(out.Volumes, VirtualMachineVolumeStatus{
+				Name:     vol.Name,
+				Attached: vol.Attached,
+				DiskUUID: vol.DiskUuid,
+				Error:    vol.Error,
+			})
+		}
+	}
+
+	if in.NetworkInterfaces != nil {
+		out.NetworkInterfaces = make([]NetworkInterfaceStatus, 0, len(in.NetworkInterfaces))
+		for _, nif := range in.NetworkInterfaces {
+			out.NetworkInterfaces = append(out.NetworkInterfaces, NetworkInterfaceStatus{
+				Connected:   nif.Connected,
+				MacAddress:  nif.MacAddress,
+				IPAddresses: append([]string(nil), nif.IpAddresses...),
+			})
+		}
+	}
+
+	return out
+}
+
+func convertStatusToV1alpha1(in *VirtualMachineStatus) vmopv1alpha1.VirtualMachineStatus {
+	out := vmopv1alpha1.VirtualMachineStatus{
+		Host:                in.Host,
+		PowerState:          vmopv1alpha1.VirtualMachinePowerState(in.PowerState),
+		Phase:               vmopv1alpha1.VMStatusPhase(in.Phase),
+		VmIp:                in.VMIP,
+		UniqueID:            in.UniqueID,
+		BiosUUID:            in.BiosUUID,
+		InstanceUUID:        in.InstanceUUID,
+		ChangeBlockTracking: copyBool(in.ChangeBlockTracking),
+		Zone:                in.Zone,
+	}
+
+	if in.Conditions != nil {
+		out.Conditions = make([]vmopv1alpha1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+
+	if in.Volumes != nil {
+		out.Volumes = make([]vmopv1alpha1.VirtualMachineVolumeStatus, 0, len(in.Volumes))
+		for _, vol := range in.Volumes {
+			out.Volumes = append(out.Volumes, vmopv1alpha1.VirtualMachineVolumeStatus{
+				Name:     vol.Name,
+				Attached: vol.Attached,
+				DiskUuid: vol.DiskUUID,
+				Error:    vol.Error,
+			})
+		}
+	}
+
+	if in.NetworkInterfaces != nil {
+		out.NetworkInterfaces = make([]vmopv1alpha1.NetworkInterfaceStatus, 0, len(in.NetworkInterfaces))
+		for _, nif := range in.NetworkInterfaces {
+			out.NetworkInterfaces = append(out.NetworkInterfaces, vmopv1alpha1.NetworkInterfaceStatus{
+				Connected:   nif.Connected,
+				MacAddress:  nif.MacAddress,
+				IpAddresses: append([]string(nil), nif.IPAddresses...),
+			})
+		}
+	}
+
+	return out
+}
+
+func copyBool(in *bool) *bool {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
@@ -0,0 +1,495 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// V1alpha1SpecAnnotation is the annotation of a v1alpha2 VirtualMachine that holds the v1alpha1 spec of the
+	// VirtualMachine when it cannot be represented in v1alpha2, so that the conversion back to v1alpha1 is lossless.
+	V1alpha1SpecAnnotation = "vmoperator.vmware.com/v1alpha1-spec"
+
+	// V1alpha2SpecAnnotation is the annotation of a v1alpha1 VirtualMachine that holds the v1alpha2 spec of the
+	// VirtualMachine when it cannot be represented in v1alpha1, so that the conversion back to v1alpha2 is lossless.
+	V1alpha2SpecAnnotation = "vmoperator.vmware.com/v1alpha2-spec"
+)
+
+// VirtualMachinePowerState represents the power state of a VirtualMachine.
+// +kubebuilder:validation:Enum=poweredOff;poweredOn
+type VirtualMachinePowerState string
+
+const (
+	// VirtualMachinePoweredOff indicates that a VirtualMachine is powered off.
+	VirtualMachinePoweredOff VirtualMachinePowerState = "poweredOff"
+
+	// VirtualMachinePoweredOn indicates that a VirtualMachine is powered on.
+	VirtualMachinePoweredOn VirtualMachinePowerState = "poweredOn"
+)
+
+// VMStatusPhase is used to indicate the phase of a VirtualMachine's lifecycle.
+type VMStatusPhase string
+
+const (
+	// Creating phase indicates that the VirtualMachine is being created by the backing infrastructure provider.
+	Creating VMStatusPhase = "Creating"
+
+	// Created phase indicates that the VirtualMachine has been created by the backing infrastructure provider.
+	Created VMStatusPhase = "Created"
+
+	// Deleting phase indicates that the VirtualMachine is being deleted by the backing infrastructure provider.
+	Deleting VMStatusPhase = "Deleting"
+
+	// Deleted phase indicates that the VirtualMachine has been deleted by the backing infrastructure provider.
+	Deleted VMStatusPhase = "Deleted"
+
+	// Unknown phase indicates that the VirtualMachine status cannot be determined from the backing infrastructure
+	// provider.
+	Unknown VMStatusPhase = "Unknown"
+)
+
+const (
+	// VirtualMachineImageKind is the kind of an image reference to a VirtualMachineImage.
+	VirtualMachineImageKind = "VirtualMachineImage"
+)
+
+// VirtualMachineImageRef is a reference to the image that a VirtualMachine is deployed from.
+type VirtualMachineImageRef struct {
+	// Kind is the kind of the image resource. The only supported kind is VirtualMachineImage.
+	// +kubebuilder:validation:Enum=VirtualMachineImage
+	// +kubebuilder:default=VirtualMachineImage
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name is the name of the image resource.
+	Name string `json:"name"`
+}
+
+// VirtualMachineBootstrapDataSource is the ConfigMap or Secret, in the same namespace as the VirtualMachine, that
+// holds the data of a bootstrap provider. Only one of ConfigMapName and SecretName can be specified.
+type VirtualMachineBootstrapDataSource struct {
+	// ConfigMapName is the name of the ConfigMap that holds the data.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// SecretName is the name of the Secret that holds the data.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// VirtualMachineBootstrapCloudInitSpec bootstraps the guest with cloud-init. The "user-data" key of the data is the
+// cloud-init user-data.
+type VirtualMachineBootstrapCloudInitSpec struct {
+	VirtualMachineBootstrapDataSource `json:",inline"`
+}
+
+// VirtualMachineBootstrapVAppConfigSpec bootstraps the guest with the vApp properties of the image, that are exposed
+// to the guest as its OVF environment. Only the keys of the data that are user configurable vApp properties of the
+// image are set.
+type VirtualMachineBootstrapVAppConfigSpec struct {
+	VirtualMachineBootstrapDataSource `json:",inline"`
+}
+
+// VirtualMachineBootstrapExtraConfigSpec bootstraps the guest with the keys of the data that are prefixed with
+// "guestinfo.", that are set as ExtraConfig keys of the VirtualMachine.
+type VirtualMachineBootstrapExtraConfigSpec struct {
+	VirtualMachineBootstrapDataSource `json:",inline"`
+}
+
+// VirtualMachineBootstrapSpec is the bootstrap provider of a VirtualMachine. Only one provider can be specified.
+// +kubebuilder:validation:MaxProperties=1
+type VirtualMachineBootstrapSpec struct {
+	// CloudInit bootstraps the guest with cloud-init.
+	// +optional
+	CloudInit *VirtualMachineBootstrapCloudInitSpec `json:"cloudInit,omitempty"`
+
+	// VAppConfig bootstraps the guest with vApp properties.
+	// +optional
+	VAppConfig *VirtualMachineBootstrapVAppConfigSpec `json:"vAppConfig,omitempty"`
+
+	// ExtraConfig bootstraps the guest with guestinfo ExtraConfig keys. It is deprecated, please use CloudInit.
+	// +optional
+	ExtraConfig *VirtualMachineBootstrapExtraConfigSpec `json:"extraConfig,omitempty"`
+}
+
+// NetworkInterfaceProviderReference is the reference to the network interface provider.
+type NetworkInterfaceProviderReference struct {
+	// APIGroup is the group for the resource being referenced.
+	APIGroup string `json:"apiGroup"`
+
+	// Kind is the type of resource being referenced.
+	Kind string `json:"kind"`
+
+	// Name is the name of resource being referenced.
+	Name string `json:"name"`
+
+	// APIVersion is the API version of the referent.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+// VirtualMachineNetworkInterfaceIPConfig is the IP configuration of a network interface. When no addresses are
+// specified, the addresses are allocated by the network provider.
+type VirtualMachineNetworkInterfaceIPConfig struct {
+	// DHCP4 configures the interface with DHCP for IPv4.
+	// +optional
+	DHCP4 bool `json:"dhcp4,omitempty"`
+
+	// DHCP6 configures the interface with DHCP for IPv6.
+	// +optional
+	DHCP6 bool `json:"dhcp6,omitempty"`
+
+	// Addresses are the static IP addresses of the interface in CIDR notation, for example "192.0.2.10/24".
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Gateway4 is the IPv4 default gateway of the interface.
+	// +optional
+	Gateway4 string `json:"gateway4,omitempty"`
+
+	// Gateway6 is the IPv6 default gateway of the interface.
+	// +optional
+	Gateway6 string `json:"gateway6,omitempty"`
+
+	// Nameservers are the DNS servers of the interface.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// SearchDomains are the DNS search domains of the interface.
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// VirtualMachineNetworkInterfaceSpec describes a network interface of a VirtualMachine.
+type VirtualMachineNetworkInterfaceSpec struct {
+	// NetworkType is the type of the network of the interface, "nsx-t" or "vsphere-distributed". It defaults to
+	// the network provider of the cluster.
+	// +optional
+	NetworkType string `json:"networkType,omitempty"`
+
+	// NetworkName is the name of the network of the interface.
+	// +optional
+	NetworkName string `json:"networkName,omitempty"`
+
+	// ProviderRef is the reference to the network interface provider, that is used instead of the network.
+	// +optional
+	ProviderRef *NetworkInterfaceProviderReference `json:"providerRef,omitempty"`
+
+	// EthernetCardType is the type of the virtual ethernet card, "pcnet32", "e1000", "e1000e" or "vmxnet3". It
+	// defaults to "vmxnet3".
+	// +optional
+	EthernetCardType string `json:"ethernetCardType,omitempty"`
+
+	// IPConfig is the IP configuration of the interface.
+	// +optional
+	IPConfig *VirtualMachineNetworkInterfaceIPConfig `json:"ipConfig,omitempty"`
+}
+
+// VirtualMachineNetworkSpec describes the network of a VirtualMachine.
+type VirtualMachineNetworkSpec struct {
+	// Interfaces are the network interfaces of the VirtualMachine.
+	// +optional
+	Interfaces []VirtualMachineNetworkInterfaceSpec `json:"interfaces,omitempty"`
+}
+
+// VirtualMachineVolume describes a Volume that should be attached to a specific VirtualMachine.
+// Only one of PersistentVolumeClaim, VsphereVolume should be specified.
+type VirtualMachineVolume struct {
+	// Name specifies the name of the VirtualMachineVolume. Each volume within the scope of a VirtualMachine must
+	// have a unique name.
+	Name string `json:"name"`
+
+	// PersistentVolumeClaim represents a reference to a PersistentVolumeClaim in the same namespace.
+	// +optional
+	PersistentVolumeClaim *PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+
+	// VsphereVolume represents a reference to a VsphereVolumeSource in the same namespace.
+	// +optional
+	VsphereVolume *VsphereVolumeSource `json:"vSphereVolume,omitempty"`
+}
+
+// PersistentVolumeClaimVolumeSource is a composite for the Kubernetes corev1.PersistentVolumeClaimVolumeSource and
+// instance storage options.
+type PersistentVolumeClaimVolumeSource struct {
+	corev1.PersistentVolumeClaimVolumeSource `json:",inline" yaml:",inline"`
+
+	// InstanceVolumeClaim is set if the PVC is backed by instance storage.
+	// +optional
+	InstanceVolumeClaim *InstanceVolumeClaimVolumeSource `json:"instanceVolumeClaim,omitempty"`
+}
+
+// InstanceVolumeClaimVolumeSource contains information about the instance storage volume claimed as a PVC.
+type InstanceVolumeClaimVolumeSource struct {
+	// StorageClass is the name of the Kubernetes StorageClass that provides the backing storage for this instance
+	// storage volume.
+	StorageClass string `json:"storageClass"`
+
+	// Size is the size of the requested instance storage volume.
+	Size resource.Quantity `json:"size"`
+}
+
+// VsphereVolumeSource describes a volume source that represent static disks that belong to a VirtualMachine.
+type VsphereVolumeSource struct {
+	// Capacity is a description of the virtual volume's resources and capacity.
+	// +optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+
+	// DeviceKey is the device key of the vSphere disk.
+	// +optional
+	DeviceKey *int `json:"deviceKey,omitempty"`
+}
+
+// Probe describes a health check to be performed against a VirtualMachine to determine whether it is
+// alive or ready to receive traffic. Only one probe action can be specified.
+type Probe struct {
+	// TCPSocket specifies an action involving a TCP port.
+	// +optional
+	TCPSocket *TCPSocketAction `json:"tcpSocket,omitempty"`
+
+	// GuestHeartbeat specifies an action involving the guest heartbeat status.
+	// +optional
+	GuestHeartbeat *GuestHeartbeatAction `json:"guestHeartbeat,omitempty"`
+
+	// TimeoutSeconds specifies a number of seconds after which the probe times out.
+	// Defaults to 10 seconds. Minimum value is 1.
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=60
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+
+	// PeriodSeconds specifics how often (in seconds) to perform the probe.
+	// Defaults to 10 seconds. Minimum value is 1.
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+}
+
+// TCPSocketAction describes an action based on opening a socket.
+type TCPSocketAction struct {
+	// Port specifies a number or name of the port to access on the VirtualMachine.
+	Port intstr.IntOrString `json:"port"`
+
+	// Host is an optional host name to connect to. Host defaults to the VirtualMachine IP.
+	// +optional
+	Host string `json:"host,omitempty"`
+}
+
+// GuestHeartbeatStatus is the guest heartbeat status.
+type GuestHeartbeatStatus string
+
+const (
+	// GrayHeartbeatStatus indicates that VMware Tools are not installed or not running.
+	GrayHeartbeatStatus GuestHeartbeatStatus = "gray"
+	// RedHeartbeatStatus indicates that there is no heartbeat.
+	RedHeartbeatStatus GuestHeartbeatStatus = "red"
+	// YellowHeartbeatStatus indicates an intermittent heartbeat.
+	YellowHeartbeatStatus GuestHeartbeatStatus = "yellow"
+	// GreenHeartbeatStatus indicates that the guest operating system is responding normally.
+	GreenHeartbeatStatus GuestHeartbeatStatus = "green"
+)
+
+// GuestHeartbeatAction describes an action based on the guest heartbeat.
+type GuestHeartbeatAction struct {
+	// ThresholdStatus is the value that the guest heartbeat status must be at or above to be considered successful.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=green
+	// +kubebuilder:validation:Enum=yellow;green
+	ThresholdStatus GuestHeartbeatStatus `json:"thresholdStatus,omitempty"`
+}
+
+// VirtualMachineAdvancedOptions describes a set of optional, advanced options for configuring a VirtualMachine.
+type VirtualMachineAdvancedOptions struct {
+	// DefaultVolumeProvisioningOptions specifies the provisioning type to be used by default for VirtualMachine
+	// volumes exclusively owned by this VirtualMachine.
+	// +optional
+	DefaultVolumeProvisioningOptions *VirtualMachineVolumeProvisioningOptions `json:"defaultVolumeProvisioningOptions,omitempty"`
+
+	// ChangeBlockTracking specifies the enablement of incremental backup support for this VirtualMachine.
+	// +optional
+	ChangeBlockTracking *bool `json:"changeBlockTracking,omitempty"`
+}
+
+// VirtualMachineVolumeProvisioningOptions specifies the provisioning options for a VirtualMachineVolume.
+type VirtualMachineVolumeProvisioningOptions struct {
+	// ThinProvisioned specifies whether to use thin provisioning for the VirtualMachineVolume.
+	// +optional
+	ThinProvisioned *bool `json:"thinProvisioned,omitempty"`
+
+	// EagerZeroed specifies whether to use eager zero provisioning for the VirtualMachineVolume. It is only
+	// applicable if ThinProvisioned is false.
+	// +optional
+	EagerZeroed *bool `json:"eagerZeroed,omitempty"`
+}
+
+// VirtualMachineSpec defines the desired state of a VirtualMachine.
+type VirtualMachineSpec struct {
+	// Image is the reference to the image that the VirtualMachine is deployed from.
+	// +optional
+	Image *VirtualMachineImageRef `json:"image,omitempty"`
+
+	// ClassName is the name of the VirtualMachineClass of the VirtualMachine.
+	ClassName string `json:"className"`
+
+	// PowerState is the desired power state of the VirtualMachine.
+	PowerState VirtualMachinePowerState `json:"powerState"`
+
+	// StorageClass is the name of the StorageClass of the storage of the VirtualMachine.
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// Bootstrap is the bootstrap provider of the VirtualMachine.
+	// +optional
+	Bootstrap *VirtualMachineBootstrapSpec `json:"bootstrap,omitempty"`
+
+	// Network is the network of the VirtualMachine.
+	// +optional
+	Network *VirtualMachineNetworkSpec `json:"network,omitempty"`
+
+	// ResourcePolicyName is the name of the VirtualMachineSetResourcePolicy of the VirtualMachine.
+	// +optional
+	ResourcePolicyName string `json:"resourcePolicyName,omitempty"`
+
+	// Volumes are the volumes that are attached to the VirtualMachine.
+	// +optional
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Volumes []VirtualMachineVolume `json:"volumes,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+
+	// ReadinessProbe is the probe that determines whether the VirtualMachine is ready.
+	// +optional
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty"`
+
+	// AdvancedOptions are the advanced options of the VirtualMachine.
+	// +optional
+	AdvancedOptions *VirtualMachineAdvancedOptions `json:"advancedOptions,omitempty"`
+}
+
+// VirtualMachineVolumeStatus defines the observed state of a VirtualMachineVolume instance.
+type VirtualMachineVolumeStatus struct {
+	// Name is the name of the volume in a VirtualMachine.
+	Name string `json:"name"`
+
+	// Attached represents whether a volume has been successfully attached to the VirtualMachine or not.
+	Attached bool `json:"attached"`
+
+	// DiskUUID represents the underlying virtual disk UUID and is present when attachment succeeds.
+	DiskUUID string `json:"diskUUID"`
+
+	// Error represents the last error seen when attaching or detaching a volume.
+	Error string `json:"error"`
+}
+
+// NetworkInterfaceStatus defines the observed state of network interfaces attached to the VirtualMachine as seen by
+// the Guest OS and VMware tools.
+type NetworkInterfaceStatus struct {
+	// Connected represents whether the network interface is connected or not.
+	Connected bool `json:"connected"`
+
+	// MacAddress is the MAC address of the network adapter.
+	// +optional
+	MacAddress string `json:"macAddress,omitempty"`
+
+	// IPAddresses are the IP addresses assigned to the network interface in CIDR notation.
+	// +optional
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+}
+
+// VirtualMachineStatus defines the observed state of a VirtualMachine instance.
+type VirtualMachineStatus struct {
+	// Host is the hostname or IP address of the infrastructure host that the VirtualMachine is executing on.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// PowerState is the current power state of the VirtualMachine.
+	// +optional
+	PowerState VirtualMachinePowerState `json:"powerState,omitempty"`
+
+	// Phase is the current phase of the VirtualMachine.
+	// +optional
+	Phase VMStatusPhase `json:"phase,omitempty"`
+
+	// Conditions are the current conditions of the VirtualMachine.
+	// +optional
+	Conditions []vmopv1alpha1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// VMIP is the primary IP address assigned to the guest operating system, if known.
+	// +optional
+	VMIP string `json:"vmIp,omitempty"`
+
+	// UniqueID is a unique identifier that is provided by the underlying infrastructure provider.
+	// +optional
+	UniqueID string `json:"uniqueID,omitempty"`
+
+	// BiosUUID is the unique identifier that is exposed to the Guest OS BIOS as a unique hardware identifier.
+	// +optional
+	BiosUUID string `json:"biosUUID,omitempty"`
+
+	// InstanceUUID is the unique instance UUID provided by the underlying infrastructure provider.
+	// +optional
+	InstanceUUID string `json:"instanceUUID,omitempty"`
+
+	// Volumes are the status of the volumes that are attached to the VirtualMachine.
+	// +optional
+	Volumes []VirtualMachineVolumeStatus `json:"volumes,omitempty"`
+
+	// ChangeBlockTracking is the CBT enablement status of the VirtualMachine.
+	// +optional
+	ChangeBlockTracking *bool `json:"changeBlockTracking,omitempty"`
+
+	// NetworkInterfaces are the status of the network interfaces of the VirtualMachine.
+	// +optional
+	NetworkInterfaces []NetworkInterfaceStatus `json:"networkInterfaces,omitempty"`
+
+	// Zone is the availability zone where the VirtualMachine has been scheduled.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+}
+
+// VirtualMachine is the Schema for the v1alpha2 virtualmachines API. VirtualMachines are stored as v1alpha1.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=vm
+// +kubebuilder:unservedversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="PowerState",type="string",JSONPath=".status.powerState"
+// +kubebuilder:printcolumn:name="Class",type="string",priority=1,JSONPath=".spec.className"
+// +kubebuilder:printcolumn:name="Image",type="string",priority=1,JSONPath=".spec.image.name"
+// +kubebuilder:printcolumn:name="Primary-IP",type="string",priority=1,JSONPath=".status.vmIp"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type VirtualMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualMachineSpec   `json:"spec,omitempty"`
+	Status VirtualMachineStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the conditions of the VirtualMachine.
+func (vm *VirtualMachine) GetConditions() vmopv1alpha1.Conditions {
+	return vm.Status.Conditions
+}
+
+// SetConditions sets the conditions of the VirtualMachine.
+func (vm *VirtualMachine) SetConditions(conditions vmopv1alpha1.Conditions) {
+	vm.Status.Conditions = conditions
+}
+
+// VirtualMachineList contains a list of VirtualMachine resources.
+//
+// +kubebuilder:object:root=true
+type VirtualMachineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualMachine `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualMachine{}, &VirtualMachineList{})
+}
@@ -5,9 +5,11 @@ package contentsource
 
 import (
 	goctx "context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -16,15 +18,21 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/record"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
 )
 
@@ -52,7 +60,8 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) er
 	return ctrl.NewControllerManagedBy(mgr).
 		For(controlledType).
 		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
-		Owns(&vmopv1alpha1.ContentLibraryProvider{}).
+		// The sync status annotation is updated on every sync, so ignore changes that do not bump the generation.
+		Owns(&vmopv1alpha1.ContentLibraryProvider{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
 
@@ -110,11 +119,32 @@ func (r *Reconciler) CreateImages(ctx goctx.Context, images []vmopv1alpha1.Virtu
 	return retErr
 }
 
-// DeleteImages deletes a set of VirtualMachineImages in a best effort manner.
+// DeleteImages deletes a set of VirtualMachineImages in a best effort manner. An image that is still used by a
+// VirtualMachine is deprecated instead, and is deleted by a later sync once no VirtualMachines use it.
 func (r *Reconciler) DeleteImages(ctx goctx.Context, images []vmopv1alpha1.VirtualMachineImage) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	imagesInUse, err := r.getImagesInUse(ctx)
+	if err != nil {
+		return err
+	}
+
 	var retErr error
 	for _, image := range images {
 		img := image
+
+		_, inUseByName := imagesInUse[img.Name]
+		_, inUseByDisplayName := imagesInUse[vmimage.DisplayName(&img)]
+		if inUseByName || inUseByDisplayName {
+			if err := r.deprecateImage(ctx, &img); err != nil {
+				retErr = err
+				r.Logger.Error(err, "failed to deprecate VirtualMachineImage", "name", img.Name)
+			}
+			continue
+		}
+
 		r.Logger.V(4).Info("Deleting image", "name", img.Name)
 		if err := r.Delete(ctx, &img); err != nil {
 			retErr = err
@@ -125,6 +155,38 @@ func (r *Reconciler) DeleteImages(ctx goctx.Context, images []vmopv1alpha1.Virtu
 	return retErr
 }
 
+// getImagesInUse returns the image names that VirtualMachines refer to, which may be either the name or the
+// display name of a VirtualMachineImage.
+func (r *Reconciler) getImagesInUse(ctx goctx.Context) (map[string]struct{}, error) {
+	vmList := &vmopv1alpha1.VirtualMachineList{}
+	if err := r.List(ctx, vmList); err != nil {
+		return nil, errors.Wrap(err, "failed to list VirtualMachines")
+	}
+
+	imagesInUse := make(map[string]struct{}, len(vmList.Items))
+	for _, vm := range vmList.Items {
+		imagesInUse[vm.Spec.ImageName] = struct{}{}
+	}
+
+	return imagesInUse, nil
+}
+
+// deprecateImage marks the image as deprecated, if it is not already.
+func (r *Reconciler) deprecateImage(ctx goctx.Context, img *vmopv1alpha1.VirtualMachineImage) error {
+	if _, ok := img.Annotations[vmopapiv1alpha1.VirtualMachineImageDeprecatedAnnotation]; ok {
+		return nil
+	}
+
+	r.Logger.Info("Deprecating VirtualMachineImage that is in use", "name", img.Name)
+	patch := client.MergeFrom(img.DeepCopy())
+	if img.Annotations == nil {
+		img.Annotations = map[string]string{}
+	}
+	img.Annotations[vmopapiv1alpha1.VirtualMachineImageDeprecatedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+	return r.Patch(ctx, img, patch)
+}
+
 // UpdateImages updates a set of VirtualMachineImages in a best effort manner.
 func (r *Reconciler) UpdateImages(ctx goctx.Context, images []vmopv1alpha1.VirtualMachineImage) error {
 	var retErr error
@@ -169,12 +231,7 @@ func IsImageOwnedByContentLibrary(img vmopv1alpha1.VirtualMachineImage, clUUID s
 // GetVMImageName returns the display name of the image defined in the template.
 // Note that this is different from the name of the VirtualMachineImage Kubernetes object.
 func GetVMImageName(img vmopv1alpha1.VirtualMachineImage) string {
-	name := img.Status.ImageName
-	// This happens if a vm image is created before duplicate vm image name is supported.
-	if name == "" {
-		name = img.Name
-	}
-	return name
+	return vmimage.DisplayName(&img)
 }
 
 // DiffImages difference two lists of VirtualMachineImages producing 3 lists: images that have been added
@@ -220,7 +277,7 @@ func (r *Reconciler) DiffImages(
 			// Image already exists on the API server.
 			beforeUpdate := image.DeepCopy()
 			// Identify updated items.
-			image.Annotations = providerImages[i].Annotations
+			image.Annotations = updatedImageAnnotations(image.Annotations, providerImages[i].Annotations)
 			image.OwnerReferences = providerImages[i].OwnerReferences
 			image.Spec = providerImages[i].Spec
 			image.Status = providerImages[i].Status
@@ -231,10 +288,12 @@ func (r *Reconciler) DiffImages(
 		}
 	}
 
-	// Identify added items
+	// Identify added items. Existing images keep their names, but new images are named so that items with the
+	// same name in different content libraries do not collide.
 	for _, i := range providerImages {
 		providerImageName := i.Status.ImageName
 		if _, ok := k8sImagesInCLMap[providerImageName]; !ok {
+			i.Name = vmimage.NameForLibraryItem(clUUID, providerImageName)
 			added = append(added, i)
 		}
 	}
@@ -242,6 +301,22 @@ func (r *Reconciler) DiffImages(
 	return added, removed, updated
 }
 
+// updatedImageAnnotations returns the annotations of the provider image, plus the annotations of the existing
+// image that are not set by the provider. An image that is listed by the provider is no longer deprecated.
+func updatedImageAnnotations(existing, provider map[string]string) map[string]string {
+	annotations := make(map[string]string, len(provider)+1)
+	for k, v := range provider {
+		annotations[k] = v
+	}
+	delete(annotations, vmopapiv1alpha1.VirtualMachineImageDeprecatedAnnotation)
+
+	if v, ok := existing[vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation]; ok {
+		annotations[vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation] = v
+	}
+
+	return annotations
+}
+
 // GetImagesFromContentProvider fetches the VM images from a given content provider. Also sets the owner ref in the images.
 func (r *Reconciler) GetImagesFromContentProvider(
 	ctx goctx.Context,
@@ -283,8 +358,11 @@ func (r *Reconciler) GetImagesFromContentProvider(
 
 	images, err := r.VMProvider.ListVirtualMachineImagesFromContentLibrary(ctx, clProvider, currentCLImages)
 	if err != nil {
-		logger.Error(err, "error listing images from provider")
-		return nil, err
+		if itemsErr := (*vmprovider.LibraryItemsError)(nil); !errors.As(err, &itemsErr) {
+			logger.Error(err, "error listing images from provider")
+			return nil, err
+		}
+		logger.Error(err, "error listing some images from provider")
 	}
 
 	convertedImages := make([]vmopv1alpha1.VirtualMachineImage, 0)
@@ -294,11 +372,22 @@ func (r *Reconciler) GetImagesFromContentProvider(
 		convertedImages = append(convertedImages, *img)
 	}
 
-	return convertedImages, nil
+	// A LibraryItemsError is returned with the images that could be listed.
+	return convertedImages, err
 }
 
+// DifferenceImages differences the VirtualMachineImages of the content source with the images listed from the
+// content provider. When some library items could not be listed, the differences for the other images are
+// returned with a LibraryItemsError.
 func (r *Reconciler) DifferenceImages(ctx goctx.Context,
 	contentSource *vmopv1alpha1.ContentSource) ([]vmopv1alpha1.VirtualMachineImage, []vmopv1alpha1.VirtualMachineImage, []vmopv1alpha1.VirtualMachineImage, error) {
+	added, removed, updated, _, err := r.differenceImages(ctx, contentSource)
+	return added, removed, updated, err
+}
+
+func (r *Reconciler) differenceImages(ctx goctx.Context,
+	contentSource *vmopv1alpha1.ContentSource) (
+	added, removed, updated []vmopv1alpha1.VirtualMachineImage, providerImageCount int, retErr error) {
 	r.Logger.V(4).Info("Differencing images")
 
 	// List the existing images from both the vm provider backend and the Kubernetes control plane (etcd).
@@ -306,7 +395,7 @@ func (r *Reconciler) DifferenceImages(ctx goctx.Context,
 	// the backend.
 	k8sManagedImageList := &vmopv1alpha1.VirtualMachineImageList{}
 	if err := r.List(ctx, k8sManagedImageList); err != nil {
-		return nil, nil, nil, errors.Wrap(err, "failed to list VirtualMachineImages from control plane")
+		return nil, nil, nil, 0, errors.Wrap(err, "failed to list VirtualMachineImages from control plane")
 	}
 
 	k8sManagedImages := k8sManagedImageList.Items
@@ -314,25 +403,50 @@ func (r *Reconciler) DifferenceImages(ctx goctx.Context,
 	// Best effort to list VirtualMachineImages from this content sources.
 	providerManagedImages, err := r.GetImagesFromContentProvider(ctx, *contentSource, k8sManagedImages)
 	if err != nil {
-		r.Logger.Error(err, "Error listing VirtualMachineImages from the content provider", "contentSourceName", contentSource.Name)
-		return nil, nil, nil, err
+		if itemsErr := (*vmprovider.LibraryItemsError)(nil); !errors.As(err, &itemsErr) {
+			r.Logger.Error(err, "Error listing VirtualMachineImages from the content provider", "contentSourceName", contentSource.Name)
+			return nil, nil, nil, 0, err
+		}
 	}
 
 	// Difference the kubernetes images with the provider images
-	added, removed, updated := r.DiffImages(contentSource.Spec.ProviderRef.Name, k8sManagedImages, providerManagedImages)
+	added, removed, updated = r.DiffImages(contentSource.Spec.ProviderRef.Name, k8sManagedImages, providerManagedImages)
 	r.Logger.V(4).Info("Differenced", "added", added, "removed", removed, "updated", updated)
 
-	return added, removed, updated, nil
+	return added, removed, updated, len(providerManagedImages), err
 }
 
-// SyncImages syncs images from the given content sources.
+// SyncImages syncs images from the given content sources, and records the result in the sync status of the
+// content provider.
 func (r *Reconciler) SyncImages(ctx goctx.Context, contentSource *vmopv1alpha1.ContentSource) error {
-	added, removed, updated, err := r.DifferenceImages(ctx, contentSource)
-	if err != nil {
+	syncStatus := &vmopapiv1alpha1.ContentLibrarySyncStatus{
+		LastSyncTime: metav1.Now(),
+	}
+
+	added, removed, updated, count, err := r.differenceImages(ctx, contentSource)
+	itemsErr := (*vmprovider.LibraryItemsError)(nil)
+	if err != nil && !errors.As(err, &itemsErr) {
 		r.Logger.Error(err, "failed to difference images")
+		syncStatus.Conditions = append(syncStatus.Conditions, *conditions.FalseCondition(
+			vmopapiv1alpha1.ContentLibrarySyncedCondition,
+			vmopapiv1alpha1.ContentLibraryListFailedReason,
+			vmopv1alpha1.ConditionSeverityError,
+			"%s", err.Error()))
+		r.updateSyncStatus(ctx, contentSource, syncStatus)
 		return err
 	}
 
+	syncStatus.ItemCount = int32(count)
+	if itemsErr != nil {
+		for _, item := range itemsErr.Items {
+			syncStatus.ItemErrors = append(syncStatus.ItemErrors, vmopapiv1alpha1.ContentLibraryItemSyncError{
+				ItemID:   item.ItemID,
+				ItemName: item.ItemName,
+				Message:  item.Err.Error(),
+			})
+		}
+	}
+
 	// Best effort to sync VirtualMachineImage resources between provider and API server.
 	// DeleteImages should be called before CreateImages, in case that removed list and added list have duplicate
 	// vm images.
@@ -351,11 +465,65 @@ func (r *Reconciler) SyncImages(ctx goctx.Context, contentSource *vmopv1alpha1.C
 		r.Logger.Error(updateErr, "failed to update VirtualMachineImages")
 	}
 
+	var syncErr error
 	if createErr != nil || updateErr != nil || deleteErr != nil {
-		return fmt.Errorf("error syncing VirtualMachineImage resources between provider and API server")
+		syncErr = fmt.Errorf("error syncing VirtualMachineImage resources between provider and API server")
 	}
 
-	return nil
+	switch {
+	case syncErr != nil:
+		syncStatus.Conditions = append(syncStatus.Conditions, *conditions.FalseCondition(
+			vmopapiv1alpha1.ContentLibrarySyncedCondition,
+			vmopapiv1alpha1.ContentLibraryImagesFailedReason,
+			vmopv1alpha1.ConditionSeverityError,
+			"%s", syncErr.Error()))
+	case itemsErr != nil:
+		syncStatus.Conditions = append(syncStatus.Conditions, *conditions.FalseCondition(
+			vmopapiv1alpha1.ContentLibrarySyncedCondition,
+			vmopapiv1alpha1.ContentLibraryItemsFailedReason,
+			vmopv1alpha1.ConditionSeverityWarning,
+			"%s", itemsErr.Error()))
+	default:
+		syncStatus.Conditions = append(syncStatus.Conditions, *conditions.TrueCondition(vmopapiv1alpha1.ContentLibrarySyncedCondition))
+	}
+
+	r.updateSyncStatus(ctx, contentSource, syncStatus)
+
+	return syncErr
+}
+
+// updateSyncStatus records the sync status on the ContentLibraryProvider of the content source. Failing to
+// record it does not fail the sync.
+func (r *Reconciler) updateSyncStatus(
+	ctx goctx.Context,
+	contentSource *vmopv1alpha1.ContentSource,
+	syncStatus *vmopapiv1alpha1.ContentLibrarySyncStatus) {
+
+	providerRef := contentSource.Spec.ProviderRef
+	logger := r.Logger.WithValues("contentSourceName", contentSource.Name, "providerRefName", providerRef.Name)
+
+	data, err := json.Marshal(syncStatus)
+	if err != nil {
+		logger.Error(err, "failed to marshal the sync status")
+		return
+	}
+
+	clProvider := &vmopv1alpha1.ContentLibraryProvider{}
+	if err := r.Get(ctx, client.ObjectKey{Name: providerRef.Name, Namespace: providerRef.Namespace}, clProvider); err != nil {
+		logger.Error(err, "failed to get ContentLibraryProvider to record the sync status")
+		return
+	}
+
+	// Patch only the annotation so that concurrent changes to the ContentLibraryProvider are not overwritten.
+	patch := client.MergeFrom(clProvider.DeepCopy())
+	if clProvider.Annotations == nil {
+		clProvider.Annotations = map[string]string{}
+	}
+	clProvider.Annotations[vmopapiv1alpha1.ContentLibrarySyncStatusAnnotation] = string(data)
+
+	if err := r.Patch(ctx, clProvider, patch); err != nil {
+		logger.Error(err, "failed to record the sync status on the ContentLibraryProvider")
+	}
 }
 
 // ReconcileProviderRef reconciles a ContentSource's provider reference. Verifies that the content provider pointed by
@@ -479,9 +647,10 @@ func (r *Reconciler) ReconcileDelete(ctx goctx.Context, contentSource *vmopv1alp
 
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentsources,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentsources/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentlibraryproviders,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentlibraryproviders,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentlibraryproviders/status,verbs=get
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentsourcebindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list;watch
 
 func (r *Reconciler) Reconcile(ctx goctx.Context, request ctrl.Request) (ctrl.Result, error) {
 	r.Logger.Info("Received reconcile request", "name", request.Name)
@@ -504,5 +673,6 @@ func (r *Reconciler) Reconcile(ctx goctx.Context, request ctrl.Request) (ctrl.Re
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Periodically resync since there are no watch events for changes to the content library.
+	return ctrl.Result{RequeueAfter: lib.GetContentSourceResyncInterval()}, nil
 }
@@ -16,6 +16,7 @@ import (
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
 )
 
@@ -70,7 +71,7 @@ func intgTests() {
 
 		csKey = types.NamespacedName{Name: cs.ObjectMeta.Name}
 		clKey = types.NamespacedName{Name: cl.ObjectMeta.Name}
-		imgKey = types.NamespacedName{Name: img.ObjectMeta.Name}
+		imgKey = types.NamespacedName{Name: vmimage.NameForLibraryItem(cl.Name, imageName)}
 	})
 
 	AfterEach(func() {
@@ -242,7 +243,8 @@ func intgTests() {
 						clObj := getContentLibraryProvider(ctx, clKey)
 						Expect(clObj).ToNot(BeNil())
 						expectedImg = populateExpectedImg(newImg, clObj)
-						waitForVirtualMachineImage(ctx, types.NamespacedName{Name: newImg.ObjectMeta.Name}, expectedImg)
+						newImgKey := types.NamespacedName{Name: vmimage.NameForLibraryItem(clObj.Name, newImg.Status.ImageName)}
+						waitForVirtualMachineImage(ctx, newImgKey, expectedImg)
 					})
 
 					By("The old VirtualMachineImage should be removed", func() {
@@ -293,7 +295,7 @@ func intgTests() {
 					Expect(err == nil || k8serrors.IsNotFound(err)).To(BeTrue())
 				})
 
-				It("should reconcile and generate a new VirtualMachineImage object", func() {
+				It("should reconcile and create a VirtualMachineImage object with a different name", func() {
 					images := &vmopv1alpha1.VirtualMachineImageList{}
 					Eventually(func() int {
 						if err := ctx.Client.List(ctx, images); err != nil {
@@ -305,16 +307,10 @@ func intgTests() {
 					newCLObj := getContentLibraryProvider(ctx, newCLKey)
 					Expect(newCLObj).ToNot(BeNil())
 
-					var generatedName string
-					if images.Items[0].Name == imageName {
-						generatedName = images.Items[1].Name
-					} else {
-						generatedName = images.Items[0].Name
-					}
-
-					Expect(generatedName).Should(HavePrefix(imageName + "-"))
+					newImgKey := types.NamespacedName{Name: vmimage.NameForLibraryItem(newCLObj.Name, imageName)}
+					Expect(newImgKey).ToNot(Equal(imgKey))
 					expectedImg := populateExpectedImg(img, newCLObj)
-					waitForVirtualMachineImage(ctx, types.NamespacedName{Name: generatedName}, expectedImg)
+					waitForVirtualMachineImage(ctx, newImgKey, expectedImg)
 				})
 			})
 		})
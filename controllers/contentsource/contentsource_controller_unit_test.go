@@ -5,6 +5,7 @@ package contentsource_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 
@@ -12,12 +13,16 @@ import (
 	. "github.com/onsi/gomega"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/controllers/contentsource"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
 	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
 )
@@ -377,6 +382,95 @@ func unitTestsCRUDImage() {
 				})
 			})
 		})
+
+		Context("sync status", func() {
+			var providerImg *v1alpha1.VirtualMachineImage
+
+			BeforeEach(func() {
+				providerImg = &v1alpha1.VirtualMachineImage{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "dummy-image",
+					},
+					Spec: v1alpha1.VirtualMachineImageSpec{
+						ImageID: "dummy-id",
+					},
+					Status: v1alpha1.VirtualMachineImageStatus{
+						ImageName: "dummy-image",
+					},
+				}
+				initObjects = append(initObjects, &cl, &cs)
+			})
+
+			getSyncStatus := func() *vmopapiv1alpha1.ContentLibrarySyncStatus {
+				clProvider := &v1alpha1.ContentLibraryProvider{}
+				Expect(ctx.Client.Get(ctx, client.ObjectKey{Name: cl.Name}, clProvider)).To(Succeed())
+				Expect(clProvider.Annotations).To(HaveKey(vmopapiv1alpha1.ContentLibrarySyncStatusAnnotation))
+
+				syncStatus := &vmopapiv1alpha1.ContentLibrarySyncStatus{}
+				Expect(json.Unmarshal([]byte(clProvider.Annotations[vmopapiv1alpha1.ContentLibrarySyncStatusAnnotation]), syncStatus)).To(Succeed())
+				return syncStatus
+			}
+
+			It("records a successful sync", func() {
+				fakeVMProvider.ListVirtualMachineImagesFromContentLibraryFn = func(_ context.Context, _ v1alpha1.ContentLibraryProvider,
+					_ map[string]v1alpha1.VirtualMachineImage) ([]*v1alpha1.VirtualMachineImage, error) {
+					return []*v1alpha1.VirtualMachineImage{providerImg}, nil
+				}
+
+				Expect(reconciler.SyncImages(ctx.Context, &cs)).To(Succeed())
+
+				syncStatus := getSyncStatus()
+				Expect(syncStatus.LastSyncTime.IsZero()).To(BeFalse())
+				Expect(syncStatus.ItemCount).To(BeEquivalentTo(1))
+				Expect(syncStatus.ItemErrors).To(BeEmpty())
+				Expect(syncStatus.Conditions).To(HaveLen(1))
+				Expect(syncStatus.Conditions[0].Type).To(Equal(vmopapiv1alpha1.ContentLibrarySyncedCondition))
+				Expect(syncStatus.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+			})
+
+			It("syncs the other images when some library items fail", func() {
+				fakeVMProvider.ListVirtualMachineImagesFromContentLibraryFn = func(_ context.Context, _ v1alpha1.ContentLibraryProvider,
+					_ map[string]v1alpha1.VirtualMachineImage) ([]*v1alpha1.VirtualMachineImage, error) {
+					return []*v1alpha1.VirtualMachineImage{providerImg}, &vmprovider.LibraryItemsError{
+						Items: []vmprovider.LibraryItemError{
+							{ItemID: "bad-id", ItemName: "bad-item", Err: fmt.Errorf("ovf error")},
+						},
+					}
+				}
+
+				Expect(reconciler.SyncImages(ctx.Context, &cs)).To(Succeed())
+
+				imgList := &v1alpha1.VirtualMachineImageList{}
+				Expect(ctx.Client.List(ctx, imgList)).To(Succeed())
+				Expect(imgList.Items).To(HaveLen(1))
+				Expect(imgList.Items[0].Name).To(Equal(vmimage.NameForLibraryItem(cl.Name, providerImg.Status.ImageName)))
+
+				syncStatus := getSyncStatus()
+				Expect(syncStatus.ItemCount).To(BeEquivalentTo(1))
+				Expect(syncStatus.ItemErrors).To(ConsistOf(vmopapiv1alpha1.ContentLibraryItemSyncError{
+					ItemID:   "bad-id",
+					ItemName: "bad-item",
+					Message:  "ovf error",
+				}))
+				Expect(syncStatus.Conditions).To(HaveLen(1))
+				Expect(syncStatus.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+				Expect(syncStatus.Conditions[0].Reason).To(Equal(vmopapiv1alpha1.ContentLibraryItemsFailedReason))
+			})
+
+			It("records a failed list", func() {
+				fakeVMProvider.ListVirtualMachineImagesFromContentLibraryFn = func(_ context.Context, _ v1alpha1.ContentLibraryProvider,
+					_ map[string]v1alpha1.VirtualMachineImage) ([]*v1alpha1.VirtualMachineImage, error) {
+					return nil, fmt.Errorf("list error")
+				}
+
+				Expect(reconciler.SyncImages(ctx.Context, &cs)).ToNot(Succeed())
+
+				syncStatus := getSyncStatus()
+				Expect(syncStatus.Conditions).To(HaveLen(1))
+				Expect(syncStatus.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+				Expect(syncStatus.Conditions[0].Reason).To(Equal(vmopapiv1alpha1.ContentLibraryListFailedReason))
+			})
+		})
 	})
 
 	Context("DeleteImages", func() {
@@ -422,6 +516,30 @@ func unitTestsCRUDImage() {
 				Expect(err).To(HaveOccurred())
 			})
 		})
+
+		When("the image is used by a VirtualMachine", func() {
+			BeforeEach(func() {
+				vm := &v1alpha1.VirtualMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "dummy-vm",
+						Namespace: "dummy-ns",
+					},
+					Spec: v1alpha1.VirtualMachineSpec{
+						ImageName: image.Name,
+					},
+				}
+				initObjects = append(initObjects, &image, vm)
+				images = append(images, image)
+			})
+
+			It("deprecates the image instead of deleting it", func() {
+				Expect(reconciler.DeleteImages(ctx, images)).To(Succeed())
+
+				img := &v1alpha1.VirtualMachineImage{}
+				Expect(ctx.Client.Get(ctx, client.ObjectKey{Name: image.Name}, img)).To(Succeed())
+				Expect(img.Annotations).To(HaveKey(vmopapiv1alpha1.VirtualMachineImageDeprecatedAnnotation))
+			})
+		})
 	})
 
 	Context("UpdateImages", func() {
@@ -669,6 +787,31 @@ func unitTestsCRUDImage() {
 				})
 			})
 
+			When("the k8s image is deprecated and in use", func() {
+				BeforeEach(func() {
+					imageK8s = v1alpha1.VirtualMachineImage{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								vmopapiv1alpha1.VirtualMachineImageDeprecatedAnnotation: "2021-01-01T00:00:00Z",
+								vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation:    "dummy-ns/dummy-vm",
+							},
+						},
+					}
+
+					imageProvider = v1alpha1.VirtualMachineImage{}
+				})
+
+				It("should no longer be deprecated but still be in use", func() {
+					added, removed, updated := reconciler.DiffImages(cl.Name, k8sImages, providerImages)
+					Expect(added).To(BeEmpty())
+					Expect(removed).To(BeEmpty())
+					Expect(updated).To(HaveLen(1))
+					Expect(updated[0].Annotations).To(Equal(map[string]string{
+						vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation: "dummy-ns/dummy-vm",
+					}))
+				})
+			})
+
 			When("k8s and provider lists have different OwnerReference", func() {
 				var ownerRef = []metav1.OwnerReference{{
 					Name: "dummy-name",
@@ -745,7 +888,9 @@ func unitTestsCRUDImage() {
 					added, removed, updated := reconciler.DiffImages(cl.Name, k8sImages, providerImages)
 					Expect(added).ToNot(BeEmpty())
 					Expect(added).To(HaveLen(1))
-					Expect(added).To(ContainElement(providerImages[0]))
+					expectedImage := providerImages[0]
+					expectedImage.Name = vmimage.NameForLibraryItem(cl.Name, expectedImage.Status.ImageName)
+					Expect(added).To(ContainElement(expectedImage))
 					Expect(removed).To(BeEmpty())
 					Expect(updated).To(BeEmpty())
 				})
@@ -800,7 +945,9 @@ func unitTestsCRUDImage() {
 				added, removed, updated := reconciler.DiffImages(cl.Name, k8sImages, providerImages)
 				Expect(added).ToNot(BeEmpty())
 				Expect(added).To(HaveLen(1))
-				Expect(added).To(ContainElement(imageProvider))
+				expectedImage := imageProvider
+				expectedImage.Name = vmimage.NameForLibraryItem(cl.Name, expectedImage.Status.ImageName)
+				Expect(added).To(ContainElement(expectedImage))
 				Expect(removed).To(BeEmpty())
 				Expect(updated).To(BeEmpty())
 			})
@@ -971,7 +1118,7 @@ func unitTestsCRUDImage() {
 
 				Expect(added).NotTo(BeEmpty())
 				Expect(added).To(HaveLen(1))
-				Expect(imageExists(img2.Name, added)).To(BeTrue())
+				Expect(imageExists(vmimage.NameForLibraryItem(cl.Name, img2.Status.ImageName), added)).To(BeTrue())
 
 				Expect(removed).NotTo(BeEmpty())
 				Expect(removed).To(HaveLen(1))
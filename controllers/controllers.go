@@ -10,12 +10,18 @@ import (
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
 
 	"github.com/vmware-tanzu/vm-operator/controllers/contentsource"
+	"github.com/vmware-tanzu/vm-operator/controllers/imagecache"
 	"github.com/vmware-tanzu/vm-operator/controllers/infracluster"
 	"github.com/vmware-tanzu/vm-operator/controllers/infraprovider"
+	"github.com/vmware-tanzu/vm-operator/controllers/orphanedvm"
 	"github.com/vmware-tanzu/vm-operator/controllers/providerconfigmap"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachine"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineclass"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineimage"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineimageimport"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinequota"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinereplicaset"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinerestore"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineservice"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinesetresourcepolicy"
 	"github.com/vmware-tanzu/vm-operator/controllers/volume"
@@ -26,12 +32,18 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) er
 	if err := contentsource.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize ContentSource controller")
 	}
+	if err := imagecache.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize ImageCache controller")
+	}
 	if err := infracluster.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize InfraCluster controller")
 	}
 	if err := infraprovider.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize InfraProvider controller")
 	}
+	if err := orphanedvm.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize OrphanedVM controller")
+	}
 	if err := providerconfigmap.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize ProviderConfigMap controller")
 	}
@@ -44,6 +56,18 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) er
 	if err := virtualmachineimage.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize VirtualMachineImage controller")
 	}
+	if err := virtualmachineimageimport.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize VirtualMachineImageImport controller")
+	}
+	if err := virtualmachinequota.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize VirtualMachineQuota controller")
+	}
+	if err := virtualmachinereplicaset.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize VirtualMachineReplicaSet controller")
+	}
+	if err := virtualmachinerestore.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize VirtualMachineRestore controller")
+	}
 	if err := virtualmachineservice.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize VirtualMachineService controller")
 	}
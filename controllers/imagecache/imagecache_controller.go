@@ -0,0 +1,154 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagecache
+
+import (
+	goctx "context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/record"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+)
+
+const (
+	imageCacheWarmedReason  = "ImageCacheWarmed"
+	imageCacheEvictedReason = "ImageCacheEvicted"
+	syncImageCacheOpName    = "SyncImageCache"
+)
+
+var (
+	imageCacheEntriesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vmoperator_image_cache_entries",
+			Help: "Number of template VMs caching images that VMs are linked cloned from.",
+		},
+		[]string{"namespace"},
+	)
+
+	imageCacheEvictedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vmoperator_image_cache_evicted_total",
+			Help: "Number of image cache template VMs evicted by VM Operator.",
+		},
+		[]string{"namespace"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(imageCacheEntriesGauge, imageCacheEvictedCounter)
+}
+
+// AddToManager adds this package's controller to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	if !lib.IsLinkedCloneImageCacheEnabled() {
+		return nil
+	}
+
+	var (
+		controlledType = &corev1.Namespace{}
+
+		controllerNameShort = "imagecache-controller"
+		controllerNameLong  = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, controllerNameShort)
+	)
+
+	r := NewReconciler(
+		mgr.GetClient(),
+		ctrl.Log.WithName("controllers").WithName("ImageCache"),
+		record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+		ctx.VMProvider,
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("imagecache").
+		For(controlledType).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(r)
+}
+
+func NewReconciler(
+	client client.Client,
+	logger logr.Logger,
+	recorder record.Recorder,
+	vmProvider vmprovider.VirtualMachineProviderInterface) *Reconciler {
+	return &Reconciler{
+		Client:     client,
+		Logger:     logger,
+		Recorder:   recorder,
+		VMProvider: vmProvider,
+	}
+}
+
+// Reconciler periodically warms up and evicts the image cache of each Namespace.
+type Reconciler struct {
+	client.Client
+	Logger     logr.Logger
+	Recorder   record.Recorder
+	VMProvider vmprovider.VirtualMachineProviderInterface
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (ctrl.Result, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, req.NamespacedName, ns); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			imageCacheEntriesGauge.DeleteLabelValues(req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !ns.DeletionTimestamp.IsZero() {
+		imageCacheEntriesGauge.DeleteLabelValues(ns.Name)
+		return ctrl.Result{}, nil
+	}
+
+	logger := r.Logger.WithValues("namespace", ns.Name)
+	if err := r.ReconcileNormal(ctx, logger, ns); err != nil {
+		logger.Error(err, "Failed to sync image cache")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: lib.GetImageCacheSyncInterval()}, nil
+}
+
+// ReconcileNormal syncs the image cache of the Namespace, and reports the template VMs that were warmed up
+// or evicted.
+func (r *Reconciler) ReconcileNormal(ctx goctx.Context, logger logr.Logger, ns *corev1.Namespace) error {
+	result, err := r.VMProvider.SyncImageCache(ctx, ns.Name, lib.GetImageCacheMaxUnusedAge())
+	if err != nil {
+		r.Recorder.EmitEvent(ns, syncImageCacheOpName, err, false)
+		return err
+	}
+
+	imageCacheEntriesGauge.WithLabelValues(ns.Name).Set(float64(len(result.Cached)))
+
+	if len(result.Warmed) > 0 {
+		logger.Info("Warmed up image cache", "templateVMs", result.Warmed)
+		r.Recorder.Eventf(ns, imageCacheWarmedReason, "Cached images in template VMs %s",
+			strings.Join(result.Warmed, ", "))
+	}
+
+	if len(result.Evicted) > 0 {
+		logger.Info("Evicted image cache", "templateVMs", result.Evicted)
+		r.Recorder.Eventf(ns, imageCacheEvictedReason, "Evicted template VMs %s",
+			strings.Join(result.Evicted, ", "))
+		imageCacheEvictedCounter.WithLabelValues(ns.Name).Add(float64(len(result.Evicted)))
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagecache_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	var (
+		ctx *builder.IntegrationTestContext
+	)
+
+	BeforeEach(func() {
+		ctx = suite.NewIntegrationTestContext()
+
+		intgFakeVMProvider.Lock()
+		intgFakeVMProvider.SyncImageCacheFn = func(_ context.Context, namespace string, _ time.Duration) (*vmprovider.ImageCacheSyncResult, error) {
+			result := &vmprovider.ImageCacheSyncResult{}
+			if namespace == ctx.Namespace {
+				result.Evicted = []string{"image-cache-item-1-1-datastore-1"}
+			}
+			return result, nil
+		}
+		intgFakeVMProvider.Unlock()
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		intgFakeVMProvider.Reset()
+	})
+
+	Describe("Reconcile", func() {
+		It("reports evicted template VMs in the namespace", func() {
+			Eventually(func() bool {
+				eventList := &corev1.EventList{}
+				if err := ctx.Client.List(ctx, eventList); err != nil {
+					return false
+				}
+				for _, e := range eventList.Items {
+					if e.InvolvedObject.Name == ctx.Namespace && e.Reason == "ImageCacheEvicted" {
+						return true
+					}
+				}
+				return false
+			}).Should(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagecache_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/vmware-tanzu/vm-operator/controllers/imagecache"
+	ctrlContext "github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+var intgFakeVMProvider = providerfake.NewVMProvider()
+
+var suite = builder.NewTestSuiteForController(
+	func(ctx *ctrlContext.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+		// The controller is only added when the linked clone image cache is enabled.
+		lib.IsLinkedCloneImageCacheEnabled = func() bool { return true }
+		return imagecache.AddToManager(ctx, mgr)
+	},
+	func(ctx *ctrlContext.ControllerManagerContext, _ ctrlmgr.Manager) error {
+		ctx.VMProvider = intgFakeVMProvider
+		return nil
+	},
+)
+
+func TestImageCache(t *testing.T) {
+	suite.Register(t, "ImageCache controller suite", intgTests, unitTests)
+}
+
+var _ = BeforeSuite(suite.BeforeSuite)
+
+var _ = AfterSuite(suite.AfterSuite)
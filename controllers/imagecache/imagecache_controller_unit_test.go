@@ -0,0 +1,122 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagecache_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/vmware-tanzu/vm-operator/controllers/imagecache"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking ReconcileNormal", unitTestsReconcileNormal)
+}
+
+func unitTestsReconcileNormal() {
+	var (
+		initObjects    []client.Object
+		ctx            *builder.UnitTestContextForController
+		reconciler     *imagecache.Reconciler
+		fakeVMProvider *providerfake.VMProvider
+
+		ns         *corev1.Namespace
+		syncResult *vmprovider.ImageCacheSyncResult
+		syncErr    error
+		maxUnused  time.Duration
+	)
+
+	BeforeEach(func() {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-ns",
+			},
+		}
+
+		syncResult = &vmprovider.ImageCacheSyncResult{}
+		syncErr = nil
+		maxUnused = 0
+	})
+
+	JustBeforeEach(func() {
+		initObjects = append(initObjects, ns)
+		ctx = suite.NewUnitTestContextForController(initObjects...)
+		reconciler = imagecache.NewReconciler(
+			ctx.Client,
+			ctx.Logger,
+			ctx.Recorder,
+			ctx.VMProvider,
+		)
+
+		fakeVMProvider = ctx.VMProvider.(*providerfake.VMProvider)
+		fakeVMProvider.SyncImageCacheFn = func(_ context.Context, _ string, age time.Duration) (*vmprovider.ImageCacheSyncResult, error) {
+			maxUnused = age
+			return syncResult, syncErr
+		}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
+	})
+
+	When("the cache is unchanged", func() {
+		BeforeEach(func() {
+			syncResult.Cached = []string{"image-cache-item-1-1-datastore-1"}
+		})
+
+		It("does not emit events", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(maxUnused).To(Equal(lib.GetImageCacheMaxUnusedAge()))
+			Expect(ctx.Events).ToNot(Receive())
+		})
+	})
+
+	When("template VMs are warmed up and evicted", func() {
+		BeforeEach(func() {
+			syncResult.Cached = []string{"image-cache-item-1-2-datastore-1"}
+			syncResult.Warmed = []string{"image-cache-item-1-2-datastore-1"}
+			syncResult.Evicted = []string{"image-cache-item-1-1-datastore-1"}
+		})
+
+		It("emits events", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			expectEvent(ctx, "ImageCacheWarmed", "image-cache-item-1-2-datastore-1")
+			expectEvent(ctx, "ImageCacheEvicted", "image-cache-item-1-1-datastore-1")
+		})
+	})
+
+	When("the sync fails", func() {
+		BeforeEach(func() {
+			syncErr = errors.New("sync error")
+		})
+
+		It("returns the error and emits a failure event", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(MatchError("sync error"))
+			expectEvent(ctx, "SyncImageCacheFailure", "sync error")
+		})
+	})
+}
+
+func expectEvent(ctx *builder.UnitTestContextForController, reason, msgSubstr string) {
+	var event string
+	EventuallyWithOffset(1, ctx.Events).Should(Receive(&event))
+	eventComponents := strings.Split(event, " ")
+	ExpectWithOffset(1, eventComponents[1]).To(Equal(reason))
+	ExpectWithOffset(1, event).To(ContainSubstring(msgSubstr))
+}
@@ -0,0 +1,367 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package orphanedvm
+
+import (
+	goctx "context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/record"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+)
+
+const (
+	// OrphanedVMPolicyAnnotationKey is the Namespace annotation that selects what is done with orphaned VMs,
+	// that is VMs created by VM Operator that no longer have a VirtualMachine resource.
+	OrphanedVMPolicyAnnotationKey = pkg.VMOperatorKey + "/orphaned-vm-policy"
+
+	// OrphanedVMPolicyReport only reports orphaned VMs through events and metrics. This is the default.
+	OrphanedVMPolicyReport = "Report"
+	// OrphanedVMPolicyDelete additionally deletes orphaned VMs once they have been orphaned for longer
+	// than the grace period.
+	OrphanedVMPolicyDelete = "Delete"
+
+	// OrphanedVMsConfigMapName is the name of the ConfigMap, in the VM Operator namespace, that records the VMs
+	// whose VirtualMachine was deleted with the Orphan deletion policy. Its keys are "<namespace>.<VM MoID>".
+	OrphanedVMsConfigMapName = "vmoperator-orphaned-vms"
+
+	orphanedVMReason       = "OrphanedVirtualMachine"
+	deleteOrphanedVMOpName = "DeleteOrphanedVirtualMachine"
+)
+
+var (
+	orphanedVMsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vmoperator_orphaned_virtual_machines",
+			Help: "Number of VMs created by VM Operator that have no VirtualMachine resource.",
+		},
+		[]string{"namespace"},
+	)
+
+	orphanedVMsDeletedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vmoperator_orphaned_virtual_machines_deleted_total",
+			Help: "Number of orphaned VMs deleted by VM Operator.",
+		},
+		[]string{"namespace"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphanedVMsGauge, orphanedVMsDeletedCounter)
+}
+
+// AddToManager adds this package's controller to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	var (
+		controlledType = &corev1.Namespace{}
+
+		controllerNameShort = "orphanedvm-controller"
+		controllerNameLong  = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, controllerNameShort)
+	)
+
+	r := NewReconciler(
+		mgr.GetClient(),
+		ctrl.Log.WithName("controllers").WithName("OrphanedVM"),
+		record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+		ctx.VMProvider,
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("orphanedvm").
+		For(controlledType).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(r)
+}
+
+func NewReconciler(
+	client client.Client,
+	logger logr.Logger,
+	recorder record.Recorder,
+	vmProvider vmprovider.VirtualMachineProviderInterface) *Reconciler {
+	return &Reconciler{
+		Client:     client,
+		Logger:     logger,
+		Recorder:   recorder,
+		VMProvider: vmProvider,
+		orphans:    map[string]map[string]time.Time{},
+	}
+}
+
+// Reconciler periodically scans each Namespace for orphaned VMs.
+type Reconciler struct {
+	client.Client
+	Logger     logr.Logger
+	Recorder   record.Recorder
+	VMProvider vmprovider.VirtualMachineProviderInterface
+
+	// orphans records, per namespace, when each orphaned VM was first observed so the delete grace
+	// period can span multiple scans.
+	orphansMu sync.Mutex
+	orphans   map[string]map[string]time.Time
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+
+func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (ctrl.Result, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, req.NamespacedName, ns); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			r.forgetNamespace(req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !ns.DeletionTimestamp.IsZero() {
+		r.forgetNamespace(ns.Name)
+		return ctrl.Result{}, nil
+	}
+
+	logger := r.Logger.WithValues("namespace", ns.Name)
+	if err := r.ReconcileNormal(ctx, logger, ns); err != nil {
+		logger.Error(err, "Failed to scan namespace for orphaned VMs")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: lib.GetOrphanedVMScanInterval()}, nil
+}
+
+// ReconcileNormal reports the orphaned VMs in the Namespace, and deletes those that have exceeded the
+// grace period when the Namespace has opted in to deletion.
+func (r *Reconciler) ReconcileNormal(ctx goctx.Context, logger logr.Logger, ns *corev1.Namespace) error {
+	managedVMs, err := r.VMProvider.ListManagedVirtualMachines(ctx, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	vmList := &vmopv1alpha1.VirtualMachineList{}
+	if err := r.List(ctx, vmList, client.InNamespace(ns.Name)); err != nil {
+		return err
+	}
+
+	orphanedByPolicy, err := r.orphanedByPolicy(ctx, ns.Name, managedVMs)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{}, len(vmList.Items))
+	uniqueIDs := make(map[string]struct{}, len(vmList.Items))
+	for _, vm := range vmList.Items {
+		names[vm.Name] = struct{}{}
+		if vm.Status.UniqueID != "" {
+			uniqueIDs[vm.Status.UniqueID] = struct{}{}
+		}
+	}
+
+	var orphanedVMs []vmprovider.ManagedVirtualMachine
+	for _, managedVM := range managedVMs {
+		if _, ok := names[managedVM.Name]; ok {
+			continue
+		}
+		if _, ok := uniqueIDs[managedVM.MoID]; ok {
+			continue
+		}
+		// The VirtualMachine was deleted with the Orphan deletion policy, so the VM is left alone.
+		if _, ok := orphanedByPolicy[managedVM.MoID]; ok {
+			continue
+		}
+		// A VM restored from a backup has its VirtualMachine recreated by the restore controller. A VM that only
+		// has a backup had its VirtualMachine deleted, so it is an orphan.
+		if managedVM.Restored && lib.IsVMServiceBackupRestoreFSSEnabled() {
+			continue
+		}
+		orphanedVMs = append(orphanedVMs, managedVM)
+	}
+
+	firstSeen, newOrphans := r.updateOrphans(ns.Name, orphanedVMs)
+	orphanedVMsGauge.WithLabelValues(ns.Name).Set(float64(len(orphanedVMs)))
+
+	deleteEnabled := ns.Annotations[OrphanedVMPolicyAnnotationKey] == OrphanedVMPolicyDelete
+	gracePeriod := lib.GetOrphanedVMDeleteGracePeriod()
+	now := time.Now()
+
+	for _, orphanedVM := range orphanedVMs {
+		orphanedFor := now.Sub(firstSeen[orphanKey(orphanedVM)])
+
+		if !deleteEnabled || orphanedFor < gracePeriod {
+			// Only report the VM when it is first seen, the gauge tracks the orphans of every scan.
+			if _, ok := newOrphans[orphanKey(orphanedVM)]; ok {
+				logger.Info("Found orphaned VM", "name", orphanedVM.Name, "moID", orphanedVM.MoID,
+					"zone", orphanedVM.Zone)
+				r.Recorder.Warnf(ns, orphanedVMReason, "VM %s (%s) has no VirtualMachine resource",
+					orphanedVM.Name, orphanedVM.MoID)
+			}
+			continue
+		}
+
+		logger.Info("Deleting orphaned VM", "name", orphanedVM.Name, "moID", orphanedVM.MoID,
+			"zone", orphanedVM.Zone, "orphanedFor", orphanedFor)
+		err := r.VMProvider.DeleteManagedVirtualMachine(ctx, ns.Name, orphanedVM)
+		r.Recorder.EmitEvent(ns, deleteOrphanedVMOpName, err, false)
+		if err != nil {
+			logger.Error(err, "Failed to delete orphaned VM", "name", orphanedVM.Name, "moID", orphanedVM.MoID)
+			continue
+		}
+		orphanedVMsDeletedCounter.WithLabelValues(ns.Name).Inc()
+	}
+
+	return nil
+}
+
+// RecordOrphanedVM records in the OrphanedVMsConfigMapName ConfigMap the VM of the VirtualMachine that is deleted
+// with the Orphan deletion policy, so that the scan neither reports nor deletes the VM that is left untouched.
+func RecordOrphanedVM(ctx goctx.Context, c client.Client, vm *vmopv1alpha1.VirtualMachine) error {
+	namespace := os.Getenv(lib.VmopNamespaceEnv)
+	if namespace == "" || vm.Status.UniqueID == "" {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: namespace, Name: OrphanedVMsConfigMapName}
+	if err := c.Get(ctx, key, configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get ConfigMap %s", key)
+		}
+
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: OrphanedVMsConfigMapName},
+			Data:       map[string]string{orphanedVMsKey(vm.Namespace, vm.Status.UniqueID): vm.Name},
+		}
+		return errors.Wrapf(c.Create(ctx, configMap), "failed to create ConfigMap %s", key)
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[orphanedVMsKey(vm.Namespace, vm.Status.UniqueID)] = vm.Name
+	return errors.Wrapf(c.Update(ctx, configMap), "failed to update ConfigMap %s", key)
+}
+
+// orphanedByPolicy returns the MoIDs of the managed VMs of the namespace that were orphaned by the Orphan deletion
+// policy. The VMs recorded in the OrphanedVMsConfigMapName ConfigMap that no longer exist are removed from it.
+func (r *Reconciler) orphanedByPolicy(
+	ctx goctx.Context,
+	namespace string,
+	managedVMs []vmprovider.ManagedVirtualMachine) (map[string]struct{}, error) {
+
+	vmopNamespace := os.Getenv(lib.VmopNamespaceEnv)
+	if vmopNamespace == "" {
+		return nil, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: vmopNamespace, Name: OrphanedVMsConfigMapName}
+	if err := r.Get(ctx, key, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s", key)
+	}
+
+	moIDs := make(map[string]struct{}, len(managedVMs))
+	for _, managedVM := range managedVMs {
+		moIDs[managedVM.MoID] = struct{}{}
+	}
+
+	orphaned := map[string]struct{}{}
+	pruned := false
+	prefix := orphanedVMsKey(namespace, "")
+	for k := range configMap.Data {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		moID := strings.TrimPrefix(k, prefix)
+		if _, ok := moIDs[moID]; ok {
+			orphaned[moID] = struct{}{}
+		} else {
+			delete(configMap.Data, k)
+			pruned = true
+		}
+	}
+
+	if pruned {
+		if err := r.Update(ctx, configMap); err != nil {
+			return nil, errors.Wrapf(err, "failed to update ConfigMap %s", key)
+		}
+	}
+
+	return orphaned, nil
+}
+
+func orphanedVMsKey(namespace, moID string) string {
+	return namespace + "." + moID
+}
+
+// updateOrphans replaces the tracked orphans of the namespace with the ones from the latest scan, keeping
+// the first observed time of VMs that remain orphaned. It also returns the orphans that were not tracked yet.
+func (r *Reconciler) updateOrphans(
+	namespace string,
+	orphanedVMs []vmprovider.ManagedVirtualMachine) (map[string]time.Time, map[string]struct{}) {
+
+	r.orphansMu.Lock()
+	defer r.orphansMu.Unlock()
+
+	prev := r.orphans[namespace]
+	cur := make(map[string]time.Time, len(orphanedVMs))
+	newOrphans := map[string]struct{}{}
+	now := time.Now()
+
+	for _, orphanedVM := range orphanedVMs {
+		key := orphanKey(orphanedVM)
+		if t, ok := prev[key]; ok {
+			cur[key] = t
+		} else {
+			cur[key] = now
+			newOrphans[key] = struct{}{}
+		}
+	}
+
+	if len(cur) == 0 {
+		delete(r.orphans, namespace)
+	} else {
+		r.orphans[namespace] = cur
+	}
+
+	return cur, newOrphans
+}
+
+func (r *Reconciler) forgetNamespace(namespace string) {
+	r.orphansMu.Lock()
+	defer r.orphansMu.Unlock()
+
+	delete(r.orphans, namespace)
+	orphanedVMsGauge.DeleteLabelValues(namespace)
+}
+
+func orphanKey(vm vmprovider.ManagedVirtualMachine) string {
+	if vm.MoID != "" {
+		return vm.Zone + "/" + vm.MoID
+	}
+	return vm.Zone + "/" + vm.Name
+}
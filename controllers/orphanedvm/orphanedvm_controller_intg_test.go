@@ -0,0 +1,93 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package orphanedvm_test
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/vmware-tanzu/vm-operator/controllers/orphanedvm"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	var (
+		ctx *builder.IntegrationTestContext
+
+		mu         sync.Mutex
+		deletedVMs []vmprovider.ManagedVirtualMachine
+	)
+
+	BeforeEach(func() {
+		ctx = suite.NewIntegrationTestContext()
+
+		deletedVMs = nil
+		intgFakeVMProvider.Lock()
+		intgFakeVMProvider.ListManagedVirtualMachinesFn = func(_ context.Context, namespace string) ([]vmprovider.ManagedVirtualMachine, error) {
+			if namespace != ctx.Namespace {
+				return nil, nil
+			}
+			return []vmprovider.ManagedVirtualMachine{{Name: "orphan-vm", MoID: "vm-42"}}, nil
+		}
+		intgFakeVMProvider.DeleteManagedVirtualMachineFn = func(_ context.Context, _ string, vm vmprovider.ManagedVirtualMachine) error {
+			mu.Lock()
+			defer mu.Unlock()
+			deletedVMs = append(deletedVMs, vm)
+			return nil
+		}
+		intgFakeVMProvider.Unlock()
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		intgFakeVMProvider.Reset()
+	})
+
+	Describe("Reconcile", func() {
+		It("reports orphaned VMs in the namespace", func() {
+			Eventually(func() bool {
+				eventList := &corev1.EventList{}
+				if err := ctx.Client.List(ctx, eventList); err != nil {
+					return false
+				}
+				for _, e := range eventList.Items {
+					if e.InvolvedObject.Name == ctx.Namespace && e.Reason == "OrphanedVirtualMachine" {
+						return true
+					}
+				}
+				return false
+			}).Should(BeTrue())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(deletedVMs).To(BeEmpty())
+		})
+
+		When("the namespace opts in to deletion", func() {
+			It("does not delete orphaned VMs within the grace period", func() {
+				ns := &corev1.Namespace{}
+				Expect(ctx.Client.Get(ctx, client.ObjectKey{Name: ctx.Namespace}, ns)).To(Succeed())
+				ns.Annotations = map[string]string{
+					orphanedvm.OrphanedVMPolicyAnnotationKey: orphanedvm.OrphanedVMPolicyDelete,
+				}
+				Expect(ctx.Client.Update(ctx, ns)).To(Succeed())
+
+				Consistently(func() int {
+					mu.Lock()
+					defer mu.Unlock()
+					return len(deletedVMs)
+				}).Should(BeZero())
+			})
+		})
+	})
+
+}
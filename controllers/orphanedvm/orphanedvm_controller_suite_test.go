@@ -0,0 +1,35 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package orphanedvm_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/vmware-tanzu/vm-operator/controllers/orphanedvm"
+	ctrlContext "github.com/vmware-tanzu/vm-operator/pkg/context"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+var intgFakeVMProvider = providerfake.NewVMProvider()
+
+var suite = builder.NewTestSuiteForController(
+	orphanedvm.AddToManager,
+	func(ctx *ctrlContext.ControllerManagerContext, _ ctrlmgr.Manager) error {
+		ctx.VMProvider = intgFakeVMProvider
+		return nil
+	},
+)
+
+func TestOrphanedVM(t *testing.T) {
+	suite.Register(t, "OrphanedVM controller suite", intgTests, unitTests)
+}
+
+var _ = BeforeSuite(suite.BeforeSuite)
+
+var _ = AfterSuite(suite.AfterSuite)
@@ -0,0 +1,229 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package orphanedvm_test
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/controllers/orphanedvm"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking ReconcileNormal", unitTestsReconcileNormal)
+}
+
+func unitTestsReconcileNormal() {
+	var (
+		initObjects    []client.Object
+		ctx            *builder.UnitTestContextForController
+		reconciler     *orphanedvm.Reconciler
+		fakeVMProvider *providerfake.VMProvider
+
+		ns         *corev1.Namespace
+		vm         *vmopv1alpha1.VirtualMachine
+		managedVMs []vmprovider.ManagedVirtualMachine
+		deletedVMs []vmprovider.ManagedVirtualMachine
+	)
+
+	BeforeEach(func() {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-ns",
+			},
+		}
+
+		vm = &vmopv1alpha1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dummy-vm",
+				Namespace: ns.Name,
+			},
+			Status: vmopv1alpha1.VirtualMachineStatus{
+				UniqueID: "vm-1",
+			},
+		}
+
+		managedVMs = []vmprovider.ManagedVirtualMachine{
+			{Name: "dummy-vm", MoID: "vm-1"},
+			{Name: "orphan-vm", MoID: "vm-2"},
+		}
+		deletedVMs = nil
+	})
+
+	JustBeforeEach(func() {
+		initObjects = append(initObjects, ns, vm)
+		ctx = suite.NewUnitTestContextForController(initObjects...)
+		reconciler = orphanedvm.NewReconciler(
+			ctx.Client,
+			ctx.Logger,
+			ctx.Recorder,
+			ctx.VMProvider,
+		)
+
+		fakeVMProvider = ctx.VMProvider.(*providerfake.VMProvider)
+		fakeVMProvider.ListManagedVirtualMachinesFn = func(_ context.Context, _ string) ([]vmprovider.ManagedVirtualMachine, error) {
+			return managedVMs, nil
+		}
+		fakeVMProvider.DeleteManagedVirtualMachineFn = func(_ context.Context, _ string, vm vmprovider.ManagedVirtualMachine) error {
+			deletedVMs = append(deletedVMs, vm)
+			return nil
+		}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
+	})
+
+	When("the namespace has no delete policy", func() {
+		It("reports but does not delete the orphaned VM", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(deletedVMs).To(BeEmpty())
+			expectEvent(ctx, "OrphanedVirtualMachine", "orphan-vm")
+			Expect(ctx.Events).ToNot(Receive())
+		})
+
+		It("reports the orphaned VM only on the first scan", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			expectEvent(ctx, "OrphanedVirtualMachine", "orphan-vm")
+
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(ctx.Events).ToNot(Receive())
+		})
+	})
+
+	When("a VM is matched only by its MoID", func() {
+		BeforeEach(func() {
+			managedVMs = []vmprovider.ManagedVirtualMachine{
+				{Name: "renamed-vm", MoID: "vm-1"},
+			}
+		})
+
+		It("is not an orphan", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(ctx.Events).ToNot(Receive())
+		})
+	})
+
+	When("the orphaned VM has a backup and backup/restore is enabled", func() {
+		var oldBackupRestoreFSSFn func() bool
+
+		BeforeEach(func() {
+			managedVMs[1].HasBackup = true
+			oldBackupRestoreFSSFn = lib.IsVMServiceBackupRestoreFSSEnabled
+			lib.IsVMServiceBackupRestoreFSSEnabled = func() bool { return true }
+		})
+
+		AfterEach(func() {
+			lib.IsVMServiceBackupRestoreFSSEnabled = oldBackupRestoreFSSFn
+		})
+
+		It("is reported when the VM was not restored", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			expectEvent(ctx, "OrphanedVirtualMachine", "orphan-vm")
+		})
+
+		Context("and the VM was restored", func() {
+			BeforeEach(func() {
+				managedVMs[1].Restored = true
+			})
+
+			It("is left to the restore controller", func() {
+				Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+				Expect(ctx.Events).ToNot(Receive())
+			})
+		})
+	})
+
+	When("the VirtualMachine of the VM was deleted with the Orphan deletion policy", func() {
+		var oldNamespace string
+
+		BeforeEach(func() {
+			oldNamespace = os.Getenv(lib.VmopNamespaceEnv)
+			Expect(os.Setenv(lib.VmopNamespaceEnv, "vmop-system")).To(Succeed())
+
+			initObjects = append(initObjects, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vmop-system", Name: orphanedvm.OrphanedVMsConfigMapName},
+				Data: map[string]string{
+					ns.Name + ".vm-2": "orphan-vm",
+					ns.Name + ".vm-3": "deleted-vm",
+					"other-ns.vm-4":   "other-vm",
+				},
+			})
+		})
+
+		AfterEach(func() {
+			Expect(os.Setenv(lib.VmopNamespaceEnv, oldNamespace)).To(Succeed())
+		})
+
+		It("neither reports the VM nor keeps the record of VMs that no longer exist", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(ctx.Events).ToNot(Receive())
+
+			configMap := &corev1.ConfigMap{}
+			key := client.ObjectKey{Namespace: "vmop-system", Name: orphanedvm.OrphanedVMsConfigMapName}
+			Expect(ctx.Client.Get(ctx, key, configMap)).To(Succeed())
+			Expect(configMap.Data).To(Equal(map[string]string{
+				ns.Name + ".vm-2": "orphan-vm",
+				"other-ns.vm-4":   "other-vm",
+			}))
+		})
+	})
+
+	When("the namespace has the delete policy", func() {
+		BeforeEach(func() {
+			ns.Annotations = map[string]string{
+				orphanedvm.OrphanedVMPolicyAnnotationKey: orphanedvm.OrphanedVMPolicyDelete,
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.Unsetenv(lib.OrphanedVMDeleteGracePeriodEnv)).To(Succeed())
+		})
+
+		Context("and the grace period has not elapsed", func() {
+			It("does not delete the orphaned VM", func() {
+				Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+				Expect(deletedVMs).To(BeEmpty())
+				expectEvent(ctx, "OrphanedVirtualMachine", "orphan-vm")
+			})
+		})
+
+		Context("and the grace period has elapsed", func() {
+			BeforeEach(func() {
+				Expect(os.Setenv(lib.OrphanedVMDeleteGracePeriodEnv, "0s")).To(Succeed())
+			})
+
+			It("deletes the orphaned VM", func() {
+				Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+				Expect(deletedVMs).To(HaveLen(1))
+				Expect(deletedVMs[0].MoID).To(Equal("vm-2"))
+				expectEvent(ctx, "DeleteOrphanedVirtualMachineSuccess", "")
+			})
+		})
+	})
+}
+
+func expectEvent(ctx *builder.UnitTestContextForController, reason, msgSubstr string) {
+	var event string
+	EventuallyWithOffset(1, ctx.Events).Should(Receive(&event))
+	eventComponents := strings.Split(event, " ")
+	ExpectWithOffset(1, eventComponents[1]).To(Equal(reason))
+	ExpectWithOffset(1, event).To(ContainSubstring(msgSubstr))
+}
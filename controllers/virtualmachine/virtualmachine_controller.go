@@ -31,20 +31,52 @@ import (
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	"github.com/vmware-tanzu/vm-operator/controllers/orphanedvm"
 	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/extraconfig"
 	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/patch"
 	"github.com/vmware-tanzu/vm-operator/pkg/prober"
 	"github.com/vmware-tanzu/vm-operator/pkg/record"
 	"github.com/vmware-tanzu/vm-operator/pkg/topology"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/compatibility"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/contentlibrary"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/instancestorage"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/vapp"
 )
 
 const finalizerName = "virtualmachine.vmoperator.vmware.com"
 
+const (
+	// GuestShutdownCondition documents the progress of the guest OS shutdown that is requested before the
+	// VM is powered off and deleted.
+	GuestShutdownCondition vmopv1alpha1.ConditionType = "GuestShutdown"
+
+	// GuestShutdownPendingReason (Severity=Info) documents that the guest OS has been asked to shut down and
+	// the VM is not yet powered off.
+	GuestShutdownPendingReason = "GuestShutdownPending"
+
+	// GuestShutdownTimedOutReason (Severity=Warning) documents that the guest OS did not shut down within the
+	// termination grace period and the VM was powered off.
+	GuestShutdownTimedOutReason = "GuestShutdownTimedOut"
+
+	// VirtualMachineMetadataValidCondition documents whether the VM metadata is valid for the vApp properties
+	// of the VM's image. It is only set for VMs that use the OvfEnv metadata transport.
+	VirtualMachineMetadataValidCondition vmopv1alpha1.ConditionType = "VirtualMachineMetadataValid"
+
+	// VirtualMachineMetadataInvalidReason (Severity=Error) documents that the VM metadata has keys or values
+	// that are not valid for the vApp properties of the VM's image, e.g. because the image was updated.
+	VirtualMachineMetadataInvalidReason = "MetadataInvalid"
+
+	// VirtualMachineImageNotCompatibleReason (Severity=Error) documents that the VM cannot be created because its
+	// VirtualMachineImage is not compatible with its VirtualMachineClass, annotations or cluster.
+	VirtualMachineImageNotCompatibleReason = "VirtualMachineImageNotCompatible"
+)
+
 // AddToManager adds this package's controller to the provided manager.
 func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
 	var (
@@ -110,10 +142,13 @@ func csBindingToVMMapperFn(ctx *context.ControllerManagerContext, c client.Reade
 		}
 
 		imagesToReconcile := make(map[string]struct{})
-		for _, img := range imageList.Items {
+		for i := range imageList.Items {
+			img := &imageList.Items[i]
 			for _, ownerRef := range img.OwnerReferences {
 				if ownerRef.Kind == "ContentLibraryProvider" && ownerRef.UID == clProviderFromBinding.UID {
+					// VMs may refer to the image by either its name or display name.
 					imagesToReconcile[img.Name] = struct{}{}
+					imagesToReconcile[vmimage.DisplayName(img)] = struct{}{}
 				}
 			}
 		}
@@ -216,6 +251,7 @@ type Reconciler struct {
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentsources,verbs=get;list;watch
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentlibraryproviders,verbs=get;list;watch
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentsourcebindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinedefaults,verbs=get;list;watch
 
 func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	vm := &vmopv1alpha1.VirtualMachine{}
@@ -254,7 +290,7 @@ func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Resu
 
 	if !vm.DeletionTimestamp.IsZero() {
 		err = r.ReconcileDelete(vmCtx)
-		return ctrl.Result{}, err
+		return ctrl.Result{RequeueAfter: deleteRequeueDelay(vmCtx)}, err
 	}
 
 	if err := r.ReconcileNormal(vmCtx); err != nil {
@@ -286,7 +322,85 @@ func requeueDelay(ctx *context.VirtualMachineContext) time.Duration {
 	return 0
 }
 
+// While waiting for the guest OS to shut down, requeue so the grace period is checked again without
+// blocking a worker.
+func deleteRequeueDelay(ctx *context.VirtualMachineContext) time.Duration {
+	if conditions.GetReason(ctx.VM, GuestShutdownCondition) == GuestShutdownPendingReason {
+		return 5 * time.Second
+	}
+
+	return 0
+}
+
+// terminationGracePeriod returns how long to wait for the guest OS to shut down before the VM is deleted. The
+// annotation is clamped to MaxVMTerminationGracePeriod in case it was set before the webhook enforced the limit.
+func terminationGracePeriod(vm *vmopv1alpha1.VirtualMachine) time.Duration {
+	if val := vm.Annotations[constants.TerminationGracePeriodAnnotation]; val != "" {
+		if gracePeriod, err := time.ParseDuration(val); err == nil {
+			if gracePeriod > lib.MaxVMTerminationGracePeriod {
+				return lib.MaxVMTerminationGracePeriod
+			}
+			return gracePeriod
+		}
+	}
+
+	return lib.GetVMTerminationGracePeriod()
+}
+
+// shutdownGuest requests a guest OS shutdown and returns whether the deletion of the VM can proceed. The
+// GuestShutdown condition records when the shutdown was first requested so the grace period spans reconciles.
+func (r *Reconciler) shutdownGuest(ctx *context.VirtualMachineContext) (bool, error) {
+	if ctx.VM.Annotations[constants.DeletionPolicyAnnotation] == constants.DeletionPolicyOrphan {
+		return true, nil
+	}
+
+	gracePeriod := terminationGracePeriod(ctx.VM)
+	if gracePeriod <= 0 {
+		return true, nil
+	}
+
+	if c := conditions.Get(ctx.VM, GuestShutdownCondition); c != nil {
+		if c.Reason != GuestShutdownPendingReason {
+			// The guest was already shut down, or the grace period expired, in an earlier reconcile.
+			return true, nil
+		}
+
+		if time.Since(c.LastTransitionTime.Time) >= gracePeriod {
+			ctx.Logger.Info("Guest OS did not shut down within the termination grace period", "gracePeriod", gracePeriod)
+			conditions.MarkFalse(ctx.VM, GuestShutdownCondition, GuestShutdownTimedOutReason, vmopv1alpha1.ConditionSeverityWarning,
+				"Guest OS did not shut down within %s", gracePeriod)
+			r.Recorder.Warnf(ctx.VM, GuestShutdownTimedOutReason, "Guest OS did not shut down within %s", gracePeriod)
+			return true, nil
+		}
+	}
+
+	done, err := r.VMProvider.ShutdownVirtualMachineGuest(ctx, ctx.VM)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return true, nil
+		}
+		ctx.Logger.Error(err, "Provider failed to shutdown VirtualMachine guest")
+		return false, err
+	}
+
+	if done {
+		conditions.MarkTrue(ctx.VM, GuestShutdownCondition)
+		return true, nil
+	}
+
+	conditions.MarkFalse(ctx.VM, GuestShutdownCondition, GuestShutdownPendingReason, vmopv1alpha1.ConditionSeverityInfo,
+		"Waiting up to %s for the guest OS to shut down", gracePeriod)
+	return false, nil
+}
+
 func (r *Reconciler) deleteVM(ctx *context.VirtualMachineContext) (err error) {
+	switch ctx.VM.Annotations[constants.DeletionPolicyAnnotation] {
+	case constants.DeletionPolicyOrphan:
+		return r.orphanVM(ctx)
+	case constants.DeletionPolicyRetain:
+		return r.retainVM(ctx)
+	}
+
 	defer func() {
 		r.Recorder.EmitEvent(ctx.VM, "Delete", err, false)
 	}()
@@ -305,6 +419,41 @@ func (r *Reconciler) deleteVM(ctx *context.VirtualMachineContext) (err error) {
 	return nil
 }
 
+func (r *Reconciler) retainVM(ctx *context.VirtualMachineContext) (err error) {
+	defer func() {
+		r.Recorder.EmitEvent(ctx.VM, "Retain", err, false)
+	}()
+
+	err = r.VMProvider.RetainVirtualMachine(ctx, ctx.VM)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			ctx.Logger.Info("To be retained VirtualMachine was not found")
+			return nil
+		}
+		ctx.Logger.Error(err, "Provider failed to retain VirtualMachine")
+		return err
+	}
+
+	ctx.Logger.V(4).Info("Retained VirtualMachine")
+	return nil
+}
+
+func (r *Reconciler) orphanVM(ctx *context.VirtualMachineContext) (err error) {
+	defer func() {
+		r.Recorder.EmitEvent(ctx.VM, "Orphan", err, false)
+	}()
+
+	// The provider VM is left untouched, and is only recorded so that the orphaned VM scan skips it.
+	err = orphanedvm.RecordOrphanedVM(ctx, r.Client, ctx.VM)
+	if err != nil {
+		ctx.Logger.Error(err, "Failed to record orphaned VirtualMachine")
+		return err
+	}
+
+	ctx.Logger.Info("Leaving the provider VM untouched due to the Orphan deletion policy")
+	return nil
+}
+
 func (r *Reconciler) ReconcileDelete(ctx *context.VirtualMachineContext) error {
 	vm := ctx.VM
 
@@ -316,6 +465,10 @@ func (r *Reconciler) ReconcileDelete(ctx *context.VirtualMachineContext) error {
 	if controllerutil.ContainsFinalizer(vm, finalizerName) {
 		vm.Status.Phase = vmopv1alpha1.Deleting
 
+		if done, err := r.shutdownGuest(ctx); err != nil || !done {
+			return err
+		}
+
 		if err := r.deleteVM(ctx); err != nil {
 			return err
 		}
@@ -426,8 +579,8 @@ func (r *Reconciler) getContentSourceFromCLProvider(ctx *context.VirtualMachineC
 func (r *Reconciler) getImageAndContentLibraryUUID(ctx *context.VirtualMachineContext) (*vmopv1alpha1.VirtualMachineImage, string, error) {
 	imageName := ctx.VM.Spec.ImageName
 
-	vmImage := &vmopv1alpha1.VirtualMachineImage{}
-	if err := r.Get(ctx, client.ObjectKey{Name: imageName}, vmImage); err != nil {
+	vmImage, err := vmimage.Get(ctx, r.Client, imageName)
+	if err != nil {
 		msg := fmt.Sprintf("Failed to get VirtualMachineImage %s: %s", ctx.VM.Spec.ImageName, err)
 		conditions.MarkFalse(ctx.VM,
 			vmopv1alpha1.VirtualMachinePrereqReadyCondition,
@@ -585,6 +738,98 @@ func (r *Reconciler) getVMMetadata(ctx *context.VirtualMachineContext) (vmprovid
 	return outMetadata, nil
 }
 
+// validateVMMetadata validates the OvfEnv transport metadata against the vApp properties of the VM's image,
+// and reflects the result in the VirtualMachineMetadataValidCondition. Since the image may be updated after
+// the VM is created, the returned error only prevents the VM from being created.
+func (r *Reconciler) validateVMMetadata(
+	ctx *context.VirtualMachineContext,
+	vmImage *vmopv1alpha1.VirtualMachineImage,
+	vmMetadata vmprovider.VMMetadata) error {
+
+	if vmMetadata.Transport != vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport {
+		conditions.Delete(ctx.VM, VirtualMachineMetadataValidCondition)
+		return nil
+	}
+
+	info, err := contentlibrary.GetVirtualMachineImageInfo(vmImage)
+	if err != nil {
+		ctx.Logger.Error(err, "Failed to get VirtualMachineImage info", "imageName", vmImage.Name)
+		return nil
+	}
+	if info == nil {
+		// The image has not been inspected yet so there is nothing to validate against.
+		return nil
+	}
+
+	fromSecret := ctx.VM.Spec.VmMetadata != nil && ctx.VM.Spec.VmMetadata.SecretName != ""
+	propertyErrs := vapp.ValidateMetadata(info.OVFProperties, vmMetadata.Data, fromSecret)
+	if len(propertyErrs) == 0 {
+		conditions.MarkTrue(ctx.VM, VirtualMachineMetadataValidCondition)
+		return nil
+	}
+
+	msgs := make([]string, 0, len(propertyErrs))
+	for _, e := range propertyErrs {
+		msgs = append(msgs, e.Error())
+	}
+	msg := strings.Join(msgs, "; ")
+
+	conditions.MarkFalse(ctx.VM,
+		VirtualMachineMetadataValidCondition,
+		VirtualMachineMetadataInvalidReason,
+		vmopv1alpha1.ConditionSeverityError,
+		"%s", msg)
+
+	return errors.Errorf("VM metadata is not valid for VirtualMachineImage %s: %s", vmImage.Name, msg)
+}
+
+// checkCompatibility checks that the VM can be created from its image and class. It is only done before the VM is
+// created since the image and class cannot be changed afterwards.
+func (r *Reconciler) checkCompatibility(
+	ctx *context.VirtualMachineContext,
+	vmClass *vmopv1alpha1.VirtualMachineClass,
+	vmImage *vmopv1alpha1.VirtualMachineImage) error {
+
+	info, err := contentlibrary.GetVirtualMachineImageInfo(vmImage)
+	if err != nil {
+		ctx.Logger.Error(err, "Failed to get VirtualMachineImage info", "imageName", vmImage.Name)
+	}
+
+	guestOSIdsToFamily, err := r.VMProvider.GetSupportedGuestOSFamilies(ctx, ctx.VM)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the supported guest OS identifiers")
+	}
+
+	incompatibilities := compatibility.Evaluate(compatibility.Args{
+		VM:                 ctx.VM,
+		VMClass:            vmClass,
+		VMImage:            vmImage,
+		VMImageInfo:        info,
+		GuestOSIdsToFamily: guestOSIdsToFamily,
+	})
+
+	msgs := make([]string, 0, len(incompatibilities))
+	for _, i := range incompatibilities {
+		if i.Warning {
+			ctx.Logger.Info("VirtualMachineImage may not be compatible", "imageName", vmImage.Name, "reason", i.Message)
+			continue
+		}
+		msgs = append(msgs, i.Message)
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	msg := strings.Join(msgs, "; ")
+
+	conditions.MarkFalse(ctx.VM,
+		vmopv1alpha1.VirtualMachinePrereqReadyCondition,
+		VirtualMachineImageNotCompatibleReason,
+		vmopv1alpha1.ConditionSeverityError,
+		"%s", msg)
+
+	return errors.Errorf("VirtualMachineImage %s is not compatible: %s", vmImage.Name, msg)
+}
+
 func (r *Reconciler) getResourcePolicy(ctx *context.VirtualMachineContext) (*vmopv1alpha1.VirtualMachineSetResourcePolicy, error) {
 	rpName := ctx.VM.Spec.ResourcePolicyName
 	if rpName == "" {
@@ -654,6 +899,8 @@ func (r *Reconciler) createOrUpdateVM(ctx *context.VirtualMachineContext) error
 		return err
 	}
 
+	metadataErr := r.validateVMMetadata(ctx, vmImage, vmMetadata)
+
 	resourcePolicy, err := r.getResourcePolicy(ctx)
 	if err != nil {
 		return err
@@ -664,6 +911,12 @@ func (r *Reconciler) createOrUpdateVM(ctx *context.VirtualMachineContext) error
 		return err
 	}
 
+	extraConfig, err := extraconfig.ForVM(ctx, r.Client, ctx.VM, vmClass)
+	if err != nil {
+		ctx.Logger.Error(err, "Failed to get VirtualMachine ExtraConfig")
+		return err
+	}
+
 	// Update VirtualMachine conditions to indicate all prereqs have been met.
 	conditions.MarkTrue(ctx.VM, vmopv1alpha1.VirtualMachinePrereqReadyCondition)
 
@@ -675,6 +928,7 @@ func (r *Reconciler) createOrUpdateVM(ctx *context.VirtualMachineContext) error
 		ResourcePolicy:     resourcePolicy,
 		StorageProfileID:   storagePolicyID,
 		ContentLibraryUUID: clUUID,
+		ExtraConfig:        extraConfig,
 	}
 
 	exists, err := r.VMProvider.DoesVirtualMachineExist(ctx, vm)
@@ -716,6 +970,18 @@ func (r *Reconciler) createOrUpdateVM(ctx *context.VirtualMachineContext) error
 			return err
 		}
 
+		if err := r.checkCompatibility(ctx, vmClass, vmImage); err != nil {
+			ctx.Logger.Error(err, "Cannot create VirtualMachine with incompatible image")
+			r.Recorder.EmitEvent(vm, "Create", err, false)
+			return err
+		}
+
+		if metadataErr != nil {
+			ctx.Logger.Error(metadataErr, "Cannot create VirtualMachine with invalid metadata")
+			r.Recorder.EmitEvent(vm, "Create", metadataErr, false)
+			return metadataErr
+		}
+
 		err = r.VMProvider.CreateVirtualMachine(ctx, vm, vmConfigArgs)
 		if err != nil {
 			ctx.Logger.Error(err, "Provider failed to create VirtualMachine")
@@ -5,11 +5,14 @@ package virtualmachine_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -24,6 +27,8 @@ import (
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/controllers/orphanedvm"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachine"
 	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	vmopContext "github.com/vmware-tanzu/vm-operator/pkg/context"
@@ -36,6 +41,15 @@ import (
 	instancestoragetestutil "github.com/vmware-tanzu/vm-operator/test/instancestorage"
 )
 
+func setImageOVFProperties(image *vmopv1alpha1.VirtualMachineImage, properties ...vmopapiv1alpha1.VirtualMachineImageOVFProperty) {
+	data, err := json.Marshal(vmopapiv1alpha1.VirtualMachineImageInfo{OVFProperties: properties})
+	Expect(err).ToNot(HaveOccurred())
+	if image.Annotations == nil {
+		image.Annotations = map[string]string{}
+	}
+	image.Annotations[vmopapiv1alpha1.VirtualMachineImageInfoAnnotation] = string(data)
+}
+
 func unitTests() {
 	Describe("Invoking Reconcile", unitTestsReconcile)
 }
@@ -517,6 +531,31 @@ func unitTestsReconcile() {
 			Expect(vmCtx.VM.Status.Phase).To(Equal(vmopv1alpha1.Creating))
 		})
 
+		When("the image is not compatible with the cluster", func() {
+			var isCalled int32
+
+			JustBeforeEach(func() {
+				isCalled = 0
+				fakeVMProvider.GetSupportedGuestOSFamiliesFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine) (map[string]string, error) {
+					return map[string]string{"windows9_64Guest": "windowsGuest"}, nil
+				}
+				fakeVMProvider.CreateVirtualMachineFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine, vmConfigArgs vmprovider.VMConfigArgs) error {
+					atomic.AddInt32(&isCalled, 1)
+					return nil
+				}
+			})
+
+			It("does not create the VM and sets the VirtualMachinePrereqReady Condition to false", func() {
+				err := reconciler.ReconcileNormal(vmCtx)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("is not supported by VMService"))
+				Expect(isCalled).To(Equal(int32(0)))
+				expectEvent(ctx, "CreateFailure")
+				Expect(conditions.IsFalse(vmCtx.VM, vmopv1alpha1.VirtualMachinePrereqReadyCondition)).To(BeTrue())
+				Expect(conditions.GetReason(vmCtx.VM, vmopv1alpha1.VirtualMachinePrereqReadyCondition)).To(Equal(virtualmachine.VirtualMachineImageNotCompatibleReason))
+			})
+		})
+
 		It("will return error when provider fails to update VM", func() {
 			// Simulate an error after the VM is created.
 			fakeVMProvider.UpdateVirtualMachineFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine, vmConfigArgs vmprovider.VMConfigArgs) error {
@@ -685,6 +724,65 @@ func unitTestsReconcile() {
 			})
 		})
 
+		When("VM Metadata uses the OvfEnv transport", func() {
+			BeforeEach(func() {
+				vm.Spec.VmMetadata = &vmopv1alpha1.VirtualMachineMetadata{
+					ConfigMapName: vmMetaDataConfigMap.Name,
+					Transport:     vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport,
+				}
+				initObjects = append(initObjects, vmMetaDataConfigMap)
+			})
+
+			When("the image has not been inspected", func() {
+				It("returns success", func() {
+					err := reconciler.ReconcileNormal(vmCtx)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(conditions.Get(vmCtx.VM, virtualmachine.VirtualMachineMetadataValidCondition)).To(BeNil())
+				})
+			})
+
+			When("the metadata keys are vApp properties of the image", func() {
+				BeforeEach(func() {
+					setImageOVFProperties(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFProperty{
+						Key: "foo", Type: "string", UserConfigurable: true,
+					})
+				})
+
+				It("returns success and marks the VirtualMachineMetadataValid Condition as True", func() {
+					err := reconciler.ReconcileNormal(vmCtx)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(conditions.IsTrue(vmCtx.VM, virtualmachine.VirtualMachineMetadataValidCondition)).To(BeTrue())
+				})
+			})
+
+			When("the metadata keys are not vApp properties of the image", func() {
+				var isCalled int32
+
+				BeforeEach(func() {
+					setImageOVFProperties(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFProperty{
+						Key: "hostname", Type: "string", UserConfigurable: true,
+					})
+					isCalled = 0
+				})
+
+				JustBeforeEach(func() {
+					fakeVMProvider.CreateVirtualMachineFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine, vmConfigArgs vmprovider.VMConfigArgs) error {
+						atomic.AddInt32(&isCalled, 1)
+						return nil
+					}
+				})
+
+				It("does not create the VM and marks the VirtualMachineMetadataValid Condition as False", func() {
+					err := reconciler.ReconcileNormal(vmCtx)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(`key "foo" is not a vApp property of the image`))
+					Expect(isCalled).To(Equal(int32(0)))
+					Expect(conditions.IsFalse(vmCtx.VM, virtualmachine.VirtualMachineMetadataValidCondition)).To(BeTrue())
+					Expect(conditions.GetReason(vmCtx.VM, virtualmachine.VirtualMachineMetadataValidCondition)).To(Equal(virtualmachine.VirtualMachineMetadataInvalidReason))
+				})
+			})
+		})
+
 		When("VM ResourcePolicy is specified", func() {
 			BeforeEach(func() {
 				vm.Spec.ResourcePolicyName = vmResourcePolicy.Name
@@ -862,6 +960,157 @@ func unitTestsReconcile() {
 			Expect(reconciler.ReconcileDelete(vmCtx)).Should(Succeed())
 			Expect(fakeProbeManager.IsRemoveFromProberManagerCalled).Should(BeTrue())
 		})
+
+		When("the guest OS is asked to shut down", func() {
+			var shutdownCalled bool
+
+			BeforeEach(func() {
+				shutdownCalled = false
+			})
+
+			JustBeforeEach(func() {
+				if vmCtx.VM.Annotations == nil {
+					vmCtx.VM.Annotations = map[string]string{}
+				}
+				fakeVMProvider.ShutdownVirtualMachineGuestFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine) (bool, error) {
+					shutdownCalled = true
+					return false, nil
+				}
+			})
+
+			It("will wait for the guest OS to shut down", func() {
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+				Expect(shutdownCalled).To(BeTrue())
+
+				vmExists, err := fakeVMProvider.DoesVirtualMachineExist(vmCtx, vmCtx.VM)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmExists).To(BeTrue())
+
+				Expect(vmCtx.VM.Status.Phase).To(Equal(vmopv1alpha1.Deleting))
+				Expect(conditions.GetReason(vmCtx.VM, virtualmachine.GuestShutdownCondition)).To(Equal(virtualmachine.GuestShutdownPendingReason))
+				Expect(vmCtx.VM.GetFinalizers()).To(ContainElement(finalizer))
+			})
+
+			It("will delete the VM once the guest OS has shut down", func() {
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+
+				fakeVMProvider.ShutdownVirtualMachineGuestFn = nil
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+
+				Expect(conditions.IsTrue(vmCtx.VM, virtualmachine.GuestShutdownCondition)).To(BeTrue())
+				expectEvent(ctx, "DeleteSuccess")
+				Expect(vmCtx.VM.Status.Phase).To(Equal(vmopv1alpha1.Deleted))
+			})
+
+			It("will delete the VM once the termination grace period has expired", func() {
+				vmCtx.VM.Annotations[constants.TerminationGracePeriodAnnotation] = "1m"
+				conditions.Set(vmCtx.VM, &vmopv1alpha1.Condition{
+					Type:               virtualmachine.GuestShutdownCondition,
+					Status:             corev1.ConditionFalse,
+					Reason:             virtualmachine.GuestShutdownPendingReason,
+					Severity:           vmopv1alpha1.ConditionSeverityInfo,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+				})
+
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+				Expect(shutdownCalled).To(BeFalse())
+
+				Expect(conditions.GetReason(vmCtx.VM, virtualmachine.GuestShutdownCondition)).To(Equal(virtualmachine.GuestShutdownTimedOutReason))
+				expectEvent(ctx, virtualmachine.GuestShutdownTimedOutReason)
+				expectEvent(ctx, "DeleteSuccess")
+				Expect(vmCtx.VM.Status.Phase).To(Equal(vmopv1alpha1.Deleted))
+			})
+
+			It("will clamp a termination grace period that is longer than the maximum", func() {
+				vmCtx.VM.Annotations[constants.TerminationGracePeriodAnnotation] = "24h"
+				conditions.Set(vmCtx.VM, &vmopv1alpha1.Condition{
+					Type:               virtualmachine.GuestShutdownCondition,
+					Status:             corev1.ConditionFalse,
+					Reason:             virtualmachine.GuestShutdownPendingReason,
+					Severity:           vmopv1alpha1.ConditionSeverityInfo,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * lib.MaxVMTerminationGracePeriod)),
+				})
+
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+				Expect(conditions.GetReason(vmCtx.VM, virtualmachine.GuestShutdownCondition)).To(Equal(virtualmachine.GuestShutdownTimedOutReason))
+				expectEvent(ctx, virtualmachine.GuestShutdownTimedOutReason)
+				expectEvent(ctx, "DeleteSuccess")
+			})
+
+			It("will not wait when the termination grace period is zero", func() {
+				vmCtx.VM.Annotations[constants.TerminationGracePeriodAnnotation] = "0s"
+
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+				Expect(shutdownCalled).To(BeFalse())
+				expectEvent(ctx, "DeleteSuccess")
+			})
+		})
+
+		When("the VM has the Retain deletion policy", func() {
+			JustBeforeEach(func() {
+				if vmCtx.VM.Annotations == nil {
+					vmCtx.VM.Annotations = map[string]string{}
+				}
+				vmCtx.VM.Annotations[constants.DeletionPolicyAnnotation] = constants.DeletionPolicyRetain
+			})
+
+			It("will retain the VM instead of deleting it and emit corresponding event", func() {
+				fakeVMProvider.DeleteVirtualMachineFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine) error {
+					return errors.New("delete should not be called")
+				}
+
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+				expectEvent(ctx, "RetainSuccess")
+				Expect(vmCtx.VM.Status.Phase).To(Equal(vmopv1alpha1.Deleted))
+				Expect(vmCtx.VM.GetFinalizers()).ToNot(ContainElement(finalizer))
+			})
+
+			It("will emit corresponding event during retain failure", func() {
+				fakeVMProvider.RetainVirtualMachineFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine) error {
+					return errors.New(providerError)
+				}
+
+				Expect(reconciler.ReconcileDelete(vmCtx)).ToNot(Succeed())
+				expectEvent(ctx, "RetainFailure")
+				Expect(vmCtx.VM.Status.Phase).To(Equal(vmopv1alpha1.Deleting))
+			})
+		})
+
+		When("the VM has the Orphan deletion policy", func() {
+			JustBeforeEach(func() {
+				if vmCtx.VM.Annotations == nil {
+					vmCtx.VM.Annotations = map[string]string{}
+				}
+				vmCtx.VM.Annotations[constants.DeletionPolicyAnnotation] = constants.DeletionPolicyOrphan
+			})
+
+			It("will leave the VM untouched, record it as orphaned and emit corresponding event", func() {
+				oldNamespace := os.Getenv("POD_NAMESPACE")
+				Expect(os.Setenv("POD_NAMESPACE", "vmop-system")).To(Succeed())
+				defer func() {
+					Expect(os.Setenv("POD_NAMESPACE", oldNamespace)).To(Succeed())
+				}()
+
+				vmCtx.VM.Status.UniqueID = "vm-42"
+				fakeVMProvider.DeleteVirtualMachineFn = func(ctx context.Context, vm *vmopv1alpha1.VirtualMachine) error {
+					return errors.New("delete should not be called")
+				}
+
+				Expect(reconciler.ReconcileDelete(vmCtx)).To(Succeed())
+
+				vmExists, err := fakeVMProvider.DoesVirtualMachineExist(vmCtx, vmCtx.VM)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmExists).To(BeTrue())
+
+				configMap := &corev1.ConfigMap{}
+				key := client.ObjectKey{Namespace: "vmop-system", Name: orphanedvm.OrphanedVMsConfigMapName}
+				Expect(ctx.Client.Get(ctx, key, configMap)).To(Succeed())
+				Expect(configMap.Data).To(HaveKeyWithValue(vmCtx.VM.Namespace+".vm-42", vmCtx.VM.Name))
+
+				expectEvent(ctx, "OrphanSuccess")
+				Expect(vmCtx.VM.Status.Phase).To(Equal(vmopv1alpha1.Deleted))
+			})
+		})
 	})
 }
 
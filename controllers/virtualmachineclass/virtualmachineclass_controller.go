@@ -5,21 +5,40 @@ package virtualmachineclass
 
 import (
 	goctx "context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/record"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+)
+
+const (
+	notSchedulableReason = "VirtualMachineClassNotSchedulable"
 )
 
 // AddToManager adds this package's controller to the provided manager.
@@ -36,34 +55,53 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) er
 		mgr.GetClient(),
 		ctrl.Log.WithName("controllers").WithName(controlledTypeName),
 		record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+		ctx.VMProvider,
 	)
 
+	// Recording the availability annotation does not change the generation, so it does not trigger another check.
 	return ctrl.NewControllerManagedBy(mgr).
-		For(controlledType).
+		For(controlledType, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Watches(&source.Kind{Type: &vmopv1alpha1.VirtualMachineClassBinding{}},
+			handler.EnqueueRequestsFromMapFunc(classBindingToVMClassMapperFn)).
 		Complete(r)
 }
 
+// classBindingToVMClassMapperFn queues the VirtualMachineClass of a VirtualMachineClassBinding, so the class's
+// capacity is checked for a namespace as soon as the namespace is bound to it.
+func classBindingToVMClassMapperFn(o client.Object) []reconcile.Request {
+	classBinding := o.(*vmopv1alpha1.VirtualMachineClassBinding)
+	if classBinding.ClassRef.Name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: classBinding.ClassRef.Name}}}
+}
+
 func NewReconciler(
 	client client.Client,
 	logger logr.Logger,
-	recorder record.Recorder) *Reconciler {
+	recorder record.Recorder,
+	vmProvider vmprovider.VirtualMachineProviderInterface) *Reconciler {
 	return &Reconciler{
-		Client:   client,
-		Logger:   logger,
-		Recorder: recorder,
+		Client:     client,
+		Logger:     logger,
+		Recorder:   recorder,
+		VMProvider: vmProvider,
 	}
 }
 
 // Reconciler reconciles a VirtualMachineClass object.
 type Reconciler struct {
 	client.Client
-	Logger   logr.Logger
-	Recorder record.Recorder
+	Logger     logr.Logger
+	Recorder   record.Recorder
+	VMProvider vmprovider.VirtualMachineProviderInterface
 }
 
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineclasses,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineclasses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineclassbindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (ctrl.Result, error) {
 	vmClass := &vmopv1alpha1.VirtualMachineClass{}
@@ -86,10 +124,156 @@ func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (ctrl.Result
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: lib.GetVMClassAvailabilityCheckInterval()}, nil
+}
+
+// ReconcileNormal checks whether VMs of the class can be realized in each availability zone, and how many more fit
+// in the namespaces bound to the class, and records the result in the class's availability annotation when it
+// changed.
+func (r *Reconciler) ReconcileNormal(ctx *context.VirtualMachineClassContext) error {
+	namespaces, err := r.boundNamespaces(ctx, ctx.VMClass.Name)
+	if err != nil {
+		return err
+	}
+
+	zones, err := r.VMProvider.GetVirtualMachineClassAvailability(ctx, ctx.VMClass, namespaces)
+	if err != nil {
+		return err
+	}
+
+	previous := recordedAvailability(ctx.VMClass)
+	availability := &vmopapiv1alpha1.VirtualMachineClassAvailability{
+		Zones: make([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability, len(zones)),
+	}
+	for i := range zones {
+		availability.Zones[i] = zones[i]
+		availability.Zones[i].Conditions = mergeConditions(previousZone(previous, zones[i].Zone).Conditions, zones[i].Conditions)
+	}
+	availability.Conditions = mergeConditions(previous.Conditions, vmopv1alpha1.Conditions{SummarizeAvailability(zones)})
+
+	if apiequality.Semantic.DeepEqual(previous.Zones, availability.Zones) &&
+		apiequality.Semantic.DeepEqual(previous.Conditions, availability.Conditions) {
+		return nil
+	}
+
+	// Only warn when the class becomes not schedulable, rather than on every check.
+	if c := schedulableCondition(availability.Conditions); c.Status == corev1.ConditionFalse {
+		if p := schedulableCondition(previous.Conditions); p == nil || p.Status != corev1.ConditionFalse {
+			r.Recorder.Warn(ctx.VMClass, notSchedulableReason, c.Message)
+		}
+	}
+
+	availability.LastCheckTime = metav1.Now()
+	data, err := json.Marshal(availability)
+	if err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(ctx.VMClass.DeepCopy())
+	if ctx.VMClass.Annotations == nil {
+		ctx.VMClass.Annotations = map[string]string{}
+	}
+	ctx.VMClass.Annotations[vmopapiv1alpha1.VirtualMachineClassAvailabilityAnnotation] = string(data)
+
+	return r.Patch(ctx, ctx.VMClass, patch)
+}
+
+// mergeConditions returns the current conditions with the LastTransitionTime of the previous conditions whose
+// state did not change, like conditions.Set does, so recording the same conditions again does not change them.
+func mergeConditions(previous, current vmopv1alpha1.Conditions) vmopv1alpha1.Conditions {
+	now := metav1.NewTime(time.Now().UTC().Truncate(time.Second))
+
+	merged := make(vmopv1alpha1.Conditions, 0, len(current))
+	for _, c := range current {
+		c.LastTransitionTime = now
+		for _, p := range previous {
+			if p.Type == c.Type && p.Status == c.Status && p.Reason == c.Reason &&
+				p.Severity == c.Severity && p.Message == c.Message {
+				c.LastTransitionTime = p.LastTransitionTime
+				break
+			}
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+// previousZone returns the recorded availability of the zone, or an empty one if it was not recorded.
+func previousZone(
+	previous *vmopapiv1alpha1.VirtualMachineClassAvailability,
+	zone string) *vmopapiv1alpha1.VirtualMachineClassZoneAvailability {
+
+	for i := range previous.Zones {
+		if previous.Zones[i].Zone == zone {
+			return &previous.Zones[i]
+		}
+	}
+	return &vmopapiv1alpha1.VirtualMachineClassZoneAvailability{Zone: zone}
+}
+
+// boundNamespaces returns the sorted namespaces that have a VirtualMachineClassBinding to the class.
+func (r *Reconciler) boundNamespaces(ctx goctx.Context, className string) ([]string, error) {
+	bindingList := &vmopv1alpha1.VirtualMachineClassBindingList{}
+	if err := r.List(ctx, bindingList); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]struct{}{}
+	var namespaces []string
+	for _, binding := range bindingList.Items {
+		if binding.ClassRef.Name != className {
+			continue
+		}
+		if _, ok := seen[binding.Namespace]; !ok {
+			seen[binding.Namespace] = struct{}{}
+			namespaces = append(namespaces, binding.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, nil
 }
 
-func (r *Reconciler) ReconcileNormal(_ *context.VirtualMachineClassContext) error {
-	// NoOp.
+// SummarizeAvailability returns the class's schedulable condition across the availability zones: true when VMs
+// of the class can be realized in at least one zone.
+func SummarizeAvailability(zones []vmopapiv1alpha1.VirtualMachineClassZoneAvailability) vmopv1alpha1.Condition {
+	if len(zones) == 0 {
+		return *conditions.UnknownCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition, "",
+			"no availability zone has a namespace")
+	}
+
+	var messages []string
+	for _, zone := range zones {
+		c := schedulableCondition(zone.Conditions)
+		if c != nil && c.Status == corev1.ConditionTrue {
+			return *conditions.TrueCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition)
+		}
+		if c != nil {
+			messages = append(messages, fmt.Sprintf("zone %s: %s", zone.Zone, c.Message))
+		}
+	}
+
+	return *conditions.FalseCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition,
+		vmopapiv1alpha1.VirtualMachineClassNotSchedulableReason, vmopv1alpha1.ConditionSeverityError,
+		"%s", strings.Join(messages, "; "))
+}
+
+// recordedAvailability returns the class's last recorded availability, or an empty one if it was never recorded.
+func recordedAvailability(vmClass *vmopv1alpha1.VirtualMachineClass) *vmopapiv1alpha1.VirtualMachineClassAvailability {
+	availability := &vmopapiv1alpha1.VirtualMachineClassAvailability{}
+	if data, ok := vmClass.Annotations[vmopapiv1alpha1.VirtualMachineClassAvailabilityAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), availability); err != nil {
+			return &vmopapiv1alpha1.VirtualMachineClassAvailability{}
+		}
+	}
+	return availability
+}
+
+func schedulableCondition(conds vmopv1alpha1.Conditions) *vmopv1alpha1.Condition {
+	for i := range conds {
+		if conds[i].Type == vmopapiv1alpha1.VirtualMachineClassSchedulableCondition {
+			return &conds[i]
+		}
+	}
 	return nil
 }
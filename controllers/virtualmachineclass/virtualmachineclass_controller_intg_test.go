@@ -4,6 +4,8 @@
 package virtualmachineclass_test
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -11,8 +13,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
 )
 
@@ -52,10 +58,27 @@ func intgTests() {
 
 	AfterEach(func() {
 		ctx.AfterEach()
+		intgFakeVMProvider.Reset()
 	})
 
 	Context("Reconcile", func() {
 		BeforeEach(func() {
+			intgFakeVMProvider.Lock()
+			intgFakeVMProvider.GetVirtualMachineClassAvailabilityFn = func(
+				_ context.Context,
+				_ *vmopv1alpha1.VirtualMachineClass,
+				_ []string) ([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability, error) {
+				return []vmopapiv1alpha1.VirtualMachineClassZoneAvailability{
+					{
+						Zone: "zone-1",
+						Conditions: vmopv1alpha1.Conditions{
+							*conditions.TrueCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition),
+						},
+					},
+				}, nil
+			}
+			intgFakeVMProvider.Unlock()
+
 			Expect(ctx.Client.Create(ctx, vmClass)).To(Succeed())
 		})
 
@@ -64,7 +87,14 @@ func intgTests() {
 			Expect(err == nil || k8serrors.IsNotFound(err)).To(BeTrue())
 		})
 
-		It("NoOp", func() {
+		It("records the availability of the class", func() {
+			Eventually(func() string {
+				obj := &vmopv1alpha1.VirtualMachineClass{}
+				if err := ctx.Client.Get(ctx, client.ObjectKey{Name: vmClass.Name}, obj); err != nil {
+					return ""
+				}
+				return obj.Annotations[vmopapiv1alpha1.VirtualMachineClassAvailabilityAnnotation]
+			}).Should(ContainSubstring(`"zone":"zone-1"`))
 		})
 	})
 }
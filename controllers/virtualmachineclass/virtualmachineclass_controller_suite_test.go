@@ -1,4 +1,4 @@
-// Copyright (c) 2020 VMware, Inc. All Rights Reserved.
+// Copyright (c) 2020-2021 VMware, Inc. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package virtualmachineclass_test
@@ -8,14 +8,22 @@ import (
 
 	. "github.com/onsi/ginkgo"
 
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineclass"
-	"github.com/vmware-tanzu/vm-operator/pkg/manager"
+	ctrlContext "github.com/vmware-tanzu/vm-operator/pkg/context"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
 )
 
+var intgFakeVMProvider = providerfake.NewVMProvider()
+
 var suite = builder.NewTestSuiteForController(
 	virtualmachineclass.AddToManager,
-	manager.InitializeProvidersNoopFn,
+	func(ctx *ctrlContext.ControllerManagerContext, _ ctrlmgr.Manager) error {
+		ctx.VMProvider = intgFakeVMProvider
+		return nil
+	},
 )
 
 func TestVirtualMachineClass(t *testing.T) {
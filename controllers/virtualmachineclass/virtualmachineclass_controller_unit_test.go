@@ -4,21 +4,31 @@
 package virtualmachineclass_test
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineclass"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	vmopContext "github.com/vmware-tanzu/vm-operator/pkg/context"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
 )
 
 func unitTests() {
 	Describe("Invoking Reconcile", unitTestsReconcile)
+	Describe("SummarizeAvailability", unitTestsSummarizeAvailability)
 }
 
 func unitTestsReconcile() {
@@ -29,6 +39,11 @@ func unitTestsReconcile() {
 		reconciler *virtualmachineclass.Reconciler
 		vmClassCtx *vmopContext.VirtualMachineClassContext
 		vmClass    *vmopv1alpha1.VirtualMachineClass
+
+		zones              []vmopapiv1alpha1.VirtualMachineClassZoneAvailability
+		checkedNamespaces  []string
+		schedulableZone    vmopapiv1alpha1.VirtualMachineClassZoneAvailability
+		notSchedulableZone vmopapiv1alpha1.VirtualMachineClassZoneAvailability
 	)
 
 	BeforeEach(func() {
@@ -37,6 +52,23 @@ func unitTestsReconcile() {
 				Name: "dummy-vmclass",
 			},
 		}
+
+		schedulableZone = vmopapiv1alpha1.VirtualMachineClassZoneAvailability{
+			Zone: "zone-1",
+			Conditions: vmopv1alpha1.Conditions{
+				*conditions.TrueCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition),
+			},
+		}
+		notSchedulableZone = vmopapiv1alpha1.VirtualMachineClassZoneAvailability{
+			Zone: "zone-1",
+			Conditions: vmopv1alpha1.Conditions{
+				*conditions.FalseCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition,
+					vmopapiv1alpha1.VirtualMachineClassVGPUProfileNotFoundReason, vmopv1alpha1.ConditionSeverityError,
+					"no host has vGPU profile %q", "grid_p40-1q"),
+			},
+		}
+		zones = []vmopapiv1alpha1.VirtualMachineClassZoneAvailability{schedulableZone}
+		checkedNamespaces = nil
 	})
 
 	JustBeforeEach(func() {
@@ -45,8 +77,18 @@ func unitTestsReconcile() {
 			ctx.Client,
 			ctx.Logger,
 			ctx.Recorder,
+			ctx.VMProvider,
 		)
 
+		fakeVMProvider := ctx.VMProvider.(*providerfake.VMProvider)
+		fakeVMProvider.GetVirtualMachineClassAvailabilityFn = func(
+			_ context.Context,
+			_ *vmopv1alpha1.VirtualMachineClass,
+			namespaces []string) ([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability, error) {
+			checkedNamespaces = namespaces
+			return zones, nil
+		}
+
 		vmClassCtx = &vmopContext.VirtualMachineClassContext{
 			Context: ctx,
 			Logger:  ctx.Logger.WithName(vmClass.Name),
@@ -54,16 +96,151 @@ func unitTestsReconcile() {
 		}
 	})
 
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
+	})
+
+	getAvailability := func() *vmopapiv1alpha1.VirtualMachineClassAvailability {
+		obj := &vmopv1alpha1.VirtualMachineClass{}
+		ExpectWithOffset(1, ctx.Client.Get(ctx, client.ObjectKey{Name: vmClass.Name}, obj)).To(Succeed())
+		data, ok := obj.Annotations[vmopapiv1alpha1.VirtualMachineClassAvailabilityAnnotation]
+		ExpectWithOffset(1, ok).To(BeTrue())
+		availability := &vmopapiv1alpha1.VirtualMachineClassAvailability{}
+		ExpectWithOffset(1, json.Unmarshal([]byte(data), availability)).To(Succeed())
+		return availability
+	}
+
 	Context("ReconcileNormal", func() {
 		BeforeEach(func() {
-			initObjects = append(initObjects, vmClass)
+			initObjects = append(initObjects, vmClass,
+				&vmopv1alpha1.VirtualMachineClassBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "binding", Namespace: "ns-2"},
+					ClassRef:   vmopv1alpha1.ClassReference{Name: vmClass.Name},
+				},
+				&vmopv1alpha1.VirtualMachineClassBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "binding", Namespace: "ns-1"},
+					ClassRef:   vmopv1alpha1.ClassReference{Name: vmClass.Name},
+				},
+				&vmopv1alpha1.VirtualMachineClassBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "other-binding", Namespace: "ns-3"},
+					ClassRef:   vmopv1alpha1.ClassReference{Name: "other-vmclass"},
+				})
+		})
+
+		It("checks the namespaces bound to the class", func() {
+			Expect(reconciler.ReconcileNormal(vmClassCtx)).To(Succeed())
+			Expect(checkedNamespaces).To(Equal([]string{"ns-1", "ns-2"}))
 		})
 
-		When("NoOp", func() {
-			It("returns success", func() {
-				err := reconciler.ReconcileNormal(vmClassCtx)
+		When("the class is schedulable", func() {
+			It("records the availability", func() {
+				Expect(reconciler.ReconcileNormal(vmClassCtx)).To(Succeed())
+
+				availability := getAvailability()
+				Expect(availability.Zones).To(HaveLen(1))
+				Expect(availability.Zones[0].Zone).To(Equal("zone-1"))
+				Expect(availability.Conditions).To(HaveLen(1))
+				Expect(availability.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+				Expect(ctx.Events).ToNot(Receive())
+			})
+
+			It("does not record the same availability again", func() {
+				Expect(reconciler.ReconcileNormal(vmClassCtx)).To(Succeed())
+				obj := &vmopv1alpha1.VirtualMachineClass{}
+				Expect(ctx.Client.Get(ctx, client.ObjectKey{Name: vmClass.Name}, obj)).To(Succeed())
+
+				vmClassCtx.VMClass = obj.DeepCopy()
+				Expect(reconciler.ReconcileNormal(vmClassCtx)).To(Succeed())
+				Expect(ctx.Client.Get(ctx, client.ObjectKey{Name: vmClass.Name}, vmClassCtx.VMClass)).To(Succeed())
+				Expect(vmClassCtx.VMClass.ResourceVersion).To(Equal(obj.ResourceVersion))
+			})
+
+			It("keeps the transition time of unchanged conditions when the availability changes", func() {
+				lastTransitionTime := metav1.NewTime(time.Now().Add(-time.Hour).UTC().Truncate(time.Second))
+				recorded := &vmopapiv1alpha1.VirtualMachineClassAvailability{
+					Zones:      []vmopapiv1alpha1.VirtualMachineClassZoneAvailability{schedulableZone},
+					Conditions: vmopv1alpha1.Conditions{virtualmachineclass.SummarizeAvailability(zones)},
+				}
+				recorded.Zones[0].Conditions[0].LastTransitionTime = lastTransitionTime
+				recorded.Conditions[0].LastTransitionTime = lastTransitionTime
+				data, err := json.Marshal(recorded)
 				Expect(err).ToNot(HaveOccurred())
+				vmClassCtx.VMClass.Annotations = map[string]string{
+					vmopapiv1alpha1.VirtualMachineClassAvailabilityAnnotation: string(data),
+				}
+
+				zones = []vmopapiv1alpha1.VirtualMachineClassZoneAvailability{schedulableZone, notSchedulableZone}
+				zones[1].Zone = "zone-2"
+				Expect(reconciler.ReconcileNormal(vmClassCtx)).To(Succeed())
+
+				availability := getAvailability()
+				Expect(availability.Zones).To(HaveLen(2))
+				Expect(availability.Zones[0].Conditions[0].LastTransitionTime.Time).To(BeTemporally("==", lastTransitionTime.Time))
+				Expect(availability.Conditions[0].LastTransitionTime.Time).To(BeTemporally("==", lastTransitionTime.Time))
+				Expect(availability.Zones[1].Conditions[0].LastTransitionTime.Time).To(BeTemporally(">", lastTransitionTime.Time))
+			})
+		})
+
+		When("the class is not schedulable", func() {
+			BeforeEach(func() {
+				zones = []vmopapiv1alpha1.VirtualMachineClassZoneAvailability{notSchedulableZone}
+			})
+
+			It("records the availability and warns once", func() {
+				Expect(reconciler.ReconcileNormal(vmClassCtx)).To(Succeed())
+
+				availability := getAvailability()
+				Expect(availability.Conditions).To(HaveLen(1))
+				Expect(availability.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+				Expect(availability.Conditions[0].Reason).To(Equal(vmopapiv1alpha1.VirtualMachineClassNotSchedulableReason))
+				Expect(availability.Conditions[0].Message).To(ContainSubstring("grid_p40-1q"))
+				expectEvent(ctx, "VirtualMachineClassNotSchedulable", "grid_p40-1q")
+
+				Expect(reconciler.ReconcileNormal(vmClassCtx)).To(Succeed())
+				Expect(ctx.Events).ToNot(Receive())
 			})
 		})
 	})
 }
+
+func unitTestsSummarizeAvailability() {
+	zone := func(name string, c *vmopv1alpha1.Condition) vmopapiv1alpha1.VirtualMachineClassZoneAvailability {
+		return vmopapiv1alpha1.VirtualMachineClassZoneAvailability{Zone: name, Conditions: vmopv1alpha1.Conditions{*c}}
+	}
+
+	It("is unknown without zones", func() {
+		c := virtualmachineclass.SummarizeAvailability(nil)
+		Expect(c.Status).To(Equal(corev1.ConditionUnknown))
+	})
+
+	It("is true when the class is schedulable in any zone", func() {
+		c := virtualmachineclass.SummarizeAvailability([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability{
+			zone("zone-1", conditions.FalseCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition,
+				vmopapiv1alpha1.VirtualMachineClassInsufficientCapacityReason, vmopv1alpha1.ConditionSeverityWarning, "full")),
+			zone("zone-2", conditions.TrueCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition)),
+		})
+		Expect(c.Status).To(Equal(corev1.ConditionTrue))
+	})
+
+	It("is false with the reasons of every zone", func() {
+		c := virtualmachineclass.SummarizeAvailability([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability{
+			zone("zone-1", conditions.FalseCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition,
+				vmopapiv1alpha1.VirtualMachineClassInsufficientCapacityReason, vmopv1alpha1.ConditionSeverityWarning, "full")),
+			zone("zone-2", conditions.FalseCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition,
+				vmopapiv1alpha1.VirtualMachineClassHardwareVersionNotSupportedReason, vmopv1alpha1.ConditionSeverityError, "old")),
+		})
+		Expect(c.Status).To(Equal(corev1.ConditionFalse))
+		Expect(c.Reason).To(Equal(vmopapiv1alpha1.VirtualMachineClassNotSchedulableReason))
+		Expect(c.Message).To(Equal("zone zone-1: full; zone zone-2: old"))
+	})
+}
+
+func expectEvent(ctx *builder.UnitTestContextForController, reason, msgSubstr string) {
+	var event string
+	EventuallyWithOffset(1, ctx.Events).Should(Receive(&event))
+	eventComponents := strings.Split(event, " ")
+	ExpectWithOffset(1, eventComponents[1]).To(Equal(reason))
+	ExpectWithOffset(1, event).To(ContainSubstring(msgSubstr))
+}
@@ -5,21 +5,51 @@ package virtualmachineimage
 
 import (
 	goctx "context"
+	"encoding/json"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
 
+	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/patch"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
 )
 
+// imageInfoLabels are the labels that are derived from the VirtualMachineImageInfo.
+var imageInfoLabels = []string{
+	vmopapiv1alpha1.VirtualMachineImageGuestOSIDLabel,
+	vmopapiv1alpha1.VirtualMachineImageFirmwareLabel,
+	vmopapiv1alpha1.VirtualMachineImageHardwareVersionLabel,
+	vmopapiv1alpha1.VirtualMachineImageDiskCountLabel,
+	vmopapiv1alpha1.VirtualMachineImageNICCountLabel,
+}
+
 // AddToManager adds this package's controller to the provided manager.
 func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
 	var (
@@ -30,33 +60,112 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) er
 	r := NewReconciler(
 		mgr.GetClient(),
 		ctrl.Log.WithName("controllers").WithName(controlledTypeName),
+		ctx.VMProvider,
 	)
 
+	if err := vmimage.IndexDisplayName(ctx, mgr.GetFieldIndexer()); err != nil {
+		return errors.Wrap(err, "failed to index VirtualMachineImages by display name")
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(controlledType).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Watches(&source.Kind{Type: &vmopv1alpha1.VirtualMachine{}},
+			handler.EnqueueRequestsFromMapFunc(vmToImageMapperFn(ctx, r.Client))).
+		// The images are verified once a namespace requires trusted images.
+		Watches(&source.Kind{Type: &corev1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(namespaceToImagesMapperFn(ctx, r.Client)),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return e.ObjectOld.GetAnnotations()[vmopapiv1alpha1.VirtualMachineImageTrustPolicyAnnotation] !=
+						e.ObjectNew.GetAnnotations()[vmopapiv1alpha1.VirtualMachineImageTrustPolicyAnnotation]
+				},
+				DeleteFunc: func(event.DeleteEvent) bool { return false },
+			})).
 		Complete(r)
 }
 
+// namespaceToImagesMapperFn returns a mapper function that returns the reconcile requests for all the images when
+// the image trust policy of a Namespace requires the images to be verified.
+func namespaceToImagesMapperFn(ctx *context.ControllerManagerContext, c client.Client) func(o client.Object) []reconcile.Request {
+	return func(o client.Object) []reconcile.Request {
+		if !requiresImageTrust(o.GetAnnotations()[vmopapiv1alpha1.VirtualMachineImageTrustPolicyAnnotation]) {
+			return nil
+		}
+
+		imageList := &vmopv1alpha1.VirtualMachineImageList{}
+		if err := c.List(ctx, imageList); err != nil {
+			ctx.Logger.Error(err, "Failed to list VirtualMachineImages for reconciliation due to Namespace watch")
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(imageList.Items))
+		for i := range imageList.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: imageList.Items[i].Name}})
+		}
+
+		return requests
+	}
+}
+
+// requiresImageTrust returns true if the stricter of the image trust policies requires images to be verified.
+func requiresImageTrust(policies ...string) bool {
+	return vmimage.StricterTrustPolicy(policies...) != vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy
+}
+
+// vmToImageMapperFn returns a mapper function that returns the reconcile requests for the images a VirtualMachine
+// refers to, so the VirtualMachines that use an image are updated when a VirtualMachine is created or deleted.
+func vmToImageMapperFn(ctx *context.ControllerManagerContext, c client.Client) func(o client.Object) []reconcile.Request {
+	return func(o client.Object) []reconcile.Request {
+		vm, ok := o.(*vmopv1alpha1.VirtualMachine)
+		if !ok || vm.Spec.ImageName == "" {
+			return nil
+		}
+
+		// The VM may refer to the image by its name, or by its display name.
+		requests := []reconcile.Request{{NamespacedName: types.NamespacedName{Name: vm.Spec.ImageName}}}
+
+		imageList := &vmopv1alpha1.VirtualMachineImageList{}
+		if err := c.List(ctx, imageList, client.MatchingFields{vmimage.DisplayNameField: vm.Spec.ImageName}); err != nil {
+			ctx.Logger.Error(err, "Failed to list VirtualMachineImages for reconciliation due to VirtualMachine watch")
+			return requests
+		}
+
+		for i := range imageList.Items {
+			image := &imageList.Items[i]
+			if image.Name != vm.Spec.ImageName && vmimage.IsReferencedBy(image, vm) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: image.Name}})
+			}
+		}
+
+		return requests
+	}
+}
+
 func NewReconciler(
 	client client.Client,
-	logger logr.Logger) *Reconciler {
+	logger logr.Logger,
+	vmProvider vmprovider.VirtualMachineProviderInterface) *Reconciler {
 	return &Reconciler{
-		Client: client,
-		Logger: logger,
+		Client:     client,
+		Logger:     logger,
+		VMProvider: vmProvider,
 	}
 }
 
-// Reconciler reconciles a VirtualMachineClass object.
+// Reconciler reconciles a VirtualMachineImage object.
 type Reconciler struct {
 	client.Client
-	Logger logr.Logger
+	Logger     logr.Logger
+	VMProvider vmprovider.VirtualMachineProviderInterface
 }
 
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimages,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimages/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
-func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	vmImage := &vmopv1alpha1.VirtualMachineImage{}
 	err := r.Get(ctx, req.NamespacedName, vmImage)
 	if err != nil {
@@ -66,14 +175,265 @@ func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (ctrl.Result
 		return ctrl.Result{}, err
 	}
 
-	if err := r.ReconcileNormal(ctx); err != nil {
+	if !vmImage.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	imageCtx := &context.VirtualMachineImageContext{
+		Context: ctx,
+		Logger:  r.Logger.WithValues("name", vmImage.Name),
+		VMImage: vmImage,
+	}
+
+	patchHelper, err := patch.NewHelper(vmImage, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to init patch helper for %s", imageCtx.String())
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, vmImage); err != nil {
+			if reterr == nil {
+				reterr = err
+			}
+			imageCtx.Logger.Error(err, "patch failed")
+		}
+	}()
+
+	if err := r.ReconcileNormal(imageCtx); err != nil {
+		imageCtx.Logger.Error(err, "Failed to reconcile VirtualMachineImage")
 		return ctrl.Result{}, err
 	}
 
 	return ctrl.Result{}, nil
 }
 
-func (r *Reconciler) ReconcileNormal(ctx goctx.Context) error {
-	// NoOp
+// ReconcileNormal records the VirtualMachines that use the image, inspects the OVF descriptor of the image, and
+// verifies its OVF manifest and signature.
+func (r *Reconciler) ReconcileNormal(ctx *context.VirtualMachineImageContext) error {
+	if err := r.reconcileInUseBy(ctx); err != nil {
+		return err
+	}
+
+	if err := r.reconcileImageInfo(ctx); err != nil {
+		return err
+	}
+
+	return r.reconcileImageTrust(ctx)
+}
+
+// reconcileInUseBy records the VirtualMachines that use the image in the VirtualMachineImageInUseByAnnotation.
+func (r *Reconciler) reconcileInUseBy(ctx *context.VirtualMachineImageContext) error {
+	vmImage := ctx.VMImage
+
+	vmList := &vmopv1alpha1.VirtualMachineList{}
+	if err := r.List(ctx, vmList); err != nil {
+		return errors.Wrap(err, "failed to list VirtualMachines")
+	}
+
+	var inUseBy []string
+	for i := range vmList.Items {
+		vm := &vmList.Items[i]
+		if vmimage.IsReferencedBy(vmImage, vm) {
+			inUseBy = append(inUseBy, vm.Namespace+"/"+vm.Name)
+		}
+	}
+
+	if len(inUseBy) == 0 {
+		delete(vmImage.Annotations, vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation)
+		return nil
+	}
+
+	sort.Strings(inUseBy)
+	if vmImage.Annotations == nil {
+		vmImage.Annotations = map[string]string{}
+	}
+	vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation] = strings.Join(inUseBy, ",")
+
+	return nil
+}
+
+// reconcileImageInfo inspects the OVF descriptor of the image, and records what it contains on the image. The
+// image is only inspected again once its content library item version changes.
+func (r *Reconciler) reconcileImageInfo(ctx *context.VirtualMachineImageContext) error {
+	vmImage := ctx.VMImage
+
+	itemVersion := vmImage.Annotations[constants.VMImageCLVersionAnnotation]
+	if v, ok := vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageInfoVersionAnnotation]; ok && v == itemVersion &&
+		conditions.Has(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition) {
+		return nil
+	}
+
+	info, err := r.VMProvider.GetVirtualMachineImageInfo(ctx, vmImage)
+	switch {
+	case errors.Is(err, vmprovider.ErrImageUnsupported):
+		ctx.Logger.Info("VirtualMachineImage cannot be inspected", "reason", err.Error())
+		clearImageInfo(vmImage, itemVersion)
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition,
+			vmopapiv1alpha1.VirtualMachineImageUnsupportedReason, vmopv1alpha1.ConditionSeverityInfo, "%v", err)
+		return nil
+	case errors.Is(err, vmprovider.ErrImageCorrupt):
+		ctx.Logger.Info("VirtualMachineImage is corrupt", "reason", err.Error())
+		clearImageInfo(vmImage, itemVersion)
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition,
+			vmopapiv1alpha1.VirtualMachineImageCorruptReason, vmopv1alpha1.ConditionSeverityError, "%v", err)
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "failed to inspect %s", ctx.String())
+	}
+
+	if err := setImageInfo(vmImage, info, itemVersion); err != nil {
+		return err
+	}
+	conditions.MarkTrue(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition)
+
+	return nil
+}
+
+// isImageTrustRequired returns true if the cluster's image trust policy, or that of a Namespace, requires the
+// images to be verified.
+func (r *Reconciler) isImageTrustRequired(ctx *context.VirtualMachineImageContext) (bool, error) {
+	if requiresImageTrust(lib.GetImageTrustPolicy()) {
+		return true, nil
+	}
+
+	nsList := &corev1.NamespaceList{}
+	if err := r.List(ctx, nsList); err != nil {
+		return false, errors.Wrap(err, "failed to list Namespaces")
+	}
+
+	for i := range nsList.Items {
+		if requiresImageTrust(nsList.Items[i].Annotations[vmopapiv1alpha1.VirtualMachineImageTrustPolicyAnnotation]) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// reconcileImageTrust verifies the OVF manifest and signature of the image, and records the result in the
+// image's conditions. The image is only verified when an image trust policy requires it, since verifying
+// downloads all the files of the image, and only again once its content library item version changes.
+func (r *Reconciler) reconcileImageTrust(ctx *context.VirtualMachineImageContext) error {
+	vmImage := ctx.VMImage
+
+	if required, err := r.isImageTrustRequired(ctx); err != nil || !required {
+		return err
+	}
+
+	itemVersion := vmImage.Annotations[constants.VMImageCLVersionAnnotation]
+	if v, ok := vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation]; ok && v == itemVersion &&
+		conditions.Has(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition) &&
+		conditions.Has(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition) {
+		return nil
+	}
+
+	result, err := r.VMProvider.VerifyVirtualMachineImage(ctx, vmImage)
+	switch {
+	case errors.Is(err, vmprovider.ErrImageUnsupported):
+		ctx.Logger.Info("VirtualMachineImage cannot be verified", "reason", err.Error())
+		setImageTrustVersion(vmImage, itemVersion, "")
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition,
+			vmopapiv1alpha1.VirtualMachineImageUnsupportedReason, vmopv1alpha1.ConditionSeverityInfo, "%v", err)
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+			vmopapiv1alpha1.VirtualMachineImageUnsupportedReason, vmopv1alpha1.ConditionSeverityInfo, "%v", err)
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "failed to verify %s", ctx.String())
+	}
+
+	setImageTrustVersion(vmImage, itemVersion, result.Signer)
+
+	switch {
+	case result.ManifestErr == nil:
+		conditions.MarkTrue(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)
+	case errors.Is(result.ManifestErr, vmprovider.ErrImageManifestMissing):
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition,
+			vmopapiv1alpha1.VirtualMachineImageManifestMissingReason, vmopv1alpha1.ConditionSeverityInfo, "%v", result.ManifestErr)
+	default:
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition,
+			vmopapiv1alpha1.VirtualMachineImageDigestMismatchReason, vmopv1alpha1.ConditionSeverityError, "%v", result.ManifestErr)
+	}
+
+	switch {
+	case result.SignatureErr == nil:
+		conditions.MarkTrue(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)
+	case errors.Is(result.SignatureErr, vmprovider.ErrImageUnsigned):
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+			vmopapiv1alpha1.VirtualMachineImageUnsignedReason, vmopv1alpha1.ConditionSeverityInfo, "%v", result.SignatureErr)
+	case errors.Is(result.SignatureErr, vmprovider.ErrImageCertificateUntrusted):
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+			vmopapiv1alpha1.VirtualMachineImageUntrustedCertificateReason, vmopv1alpha1.ConditionSeverityError, "%v", result.SignatureErr)
+	default:
+		conditions.MarkFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+			vmopapiv1alpha1.VirtualMachineImageInvalidSignatureReason, vmopv1alpha1.ConditionSeverityError, "%v", result.SignatureErr)
+	}
+
+	return nil
+}
+
+// setImageTrustVersion records the item version the image was verified for, and the signer of the image.
+func setImageTrustVersion(vmImage *vmopv1alpha1.VirtualMachineImage, itemVersion, signer string) {
+	if vmImage.Annotations == nil {
+		vmImage.Annotations = map[string]string{}
+	}
+	vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation] = itemVersion
+
+	if signer == "" {
+		delete(vmImage.Annotations, vmopapiv1alpha1.VirtualMachineImageSignerAnnotation)
+	} else {
+		vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageSignerAnnotation] = signer
+	}
+}
+
+// clearImageInfo removes the recorded info and capability labels from the image.
+func clearImageInfo(vmImage *vmopv1alpha1.VirtualMachineImage, itemVersion string) {
+	if vmImage.Annotations == nil {
+		vmImage.Annotations = map[string]string{}
+	}
+	vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageInfoVersionAnnotation] = itemVersion
+	delete(vmImage.Annotations, vmopapiv1alpha1.VirtualMachineImageInfoAnnotation)
+
+	for _, label := range imageInfoLabels {
+		delete(vmImage.Labels, label)
+	}
+}
+
+// setImageInfo records the info in the image's annotations and capability labels. The status of the
+// VirtualMachineImage type cannot be extended here since the type is defined by vm-operator-api, so the info is a
+// JSON annotation, and the capability labels let images be selected by what they support.
+// TODO: Move the info into the status of the VirtualMachineImage once vm-operator-api has a field for it.
+func setImageInfo(
+	vmImage *vmopv1alpha1.VirtualMachineImage,
+	info *vmopapiv1alpha1.VirtualMachineImageInfo,
+	itemVersion string) error {
+
+	clearImageInfo(vmImage, itemVersion)
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal VirtualMachineImageInfo")
+	}
+	vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageInfoAnnotation] = string(data)
+
+	labels := map[string]string{
+		vmopapiv1alpha1.VirtualMachineImageGuestOSIDLabel: info.GuestOSID,
+		vmopapiv1alpha1.VirtualMachineImageFirmwareLabel:  info.Firmware,
+		vmopapiv1alpha1.VirtualMachineImageDiskCountLabel: strconv.Itoa(len(info.Disks)),
+		vmopapiv1alpha1.VirtualMachineImageNICCountLabel:  strconv.Itoa(int(info.NICCount)),
+	}
+	if info.HardwareVersion > 0 {
+		labels[vmopapiv1alpha1.VirtualMachineImageHardwareVersionLabel] = strconv.Itoa(int(info.HardwareVersion))
+	}
+
+	for k, v := range labels {
+		// Skip values from the OVF that cannot be used as a label value.
+		if v == "" || len(validation.IsValidLabelValue(v)) != 0 {
+			continue
+		}
+		if vmImage.Labels == nil {
+			vmImage.Labels = map[string]string{}
+		}
+		vmImage.Labels[k] = v
+	}
+
 	return nil
 }
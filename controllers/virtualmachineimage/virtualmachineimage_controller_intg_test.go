@@ -1,17 +1,22 @@
-// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// Copyright (c) 2019-2021 VMware, Inc. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package virtualmachineimage_test
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
 )
 
@@ -33,11 +38,22 @@ func virtualMachineImageReconcile() {
 				Name: "dummy-image",
 			},
 		}
+
+		intgFakeVMProvider.Lock()
+		intgFakeVMProvider.GetVirtualMachineImageInfoFn = func(_ context.Context, _ *vmopv1alpha1.VirtualMachineImage) (*vmopapiv1alpha1.VirtualMachineImageInfo, error) {
+			return &vmopapiv1alpha1.VirtualMachineImageInfo{
+				GuestOSID: "ubuntu64Guest",
+				Firmware:  vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS,
+				NICCount:  1,
+			}, nil
+		}
+		intgFakeVMProvider.Unlock()
 	})
 
 	AfterEach(func() {
 		ctx.AfterEach()
 		ctx = nil
+		intgFakeVMProvider.Reset()
 	})
 
 	Context("Reconcile", func() {
@@ -50,8 +66,15 @@ func virtualMachineImageReconcile() {
 			Expect(err == nil || k8serrors.IsNotFound(err)).To(BeTrue())
 		})
 
-		It("resource successfully created", func() {
-			// Tested in BeforeEach/AfterEach
+		It("records the image info", func() {
+			Eventually(func() bool {
+				obj := &vmopv1alpha1.VirtualMachineImage{}
+				if err := ctx.Client.Get(ctx, client.ObjectKeyFromObject(vmImage), obj); err != nil {
+					return false
+				}
+				return conditions.IsTrue(obj, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition) &&
+					obj.Labels[vmopapiv1alpha1.VirtualMachineImageGuestOSIDLabel] == "ubuntu64Guest"
+			}).Should(BeTrue())
 		})
 	})
 }
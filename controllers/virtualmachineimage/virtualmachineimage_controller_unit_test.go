@@ -4,15 +4,28 @@
 package virtualmachineimage_test
 
 import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineimage"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	vmopContext "github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
 )
 
@@ -25,14 +38,55 @@ func unitTestsReconcile() {
 		initObjects []client.Object
 		ctx         *builder.UnitTestContextForController
 
-		reconciler *virtualmachineimage.Reconciler
-		vmImage    *vmopv1alpha1.VirtualMachineImage
+		reconciler     *virtualmachineimage.Reconciler
+		fakeVMProvider *providerfake.VMProvider
+		vmImage        *vmopv1alpha1.VirtualMachineImage
+		vmImageCtx     *vmopContext.VirtualMachineImageContext
+		namespace      *corev1.Namespace
+
+		info       *vmopapiv1alpha1.VirtualMachineImageInfo
+		infoErr    error
+		infoCalled int
+
+		verification *vmprovider.ImageVerification
+		verifyErr    error
+		verifyCalled int
 	)
 
 	BeforeEach(func() {
 		vmImage = &vmopv1alpha1.VirtualMachineImage{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "dummy-vmclass",
+				Name: "dummy-image",
+				Annotations: map[string]string{
+					constants.VMImageCLVersionAnnotation: "item-id:1:1",
+				},
+			},
+		}
+
+		capacity := resource.MustParse("10Gi")
+		info = &vmopapiv1alpha1.VirtualMachineImageInfo{
+			GuestOSID:       "ubuntu64Guest",
+			Firmware:        vmopapiv1alpha1.VirtualMachineImageFirmwareEFI,
+			HardwareVersion: 15,
+			Disks:           []vmopapiv1alpha1.VirtualMachineImageDiskInfo{{ID: "vmdisk1", Capacity: &capacity}},
+			NICCount:        1,
+			OVFProperties: []vmopapiv1alpha1.VirtualMachineImageOVFProperty{
+				{Key: "hostname", Type: "string", UserConfigurable: true},
+			},
+		}
+		infoErr = nil
+		infoCalled = 0
+
+		verification = &vmprovider.ImageVerification{Signer: "CN=test-signer"}
+		verifyErr = nil
+		verifyCalled = 0
+
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-ns",
+				Annotations: map[string]string{
+					vmopapiv1alpha1.VirtualMachineImageTrustPolicyAnnotation: string(vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy),
+				},
 			},
 		}
 	})
@@ -42,18 +96,236 @@ func unitTestsReconcile() {
 		reconciler = virtualmachineimage.NewReconciler(
 			ctx.Client,
 			ctx.Logger,
+			ctx.VMProvider,
 		)
+
+		fakeVMProvider = ctx.VMProvider.(*providerfake.VMProvider)
+		fakeVMProvider.GetVirtualMachineImageInfoFn = func(_ context.Context, _ *vmopv1alpha1.VirtualMachineImage) (*vmopapiv1alpha1.VirtualMachineImageInfo, error) {
+			infoCalled++
+			return info, infoErr
+		}
+		fakeVMProvider.VerifyVirtualMachineImageFn = func(_ context.Context, _ *vmopv1alpha1.VirtualMachineImage) (*vmprovider.ImageVerification, error) {
+			verifyCalled++
+			return verification, verifyErr
+		}
+
+		vmImageCtx = &vmopContext.VirtualMachineImageContext{
+			Context: ctx,
+			Logger:  ctx.Logger,
+			VMImage: vmImage,
+		}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
 	})
 
 	Context("ReconcileNormal", func() {
 		BeforeEach(func() {
-			initObjects = append(initObjects, vmImage)
+			initObjects = append(initObjects, vmImage, namespace)
+		})
+
+		When("the image is inspected", func() {
+			It("records the image info", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(conditions.IsTrue(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition)).To(BeTrue())
+
+				Expect(vmImage.Labels).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageGuestOSIDLabel, "ubuntu64Guest"))
+				Expect(vmImage.Labels).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageFirmwareLabel, "efi"))
+				Expect(vmImage.Labels).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageHardwareVersionLabel, "15"))
+				Expect(vmImage.Labels).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageDiskCountLabel, "1"))
+				Expect(vmImage.Labels).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageNICCountLabel, "1"))
+
+				Expect(vmImage.Annotations).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageInfoVersionAnnotation, "item-id:1:1"))
+				Expect(vmImage.Annotations).To(HaveKey(vmopapiv1alpha1.VirtualMachineImageInfoAnnotation))
+				recorded := &vmopapiv1alpha1.VirtualMachineImageInfo{}
+				Expect(json.Unmarshal([]byte(vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageInfoAnnotation]), recorded)).To(Succeed())
+				Expect(recorded.OVFProperties).To(Equal(info.OVFProperties))
+				Expect(recorded.Disks).To(HaveLen(1))
+				Expect(recorded.Disks[0].Capacity.String()).To(Equal("10Gi"))
+			})
+
+			It("is not inspected again until the item version changes", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(infoCalled).To(Equal(1))
+
+				vmImage.Annotations[constants.VMImageCLVersionAnnotation] = "item-id:2:1"
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(infoCalled).To(Equal(2))
+			})
+		})
+
+		When("the image is not supported", func() {
+			BeforeEach(func() {
+				infoErr = errors.Wrap(vmprovider.ErrImageUnsupported, "vmtx")
+				vmImage.Labels = map[string]string{
+					vmopapiv1alpha1.VirtualMachineImageFirmwareLabel: "efi",
+					"foo": "bar",
+				}
+			})
+
+			It("marks the condition false and removes stale labels", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(conditions.IsFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition)).To(BeTrue())
+				Expect(conditions.GetReason(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageUnsupportedReason))
+				Expect(vmImage.Labels).To(Equal(map[string]string{"foo": "bar"}))
+				Expect(vmImage.Annotations).ToNot(HaveKey(vmopapiv1alpha1.VirtualMachineImageInfoAnnotation))
+			})
+		})
+
+		When("the image is corrupt", func() {
+			BeforeEach(func() {
+				infoErr = errors.Wrap(vmprovider.ErrImageCorrupt, "bad xml")
+			})
+
+			It("marks the condition false", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(conditions.IsFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition)).To(BeTrue())
+				Expect(conditions.GetReason(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageCorruptReason))
+			})
+		})
+
+		When("the image is verified", func() {
+			It("marks the conditions true and records the signer", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(conditions.IsTrue(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)).To(BeTrue())
+				Expect(conditions.IsTrue(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)).To(BeTrue())
+				Expect(vmImage.Annotations).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageSignerAnnotation, "CN=test-signer"))
+				Expect(vmImage.Annotations).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation, "item-id:1:1"))
+			})
+
+			It("is not verified again until the item version changes", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(verifyCalled).To(Equal(1))
+
+				vmImage.Annotations[constants.VMImageCLVersionAnnotation] = "item-id:2:1"
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(verifyCalled).To(Equal(2))
+			})
+		})
+
+		When("no image trust policy requires the image to be verified", func() {
+			BeforeEach(func() {
+				namespace.Annotations = nil
+			})
+
+			It("does not verify the image", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(verifyCalled).To(BeZero())
+				Expect(conditions.Has(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)).To(BeFalse())
+				Expect(vmImage.Annotations).ToNot(HaveKey(vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation))
+			})
+		})
+
+		When("the image is unsigned", func() {
+			BeforeEach(func() {
+				verification = &vmprovider.ImageVerification{SignatureErr: errors.Wrap(vmprovider.ErrImageUnsigned, "no .cert file")}
+				vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageSignerAnnotation] = "CN=old-signer"
+			})
+
+			It("marks the signature condition false and removes the signer", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(conditions.IsTrue(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)).To(BeTrue())
+				Expect(conditions.IsFalse(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)).To(BeTrue())
+				Expect(conditions.GetReason(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageUnsignedReason))
+				Expect(*conditions.GetSeverity(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)).To(
+					Equal(vmopv1alpha1.ConditionSeverityInfo))
+				Expect(vmImage.Annotations).ToNot(HaveKey(vmopapiv1alpha1.VirtualMachineImageSignerAnnotation))
+			})
+		})
+
+		When("the image does not match its manifest and is signed by an untrusted certificate", func() {
+			BeforeEach(func() {
+				verification = &vmprovider.ImageVerification{
+					ManifestErr:  errors.Wrap(vmprovider.ErrImageDigestMismatch, "disk.vmdk"),
+					SignatureErr: errors.Wrap(vmprovider.ErrImageCertificateUntrusted, "unknown authority"),
+				}
+			})
+
+			It("marks the conditions false", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(conditions.GetReason(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageDigestMismatchReason))
+				Expect(conditions.GetReason(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageUntrustedCertificateReason))
+			})
+		})
+
+		When("the image has no manifest", func() {
+			BeforeEach(func() {
+				verification = &vmprovider.ImageVerification{
+					ManifestErr:  errors.Wrap(vmprovider.ErrImageManifestMissing, "no .mf file"),
+					SignatureErr: errors.Wrap(vmprovider.ErrImageSignatureInvalid, "bad signature"),
+				}
+			})
+
+			It("marks the conditions false", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(conditions.GetReason(vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageManifestMissingReason))
+				Expect(conditions.GetReason(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageInvalidSignatureReason))
+			})
+		})
+
+		When("the image cannot be verified", func() {
+			BeforeEach(func() {
+				verifyErr = errors.New("fake verify error")
+			})
+
+			It("returns an error", func() {
+				err := reconciler.ReconcileNormal(vmImageCtx)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake verify error"))
+				Expect(conditions.Has(vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)).To(BeFalse())
+			})
+		})
+
+		When("VirtualMachines use the image", func() {
+			BeforeEach(func() {
+				for _, vm := range []*vmopv1alpha1.VirtualMachine{
+					{ObjectMeta: metav1.ObjectMeta{Name: "vm-b", Namespace: "ns-1"}, Spec: vmopv1alpha1.VirtualMachineSpec{ImageName: vmImage.Name}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "vm-a", Namespace: "ns-2"}, Spec: vmopv1alpha1.VirtualMachineSpec{ImageName: vmImage.Name}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "vm-c", Namespace: "ns-1"}, Spec: vmopv1alpha1.VirtualMachineSpec{ImageName: "other-image"}},
+				} {
+					initObjects = append(initObjects, vm)
+				}
+			})
+
+			It("records the VirtualMachines that use the image", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(vmImage.Annotations).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation, "ns-1/vm-b,ns-2/vm-a"))
+			})
+		})
+
+		When("no VirtualMachines use the image", func() {
+			BeforeEach(func() {
+				vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation] = "ns-1/deleted-vm"
+			})
+
+			It("removes the annotation", func() {
+				Expect(reconciler.ReconcileNormal(vmImageCtx)).To(Succeed())
+				Expect(vmImage.Annotations).ToNot(HaveKey(vmopapiv1alpha1.VirtualMachineImageInUseByAnnotation))
+			})
 		})
 
-		When("NoOp", func() {
-			It("returns success", func() {
-				err := reconciler.ReconcileNormal(ctx.Context)
-				Expect(err).ToNot(HaveOccurred())
+		When("the image cannot be fetched", func() {
+			BeforeEach(func() {
+				infoErr = errors.New("fake error")
+			})
+
+			It("returns an error", func() {
+				err := reconciler.ReconcileNormal(vmImageCtx)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake error"))
+				Expect(conditions.Has(vmImage, vmopapiv1alpha1.VirtualMachineImageOVFInspectedCondition)).To(BeFalse())
 			})
 		})
 	})
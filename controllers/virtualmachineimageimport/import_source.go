@@ -0,0 +1,389 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachineimageimport
+
+import (
+	"archive/tar"
+	"bytes"
+	goctx "context"
+	"crypto/sha1" //nolint:gosec // SHA-1 is only used to verify a checksum given by the user.
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vapi/library"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+)
+
+// maxDescriptorSize is the maximum size of an OVF descriptor, which is read into memory to find its files.
+const maxDescriptorSize = 10 * 1024 * 1024
+
+// fileKind is the kind of file at the URL of an import.
+type fileKind string
+
+const (
+	ovaFileKind fileKind = ".ova"
+	ovfFileKind fileKind = ".ovf"
+	isoFileKind fileKind = ".iso"
+)
+
+// parseImportURL parses the URL of an import, and returns the kind of file at the URL.
+func parseImportURL(rawURL string) (*url.URL, fileKind, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, "", errors.Errorf("URL scheme %q is not http or https", u.Scheme)
+	}
+
+	kind := fileKind(strings.ToLower(path.Ext(u.Path)))
+	switch kind {
+	case ovaFileKind, ovfFileKind, isoFileKind:
+		return u, kind, nil
+	default:
+		return nil, "", errors.Errorf("URL path %q does not have an .ova, .ovf or .iso extension", u.Path)
+	}
+}
+
+// itemTypeForFileKind returns the content library item type for the kind of file.
+func itemTypeForFileKind(kind fileKind) string {
+	if kind == isoFileKind {
+		return library.ItemTypeISO
+	}
+	return library.ItemTypeOVF
+}
+
+// defaultItemName returns the name of the file at the URL without its extension.
+func defaultItemName(u *url.URL) string {
+	base := path.Base(u.Path)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// newChecksumHash returns the hash for the algorithm of the checksum.
+func newChecksumHash(checksum *vmopapiv1alpha1.VirtualMachineImageImportChecksum) (hash.Hash, error) {
+	switch checksum.Algorithm {
+	case vmopapiv1alpha1.SHA1ChecksumAlgorithm:
+		return sha1.New(), nil //nolint:gosec // SHA-1 is only used to verify a checksum given by the user.
+	case vmopapiv1alpha1.SHA256ChecksumAlgorithm:
+		return sha256.New(), nil
+	case vmopapiv1alpha1.SHA512ChecksumAlgorithm:
+		return sha512.New(), nil
+	default:
+		return nil, errors.Errorf("unsupported checksum algorithm %q", checksum.Algorithm)
+	}
+}
+
+// importSource downloads the file at the URL of an import, and the files an OVF descriptor refers to, as the
+// files of the library item. The checksum of the file at the URL is verified once it has been read to its end,
+// before the last file is returned, so the upload is aborted when the checksum does not match.
+type importSource struct {
+	ctx        goctx.Context
+	httpClient *http.Client
+	url        *url.URL
+	kind       fileKind
+
+	checksum *vmopapiv1alpha1.VirtualMachineImageImportChecksum
+	hash     hash.Hash
+
+	// total and transferred are accessed atomically because the files are read by the upload while the
+	// progress is reported.
+	total       int64
+	transferred int64
+
+	body       io.ReadCloser
+	tarReader  *tar.Reader
+	references []ovf.File
+	started    bool
+
+	// downloadErr is the first error downloading a file. It is guarded by mu because a response may be read by
+	// the upload on another goroutine.
+	mu          sync.Mutex
+	downloadErr error
+	// checksumErr is the error when the checksum does not match.
+	checksumErr error
+	// checksumVerified is true once the checksum has been verified.
+	checksumVerified bool
+}
+
+var _ vmprovider.LibraryItemFiles = &importSource{}
+
+func newImportSource(
+	ctx goctx.Context,
+	httpClient *http.Client,
+	u *url.URL,
+	kind fileKind,
+	checksum *vmopapiv1alpha1.VirtualMachineImageImportChecksum) (*importSource, error) {
+
+	s := &importSource{
+		ctx:        ctx,
+		httpClient: httpClient,
+		url:        u,
+		kind:       kind,
+		checksum:   checksum,
+	}
+
+	if checksum != nil {
+		h, err := newChecksumHash(checksum)
+		if err != nil {
+			return nil, err
+		}
+		s.hash = h
+	}
+
+	return s, nil
+}
+
+// Progress returns the size of the files when it is known, and the number of bytes downloaded so far.
+func (s *importSource) Progress() (int64, int64) {
+	return atomic.LoadInt64(&s.total), atomic.LoadInt64(&s.transferred)
+}
+
+// Close closes the response of the file that is being downloaded.
+func (s *importSource) Close() {
+	if s.body != nil {
+		_ = s.body.Close()
+		s.body = nil
+	}
+}
+
+func (s *importSource) Next() (*vmprovider.LibraryItemFile, error) {
+	var (
+		file *vmprovider.LibraryItemFile
+		err  error
+	)
+
+	switch s.kind {
+	case ovaFileKind:
+		file, err = s.nextOVAFile()
+	case ovfFileKind:
+		file, err = s.nextOVFFile()
+	default:
+		file, err = s.nextFile()
+	}
+
+	if err == io.EOF {
+		// The file at the URL has been read to its end, so its checksum is complete.
+		err = s.verifyChecksum()
+		if err == nil {
+			err = io.EOF
+		}
+	}
+
+	return file, err
+}
+
+// nextFile returns the file at the URL as the only file.
+func (s *importSource) nextFile() (*vmprovider.LibraryItemFile, error) {
+	if s.started {
+		return nil, io.EOF
+	}
+	s.started = true
+
+	size, err := s.open(s.url, true)
+	if err != nil {
+		return nil, err
+	}
+	atomic.StoreInt64(&s.total, size)
+
+	return &vmprovider.LibraryItemFile{Name: path.Base(s.url.Path), Size: size, Reader: s.body}, nil
+}
+
+// nextOVAFile returns the next file in the OVA at the URL.
+func (s *importSource) nextOVAFile() (*vmprovider.LibraryItemFile, error) {
+	if !s.started {
+		s.started = true
+
+		size, err := s.open(s.url, true)
+		if err != nil {
+			return nil, err
+		}
+		atomic.StoreInt64(&s.total, size)
+		s.tarReader = tar.NewReader(s.body)
+	}
+
+	for {
+		header, err := s.tarReader.Next()
+		if err == io.EOF {
+			// Read the padding after the end of the archive so the checksum covers the whole file.
+			if _, err := io.Copy(ioutil.Discard, s.body); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, errors.Wrap(s.recordDownloadErr(err), "failed to read OVA")
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		return &vmprovider.LibraryItemFile{Name: path.Base(header.Name), Size: header.Size, Reader: s.tarReader}, nil
+	}
+}
+
+// nextOVFFile returns the OVF descriptor at the URL, and then the files it refers to.
+func (s *importSource) nextOVFFile() (*vmprovider.LibraryItemFile, error) {
+	if !s.started {
+		s.started = true
+		return s.openDescriptor()
+	}
+
+	if len(s.references) == 0 {
+		return nil, io.EOF
+	}
+	ref := s.references[0]
+	s.references = s.references[1:]
+
+	refURL, err := s.url.Parse(ref.Href)
+	if err != nil {
+		return nil, s.recordDownloadErr(errors.Wrapf(err, "invalid file reference %q", ref.Href))
+	}
+
+	size, err := s.open(refURL, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vmprovider.LibraryItemFile{Name: ref.Href, Size: size, Reader: s.body}, nil
+}
+
+// openDescriptor downloads and parses the OVF descriptor at the URL, to find the files it refers to.
+func (s *importSource) openDescriptor() (*vmprovider.LibraryItemFile, error) {
+	if _, err := s.open(s.url, true); err != nil {
+		return nil, err
+	}
+
+	descriptor, err := ioutil.ReadAll(io.LimitReader(s.body, maxDescriptorSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read OVF descriptor")
+	}
+	if len(descriptor) > maxDescriptorSize {
+		return nil, s.recordDownloadErr(errors.Errorf("OVF descriptor is larger than %d bytes", maxDescriptorSize))
+	}
+
+	// Verify the descriptor before the files it refers to are downloaded.
+	if err := s.verifyChecksum(); err != nil {
+		return nil, err
+	}
+
+	envelope, err := ovf.Unmarshal(bytes.NewReader(descriptor))
+	if err != nil {
+		return nil, s.recordDownloadErr(errors.Wrap(err, "failed to parse OVF descriptor"))
+	}
+
+	total := int64(len(descriptor))
+	for _, ref := range envelope.References {
+		total += int64(ref.Size)
+	}
+	atomic.StoreInt64(&s.total, total)
+	s.references = envelope.References
+
+	return &vmprovider.LibraryItemFile{
+		Name:   path.Base(s.url.Path),
+		Size:   int64(len(descriptor)),
+		Reader: bytes.NewReader(descriptor),
+	}, nil
+}
+
+// open downloads the file at the URL, and returns its size, or -1 if it is not known. The checksum is computed
+// over the file when hashed is true.
+func (s *importSource) open(u *url.URL, hashed bool) (int64, error) {
+	s.Close()
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, s.recordDownloadErr(err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, s.recordDownloadErr(errors.Wrapf(err, "failed to download %s", u))
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return 0, s.recordDownloadErr(errors.Errorf("failed to download %s: %s", u, resp.Status))
+	}
+
+	body := &downloadReader{source: s, body: resp.Body}
+	s.body = body
+	if hashed && s.hash != nil {
+		body.hash = s.hash
+	}
+
+	return resp.ContentLength, nil
+}
+
+// verifyChecksum compares the checksum of the file at the URL with the expected checksum.
+func (s *importSource) verifyChecksum() error {
+	if s.checksum == nil || s.checksumVerified || s.checksumErr != nil {
+		return s.checksumErr
+	}
+
+	actual := hex.EncodeToString(s.hash.Sum(nil))
+	if !strings.EqualFold(actual, s.checksum.Value) {
+		s.checksumErr = fmt.Errorf("%s checksum %s does not match the expected checksum %s",
+			s.checksum.Algorithm, actual, s.checksum.Value)
+		return s.checksumErr
+	}
+
+	s.checksumVerified = true
+	return nil
+}
+
+// DownloadErr returns the first error downloading a file, if any.
+func (s *importSource) DownloadErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.downloadErr
+}
+
+func (s *importSource) recordDownloadErr(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.downloadErr == nil {
+		s.downloadErr = err
+	}
+	return err
+}
+
+// downloadReader counts and hashes the bytes read from a response, and records errors reading it.
+type downloadReader struct {
+	source *importSource
+	body   io.ReadCloser
+	hash   hash.Hash
+}
+
+func (d *downloadReader) Read(p []byte) (int, error) {
+	n, err := d.body.Read(p)
+	if n > 0 {
+		atomic.AddInt64(&d.source.transferred, int64(n))
+		if d.hash != nil {
+			_, _ = d.hash.Write(p[:n])
+		}
+	}
+	if err != nil && err != io.EOF {
+		err = d.source.recordDownloadErr(errors.Wrap(err, "failed to download"))
+	}
+	return n, err
+}
+
+func (d *downloadReader) Close() error {
+	return d.body.Close()
+}
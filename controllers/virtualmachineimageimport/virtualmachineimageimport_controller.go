@@ -0,0 +1,269 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachineimageimport
+
+import (
+	goctx "context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/patch"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+)
+
+// defaultProgressInterval is how often the progress of an import is recorded in its status.
+const defaultProgressInterval = 10 * time.Second
+
+// AddToManager adds this package's controller to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	var (
+		controlledType     = &vmopapiv1alpha1.VirtualMachineImageImport{}
+		controlledTypeName = reflect.TypeOf(controlledType).Elem().Name()
+	)
+
+	r := NewReconciler(
+		mgr.GetClient(),
+		ctrl.Log.WithName("controllers").WithName(controlledTypeName),
+		ctx.VMProvider,
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(controlledType).
+		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+func NewReconciler(
+	client client.Client,
+	logger logr.Logger,
+	vmProvider vmprovider.VirtualMachineProviderInterface) *Reconciler {
+	return &Reconciler{
+		Client:           client,
+		Logger:           logger,
+		VMProvider:       vmProvider,
+		HTTPClient:       http.DefaultClient,
+		ProgressInterval: defaultProgressInterval,
+	}
+}
+
+// Reconciler reconciles a VirtualMachineImageImport object.
+type Reconciler struct {
+	client.Client
+	Logger     logr.Logger
+	VMProvider vmprovider.VirtualMachineProviderInterface
+	// HTTPClient downloads the files of the imports.
+	HTTPClient *http.Client
+	// ProgressInterval is how often the progress of an import is recorded in its status.
+	ProgressInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimageimports,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimageimports/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentlibraryproviders,verbs=get;list;watch
+
+func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	imageImport := &vmopapiv1alpha1.VirtualMachineImageImport{}
+	if err := r.Get(ctx, req.NamespacedName, imageImport); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// The created library item is not owned by the import, so it is kept when the import is deleted.
+	if !imageImport.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// An import is only done once.
+	switch imageImport.Status.Phase {
+	case vmopapiv1alpha1.VirtualMachineImageImportSucceeded, vmopapiv1alpha1.VirtualMachineImageImportFailed:
+		return ctrl.Result{}, nil
+	}
+
+	importCtx := &context.VirtualMachineImageImportContext{
+		Context:     ctx,
+		Logger:      r.Logger.WithValues("name", imageImport.Name),
+		ImageImport: imageImport,
+	}
+
+	patchHelper, err := patch.NewHelper(imageImport, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to init patch helper for %s", importCtx.String())
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, imageImport); err != nil {
+			if reterr == nil {
+				reterr = err
+			}
+			importCtx.Logger.Error(err, "patch failed")
+		}
+	}()
+
+	if err := r.ReconcileNormal(importCtx); err != nil {
+		importCtx.Logger.Error(err, "Failed to reconcile VirtualMachineImageImport")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileNormal downloads the file at the URL of the import and streams it into a new item of the content
+// library. An import that cannot succeed, because its spec is invalid, the file cannot be downloaded, or the
+// checksum does not match, is marked as failed. An error uploading to the content library is retried.
+func (r *Reconciler) ReconcileNormal(ctx *context.VirtualMachineImageImportContext) error {
+	imageImport := ctx.ImageImport
+
+	u, kind, err := parseImportURL(imageImport.Spec.URL)
+	if err != nil {
+		markFailed(imageImport, vmopapiv1alpha1.VirtualMachineImageImportInvalidSpecReason, err)
+		return nil
+	}
+
+	itemName := imageImport.Spec.ItemName
+	if itemName == "" {
+		itemName = defaultItemName(u)
+	}
+
+	source, err := newImportSource(ctx, r.HTTPClient, u, kind, imageImport.Spec.Checksum)
+	if err != nil {
+		markFailed(imageImport, vmopapiv1alpha1.VirtualMachineImageImportInvalidSpecReason, err)
+		return nil
+	}
+	defer source.Close()
+
+	clProvider := &vmopv1alpha1.ContentLibraryProvider{}
+	if err := r.Get(ctx, client.ObjectKey{Name: imageImport.Spec.LibraryName}, clProvider); err != nil {
+		if apierrors.IsNotFound(err) {
+			markFailed(imageImport, vmopapiv1alpha1.VirtualMachineImageImportLibraryNotFoundReason, err)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get ContentLibraryProvider %s", imageImport.Spec.LibraryName)
+	}
+
+	imageImport.Status.Phase = vmopapiv1alpha1.VirtualMachineImageImportImporting
+	imageImport.Status.ItemName = itemName
+
+	ctx.Logger.Info("Importing library item", "url", u.Redacted(), "itemName", itemName, "libraryUUID", clProvider.Spec.UUID)
+
+	stopProgress := r.reportProgress(ctx, source)
+	// The library item of an import that was interrupted, like by a restart, is replaced by the provider.
+	itemID, err := r.VMProvider.ImportLibraryItem(ctx, clProvider.Spec.UUID, itemName, itemTypeForFileKind(kind),
+		string(imageImport.UID), source)
+	stopProgress()
+
+	imageImport.Status.BytesTotal, imageImport.Status.BytesTransferred = source.Progress()
+
+	if imageImport.Spec.Checksum != nil {
+		switch {
+		case source.checksumErr != nil:
+			conditions.MarkFalse(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition,
+				vmopapiv1alpha1.VirtualMachineImageImportChecksumMismatchReason, vmopv1alpha1.ConditionSeverityError,
+				"%v", source.checksumErr)
+		case source.checksumVerified:
+			conditions.MarkTrue(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition)
+		}
+	}
+
+	switch {
+	case source.checksumErr != nil:
+		markFailed(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumMismatchReason, source.checksumErr)
+		return nil
+	case source.DownloadErr() != nil:
+		markFailed(imageImport, vmopapiv1alpha1.VirtualMachineImageImportDownloadFailedReason, source.DownloadErr())
+		return nil
+	case errors.Is(err, vmprovider.ErrLibraryItemExists):
+		markFailed(imageImport, vmopapiv1alpha1.VirtualMachineImageImportItemExistsReason, err)
+		return nil
+	case err != nil:
+		conditions.MarkFalse(imageImport, vmopv1alpha1.ReadyCondition,
+			vmopapiv1alpha1.VirtualMachineImageImportUploadFailedReason, vmopv1alpha1.ConditionSeverityWarning, "%v", err)
+		return errors.Wrapf(err, "failed to import %s", ctx.String())
+	}
+
+	ctx.Logger.Info("Imported library item", "itemName", itemName, "itemID", itemID)
+
+	now := metav1.Now()
+	imageImport.Status.Phase = vmopapiv1alpha1.VirtualMachineImageImportSucceeded
+	imageImport.Status.ItemID = itemID
+	imageImport.Status.CompletionTime = &now
+	conditions.MarkTrue(imageImport, vmopv1alpha1.ReadyCondition)
+
+	return nil
+}
+
+// reportProgress records the progress of the import in its status every ProgressInterval until the returned
+// function is called. The status is patched directly because the import is still being reconciled.
+func (r *Reconciler) reportProgress(
+	ctx *context.VirtualMachineImageImportContext,
+	source *importSource) func() {
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(r.ProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.patchProgress(ctx, source); err != nil {
+				ctx.Logger.Error(err, "Failed to record import progress")
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func (r *Reconciler) patchProgress(ctx *context.VirtualMachineImageImportContext, source *importSource) error {
+	status := vmopapiv1alpha1.VirtualMachineImageImportStatus{
+		Phase:    vmopapiv1alpha1.VirtualMachineImageImportImporting,
+		ItemName: ctx.ImageImport.Status.ItemName,
+	}
+	status.BytesTotal, status.BytesTransferred = source.Progress()
+
+	data, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+
+	obj := &vmopapiv1alpha1.VirtualMachineImageImport{ObjectMeta: metav1.ObjectMeta{Name: ctx.ImageImport.Name}}
+	return r.Status().Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+// markFailed marks the import as failed with the reason.
+func markFailed(imageImport *vmopapiv1alpha1.VirtualMachineImageImport, reason string, err error) {
+	now := metav1.Now()
+	imageImport.Status.Phase = vmopapiv1alpha1.VirtualMachineImageImportFailed
+	imageImport.Status.CompletionTime = &now
+	conditions.MarkFalse(imageImport, vmopv1alpha1.ReadyCondition, reason, vmopv1alpha1.ConditionSeverityError, "%v", err)
+}
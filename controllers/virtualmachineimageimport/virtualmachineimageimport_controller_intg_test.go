@@ -0,0 +1,90 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachineimageimport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	Describe("Invoking VirtualMachineImageImport controller tests", virtualMachineImageImportReconcile)
+}
+
+func virtualMachineImageImportReconcile() {
+	var (
+		ctx    *builder.IntegrationTestContext
+		server *httptest.Server
+
+		clProvider  *vmopv1alpha1.ContentLibraryProvider
+		imageImport *vmopapiv1alpha1.VirtualMachineImageImport
+	)
+
+	BeforeEach(func() {
+		ctx = suite.NewIntegrationTestContext()
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(isoContent))
+		}))
+
+		clProvider = &vmopv1alpha1.ContentLibraryProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "dummy-cl"},
+			Spec:       vmopv1alpha1.ContentLibraryProviderSpec{UUID: "dummy-cl-uuid"},
+		}
+
+		imageImport = &vmopapiv1alpha1.VirtualMachineImageImport{
+			ObjectMeta: metav1.ObjectMeta{Name: "dummy-import"},
+			Spec: vmopapiv1alpha1.VirtualMachineImageImportSpec{
+				URL:         server.URL + "/images/test.iso",
+				LibraryName: clProvider.Name,
+				Checksum: &vmopapiv1alpha1.VirtualMachineImageImportChecksum{
+					Algorithm: vmopapiv1alpha1.SHA256ChecksumAlgorithm,
+					Value:     sha256Sum([]byte(isoContent)),
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(ctx.Client.Delete(ctx, imageImport)).To(Succeed())
+		Expect(ctx.Client.Delete(ctx, clProvider)).To(Succeed())
+		ctx.AfterEach()
+		ctx = nil
+		server.Close()
+		intgFakeVMProvider.Reset()
+	})
+
+	Context("Reconcile", func() {
+		It("imports the file into the library", func() {
+			Expect(ctx.Client.Create(ctx, clProvider)).To(Succeed())
+			Expect(ctx.Client.Create(ctx, imageImport)).To(Succeed())
+
+			obj := &vmopapiv1alpha1.VirtualMachineImageImport{}
+			Eventually(func() vmopapiv1alpha1.VirtualMachineImageImportPhase {
+				if err := ctx.Client.Get(ctx, client.ObjectKeyFromObject(imageImport), obj); err != nil {
+					return ""
+				}
+				return obj.Status.Phase
+			}).Should(Equal(vmopapiv1alpha1.VirtualMachineImageImportSucceeded))
+
+			Expect(obj.Status.ItemName).To(Equal("test"))
+			Expect(obj.Status.ItemID).To(Equal("fake-test"))
+			Expect(obj.Status.BytesTransferred).To(Equal(int64(len(isoContent))))
+			Expect(conditions.IsTrue(obj, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition)).To(BeTrue())
+			Expect(conditions.IsTrue(obj, vmopv1alpha1.ReadyCondition)).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,406 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachineimageimport_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineimageimport"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	vmopContext "github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+const (
+	isoContent  = "iso-content"
+	diskContent = "disk-content"
+	ovfContent  = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:id="file1" ovf:href="disk.vmdk" ovf:size="12"/>
+  </References>
+  <VirtualSystem ovf:id="test-vm"/>
+</Envelope>
+`
+)
+
+func sha256Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newOVA(files map[string]string, names ...string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range names {
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(files[name])), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err := tw.Write([]byte(files[name]))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func unitTests() {
+	Describe("Invoking VirtualMachineImageImport controller tests", unitTestsReconcile)
+}
+
+func unitTestsReconcile() {
+	var (
+		initObjects []client.Object
+		ctx         *builder.UnitTestContextForController
+
+		reconciler     *virtualmachineimageimport.Reconciler
+		fakeVMProvider *providerfake.VMProvider
+		server         *httptest.Server
+		ova            []byte
+
+		clProvider  *vmopv1alpha1.ContentLibraryProvider
+		imageImport *vmopapiv1alpha1.VirtualMachineImageImport
+		importCtx   *vmopContext.VirtualMachineImageImportContext
+
+		importErr      error
+		importCalled   bool
+		importedUUID   string
+		importedID     string
+		importedName   string
+		importedType   string
+		importedFiles  map[string]string
+		importedSizes  map[string]int64
+		importedOrder  []string
+		importedResult string
+	)
+
+	BeforeEach(func() {
+		ova = newOVA(map[string]string{"test.ovf": ovfContent, "disk.vmdk": diskContent}, "test.ovf", "disk.vmdk")
+		content := map[string][]byte{
+			"/images/test.iso":  []byte(isoContent),
+			"/images/test.ovf":  []byte(ovfContent),
+			"/images/disk.vmdk": []byte(diskContent),
+			"/images/test.ova":  ova,
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, ok := content[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		}))
+
+		clProvider = &vmopv1alpha1.ContentLibraryProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "dummy-cl"},
+			Spec:       vmopv1alpha1.ContentLibraryProviderSpec{UUID: "dummy-cl-uuid"},
+		}
+
+		imageImport = &vmopapiv1alpha1.VirtualMachineImageImport{
+			ObjectMeta: metav1.ObjectMeta{Name: "dummy-import", UID: "dummy-import-uid"},
+			Spec: vmopapiv1alpha1.VirtualMachineImageImportSpec{
+				URL:         server.URL + "/images/test.iso",
+				LibraryName: clProvider.Name,
+			},
+		}
+
+		initObjects = []client.Object{clProvider, imageImport}
+
+		importErr = nil
+		importCalled = false
+		importedFiles = map[string]string{}
+		importedSizes = map[string]int64{}
+		importedOrder = nil
+		importedResult = "dummy-item-id"
+	})
+
+	JustBeforeEach(func() {
+		ctx = suite.NewUnitTestContextForController(initObjects...)
+		reconciler = virtualmachineimageimport.NewReconciler(
+			ctx.Client,
+			ctx.Logger,
+			ctx.VMProvider,
+		)
+
+		fakeVMProvider = ctx.VMProvider.(*providerfake.VMProvider)
+		fakeVMProvider.ImportLibraryItemFn = func(_ context.Context, clUUID, itemName, itemType, importID string, files vmprovider.LibraryItemFiles) (string, error) {
+			importCalled = true
+			importedUUID, importedName, importedType, importedID = clUUID, itemName, itemType, importID
+			for {
+				file, err := files.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return "", err
+				}
+				data, err := ioutil.ReadAll(file.Reader)
+				if err != nil {
+					return "", err
+				}
+				importedFiles[file.Name] = string(data)
+				importedSizes[file.Name] = file.Size
+				importedOrder = append(importedOrder, file.Name)
+			}
+			if importErr != nil {
+				return "", importErr
+			}
+			return importedResult, nil
+		}
+
+		importCtx = &vmopContext.VirtualMachineImageImportContext{
+			Context:     ctx,
+			Logger:      ctx.Logger,
+			ImageImport: imageImport,
+		}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
+		server.Close()
+	})
+
+	expectFailed := func(reason string) {
+		ExpectWithOffset(1, imageImport.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportFailed))
+		ExpectWithOffset(1, imageImport.Status.CompletionTime).ToNot(BeNil())
+		ExpectWithOffset(1, conditions.IsFalse(imageImport, vmopv1alpha1.ReadyCondition)).To(BeTrue())
+		ExpectWithOffset(1, conditions.GetReason(imageImport, vmopv1alpha1.ReadyCondition)).To(Equal(reason))
+	}
+
+	Context("ReconcileNormal", func() {
+
+		When("the URL is an ISO", func() {
+			It("imports the ISO into the library", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+
+				Expect(importedUUID).To(Equal("dummy-cl-uuid"))
+				Expect(importedID).To(Equal("dummy-import-uid"))
+				Expect(importedName).To(Equal("test"))
+				Expect(importedType).To(Equal("iso"))
+				Expect(importedFiles).To(Equal(map[string]string{"test.iso": isoContent}))
+				Expect(importedSizes).To(HaveKeyWithValue("test.iso", int64(len(isoContent))))
+
+				Expect(imageImport.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportSucceeded))
+				Expect(imageImport.Status.ItemName).To(Equal("test"))
+				Expect(imageImport.Status.ItemID).To(Equal("dummy-item-id"))
+				Expect(imageImport.Status.BytesTotal).To(Equal(int64(len(isoContent))))
+				Expect(imageImport.Status.BytesTransferred).To(Equal(int64(len(isoContent))))
+				Expect(imageImport.Status.CompletionTime).ToNot(BeNil())
+				Expect(conditions.IsTrue(imageImport, vmopv1alpha1.ReadyCondition)).To(BeTrue())
+				Expect(conditions.Has(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition)).To(BeFalse())
+			})
+
+			It("records the progress in the status while importing", func() {
+				fakeVMProvider.ImportLibraryItemFn = func(_ context.Context, _, _, _, _ string, files vmprovider.LibraryItemFiles) (string, error) {
+					file, err := files.Next()
+					Expect(err).ToNot(HaveOccurred())
+					_, err = io.CopyN(ioutil.Discard, file.Reader, 4)
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(func() int64 {
+						obj := &vmopapiv1alpha1.VirtualMachineImageImport{}
+						Expect(ctx.Client.Get(ctx, client.ObjectKeyFromObject(imageImport), obj)).To(Succeed())
+						Expect(obj.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportImporting))
+						return obj.Status.BytesTransferred
+					}).Should(Equal(int64(4)))
+
+					_, err = io.Copy(ioutil.Discard, file.Reader)
+					Expect(err).ToNot(HaveOccurred())
+					return "dummy-item-id", nil
+				}
+				reconciler.ProgressInterval = 10 * time.Millisecond
+
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				Expect(imageImport.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportSucceeded))
+			})
+		})
+
+		When("the checksum matches", func() {
+			BeforeEach(func() {
+				imageImport.Spec.Checksum = &vmopapiv1alpha1.VirtualMachineImageImportChecksum{
+					Algorithm: vmopapiv1alpha1.SHA256ChecksumAlgorithm,
+					Value:     strings.ToUpper(sha256Sum([]byte(isoContent))),
+				}
+			})
+
+			It("marks the checksum as verified", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				Expect(imageImport.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportSucceeded))
+				Expect(conditions.IsTrue(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition)).To(BeTrue())
+			})
+		})
+
+		When("the checksum does not match", func() {
+			BeforeEach(func() {
+				imageImport.Spec.Checksum = &vmopapiv1alpha1.VirtualMachineImageImportChecksum{
+					Algorithm: vmopapiv1alpha1.SHA256ChecksumAlgorithm,
+					Value:     sha256Sum([]byte("other-content")),
+				}
+			})
+
+			It("aborts the upload and marks the import as failed", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				expectFailed(vmopapiv1alpha1.VirtualMachineImageImportChecksumMismatchReason)
+				Expect(imageImport.Status.ItemID).To(BeEmpty())
+				Expect(conditions.IsFalse(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition)).To(BeTrue())
+				Expect(conditions.GetReason(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageImportChecksumMismatchReason))
+			})
+		})
+
+		When("the URL is an OVA", func() {
+			BeforeEach(func() {
+				imageImport.Spec.URL = server.URL + "/images/test.ova"
+				imageImport.Spec.Checksum = &vmopapiv1alpha1.VirtualMachineImageImportChecksum{
+					Algorithm: vmopapiv1alpha1.SHA256ChecksumAlgorithm,
+					Value:     sha256Sum(ova),
+				}
+			})
+
+			It("imports the files in the OVA and verifies the checksum of the OVA", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+
+				Expect(importedType).To(Equal("ovf"))
+				Expect(importedOrder).To(Equal([]string{"test.ovf", "disk.vmdk"}))
+				Expect(importedFiles).To(Equal(map[string]string{"test.ovf": ovfContent, "disk.vmdk": diskContent}))
+
+				Expect(imageImport.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportSucceeded))
+				Expect(imageImport.Status.BytesTransferred).To(Equal(int64(len(ova))))
+				Expect(conditions.IsTrue(imageImport, vmopapiv1alpha1.VirtualMachineImageImportChecksumVerifiedCondition)).To(BeTrue())
+			})
+		})
+
+		When("the URL is an OVF", func() {
+			BeforeEach(func() {
+				imageImport.Spec.URL = server.URL + "/images/test.ovf"
+				imageImport.Spec.ItemName = "my-item"
+			})
+
+			It("imports the descriptor and the files it refers to", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+
+				Expect(importedName).To(Equal("my-item"))
+				Expect(importedType).To(Equal("ovf"))
+				Expect(importedOrder).To(Equal([]string{"test.ovf", "disk.vmdk"}))
+				Expect(importedFiles).To(Equal(map[string]string{"test.ovf": ovfContent, "disk.vmdk": diskContent}))
+
+				Expect(imageImport.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportSucceeded))
+				Expect(imageImport.Status.ItemName).To(Equal("my-item"))
+				Expect(imageImport.Status.BytesTotal).To(Equal(int64(len(ovfContent) + len(diskContent))))
+				Expect(imageImport.Status.BytesTransferred).To(Equal(int64(len(ovfContent) + len(diskContent))))
+			})
+		})
+
+		When("the file cannot be downloaded", func() {
+			BeforeEach(func() {
+				imageImport.Spec.URL = server.URL + "/images/missing.iso"
+			})
+
+			It("marks the import as failed", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				expectFailed(vmopapiv1alpha1.VirtualMachineImageImportDownloadFailedReason)
+				Expect(conditions.GetMessage(imageImport, vmopv1alpha1.ReadyCondition)).To(ContainSubstring("404"))
+			})
+		})
+
+		When("the URL is not of a supported file", func() {
+			BeforeEach(func() {
+				imageImport.Spec.URL = server.URL + "/images/test.zip"
+			})
+
+			It("marks the import as failed without importing", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				expectFailed(vmopapiv1alpha1.VirtualMachineImageImportInvalidSpecReason)
+				Expect(importCalled).To(BeFalse())
+			})
+		})
+
+		When("the URL is not HTTP", func() {
+			BeforeEach(func() {
+				imageImport.Spec.URL = "file:///images/test.iso"
+			})
+
+			It("marks the import as failed without importing", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				expectFailed(vmopapiv1alpha1.VirtualMachineImageImportInvalidSpecReason)
+				Expect(importCalled).To(BeFalse())
+			})
+		})
+
+		When("the library does not exist", func() {
+			BeforeEach(func() {
+				imageImport.Spec.LibraryName = "missing-cl"
+			})
+
+			It("marks the import as failed without importing", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				expectFailed(vmopapiv1alpha1.VirtualMachineImageImportLibraryNotFoundReason)
+				Expect(importCalled).To(BeFalse())
+			})
+		})
+
+		When("the library already has an item with the name", func() {
+			BeforeEach(func() {
+				importErr = errors.Wrap(vmprovider.ErrLibraryItemExists, "library item test")
+			})
+
+			It("marks the import as failed", func() {
+				Expect(reconciler.ReconcileNormal(importCtx)).To(Succeed())
+				expectFailed(vmopapiv1alpha1.VirtualMachineImageImportItemExistsReason)
+			})
+		})
+
+		When("the upload fails", func() {
+			BeforeEach(func() {
+				importErr = errors.New("fake upload error")
+			})
+
+			It("returns the error so the import is retried", func() {
+				err := reconciler.ReconcileNormal(importCtx)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake upload error"))
+				Expect(imageImport.Status.Phase).To(Equal(vmopapiv1alpha1.VirtualMachineImageImportImporting))
+				Expect(conditions.GetReason(imageImport, vmopv1alpha1.ReadyCondition)).To(
+					Equal(vmopapiv1alpha1.VirtualMachineImageImportUploadFailedReason))
+			})
+		})
+	})
+
+	Context("Reconcile", func() {
+		When("the import has already succeeded", func() {
+			BeforeEach(func() {
+				imageImport.Status.Phase = vmopapiv1alpha1.VirtualMachineImageImportSucceeded
+			})
+
+			It("does not import again", func() {
+				_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(imageImport)})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(importCalled).To(BeFalse())
+			})
+		})
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinequota
+
+import (
+	goctx "context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/patch"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmquota"
+)
+
+// AddToManager adds this package's controller to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	var (
+		controlledType     = &vmopapiv1alpha1.VirtualMachineQuota{}
+		controlledTypeName = reflect.TypeOf(controlledType).Elem().Name()
+	)
+
+	r := NewReconciler(
+		mgr.GetClient(),
+		ctrl.Log.WithName("controllers").WithName(controlledTypeName),
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(controlledType).
+		Watches(&source.Kind{Type: &vmopv1alpha1.VirtualMachine{}},
+			handler.EnqueueRequestsFromMapFunc(vmToQuotaMapperFn(ctx, r.Client))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+// vmToQuotaMapperFn returns a mapper function that returns the reconcile requests for the quotas of a
+// VirtualMachine's namespace, so their usage is updated when a VirtualMachine is created, changed or deleted.
+func vmToQuotaMapperFn(ctx *context.ControllerManagerContext, c client.Client) func(o client.Object) []reconcile.Request {
+	return func(o client.Object) []reconcile.Request {
+		quotas, err := vmquota.List(ctx, c, o.GetNamespace())
+		if err != nil {
+			ctx.Logger.Error(err, "Failed to list VirtualMachineQuotas for reconciliation due to VirtualMachine watch")
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(quotas))
+		for _, quota := range quotas {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: quota.Namespace, Name: quota.Name},
+			})
+		}
+
+		return requests
+	}
+}
+
+func NewReconciler(
+	client client.Client,
+	logger logr.Logger) *Reconciler {
+	return &Reconciler{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// Reconciler reconciles a VirtualMachineQuota object.
+type Reconciler struct {
+	client.Client
+	Logger logr.Logger
+}
+
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinequotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinequotas/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineclasses,verbs=get;list;watch
+
+func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	quota := &vmopapiv1alpha1.VirtualMachineQuota{}
+	if err := r.Get(ctx, req.NamespacedName, quota); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !quota.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	quotaCtx := &context.VirtualMachineQuotaContext{
+		Context: ctx,
+		Logger:  r.Logger.WithValues("name", req.NamespacedName),
+		Quota:   quota,
+	}
+
+	patchHelper, err := patch.NewHelper(quota, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to init patch helper for %s", quotaCtx.String())
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, quota); err != nil {
+			if reterr == nil {
+				reterr = err
+			}
+			quotaCtx.Logger.Error(err, "patch failed")
+		}
+	}()
+
+	if err := r.ReconcileNormal(quotaCtx); err != nil {
+		quotaCtx.Logger.Error(err, "Failed to reconcile VirtualMachineQuota")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileNormal records the usage of the limited resources by the VirtualMachines of the quota's namespace.
+func (r *Reconciler) ReconcileNormal(ctx *context.VirtualMachineQuotaContext) error {
+	quota := ctx.Quota
+
+	used, err := vmquota.NamespaceUsage(ctx, r.Client, quota.Namespace, nil)
+	if err != nil {
+		return err
+	}
+
+	quota.Status.Hard = quota.Spec.DeepCopy().Hard
+	quota.Status.Used = vmquota.Mask(used, quota.Spec.Hard)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinequota_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	var (
+		ctx *builder.IntegrationTestContext
+
+		quota    *vmopapiv1alpha1.VirtualMachineQuota
+		quotaKey client.ObjectKey
+	)
+
+	BeforeEach(func() {
+		ctx = suite.NewIntegrationTestContext()
+
+		quota = &vmopapiv1alpha1.VirtualMachineQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ctx.Namespace,
+				Name:      "dummy-quota",
+			},
+			Spec: vmopapiv1alpha1.VirtualMachineQuotaSpec{
+				Hard: corev1.ResourceList{
+					vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines: resource.MustParse("10"),
+				},
+			},
+		}
+		quotaKey = client.ObjectKey{Namespace: quota.Namespace, Name: quota.Name}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+	})
+
+	usedVMs := func() int64 {
+		obj := &vmopapiv1alpha1.VirtualMachineQuota{}
+		if err := ctx.Client.Get(ctx, quotaKey, obj); err != nil {
+			return -1
+		}
+		used, ok := obj.Status.Used[vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines]
+		if !ok {
+			return -1
+		}
+		return used.Value()
+	}
+
+	Context("Reconcile", func() {
+		It("tracks the VMs of the namespace", func() {
+			Expect(ctx.Client.Create(ctx, quota)).To(Succeed())
+			Eventually(usedVMs).Should(BeEquivalentTo(0))
+
+			vm := &vmopv1alpha1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ctx.Namespace,
+					Name:      "dummy-vm",
+				},
+				Spec: vmopv1alpha1.VirtualMachineSpec{
+					ImageName:  "dummy-image",
+					ClassName:  "dummy-class",
+					PowerState: vmopv1alpha1.VirtualMachinePoweredOn,
+				},
+			}
+			Expect(ctx.Client.Create(ctx, vm)).To(Succeed())
+			Eventually(usedVMs).Should(BeEquivalentTo(1))
+		})
+	})
+}
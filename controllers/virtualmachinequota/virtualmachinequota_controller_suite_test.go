@@ -0,0 +1,27 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinequota_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinequota"
+	"github.com/vmware-tanzu/vm-operator/pkg/manager"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+var suite = builder.NewTestSuiteForController(
+	virtualmachinequota.AddToManager,
+	manager.InitializeProvidersNoopFn,
+)
+
+func TestVirtualMachineQuota(t *testing.T) {
+	suite.Register(t, "VirtualMachineQuota controller suite", intgTests, unitTests)
+}
+
+var _ = BeforeSuite(suite.BeforeSuite)
+
+var _ = AfterSuite(suite.AfterSuite)
@@ -0,0 +1,110 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinequota_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinequota"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking Reconcile", unitTestsReconcile)
+}
+
+func unitTestsReconcile() {
+	var (
+		initObjects []client.Object
+		ctx         *builder.UnitTestContextForController
+
+		reconciler *virtualmachinequota.Reconciler
+		quotaCtx   *context.VirtualMachineQuotaContext
+		quota      *vmopapiv1alpha1.VirtualMachineQuota
+	)
+
+	newVM := func(namespace, name, className string) *vmopv1alpha1.VirtualMachine {
+		return &vmopv1alpha1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       vmopv1alpha1.VirtualMachineSpec{ClassName: className},
+		}
+	}
+
+	BeforeEach(func() {
+		quota = &vmopapiv1alpha1.VirtualMachineQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dummy-quota",
+				Namespace: "dummy-ns",
+			},
+			Spec: vmopapiv1alpha1.VirtualMachineQuotaSpec{
+				Hard: corev1.ResourceList{
+					vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines: resource.MustParse("10"),
+					vmopapiv1alpha1.VirtualMachineQuotaCPU:             resource.MustParse("16"),
+					vmopapiv1alpha1.VirtualMachineQuotaGPUs:            resource.MustParse("2"),
+				},
+			},
+		}
+
+		vmClass := &vmopv1alpha1.VirtualMachineClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "dummy-class"},
+			Spec: vmopv1alpha1.VirtualMachineClassSpec{
+				Hardware: vmopv1alpha1.VirtualMachineClassHardware{
+					Cpus:   4,
+					Memory: resource.MustParse("4Gi"),
+				},
+			},
+		}
+
+		initObjects = append(initObjects, vmClass,
+			newVM("dummy-ns", "vm-1", "dummy-class"),
+			newVM("dummy-ns", "vm-2", "dummy-class"),
+			newVM("other-ns", "vm-3", "dummy-class"))
+	})
+
+	JustBeforeEach(func() {
+		ctx = suite.NewUnitTestContextForController(initObjects...)
+		reconciler = virtualmachinequota.NewReconciler(
+			ctx.Client,
+			ctx.Logger,
+		)
+
+		quotaCtx = &context.VirtualMachineQuotaContext{
+			Context: ctx,
+			Logger:  ctx.Logger.WithName(quota.Name),
+			Quota:   quota,
+		}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
+	})
+
+	Context("ReconcileNormal", func() {
+		It("records the usage of the limited resources by the VMs of the namespace", func() {
+			Expect(reconciler.ReconcileNormal(quotaCtx)).To(Succeed())
+
+			Expect(quota.Status.Hard).To(Equal(quota.Spec.Hard))
+			Expect(quota.Status.Used).To(HaveLen(3))
+
+			vms := quota.Status.Used[vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines]
+			Expect(vms.Value()).To(BeEquivalentTo(2))
+			cpus := quota.Status.Used[vmopapiv1alpha1.VirtualMachineQuotaCPU]
+			Expect(cpus.Value()).To(BeEquivalentTo(8))
+			gpus := quota.Status.Used[vmopapiv1alpha1.VirtualMachineQuotaGPUs]
+			Expect(gpus.IsZero()).To(BeTrue())
+		})
+	})
+}
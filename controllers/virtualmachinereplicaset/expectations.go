@@ -0,0 +1,101 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinereplicaset
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+// expectationsTimeout is how long the created and deleted VirtualMachines are waited for, in case their watch
+// events are missed.
+const expectationsTimeout = 5 * time.Minute
+
+// expectations tracks, like the expectations of the Kubernetes ReplicaSet controller, the VirtualMachines that a
+// VirtualMachineReplicaSet created or deleted and that the cache has not observed yet. The VirtualMachines are
+// listed from the cache, so a reconcile that runs before the cache observes them would otherwise create or
+// delete VirtualMachines again.
+type expectations struct {
+	mutex   sync.Mutex
+	pending map[types.NamespacedName]*pendingVMs
+}
+
+type pendingVMs struct {
+	creates   sets.String
+	deletes   sets.String
+	timestamp time.Time
+}
+
+func newExpectations() *expectations {
+	return &expectations{
+		pending: map[types.NamespacedName]*pendingVMs{},
+	}
+}
+
+func (e *expectations) expectCreate(key types.NamespacedName, name string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	p := e.get(key)
+	p.creates.Insert(name)
+	p.timestamp = time.Now()
+}
+
+func (e *expectations) expectDelete(key types.NamespacedName, name string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	p := e.get(key)
+	p.deletes.Insert(name)
+	p.timestamp = time.Now()
+}
+
+// satisfied returns true when the VirtualMachines listed from the cache include the created VirtualMachines and
+// no longer include the deleted ones, unless they are being deleted, or when they were waited for too long.
+func (e *expectations) satisfied(key types.NamespacedName, vms []vmopv1alpha1.VirtualMachine) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	p, ok := e.pending[key]
+	if !ok {
+		return true
+	}
+
+	notDeleted := sets.NewString()
+	for i := range vms {
+		vm := &vms[i]
+		p.creates.Delete(vm.Name)
+		if vm.DeletionTimestamp.IsZero() {
+			notDeleted.Insert(vm.Name)
+		}
+	}
+	p.deletes = p.deletes.Intersection(notDeleted)
+
+	if p.creates.Len() == 0 && p.deletes.Len() == 0 || time.Since(p.timestamp) > expectationsTimeout {
+		delete(e.pending, key)
+		return true
+	}
+	return false
+}
+
+func (e *expectations) forget(key types.NamespacedName) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	delete(e.pending, key)
+}
+
+func (e *expectations) get(key types.NamespacedName) *pendingVMs {
+	p, ok := e.pending[key]
+	if !ok {
+		p = &pendingVMs{creates: sets.NewString(), deletes: sets.NewString()}
+		e.pending[key] = p
+	}
+	return p
+}
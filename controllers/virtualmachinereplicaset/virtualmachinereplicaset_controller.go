@@ -0,0 +1,366 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinereplicaset
+
+import (
+	goctx "context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/rand"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/patch"
+	"github.com/vmware-tanzu/vm-operator/pkg/record"
+)
+
+const (
+	createVMOpName = "CreateVirtualMachine"
+	deleteVMOpName = "DeleteVirtualMachine"
+)
+
+// AddToManager adds this package's controller to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	var (
+		controlledType     = &vmopapiv1alpha1.VirtualMachineReplicaSet{}
+		controlledTypeName = reflect.TypeOf(controlledType).Elem().Name()
+
+		controllerNameShort = fmt.Sprintf("%s-controller", "virtualmachinereplicaset")
+		controllerNameLong  = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, controllerNameShort)
+	)
+
+	r := NewReconciler(
+		mgr.GetClient(),
+		ctrl.Log.WithName("controllers").WithName(controlledTypeName),
+		record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(controlledType).
+		Owns(&vmopv1alpha1.VirtualMachine{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: ctx.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+func NewReconciler(
+	client client.Client,
+	logger logr.Logger,
+	recorder record.Recorder) *Reconciler {
+	return &Reconciler{
+		Client:       client,
+		Logger:       logger,
+		Recorder:     recorder,
+		expectations: newExpectations(),
+	}
+}
+
+// Reconciler reconciles a VirtualMachineReplicaSet object.
+type Reconciler struct {
+	client.Client
+	Logger   logr.Logger
+	Recorder record.Recorder
+
+	expectations *expectations
+}
+
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinereplicasets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinereplicasets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinereplicasets/scale,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	rs := &vmopapiv1alpha1.VirtualMachineReplicaSet{}
+	if err := r.Get(ctx, req.NamespacedName, rs); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.expectations.forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// The owned VirtualMachines are garbage collected through their OwnerReference.
+	if !rs.DeletionTimestamp.IsZero() {
+		r.expectations.forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	rsCtx := &context.VirtualMachineReplicaSetContext{
+		Context:    ctx,
+		Logger:     r.Logger.WithName("VirtualMachineReplicaSet").WithValues("name", rs.NamespacedName()),
+		ReplicaSet: rs,
+	}
+
+	patchHelper, err := patch.NewHelper(rs, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to init patch helper for %s", rsCtx.String())
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, rs); err != nil {
+			if reterr == nil {
+				reterr = err
+			}
+			rsCtx.Logger.Error(err, "patch failed")
+		}
+	}()
+
+	return ctrl.Result{}, r.ReconcileNormal(rsCtx)
+}
+
+// ReconcileNormal creates and deletes the VirtualMachines of the VirtualMachineReplicaSet until the desired
+// number of replicas exist, replacing the ones created from an older template according to the update strategy.
+// No VirtualMachines are created or deleted until the cache has observed the ones created and deleted by the
+// previous reconciles.
+func (r *Reconciler) ReconcileNormal(ctx *context.VirtualMachineReplicaSetContext) error {
+	rs := ctx.ReplicaSet
+
+	selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
+	if err != nil {
+		return errors.Wrap(err, "invalid selector")
+	}
+	if selector.Empty() || !selector.Matches(labels.Set(rs.Spec.Template.ObjectMeta.Labels)) {
+		return errors.New("selector does not match the template labels")
+	}
+	rs.Status.Selector = selector.String()
+
+	vmList := &vmopv1alpha1.VirtualMachineList{}
+	if err := r.List(ctx, vmList, client.InNamespace(rs.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return errors.Wrap(err, "failed to list VirtualMachines")
+	}
+
+	templateHash, err := computeTemplateHash(rs)
+	if err != nil {
+		return err
+	}
+
+	var active []*vmopv1alpha1.VirtualMachine
+	for i := range vmList.Items {
+		vm := &vmList.Items[i]
+		if metav1.IsControlledBy(vm, rs) && vm.DeletionTimestamp.IsZero() {
+			active = append(active, vm)
+		}
+	}
+
+	// Order the VirtualMachines by deletion preference: outdated, then not ready, then newest first.
+	sort.SliceStable(active, func(i, j int) bool {
+		iUpdated, jUpdated := isUpdated(active[i], templateHash), isUpdated(active[j], templateHash)
+		if iUpdated != jUpdated {
+			return !iUpdated
+		}
+		iReady, jReady := isReady(active[i]), isReady(active[j])
+		if iReady != jReady {
+			return !iReady
+		}
+		return active[j].CreationTimestamp.Before(&active[i].CreationTimestamp)
+	})
+
+	key := types.NamespacedName{Namespace: rs.Namespace, Name: rs.Name}
+	replicas := desiredReplicas(rs)
+	var toDelete []*vmopv1alpha1.VirtualMachine
+
+	switch diff := len(active) - replicas; {
+	case !r.expectations.satisfied(key, vmList.Items):
+		ctx.Logger.V(4).Info("Waiting for the cache to observe the created and deleted VirtualMachines")
+	case diff < 0:
+		for i := 0; i < -diff; i++ {
+			vm, err := r.createVM(ctx, templateHash)
+			if err != nil {
+				return err
+			}
+			r.expectations.expectCreate(key, vm.Name)
+			active = append(active, vm)
+		}
+	case diff > 0:
+		toDelete = active[:diff]
+	case rs.Spec.Strategy.Type != vmopapiv1alpha1.OnDeleteVirtualMachineReplicaSetStrategyType:
+		toDelete = outdatedToReplace(rs, active, templateHash)
+	}
+
+	for _, vm := range toDelete {
+		ctx.Logger.Info("Deleting VirtualMachine", "vmName", vm.Name)
+		err := r.Delete(ctx, vm)
+		r.Recorder.EmitEvent(rs, deleteVMOpName, client.IgnoreNotFound(err), false)
+		if client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "failed to delete VirtualMachine %s", vm.Name)
+		}
+		r.expectations.expectDelete(key, vm.Name)
+	}
+	active = active[len(toDelete):]
+
+	rs.Status.Replicas = int32(len(active))
+	rs.Status.ReadyReplicas = 0
+	rs.Status.UpdatedReplicas = 0
+	for _, vm := range active {
+		if isReady(vm) {
+			rs.Status.ReadyReplicas++
+		}
+		if isUpdated(vm, templateHash) {
+			rs.Status.UpdatedReplicas++
+		}
+	}
+	rs.Status.ObservedGeneration = rs.Generation
+
+	return nil
+}
+
+// outdatedToReplace returns the outdated VirtualMachines that can be deleted without exceeding the rolling
+// update's MaxUnavailable. The VirtualMachines are expected to be sorted by deletion preference so the
+// outdated ones come first. Outdated VirtualMachines that are not ready are always replaced since deleting
+// them does not reduce availability.
+func outdatedToReplace(
+	rs *vmopapiv1alpha1.VirtualMachineReplicaSet,
+	vms []*vmopv1alpha1.VirtualMachine,
+	templateHash string) []*vmopv1alpha1.VirtualMachine {
+
+	replicas := desiredReplicas(rs)
+
+	readyCount := 0
+	for _, vm := range vms {
+		if isReady(vm) {
+			readyCount++
+		}
+	}
+	budget := maxUnavailable(rs, replicas) - (replicas - readyCount)
+
+	n := 0
+	for _, vm := range vms {
+		if isUpdated(vm, templateHash) {
+			break
+		}
+		if isReady(vm) {
+			if budget <= 0 {
+				break
+			}
+			budget--
+		}
+		n++
+	}
+
+	return vms[:n]
+}
+
+func (r *Reconciler) createVM(ctx *context.VirtualMachineReplicaSetContext, templateHash string) (*vmopv1alpha1.VirtualMachine, error) {
+	rs := ctx.ReplicaSet
+
+	vm := newVM(rs, templateHash)
+	if err := controllerutil.SetControllerReference(rs, vm, r.Scheme()); err != nil {
+		return nil, err
+	}
+
+	err := r.Create(ctx, vm)
+	r.Recorder.EmitEvent(rs, createVMOpName, err, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create VirtualMachine")
+	}
+
+	ctx.Logger.Info("Created VirtualMachine", "vmName", vm.Name)
+	return vm, nil
+}
+
+// newVM returns a VirtualMachine built from the VirtualMachineReplicaSet's template, without its OwnerReference.
+func newVM(rs *vmopapiv1alpha1.VirtualMachineReplicaSet, templateHash string) *vmopv1alpha1.VirtualMachine {
+	template := rs.Spec.Template.DeepCopy()
+
+	vm := &vmopv1alpha1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: rs.Name + "-",
+			Namespace:    rs.Namespace,
+			Labels:       template.ObjectMeta.Labels,
+			Annotations:  template.ObjectMeta.Annotations,
+		},
+		Spec: template.Spec,
+	}
+
+	if vm.Labels == nil {
+		vm.Labels = map[string]string{}
+	}
+	vm.Labels[vmopapiv1alpha1.VirtualMachineReplicaSetNameLabel] = rs.Name
+	if templateHash != "" {
+		vm.Labels[vmopapiv1alpha1.VirtualMachineReplicaSetTemplateHashLabel] = templateHash
+	}
+
+	if rs.Spec.ResourcePolicyName != "" {
+		vm.Spec.ResourcePolicyName = rs.Spec.ResourcePolicyName
+	}
+	if rs.Spec.ClusterModuleGroupName != "" {
+		if vm.Annotations == nil {
+			vm.Annotations = map[string]string{}
+		}
+		vm.Annotations[pkg.ClusterModuleNameKey] = rs.Spec.ClusterModuleGroupName
+	}
+
+	return vm
+}
+
+// computeTemplateHash returns a hash of the VirtualMachine that would be created from the
+// VirtualMachineReplicaSet, so that a change to the template or placement is detected.
+func computeTemplateHash(rs *vmopapiv1alpha1.VirtualMachineReplicaSet) (string, error) {
+	vm := newVM(rs, "")
+
+	data, err := json.Marshal(struct {
+		Labels      map[string]string
+		Annotations map[string]string
+		Spec        vmopv1alpha1.VirtualMachineSpec
+	}{vm.Labels, vm.Annotations, vm.Spec})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash template")
+	}
+
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(data)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())), nil
+}
+
+func desiredReplicas(rs *vmopapiv1alpha1.VirtualMachineReplicaSet) int {
+	if rs.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*rs.Spec.Replicas)
+}
+
+func maxUnavailable(rs *vmopapiv1alpha1.VirtualMachineReplicaSet, replicas int) int {
+	value := intstr.FromInt(1)
+	if ru := rs.Spec.Strategy.RollingUpdate; ru != nil && ru.MaxUnavailable != nil {
+		value = *ru.MaxUnavailable
+	}
+
+	n, err := intstr.GetScaledValueFromIntOrPercent(&value, replicas, false)
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func isUpdated(vm *vmopv1alpha1.VirtualMachine, templateHash string) bool {
+	return vm.Labels[vmopapiv1alpha1.VirtualMachineReplicaSetTemplateHashLabel] == templateHash
+}
+
+// isReady returns true when the VirtualMachine's readiness probe succeeds, or, when the VirtualMachine
+// has no readiness probe, when it is powered on.
+func isReady(vm *vmopv1alpha1.VirtualMachine) bool {
+	if vm.Spec.ReadinessProbe != nil {
+		return conditions.IsTrue(vm, vmopv1alpha1.ReadyCondition)
+	}
+	return vm.Status.PowerState == vmopv1alpha1.VirtualMachinePoweredOn
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinereplicaset_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	var (
+		ctx *builder.IntegrationTestContext
+
+		rs    *vmopapiv1alpha1.VirtualMachineReplicaSet
+		rsKey client.ObjectKey
+	)
+
+	BeforeEach(func() {
+		ctx = suite.NewIntegrationTestContext()
+
+		replicas := int32(2)
+		rs = &vmopapiv1alpha1.VirtualMachineReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ctx.Namespace,
+				Name:      "dummy-rs",
+			},
+			Spec: vmopapiv1alpha1.VirtualMachineReplicaSetSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "dummy"},
+				},
+				Template: vmopapiv1alpha1.VirtualMachineTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"app": "dummy"},
+					},
+					Spec: vmopv1alpha1.VirtualMachineSpec{
+						ImageName:  "dummy-image",
+						ClassName:  "dummy-class",
+						PowerState: vmopv1alpha1.VirtualMachinePoweredOn,
+					},
+				},
+			},
+		}
+		rsKey = client.ObjectKey{Namespace: rs.Namespace, Name: rs.Name}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+	})
+
+	ownedVMCount := func() int {
+		vmList := &vmopv1alpha1.VirtualMachineList{}
+		if err := ctx.Client.List(ctx, vmList, client.InNamespace(ctx.Namespace)); err != nil {
+			return -1
+		}
+		count := 0
+		for i := range vmList.Items {
+			vm := &vmList.Items[i]
+			if metav1.IsControlledBy(vm, rs) && vm.DeletionTimestamp.IsZero() {
+				count++
+			}
+		}
+		return count
+	}
+
+	Context("Reconcile", func() {
+		It("creates and scales the owned VirtualMachines", func() {
+			Expect(ctx.Client.Create(ctx, rs)).To(Succeed())
+
+			By("creating the replicas", func() {
+				Eventually(ownedVMCount).Should(Equal(2))
+				Eventually(func() int32 {
+					obj := &vmopapiv1alpha1.VirtualMachineReplicaSet{}
+					if err := ctx.Client.Get(ctx, rsKey, obj); err != nil {
+						return -1
+					}
+					return obj.Status.Replicas
+				}).Should(BeEquivalentTo(2))
+			})
+
+			By("scaling down", func() {
+				Eventually(func() error {
+					obj := &vmopapiv1alpha1.VirtualMachineReplicaSet{}
+					if err := ctx.Client.Get(ctx, rsKey, obj); err != nil {
+						return err
+					}
+					replicas := int32(1)
+					obj.Spec.Replicas = &replicas
+					return ctx.Client.Update(ctx, obj)
+				}).Should(Succeed())
+
+				Eventually(ownedVMCount).Should(Equal(1))
+			})
+		})
+	})
+}
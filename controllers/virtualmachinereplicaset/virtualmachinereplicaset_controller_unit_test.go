@@ -0,0 +1,328 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinereplicaset_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinereplicaset"
+	"github.com/vmware-tanzu/vm-operator/pkg"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking Reconcile", unitTestsReconcile)
+}
+
+func unitTestsReconcile() {
+	var (
+		initObjects []client.Object
+		ctx         *builder.UnitTestContextForController
+
+		reconciler *virtualmachinereplicaset.Reconciler
+		rsCtx      *context.VirtualMachineReplicaSetContext
+		rs         *vmopapiv1alpha1.VirtualMachineReplicaSet
+	)
+
+	BeforeEach(func() {
+		replicas := int32(3)
+		rs = &vmopapiv1alpha1.VirtualMachineReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "dummy-rs",
+				Namespace:  "dummy-ns",
+				UID:        types.UID("dummy-rs-uid"),
+				Generation: 2,
+			},
+			Spec: vmopapiv1alpha1.VirtualMachineReplicaSetSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "dummy"},
+				},
+				Template: vmopapiv1alpha1.VirtualMachineTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"app": "dummy"},
+					},
+					Spec: vmopv1alpha1.VirtualMachineSpec{
+						ImageName:  "dummy-image",
+						ClassName:  "dummy-class",
+						PowerState: vmopv1alpha1.VirtualMachinePoweredOn,
+					},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		ctx = suite.NewUnitTestContextForController(initObjects...)
+		reconciler = virtualmachinereplicaset.NewReconciler(
+			ctx.Client,
+			ctx.Logger,
+			ctx.Recorder,
+		)
+
+		rsCtx = &context.VirtualMachineReplicaSetContext{
+			Context:    ctx,
+			Logger:     ctx.Logger.WithName(rs.Name),
+			ReplicaSet: rs,
+		}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
+		reconciler = nil
+		rsCtx = nil
+	})
+
+	listVMs := func() []vmopv1alpha1.VirtualMachine {
+		vmList := &vmopv1alpha1.VirtualMachineList{}
+		Expect(ctx.Client.List(ctx, vmList, client.InNamespace(rs.Namespace))).To(Succeed())
+		return vmList.Items
+	}
+
+	// newOwnedVM returns a VM owned by the ReplicaSet that was created from the template with templateHash.
+	newOwnedVM := func(name, templateHash string, ready bool) *vmopv1alpha1.VirtualMachine {
+		vm := &vmopv1alpha1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: rs.Namespace,
+				Labels: map[string]string{
+					"app": "dummy",
+					vmopapiv1alpha1.VirtualMachineReplicaSetNameLabel:         rs.Name,
+					vmopapiv1alpha1.VirtualMachineReplicaSetTemplateHashLabel: templateHash,
+				},
+			},
+			Spec: rs.Spec.Template.Spec,
+		}
+		if ready {
+			vm.Status.PowerState = vmopv1alpha1.VirtualMachinePoweredOn
+		}
+		Expect(controllerutil.SetControllerReference(rs, vm, builder.NewScheme())).To(Succeed())
+		return vm
+	}
+
+	currentTemplateHash := func() string {
+		// Reconcile a copy without any VMs to learn the hash of the current template.
+		fakeClient := builder.NewFakeClient(rs.DeepCopy())
+		r := virtualmachinereplicaset.NewReconciler(fakeClient, ctx.Logger, ctx.Recorder)
+		rsCopy := rs.DeepCopy()
+		replicas := int32(1)
+		rsCopy.Spec.Replicas = &replicas
+		Expect(r.ReconcileNormal(&context.VirtualMachineReplicaSetContext{
+			Context:    ctx,
+			Logger:     ctx.Logger,
+			ReplicaSet: rsCopy,
+		})).To(Succeed())
+
+		vmList := &vmopv1alpha1.VirtualMachineList{}
+		Expect(fakeClient.List(ctx, vmList)).To(Succeed())
+		Expect(vmList.Items).To(HaveLen(1))
+		return vmList.Items[0].Labels[vmopapiv1alpha1.VirtualMachineReplicaSetTemplateHashLabel]
+	}
+
+	Context("ReconcileNormal", func() {
+		BeforeEach(func() {
+			initObjects = append(initObjects, rs)
+		})
+
+		It("returns an error when the selector does not match the template", func() {
+			rs.Spec.Selector.MatchLabels["app"] = "other"
+			err := reconciler.ReconcileNormal(rsCtx)
+			Expect(err).To(MatchError("selector does not match the template labels"))
+			Expect(listVMs()).To(BeEmpty())
+		})
+
+		When("no VMs exist", func() {
+			It("creates the desired number of owned VMs", func() {
+				rs.Spec.ResourcePolicyName = "dummy-rp"
+				rs.Spec.ClusterModuleGroupName = "dummy-module"
+
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+
+				vms := listVMs()
+				Expect(vms).To(HaveLen(3))
+				for _, vm := range vms {
+					Expect(vm.Name).To(HavePrefix(rs.Name + "-"))
+					Expect(metav1.IsControlledBy(&vm, rs)).To(BeTrue())
+					Expect(vm.Labels).To(HaveKeyWithValue("app", "dummy"))
+					Expect(vm.Labels).To(HaveKeyWithValue(vmopapiv1alpha1.VirtualMachineReplicaSetNameLabel, rs.Name))
+					Expect(vm.Labels).To(HaveKey(vmopapiv1alpha1.VirtualMachineReplicaSetTemplateHashLabel))
+					Expect(vm.Annotations).To(HaveKeyWithValue(pkg.ClusterModuleNameKey, "dummy-module"))
+					Expect(vm.Spec.ResourcePolicyName).To(Equal("dummy-rp"))
+					Expect(vm.Spec.ImageName).To(Equal("dummy-image"))
+				}
+
+				Expect(rs.Status.Replicas).To(BeEquivalentTo(3))
+				Expect(rs.Status.ReadyReplicas).To(BeEquivalentTo(0))
+				Expect(rs.Status.UpdatedReplicas).To(BeEquivalentTo(3))
+				Expect(rs.Status.Selector).To(Equal("app=dummy"))
+				Expect(rs.Status.ObservedGeneration).To(BeEquivalentTo(2))
+				Expect(strings.Count(strings.Join(drainEvents(ctx.Events), "\n"), "CreateVirtualMachineSuccess")).To(Equal(3))
+			})
+		})
+
+		When("the cache has not observed the created VMs yet", func() {
+			It("does not create VMs again", func() {
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+				vms := listVMs()
+				Expect(vms).To(HaveLen(3))
+
+				// Remove the VMs from the client like a cache that has not observed them yet.
+				for i := range vms {
+					Expect(ctx.Client.Delete(ctx, &vms[i])).To(Succeed())
+				}
+
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+				Expect(listVMs()).To(BeEmpty())
+				Expect(strings.Count(strings.Join(drainEvents(ctx.Events), "\n"), "CreateVirtualMachineSuccess")).To(Equal(3))
+			})
+		})
+
+		When("there are more VMs than replicas", func() {
+			BeforeEach(func() {
+				replicas := int32(1)
+				rs.Spec.Replicas = &replicas
+				initObjects = append(initObjects,
+					newOwnedVM("vm-ready", "", true),
+					newOwnedVM("vm-not-ready", "", false))
+			})
+
+			It("deletes the VMs that are not ready first", func() {
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+
+				vms := listVMs()
+				Expect(vms).To(HaveLen(1))
+				Expect(vms[0].Name).To(Equal("vm-ready"))
+				Expect(rs.Status.Replicas).To(BeEquivalentTo(1))
+				Expect(rs.Status.ReadyReplicas).To(BeEquivalentTo(1))
+			})
+		})
+
+		When("VMs are not owned by the ReplicaSet", func() {
+			BeforeEach(func() {
+				vm := newOwnedVM("vm-not-owned", "", true)
+				vm.OwnerReferences = nil
+				initObjects = append(initObjects, vm)
+			})
+
+			It("ignores them", func() {
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+				Expect(listVMs()).To(HaveLen(4))
+				Expect(rs.Status.Replicas).To(BeEquivalentTo(3))
+			})
+		})
+
+		When("the template has changed", func() {
+			BeforeEach(func() {
+				initObjects = append(initObjects,
+					newOwnedVM("vm-0", "outdated", true),
+					newOwnedVM("vm-1", "outdated", true),
+					newOwnedVM("vm-2", "outdated", true))
+			})
+
+			It("replaces up to maxUnavailable ready VMs at a time with RollingUpdate", func() {
+				maxUnavailable := intstr.FromInt(2)
+				rs.Spec.Strategy.RollingUpdate = &vmopapiv1alpha1.RollingUpdateVirtualMachineReplicaSetStrategy{
+					MaxUnavailable: &maxUnavailable,
+				}
+
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+				Expect(listVMs()).To(HaveLen(1))
+				Expect(rs.Status.Replicas).To(BeEquivalentTo(1))
+				Expect(rs.Status.UpdatedReplicas).To(BeEquivalentTo(0))
+
+				By("creating the replacements on the next reconcile", func() {
+					Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+					Expect(listVMs()).To(HaveLen(3))
+					Expect(rs.Status.UpdatedReplicas).To(BeEquivalentTo(2))
+				})
+
+				By("not replacing the last outdated VM until the new VMs are ready", func() {
+					Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+					var names []string
+					for _, vm := range listVMs() {
+						names = append(names, vm.Name)
+					}
+					Expect(names).To(HaveLen(3))
+					Expect(names).To(ContainElement("vm-2"))
+				})
+			})
+
+			It("replaces one VM at a time by default", func() {
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+				Expect(listVMs()).To(HaveLen(2))
+			})
+
+			It("does not replace VMs with OnDelete", func() {
+				rs.Spec.Strategy.Type = vmopapiv1alpha1.OnDeleteVirtualMachineReplicaSetStrategyType
+
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+				Expect(listVMs()).To(HaveLen(3))
+				Expect(rs.Status.UpdatedReplicas).To(BeEquivalentTo(0))
+			})
+		})
+
+		When("VMs have a readiness probe", func() {
+			var templateHash string
+
+			BeforeEach(func() {
+				rs.Spec.Template.Spec.ReadinessProbe = &vmopv1alpha1.Probe{
+					TCPSocket: &vmopv1alpha1.TCPSocketAction{Port: intstr.FromInt(22)},
+				}
+			})
+
+			JustBeforeEach(func() {
+				templateHash = currentTemplateHash()
+			})
+
+			It("uses the Ready condition to gate the rollout", func() {
+				for i := 0; i < 2; i++ {
+					vm := newOwnedVM(fmt.Sprintf("vm-new-%d", i), templateHash, true)
+					if i == 0 {
+						conditions.MarkTrue(vm, vmopv1alpha1.ReadyCondition)
+					} else {
+						conditions.MarkFalse(vm, vmopv1alpha1.ReadyCondition, "NotReady", vmopv1alpha1.ConditionSeverityInfo, "")
+					}
+					Expect(ctx.Client.Create(ctx, vm)).To(Succeed())
+				}
+				oldVM := newOwnedVM("vm-old", "outdated", true)
+				conditions.MarkTrue(oldVM, vmopv1alpha1.ReadyCondition)
+				Expect(ctx.Client.Create(ctx, oldVM)).To(Succeed())
+
+				Expect(reconciler.ReconcileNormal(rsCtx)).To(Succeed())
+				Expect(listVMs()).To(HaveLen(3))
+				Expect(rs.Status.ReadyReplicas).To(BeEquivalentTo(2))
+				Expect(rs.Status.UpdatedReplicas).To(BeEquivalentTo(2))
+			})
+		})
+	})
+}
+
+func drainEvents(events chan string) []string {
+	var out []string
+	for {
+		select {
+		case e := <-events:
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinerestore
+
+import (
+	goctx "context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/record"
+	"github.com/vmware-tanzu/vm-operator/pkg/topology"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+const (
+	restoreVMOpName = "RestoreVirtualMachine"
+)
+
+// AddToManager adds this package's controller to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
+	if !lib.IsVMServiceBackupRestoreFSSEnabled() {
+		return nil
+	}
+
+	var (
+		controlledType = &corev1.Namespace{}
+
+		controllerNameShort = "virtualmachinerestore-controller"
+		controllerNameLong  = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, controllerNameShort)
+	)
+
+	r := NewReconciler(
+		mgr.GetClient(),
+		ctrl.Log.WithName("controllers").WithName("VirtualMachineRestore"),
+		record.New(mgr.GetEventRecorderFor(controllerNameLong)),
+		ctx.VMProvider,
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("virtualmachinerestore").
+		For(controlledType).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(r)
+}
+
+func NewReconciler(
+	client client.Client,
+	logger logr.Logger,
+	recorder record.Recorder,
+	vmProvider vmprovider.VirtualMachineProviderInterface) *Reconciler {
+	return &Reconciler{
+		Client:     client,
+		Logger:     logger,
+		Recorder:   recorder,
+		VMProvider: vmProvider,
+	}
+}
+
+// Reconciler periodically scans each Namespace for VMs that were restored from a backup and recreates
+// their VirtualMachine resources.
+type Reconciler struct {
+	client.Client
+	Logger     logr.Logger
+	Recorder   record.Recorder
+	VMProvider vmprovider.VirtualMachineProviderInterface
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinereplicasets,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *Reconciler) Reconcile(ctx goctx.Context, req ctrl.Request) (ctrl.Result, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, req.NamespacedName, ns); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !ns.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	logger := r.Logger.WithValues("namespace", ns.Name)
+	if err := r.ReconcileNormal(ctx, logger, ns); err != nil {
+		logger.Error(err, "Failed to scan namespace for restored VMs")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: lib.GetVMRestoreScanInterval()}, nil
+}
+
+// ReconcileNormal recreates the VirtualMachine of each VM in the Namespace that was restored from a backup and
+// has no VirtualMachine, and finishes restores that were interrupted. A VM whose VirtualMachine was deleted still
+// has its backup, but it is not a restored VM, so it is left to the orphaned VM scanner.
+func (r *Reconciler) ReconcileNormal(ctx goctx.Context, logger logr.Logger, ns *corev1.Namespace) error {
+	managedVMs, err := r.VMProvider.ListManagedVirtualMachines(ctx, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	vmList := &vmopv1alpha1.VirtualMachineList{}
+	if err := r.List(ctx, vmList, client.InNamespace(ns.Name)); err != nil {
+		return err
+	}
+
+	// A VirtualMachine that is still paused by an earlier restore does not count as existing so
+	// that the restore is completed.
+	names := make(map[string]struct{}, len(vmList.Items))
+	uniqueIDs := make(map[string]struct{}, len(vmList.Items))
+	for i := range vmList.Items {
+		vm := &vmList.Items[i]
+		if isRestorePending(vm) {
+			continue
+		}
+		names[vm.Name] = struct{}{}
+		if vm.Status.UniqueID != "" {
+			uniqueIDs[vm.Status.UniqueID] = struct{}{}
+		}
+	}
+
+	for _, managedVM := range managedVMs {
+		if !managedVM.Restored {
+			continue
+		}
+		if _, ok := names[managedVM.Name]; ok {
+			continue
+		}
+		if _, ok := uniqueIDs[managedVM.MoID]; ok {
+			continue
+		}
+
+		if err := r.restoreVM(ctx, logger, ns, managedVM); err != nil {
+			logger.Error(err, "Failed to restore VirtualMachine", "name", managedVM.Name, "moID", managedVM.MoID)
+			r.Recorder.EmitEvent(ns, restoreVMOpName, err, false)
+		}
+	}
+
+	return nil
+}
+
+// restoreVM recreates the VirtualMachine, and its metadata ConfigMap, from the backup recorded on the VM. The
+// VirtualMachine is created paused so the VM controller does not act on it until its status identifies the
+// restored VM, which ensures the VM is not provisioned again.
+func (r *Reconciler) restoreVM(
+	ctx goctx.Context,
+	logger logr.Logger,
+	ns *corev1.Namespace,
+	managedVM vmprovider.ManagedVirtualMachine) error {
+
+	backup, err := r.VMProvider.GetVirtualMachineBackup(ctx, ns.Name, managedVM)
+	if err != nil {
+		return err
+	}
+	if backup == nil || backup.VirtualMachine == nil {
+		return nil
+	}
+
+	backupVM := backup.VirtualMachine
+	if backupVM.Namespace != ns.Name {
+		logger.Info("Skipping restored VM that was backed up from another namespace",
+			"name", managedVM.Name, "moID", managedVM.MoID, "backupNamespace", backupVM.Namespace)
+		return nil
+	}
+
+	logger = logger.WithValues("name", backupVM.Name, "moID", managedVM.MoID)
+
+	if cm := backup.ConfigMap; cm != nil {
+		restoredCM := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cm.Name,
+				Namespace: ns.Name,
+			},
+			Data: cm.Data,
+		}
+		if err := r.Create(ctx, restoredCM); err != nil && !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to restore ConfigMap %s", cm.Name)
+		}
+	}
+
+	ownerRefs, err := r.restoreOwnerReferences(ctx, logger, ns.Name, backupVM.OwnerReferences)
+	if err != nil {
+		return err
+	}
+
+	vm := &vmopv1alpha1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            backupVM.Name,
+			Namespace:       ns.Name,
+			Labels:          backupVM.Labels,
+			Annotations:     map[string]string{},
+			OwnerReferences: ownerRefs,
+		},
+		Spec: backupVM.Spec,
+	}
+	for k, v := range backupVM.Annotations {
+		vm.Annotations[k] = v
+	}
+	vm.Annotations[constants.RestoredVMAnnotation] = managedVM.MoID
+	vm.Annotations[vmopv1alpha1.PauseAnnotation] = ""
+	if managedVM.Zone != "" {
+		if vm.Labels == nil {
+			vm.Labels = map[string]string{}
+		}
+		vm.Labels[topology.KubernetesTopologyZoneLabelKey] = managedVM.Zone
+	}
+
+	if err := r.Create(ctx, vm); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, "failed to create VirtualMachine")
+		}
+
+		// Resume an interrupted restore, but never take over a VirtualMachine that was created otherwise.
+		if err := r.Get(ctx, client.ObjectKeyFromObject(vm), vm); err != nil {
+			return err
+		}
+		if !isRestorePending(vm) {
+			logger.Info("Skipping restored VM since a VirtualMachine with its name already exists")
+			return nil
+		}
+	}
+
+	vm.Status.UniqueID = managedVM.MoID
+	vm.Status.Zone = managedVM.Zone
+	vm.Status.Volumes = backup.Volumes
+	if err := r.Status().Update(ctx, vm); err != nil {
+		return errors.Wrap(err, "failed to update VirtualMachine status")
+	}
+
+	patch := client.MergeFrom(vm.DeepCopy())
+	delete(vm.Annotations, vmopv1alpha1.PauseAnnotation)
+	if err := r.Patch(ctx, vm, patch); err != nil {
+		return errors.Wrap(err, "failed to remove pause annotation")
+	}
+
+	logger.Info("Restored VirtualMachine")
+	r.Recorder.EmitEvent(vm, restoreVMOpName, nil, false)
+	return nil
+}
+
+// restoreOwnerReferences returns the backed up owner references of a VirtualMachine that refer to VM Operator
+// owners that exist in the namespace, with the UID of the existing owner, since a restored owner is a new object.
+// The other owner references are dropped, because the garbage collector would delete the VirtualMachine when its
+// owner with the backed up UID does not exist, and VM Operator cannot read owners of other API groups.
+func (r *Reconciler) restoreOwnerReferences(
+	ctx goctx.Context,
+	logger logr.Logger,
+	namespace string,
+	backupRefs []metav1.OwnerReference) ([]metav1.OwnerReference, error) {
+
+	var ownerRefs []metav1.OwnerReference
+	for _, ref := range backupRefs {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != vmopv1alpha1.SchemeGroupVersion.Group {
+			logger.Info("Dropping owner reference of restored VirtualMachine", "owner", ref)
+			continue
+		}
+
+		owner := &unstructured.Unstructured{}
+		owner.SetGroupVersionKind(gv.WithKind(ref.Kind))
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, owner); err != nil {
+			if apierrors.IsNotFound(err) {
+				logger.Info("Dropping owner reference of restored VirtualMachine to missing owner", "owner", ref)
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get owner %s %s", ref.Kind, ref.Name)
+		}
+
+		ref.UID = owner.GetUID()
+		ownerRefs = append(ownerRefs, ref)
+	}
+
+	return ownerRefs, nil
+}
+
+// isRestorePending returns true for a VirtualMachine created by a restore that has not finished yet.
+func isRestorePending(vm *vmopv1alpha1.VirtualMachine) bool {
+	if _, ok := vm.Annotations[constants.RestoredVMAnnotation]; !ok {
+		return false
+	}
+	_, ok := vm.Annotations[vmopv1alpha1.PauseAnnotation]
+	return ok
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinerestore_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	var (
+		ctx *builder.IntegrationTestContext
+	)
+
+	BeforeEach(func() {
+		ctx = suite.NewIntegrationTestContext()
+
+		intgFakeVMProvider.Lock()
+		intgFakeVMProvider.ListManagedVirtualMachinesFn = func(_ context.Context, namespace string) ([]vmprovider.ManagedVirtualMachine, error) {
+			if namespace != ctx.Namespace {
+				return nil, nil
+			}
+			return []vmprovider.ManagedVirtualMachine{{Name: "restored-vm", MoID: "vm-42", HasBackup: true, Restored: true}}, nil
+		}
+		intgFakeVMProvider.GetVirtualMachineBackupFn = func(_ context.Context, namespace string, _ vmprovider.ManagedVirtualMachine) (*vmprovider.VirtualMachineBackup, error) {
+			return &vmprovider.VirtualMachineBackup{
+				VirtualMachine: &vmopv1alpha1.VirtualMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "restored-vm",
+						Namespace: namespace,
+					},
+					Spec: vmopv1alpha1.VirtualMachineSpec{
+						ImageName:  "dummy-image",
+						ClassName:  "dummy-class",
+						PowerState: vmopv1alpha1.VirtualMachinePoweredOn,
+					},
+				},
+			}, nil
+		}
+		intgFakeVMProvider.Unlock()
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		intgFakeVMProvider.Reset()
+	})
+
+	Describe("Reconcile", func() {
+		It("recreates the VirtualMachine of a restored VM", func() {
+			vmKey := client.ObjectKey{Namespace: ctx.Namespace, Name: "restored-vm"}
+
+			Eventually(func() string {
+				vm := &vmopv1alpha1.VirtualMachine{}
+				if err := ctx.Client.Get(ctx, vmKey, vm); err != nil {
+					return ""
+				}
+				if _, ok := vm.Annotations[vmopv1alpha1.PauseAnnotation]; ok {
+					return ""
+				}
+				return vm.Status.UniqueID
+			}).Should(Equal("vm-42"))
+		})
+	})
+}
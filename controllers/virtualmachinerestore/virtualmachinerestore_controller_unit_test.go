@@ -0,0 +1,268 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package virtualmachinerestore_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachinerestore"
+	"github.com/vmware-tanzu/vm-operator/pkg/topology"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	providerfake "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/fake"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking ReconcileNormal", unitTestsReconcileNormal)
+}
+
+func unitTestsReconcileNormal() {
+	var (
+		initObjects    []client.Object
+		ctx            *builder.UnitTestContextForController
+		reconciler     *virtualmachinerestore.Reconciler
+		fakeVMProvider *providerfake.VMProvider
+
+		ns         *corev1.Namespace
+		managedVMs []vmprovider.ManagedVirtualMachine
+		backup     *vmprovider.VirtualMachineBackup
+		backupErr  error
+	)
+
+	BeforeEach(func() {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-ns",
+			},
+		}
+
+		managedVMs = []vmprovider.ManagedVirtualMachine{
+			{Name: "dummy-vm", MoID: "vm-42", Zone: "zone-1", HasBackup: true, Restored: true},
+		}
+
+		backup = &vmprovider.VirtualMachineBackup{
+			VirtualMachine: &vmopv1alpha1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dummy-vm",
+					Namespace:   ns.Name,
+					Labels:      map[string]string{"foo": "bar"},
+					Annotations: map[string]string{"hello": "world"},
+				},
+				Spec: vmopv1alpha1.VirtualMachineSpec{
+					ImageName:  "dummy-image",
+					ClassName:  "dummy-class",
+					PowerState: vmopv1alpha1.VirtualMachinePoweredOn,
+					VmMetadata: &vmopv1alpha1.VirtualMachineMetadata{
+						ConfigMapName: "dummy-cm",
+						Transport:     vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport,
+					},
+				},
+			},
+			ConfigMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dummy-cm",
+					Namespace: ns.Name,
+				},
+				Data: map[string]string{"key": "value"},
+			},
+			Volumes: []vmopv1alpha1.VirtualMachineVolumeStatus{
+				{Name: "dummy-vol", Attached: true, DiskUuid: "dummy-uuid"},
+			},
+		}
+		backupErr = nil
+	})
+
+	JustBeforeEach(func() {
+		initObjects = append(initObjects, ns)
+		ctx = suite.NewUnitTestContextForController(initObjects...)
+		reconciler = virtualmachinerestore.NewReconciler(
+			ctx.Client,
+			ctx.Logger,
+			ctx.Recorder,
+			ctx.VMProvider,
+		)
+
+		fakeVMProvider = ctx.VMProvider.(*providerfake.VMProvider)
+		fakeVMProvider.ListManagedVirtualMachinesFn = func(_ context.Context, _ string) ([]vmprovider.ManagedVirtualMachine, error) {
+			return managedVMs, nil
+		}
+		fakeVMProvider.GetVirtualMachineBackupFn = func(_ context.Context, _ string, _ vmprovider.ManagedVirtualMachine) (*vmprovider.VirtualMachineBackup, error) {
+			return backup, backupErr
+		}
+	})
+
+	AfterEach(func() {
+		ctx.AfterEach()
+		ctx = nil
+		initObjects = nil
+	})
+
+	getVM := func() *vmopv1alpha1.VirtualMachine {
+		vm := &vmopv1alpha1.VirtualMachine{}
+		if err := ctx.Client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: "dummy-vm"}, vm); err != nil {
+			return nil
+		}
+		return vm
+	}
+
+	When("a restored VM has no VirtualMachine", func() {
+		It("recreates the VirtualMachine and its ConfigMap", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+
+			vm := getVM()
+			Expect(vm).ToNot(BeNil())
+			Expect(vm.Spec).To(Equal(backup.VirtualMachine.Spec))
+			Expect(vm.Labels).To(HaveKeyWithValue("foo", "bar"))
+			Expect(vm.Labels).To(HaveKeyWithValue(topology.KubernetesTopologyZoneLabelKey, "zone-1"))
+			Expect(vm.Annotations).To(HaveKeyWithValue("hello", "world"))
+			Expect(vm.Annotations).To(HaveKeyWithValue(constants.RestoredVMAnnotation, "vm-42"))
+			Expect(vm.Annotations).ToNot(HaveKey(vmopv1alpha1.PauseAnnotation))
+			Expect(vm.Status.UniqueID).To(Equal("vm-42"))
+			Expect(vm.Status.Zone).To(Equal("zone-1"))
+			Expect(vm.Status.Volumes).To(Equal(backup.Volumes))
+
+			cm := &corev1.ConfigMap{}
+			Expect(ctx.Client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: "dummy-cm"}, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(backup.ConfigMap.Data))
+
+			expectEvent(ctx, "RestoreVirtualMachineSuccess")
+		})
+	})
+
+	When("the backup has owner references", func() {
+		BeforeEach(func() {
+			rs := &vmopapiv1alpha1.VirtualMachineReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dummy-rs",
+					Namespace: ns.Name,
+					UID:       "restored-rs-uid",
+				},
+			}
+			initObjects = append(initObjects, rs)
+
+			backup.VirtualMachine.OwnerReferences = []metav1.OwnerReference{
+				{APIVersion: "vmoperator.vmware.com/v1alpha1", Kind: "VirtualMachineReplicaSet", Name: "dummy-rs", UID: "backup-rs-uid"},
+				{APIVersion: "vmoperator.vmware.com/v1alpha1", Kind: "VirtualMachineReplicaSet", Name: "missing-rs", UID: "missing-rs-uid"},
+				{APIVersion: "cluster.x-k8s.io/v1beta1", Kind: "Machine", Name: "dummy-machine", UID: "machine-uid"},
+			}
+		})
+
+		It("only restores the references to existing VM Operator owners with their current UID", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+
+			vm := getVM()
+			Expect(vm).ToNot(BeNil())
+			Expect(vm.OwnerReferences).To(HaveLen(1))
+			Expect(vm.OwnerReferences[0].Name).To(Equal("dummy-rs"))
+			Expect(vm.OwnerReferences[0].UID).To(BeEquivalentTo("restored-rs-uid"))
+		})
+	})
+
+	When("the VM has no backup", func() {
+		BeforeEach(func() {
+			managedVMs[0].HasBackup = false
+			managedVMs[0].Restored = false
+		})
+
+		It("does nothing", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(getVM()).To(BeNil())
+		})
+	})
+
+	When("the VM has a backup but was not restored", func() {
+		BeforeEach(func() {
+			managedVMs[0].Restored = false
+		})
+
+		It("does nothing", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(getVM()).To(BeNil())
+		})
+	})
+
+	When("the backup is from another namespace", func() {
+		BeforeEach(func() {
+			backup.VirtualMachine.Namespace = "other-ns"
+		})
+
+		It("does nothing", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(getVM()).To(BeNil())
+		})
+	})
+
+	When("the backup cannot be read", func() {
+		BeforeEach(func() {
+			backupErr = errors.New("fake error")
+		})
+
+		It("emits a failure event", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(getVM()).To(BeNil())
+			expectEvent(ctx, "RestoreVirtualMachineFailure")
+		})
+	})
+
+	When("a VirtualMachine with the same name exists", func() {
+		BeforeEach(func() {
+			initObjects = append(initObjects, &vmopv1alpha1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dummy-vm",
+					Namespace: ns.Name,
+				},
+			})
+		})
+
+		It("is not restored", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+			Expect(getVM().Annotations).ToNot(HaveKey(constants.RestoredVMAnnotation))
+			Expect(ctx.Events).ToNot(Receive())
+		})
+	})
+
+	When("an earlier restore was interrupted", func() {
+		BeforeEach(func() {
+			initObjects = append(initObjects, &vmopv1alpha1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dummy-vm",
+					Namespace: ns.Name,
+					Annotations: map[string]string{
+						constants.RestoredVMAnnotation: "vm-42",
+						vmopv1alpha1.PauseAnnotation:   "",
+					},
+				},
+			})
+		})
+
+		It("completes the restore", func() {
+			Expect(reconciler.ReconcileNormal(ctx, ctx.Logger, ns)).To(Succeed())
+
+			vm := getVM()
+			Expect(vm.Annotations).ToNot(HaveKey(vmopv1alpha1.PauseAnnotation))
+			Expect(vm.Status.UniqueID).To(Equal("vm-42"))
+			expectEvent(ctx, "RestoreVirtualMachineSuccess")
+		})
+	})
+}
+
+func expectEvent(ctx *builder.UnitTestContextForController, reason string) {
+	var event string
+	EventuallyWithOffset(1, ctx.Events).Should(Receive(&event))
+	eventComponents := strings.Split(event, " ")
+	ExpectWithOffset(1, eventComponents[1]).To(Equal(reason))
+}
@@ -5,6 +5,7 @@ package virtualmachineservice
 
 import (
 	goctx "context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
@@ -29,6 +30,7 @@ import (
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineservice/providers"
 	"github.com/vmware-tanzu/vm-operator/controllers/virtualmachineservice/utils"
 	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
@@ -43,6 +45,16 @@ const (
 	OpCreate = "CreateK8sService"
 	OpDelete = "DeleteK8sService"
 	OpUpdate = "UpdateK8sService"
+
+	// ConditionsAnnotation is the annotation key for a JSON list of the conditions of the VirtualMachineService,
+	// since its status has no conditions.
+	ConditionsAnnotation = vmopapiv1alpha1.VirtualMachineServiceConditionsAnnotation
+
+	// SelectorMatchedCondition reports whether the selector of the VirtualMachineService matches VirtualMachines of
+	// its namespace. Its Endpoints stay empty while it is false.
+	SelectorMatchedCondition vmopv1alpha1.ConditionType = "SelectorMatched"
+	// NoVirtualMachinesSelectedReason is the reason of a false SelectorMatchedCondition.
+	NoVirtualMachinesSelectedReason = "NoVirtualMachinesSelected"
 )
 
 func AddToManager(ctx *context.ControllerManagerContext, mgr manager.Manager) error {
@@ -271,6 +283,12 @@ func (r *ReconcileVirtualMachineService) reconcileVMService(ctx *context.Virtual
 		return err
 	}
 
+	err = r.reconcileSelectorMatchedCondition(ctx)
+	if err != nil {
+		ctx.Logger.Error(err, "Failed to update VirtualMachineService SelectorMatched condition")
+		return err
+	}
+
 	return nil
 }
 
@@ -372,6 +390,9 @@ func (r *ReconcileVirtualMachineService) setServiceAnnotationsAndLabels(
 		}
 	}
 
+	// The conditions are only the status of the VirtualMachineService.
+	delete(service.Annotations, ConditionsAnnotation)
+
 	return nil
 }
 
@@ -702,6 +723,63 @@ func (r *ReconcileVirtualMachineService) generateSubsetsForService(
 	return subsets, nil
 }
 
+// reconcileSelectorMatchedCondition sets the SelectorMatchedCondition from the VirtualMachines that the selector of the
+// VirtualMachineService matches. ExternalName services select no VirtualMachines, so they have no such condition.
+func (r *ReconcileVirtualMachineService) reconcileSelectorMatchedCondition(ctx *context.VirtualMachineServiceContext) error {
+	vmService := ctx.VMService
+	setter := vmServiceConditions{vmService}
+
+	if vmService.Spec.Type == vmopv1alpha1.VirtualMachineServiceTypeExternalName {
+		conditions.Delete(setter, SelectorMatchedCondition)
+		return nil
+	}
+
+	vmList, err := r.getVirtualMachinesSelectedByVMService(ctx, vmService)
+	if err != nil {
+		return err
+	}
+
+	if len(vmList.Items) == 0 {
+		conditions.MarkFalse(setter, SelectorMatchedCondition, NoVirtualMachinesSelectedReason,
+			vmopv1alpha1.ConditionSeverityWarning, "selector %v matches no VirtualMachines in namespace %s",
+			vmService.Spec.Selector, vmService.Namespace)
+	} else {
+		conditions.MarkTrue(setter, SelectorMatchedCondition)
+	}
+
+	return nil
+}
+
+// vmServiceConditions stores the conditions of a VirtualMachineService in its ConditionsAnnotation.
+type vmServiceConditions struct {
+	*vmopv1alpha1.VirtualMachineService
+}
+
+func (c vmServiceConditions) GetConditions() vmopv1alpha1.Conditions {
+	var conditions vmopv1alpha1.Conditions
+	if value, ok := c.Annotations[ConditionsAnnotation]; ok {
+		// An invalid annotation is replaced by the next SetConditions.
+		_ = json.Unmarshal([]byte(value), &conditions)
+	}
+	return conditions
+}
+
+func (c vmServiceConditions) SetConditions(conditions vmopv1alpha1.Conditions) {
+	if len(conditions) == 0 {
+		delete(c.Annotations, ConditionsAnnotation)
+		return
+	}
+
+	data, err := json.Marshal(conditions)
+	if err != nil {
+		return
+	}
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
+	}
+	c.Annotations[ConditionsAnnotation] = string(data)
+}
+
 // updateVMService syncs the VirtualMachineService Status from the Service status.
 func (r *ReconcileVirtualMachineService) updateVMService(ctx *context.VirtualMachineServiceContext, service *corev1.Service) error {
 	vmService := ctx.VMService
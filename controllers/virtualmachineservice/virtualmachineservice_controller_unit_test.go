@@ -4,6 +4,8 @@
 package virtualmachineservice_test
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -33,6 +35,24 @@ func unitTests() {
 
 const LabelServiceProxyName = "service.kubernetes.io/service-proxy-name"
 
+// selectorMatchedCondition returns the SelectorMatched condition of the conditions annotation of the
+// VirtualMachineService.
+func selectorMatchedCondition(vmService *vmopv1alpha1.VirtualMachineService) *vmopv1alpha1.Condition {
+	var vmServiceConditions vmopv1alpha1.Conditions
+	value, ok := vmService.Annotations[virtualmachineservice.ConditionsAnnotation]
+	if !ok {
+		return nil
+	}
+	Expect(json.Unmarshal([]byte(value), &vmServiceConditions)).To(Succeed())
+
+	for i := range vmServiceConditions {
+		if vmServiceConditions[i].Type == virtualmachineservice.SelectorMatchedCondition {
+			return &vmServiceConditions[i]
+		}
+	}
+	return nil
+}
+
 func unitTestsReconcile() {
 	var (
 		initObjects []client.Object
@@ -482,6 +502,15 @@ func unitTestsReconcile() {
 				Expect(endpoints.Subsets).To(BeEmpty())
 			})
 
+			It("False SelectorMatched condition when no VM matches", func() {
+				condition := selectorMatchedCondition(vmServiceCtx.VMService)
+				Expect(condition).ToNot(BeNil())
+				Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(condition.Reason).To(Equal(virtualmachineservice.NoVirtualMachinesSelectedReason))
+				Expect(condition.Message).To(ContainSubstring("matches no VirtualMachines in namespace dummy-ns"))
+				Expect(endpoints.Annotations).ToNot(HaveKey(virtualmachineservice.ConditionsAnnotation))
+			})
+
 			Context("When one VM matches label selector", func() {
 				BeforeEach(func() {
 					initObjects = append(initObjects, vm1, vm3)
@@ -510,6 +539,12 @@ func unitTestsReconcile() {
 					Expect(subset.NotReadyAddresses).To(BeEmpty())
 				})
 
+				It("True SelectorMatched condition", func() {
+					condition := selectorMatchedCondition(vmServiceCtx.VMService)
+					Expect(condition).ToNot(BeNil())
+					Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+				})
+
 				Context("When VM does not have IP", func() {
 					BeforeEach(func() {
 						vm1.Status.VmIp = ""
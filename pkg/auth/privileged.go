@@ -0,0 +1,323 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+const (
+	// PrivilegedFieldsConfigMapName is the name of the ConfigMap, in the VM Operator namespace, of the
+	// PrivilegedFieldRules that grant the modification of privileged fields to other users than VM Operator and
+	// the Kubernetes administrator.
+	PrivilegedFieldsConfigMapName = "vmoperator-privileged-fields"
+	// PrivilegedFieldsRulesKey is the key of the YAML list of PrivilegedFieldRules in the ConfigMap.
+	PrivilegedFieldsRulesKey = "rules"
+)
+
+// PrivilegedFieldRule grants the modification of an annotation or a field to users, groups and service accounts.
+type PrivilegedFieldRule struct {
+	// Annotation is the key of the annotation.
+	Annotation string `json:"annotation,omitempty"`
+	// Field is the dot separated path of the field, for example "spec.advancedOptions". The path cannot go
+	// through a list.
+	Field string `json:"field,omitempty"`
+	// Kinds are the kinds of the resources whose annotation or field is privileged. All kinds when it is empty.
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Users are the names of the users that can modify the annotation or field.
+	Users []string `json:"users,omitempty"`
+	// Groups are the groups whose users can modify the annotation or field.
+	Groups []string `json:"groups,omitempty"`
+	// ServiceAccounts are the "namespace/name" of the service accounts that can modify the annotation or field.
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+}
+
+// DefaultPrivilegedFieldRules are the annotations that only VM Operator and the Kubernetes administrator can modify
+// unless the ConfigMap grants them to other users.
+var DefaultPrivilegedFieldRules = []PrivilegedFieldRule{
+	{Annotation: constants.VSphereCustomizationBypassKey},
+	{Annotation: constants.FirmwareOverrideAnnotation},
+	{Annotation: constants.PCIPassthruMMIOOverrideAnnotation},
+	{Annotation: vmopv1alpha1.PauseAnnotation},
+	{Annotation: constants.VMOperatorImageSupportedCheckKey},
+
+	// The annotations that VM Operator records the status of its resources in, since it cannot extend the
+	// status of their types.
+	{Annotation: vmopapiv1alpha1.VirtualMachineImageInfoAnnotation, Kinds: []string{"VirtualMachineImage"}},
+	{Annotation: vmopapiv1alpha1.VirtualMachineImageInfoVersionAnnotation, Kinds: []string{"VirtualMachineImage"}},
+	{Annotation: vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation, Kinds: []string{"VirtualMachineImage"}},
+	{Annotation: vmopapiv1alpha1.VirtualMachineImageSignerAnnotation, Kinds: []string{"VirtualMachineImage"}},
+	{Annotation: vmopapiv1alpha1.ContentLibrarySyncStatusAnnotation, Kinds: []string{"ContentLibraryProvider"}},
+	{Annotation: vmopapiv1alpha1.VirtualMachineClassAvailabilityAnnotation, Kinds: []string{"VirtualMachineClass"}},
+	{Annotation: constants.EffectiveExtraConfigAnnotation, Kinds: []string{"VirtualMachine"}},
+	{Annotation: vmopapiv1alpha1.VirtualMachineServiceConditionsAnnotation, Kinds: []string{"VirtualMachineService"}},
+	{Annotation: constants.VMImageCLVersionAnnotation, Kinds: []string{"VirtualMachineImage"}},
+	{Annotation: constants.InstanceStoragePVCsBoundAnnotationKey, Kinds: []string{"VirtualMachine"}},
+	{Annotation: constants.RestoredVMAnnotation, Kinds: []string{"VirtualMachine"}},
+
+	// The annotations that make VM Operator act on vSphere objects outside of the namespace of the VirtualMachine.
+	{Annotation: constants.RetainedVMFolderAnnotation, Kinds: []string{"VirtualMachine"}},
+	// How long the deletion of a VirtualMachine waits for the guest OS, which holds the resources of the VM.
+	{Annotation: constants.TerminationGracePeriodAnnotation, Kinds: []string{"VirtualMachine"}},
+
+	// The ExtraConfig of the VirtualMachines of a namespace, and the policy that restricts the ExtraConfig that
+	// the other users of the namespace can set.
+	{Field: "spec.extraConfig", Kinds: []string{"VirtualMachineDefaults"}},
+	{Field: "spec.extraConfigPolicy", Kinds: []string{"VirtualMachineDefaults"}},
+}
+
+// UnprivilegedAnnotations are the annotations that VM Operator acts on but that are deliberately not in the
+// DefaultPrivilegedFieldRules.
+var UnprivilegedAnnotations = []string{
+	// The options of a VirtualMachine that its users choose, which the VirtualMachine webhook validates.
+	constants.DeletionPolicyAnnotation,
+	constants.BootDiskSizeAnnotation,
+	constants.GuestIDAnnotation,
+	constants.EjectISOAnnotation,
+	constants.CloudInitTypeAnnotation,
+	// The ExtraConfig of a VirtualMachine, which is checked against the ExtraConfig policy of the namespace.
+	constants.ExtraConfigAnnotation,
+	// Set by the placement of the instance storage volumes, outside of VM Operator.
+	constants.InstanceStorageSelectedNodeAnnotationKey,
+}
+
+// GetPrivilegedFieldRules returns the DefaultPrivilegedFieldRules, replaced or extended by the rules of the
+// PrivilegedFieldsConfigMapName ConfigMap. It returns an error when a rule of the ConfigMap has a field that is a
+// path through a list, which cannot be enforced.
+func GetPrivilegedFieldRules(ctx context.Context, client ctrlclient.Client) ([]PrivilegedFieldRule, error) {
+	rules := append([]PrivilegedFieldRule{}, DefaultPrivilegedFieldRules...)
+
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		return rules, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	key := ctrlclient.ObjectKey{Namespace: namespace, Name: PrivilegedFieldsConfigMapName}
+	if err := client.Get(ctx, key, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return rules, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s", key)
+	}
+
+	var configured []PrivilegedFieldRule
+	if err := yaml.Unmarshal([]byte(configMap.Data[PrivilegedFieldsRulesKey]), &configured); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s key of ConfigMap %s", PrivilegedFieldsRulesKey, key)
+	}
+
+	for _, c := range configured {
+		if err := validateFieldRule(client.Scheme(), c); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s key of ConfigMap %s", PrivilegedFieldsRulesKey, key)
+		}
+
+		replaced := false
+		for i := range rules {
+			if rules[i].Annotation == c.Annotation && rules[i].Field == c.Field {
+				rules[i], replaced = c, true
+				break
+			}
+		}
+		if !replaced {
+			rules = append(rules, c)
+		}
+	}
+
+	return rules, nil
+}
+
+// validateFieldRule returns an error when the field of the rule is a path through a list of one of the VM Operator
+// kinds of the rule, since the field of the items of a list cannot be compared and the rule would never deny.
+func validateFieldRule(scheme *runtime.Scheme, rule PrivilegedFieldRule) error {
+	if rule.Field == "" {
+		return nil
+	}
+
+	fieldPath := strings.Split(rule.Field, ".")
+	for gvk, t := range scheme.AllKnownTypes() {
+		if gvk.Group != vmopapiv1alpha1.GroupVersion.Group || !rule.appliesTo(gvk.Kind) {
+			continue
+		}
+		if throughList(t, fieldPath) {
+			return errors.Errorf("field %s of %s is a path through a list, which is not supported", rule.Field, gvk)
+		}
+	}
+	return nil
+}
+
+// throughList returns true when the JSON field path goes through a list of the type.
+func throughList(t reflect.Type, fieldPath []string) bool {
+	for _, name := range fieldPath {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := jsonField(t, name)
+			if !ok {
+				return false
+			}
+			t = f.Type
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Slice, reflect.Array:
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// jsonField returns the field of the struct type whose JSON name is the name, including the fields of the
+// inlined structs.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Anonymous && jsonName == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if inlined, ok := jsonField(ft, name); ok {
+					return inlined, true
+				}
+			}
+			continue
+		}
+		if jsonName == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// IsAllowed returns true when the user can modify the annotation or field of the rule. VM Operator and the
+// Kubernetes administrator can modify all of them.
+func (r PrivilegedFieldRule) IsAllowed(userInfo authv1.UserInfo) bool {
+	if IsPODServiceAccountUser(userInfo) || IsKubernetesAdmin(userInfo) {
+		return true
+	}
+
+	for _, user := range r.Users {
+		if strings.EqualFold(userInfo.Username, user) {
+			return true
+		}
+	}
+	for _, sa := range r.ServiceAccounts {
+		if strings.EqualFold(userInfo.Username, "system:serviceaccount:"+strings.Replace(sa, "/", ":", 1)) {
+			return true
+		}
+	}
+	for _, group := range r.Groups {
+		for _, g := range userInfo.Groups {
+			if g == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String returns the annotation or field of the rule.
+func (r PrivilegedFieldRule) String() string {
+	if r.Annotation != "" {
+		return "annotation " + r.Annotation
+	}
+	return "field " + r.Field
+}
+
+func (r PrivilegedFieldRule) appliesTo(kind string) bool {
+	if len(r.Kinds) == 0 {
+		return true
+	}
+	for _, k := range r.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r PrivilegedFieldRule) value(obj *unstructured.Unstructured) (interface{}, bool) {
+	if obj == nil {
+		return nil, false
+	}
+	if r.Annotation != "" {
+		value, ok := obj.GetAnnotations()[r.Annotation]
+		return value, ok
+	}
+	if r.Field == "" {
+		return nil, false
+	}
+	value, ok, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(r.Field, ".")...)
+	return value, ok && err == nil
+}
+
+// DeniedPrivilegedFields returns the rules whose annotation or field the user modifies, by adding, changing or
+// removing it, without being allowed to. The old object is nil on create.
+func DeniedPrivilegedFields(
+	rules []PrivilegedFieldRule,
+	userInfo authv1.UserInfo,
+	obj, oldObj *unstructured.Unstructured) []PrivilegedFieldRule {
+
+	var denied []PrivilegedFieldRule
+	for _, rule := range rules {
+		if !rule.appliesTo(obj.GetKind()) {
+			continue
+		}
+
+		value, ok := rule.value(obj)
+		oldValue, oldOK := rule.value(oldObj)
+		if !ok && !oldOK || ok && oldOK && reflect.DeepEqual(value, oldValue) {
+			continue
+		}
+
+		if !rule.IsAllowed(userInfo) {
+			denied = append(denied, rule)
+		}
+	}
+	return denied
+}
+
+// PrivilegedFieldDeniedMessage returns the message of the denial of the modification of the privileged annotation
+// or field by the user, that names the user and the users that are allowed.
+func PrivilegedFieldDeniedMessage(rule PrivilegedFieldRule, userInfo authv1.UserInfo) string {
+	allowed := []string{"VM Operator", "Kubernetes administrators"}
+	if len(rule.Users) > 0 {
+		allowed = append(allowed, fmt.Sprintf("users %v", rule.Users))
+	}
+	if len(rule.Groups) > 0 {
+		allowed = append(allowed, fmt.Sprintf("groups %v", rule.Groups))
+	}
+	if len(rule.ServiceAccounts) > 0 {
+		allowed = append(allowed, fmt.Sprintf("service accounts %v", rule.ServiceAccounts))
+	}
+
+	return fmt.Sprintf("user %q with groups %v is not allowed to modify %s, which can only be modified by %s",
+		userInfo.Username, userInfo.Groups, rule, strings.Join(allowed, ", "))
+}
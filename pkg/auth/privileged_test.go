@@ -0,0 +1,279 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth_test
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg"
+	"github.com/vmware-tanzu/vm-operator/pkg/auth"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+var _ = Describe("Privileged fields", func() {
+	var (
+		userInfo authv1.UserInfo
+		obj      *unstructured.Unstructured
+		oldObj   *unstructured.Unstructured
+	)
+
+	BeforeEach(func() {
+		userInfo = authv1.UserInfo{Username: "sso:devUser1@vsphere.local", Groups: []string{"sso:Developers@vsphere.local"}}
+		obj = &unstructured.Unstructured{}
+		obj.SetKind("VirtualMachine")
+		obj.SetName("vm")
+		oldObj = nil
+	})
+
+	Context("DeniedPrivilegedFields", func() {
+		It("denies setting a default privileged annotation", func() {
+			obj.SetAnnotations(map[string]string{vmopv1alpha1.PauseAnnotation: ""})
+			denied := auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)
+			Expect(denied).To(HaveLen(1))
+			Expect(denied[0].Annotation).To(Equal(vmopv1alpha1.PauseAnnotation))
+		})
+
+		DescribeTable("denies setting a status annotation",
+			func(kind, annotation string) {
+				obj.SetKind(kind)
+				obj.SetAnnotations(map[string]string{annotation: "{}"})
+				denied := auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)
+				Expect(denied).To(HaveLen(1))
+				Expect(denied[0].Annotation).To(Equal(annotation))
+
+				userInfo.Username = auth.KubeAdminUser
+				Expect(auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)).To(BeEmpty())
+			},
+			Entry("image info", "VirtualMachineImage", vmopapiv1alpha1.VirtualMachineImageInfoAnnotation),
+			Entry("image info version", "VirtualMachineImage", vmopapiv1alpha1.VirtualMachineImageInfoVersionAnnotation),
+			Entry("image trust version", "VirtualMachineImage", vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation),
+			Entry("image signer", "VirtualMachineImage", vmopapiv1alpha1.VirtualMachineImageSignerAnnotation),
+			Entry("content library sync status", "ContentLibraryProvider", vmopapiv1alpha1.ContentLibrarySyncStatusAnnotation),
+			Entry("class availability", "VirtualMachineClass", vmopapiv1alpha1.VirtualMachineClassAvailabilityAnnotation),
+			Entry("effective ExtraConfig", "VirtualMachine", constants.EffectiveExtraConfigAnnotation),
+			Entry("service conditions", "VirtualMachineService", vmopapiv1alpha1.VirtualMachineServiceConditionsAnnotation),
+			Entry("content library version", "VirtualMachineImage", constants.VMImageCLVersionAnnotation),
+			Entry("instance storage PVCs bound", "VirtualMachine", constants.InstanceStoragePVCsBoundAnnotationKey),
+			Entry("restored VM", "VirtualMachine", constants.RestoredVMAnnotation),
+		)
+
+		DescribeTable("denies setting a VirtualMachine annotation that acts outside of the namespace",
+			func(annotation, value string) {
+				obj.SetKind("VirtualMachine")
+				obj.SetAnnotations(map[string]string{annotation: value})
+				denied := auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)
+				Expect(denied).To(HaveLen(1))
+				Expect(denied[0].Annotation).To(Equal(annotation))
+
+				userInfo.Username = auth.KubeAdminUser
+				Expect(auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)).To(BeEmpty())
+			},
+			Entry("retained VM folder", constants.RetainedVMFolderAnnotation, "group-v42"),
+			Entry("termination grace period", constants.TerminationGracePeriodAnnotation, "1m"),
+		)
+
+		It("allows the Kubernetes administrator", func() {
+			userInfo.Username = auth.KubeAdminUser
+			obj.SetAnnotations(map[string]string{vmopv1alpha1.PauseAnnotation: ""})
+			Expect(auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)).To(BeEmpty())
+		})
+
+		It("allows an unchanged privileged annotation", func() {
+			obj.SetAnnotations(map[string]string{constants.FirmwareOverrideAnnotation: "efi", "foo": "bar"})
+			oldObj = obj.DeepCopy()
+			oldObj.SetAnnotations(map[string]string{constants.FirmwareOverrideAnnotation: "efi"})
+			Expect(auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)).To(BeEmpty())
+		})
+
+		It("denies removing a privileged annotation", func() {
+			oldObj = obj.DeepCopy()
+			oldObj.SetAnnotations(map[string]string{vmopv1alpha1.PauseAnnotation: ""})
+			Expect(auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)).To(HaveLen(1))
+		})
+
+		It("allows a granted group, user or service account", func() {
+			rule := auth.PrivilegedFieldRule{Annotation: constants.VSphereCustomizationBypassKey}
+			obj.SetAnnotations(map[string]string{constants.VSphereCustomizationBypassKey: "disable"})
+
+			Expect(auth.DeniedPrivilegedFields([]auth.PrivilegedFieldRule{rule}, userInfo, obj, oldObj)).To(HaveLen(1))
+
+			rule.Groups = []string{"sso:Developers@vsphere.local"}
+			Expect(auth.DeniedPrivilegedFields([]auth.PrivilegedFieldRule{rule}, userInfo, obj, oldObj)).To(BeEmpty())
+
+			rule.Groups = nil
+			rule.Users = []string{"sso:devUser1@vsphere.local"}
+			Expect(auth.DeniedPrivilegedFields([]auth.PrivilegedFieldRule{rule}, userInfo, obj, oldObj)).To(BeEmpty())
+
+			rule.Users = nil
+			rule.ServiceAccounts = []string{"ns/sa"}
+			userInfo.Username = "system:serviceaccount:ns:sa"
+			Expect(auth.DeniedPrivilegedFields([]auth.PrivilegedFieldRule{rule}, userInfo, obj, oldObj)).To(BeEmpty())
+		})
+
+		DescribeTable("denies setting a namespace ExtraConfig field",
+			func(value interface{}, fields ...string) {
+				obj.SetKind("VirtualMachineDefaults")
+				Expect(unstructured.SetNestedField(obj.Object, value, fields...)).To(Succeed())
+				denied := auth.DeniedPrivilegedFields(auth.DefaultPrivilegedFieldRules, userInfo, obj, oldObj)
+				Expect(denied).To(HaveLen(1))
+				Expect(denied[0].Field).To(Equal(strings.Join(fields, ".")))
+			},
+			Entry("ExtraConfig", map[string]interface{}{"disk.enableUUID": "TRUE"}, "spec", "extraConfig"),
+			Entry("ExtraConfig policy", map[string]interface{}{"allowedKeys": []interface{}{"*"}}, "spec", "extraConfigPolicy"),
+		)
+
+		It("denies modifying a privileged field of the kinds of the rule", func() {
+			rules := []auth.PrivilegedFieldRule{{Field: "spec.advancedOptions.changeBlockTracking", Kinds: []string{"VirtualMachine"}}}
+			oldObj = obj.DeepCopy()
+			Expect(unstructured.SetNestedField(obj.Object, true, "spec", "advancedOptions", "changeBlockTracking")).To(Succeed())
+			Expect(auth.DeniedPrivilegedFields(rules, userInfo, obj, oldObj)).To(HaveLen(1))
+
+			obj.SetKind("VirtualMachineClass")
+			Expect(auth.DeniedPrivilegedFields(rules, userInfo, obj, oldObj)).To(BeEmpty())
+		})
+	})
+
+	Context("PrivilegedFieldDeniedMessage", func() {
+		It("names the user and the allowed users", func() {
+			rule := auth.PrivilegedFieldRule{Annotation: vmopv1alpha1.PauseAnnotation, Groups: []string{"admins"}}
+			Expect(auth.PrivilegedFieldDeniedMessage(rule, userInfo)).To(Equal(
+				`user "sso:devUser1@vsphere.local" with groups [sso:Developers@vsphere.local] is not allowed to modify ` +
+					`annotation ` + vmopv1alpha1.PauseAnnotation + `, which can only be modified by VM Operator, ` +
+					`Kubernetes administrators, groups [admins]`))
+		})
+	})
+
+	Context("GetPrivilegedFieldRules", func() {
+		var oldNamespace string
+
+		BeforeEach(func() {
+			oldNamespace = os.Getenv("POD_NAMESPACE")
+			Expect(os.Setenv("POD_NAMESPACE", "vmop-system")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.Setenv("POD_NAMESPACE", oldNamespace)).To(Succeed())
+		})
+
+		It("returns the default rules without the ConfigMap", func() {
+			rules, err := auth.GetPrivilegedFieldRules(context.Background(), builder.NewFakeClient())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rules).To(Equal(auth.DefaultPrivilegedFieldRules))
+		})
+
+		It("replaces and extends the default rules with the ConfigMap rules", func() {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vmop-system", Name: auth.PrivilegedFieldsConfigMapName},
+				Data: map[string]string{
+					auth.PrivilegedFieldsRulesKey: "- annotation: " + vmopv1alpha1.PauseAnnotation + "\n" +
+						"  groups: [admins]\n" +
+						"- field: spec.advancedOptions\n" +
+						"  kinds: [VirtualMachine]\n",
+				},
+			}
+			rules, err := auth.GetPrivilegedFieldRules(context.Background(), builder.NewFakeClient(configMap))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rules).To(HaveLen(len(auth.DefaultPrivilegedFieldRules) + 1))
+			Expect(rules).To(ContainElement(auth.PrivilegedFieldRule{Annotation: vmopv1alpha1.PauseAnnotation, Groups: []string{"admins"}}))
+			Expect(rules).To(ContainElement(auth.PrivilegedFieldRule{Field: "spec.advancedOptions", Kinds: []string{"VirtualMachine"}}))
+		})
+
+		It("returns an error when a ConfigMap rule field is a path through a list", func() {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vmop-system", Name: auth.PrivilegedFieldsConfigMapName},
+				Data: map[string]string{
+					auth.PrivilegedFieldsRulesKey: "- field: spec.networkInterfaces.networkName\n" +
+						"  kinds: [VirtualMachine]\n",
+				},
+			}
+			_, err := auth.GetPrivilegedFieldRules(context.Background(), builder.NewFakeClient(configMap))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.networkInterfaces.networkName"))
+		})
+
+		It("returns an error when the ConfigMap rules are invalid", func() {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vmop-system", Name: auth.PrivilegedFieldsConfigMapName},
+				Data:       map[string]string{auth.PrivilegedFieldsRulesKey: "invalid"},
+			}
+			_, err := auth.GetPrivilegedFieldRules(context.Background(), builder.NewFakeClient(configMap))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("DefaultPrivilegedFieldRules", func() {
+		It("lists every VM Operator annotation that is not explicitly unprivileged", func() {
+			classified := map[string]bool{}
+			for _, rule := range auth.DefaultPrivilegedFieldRules {
+				classified[rule.Annotation] = true
+			}
+			for _, annotation := range auth.UnprivilegedAnnotations {
+				Expect(classified).ToNot(HaveKey(annotation), "%s is both privileged and unprivileged", annotation)
+				classified[annotation] = true
+			}
+
+			annotations := vmOperatorAnnotationConstants("../vmprovider/providers/vsphere/constants/constants.go")
+			Expect(annotations).ToNot(BeEmpty())
+			for name, annotation := range annotations {
+				Expect(classified).To(HaveKey(annotation),
+					"%s must be in DefaultPrivilegedFieldRules or UnprivilegedAnnotations", name)
+			}
+		})
+	})
+})
+
+// vmOperatorAnnotationConstants returns the string constants of the file that are VM Operator annotation keys, by
+// name. The labels are skipped.
+func vmOperatorAnnotationConstants(filename string) map[string]string {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+	Expect(err).ToNot(HaveOccurred())
+
+	annotations := map[string]string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != 1 {
+			return true
+		}
+
+		var value string
+		switch v := spec.Values[0].(type) {
+		case *ast.BasicLit:
+			value, _ = strconv.Unquote(v.Value)
+		case *ast.BinaryExpr:
+			// pkg.VMOperatorKey + "/<name>"
+			if sel, ok := v.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "VMOperatorKey" {
+				if lit, ok := v.Y.(*ast.BasicLit); ok {
+					suffix, _ := strconv.Unquote(lit.Value)
+					value = pkg.VMOperatorKey + suffix
+				}
+			}
+		}
+
+		name := spec.Names[0].Name
+		if strings.HasPrefix(value, pkg.VMOperatorKey+"/") && !strings.Contains(name, "Label") {
+			annotations[name] = value
+		}
+		return true
+	})
+	return annotations
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/record"
+)
+
+// ConversionWebhook is a webhook that converts resources between the served versions of their CRD.
+type ConversionWebhook struct {
+	http.Handler
+
+	// Name is the name of the webhook.
+	Name string
+
+	// Path is the path of the webhook.
+	Path string
+}
+
+// Converter is used to create a new webhook for converting resources.
+type Converter interface {
+	// For returns the GroupKind for which this webhook converts resources.
+	For() schema.GroupKind
+
+	// Convert returns the object of the request converted to the desired API version.
+	Convert(ctx *context.WebhookRequestContext, desiredAPIVersion string) (*unstructured.Unstructured, error)
+}
+
+// NewConversionWebhook returns a new webhook for converting resources.
+func NewConversionWebhook(
+	ctx *context.ControllerManagerContext,
+	mgr ctrlmgr.Manager,
+	webhookName string,
+	converter Converter) (*ConversionWebhook, error) {
+	if webhookName == "" {
+		return nil, errors.New("webhookName arg is empty")
+	}
+	if converter == nil {
+		return nil, errors.New("converter arg is nil")
+	}
+
+	var (
+		webhookNameShort = generateConvertName(webhookName, converter.For())
+		webhookPath      = "/" + webhookNameShort
+		webhookNameLong  = fmt.Sprintf("%s/%s/%s", ctx.Namespace, ctx.Name, webhookNameShort)
+	)
+
+	// Build the webhookContext.
+	webhookContext := &context.WebhookContext{
+		Context:  ctx,
+		Name:     webhookNameShort,
+		Recorder: record.New(mgr.GetEventRecorderFor(webhookNameLong)),
+		Logger:   ctx.Logger.WithName(webhookNameShort),
+	}
+
+	// Create the webhook.
+	return &ConversionWebhook{
+		Name: webhookNameShort,
+		Path: webhookPath,
+		Handler: &conversionWebhookHandler{
+			WebhookContext: webhookContext,
+			Converter:      converter,
+		},
+	}, nil
+}
+
+var _ http.Handler = &conversionWebhookHandler{}
+
+type conversionWebhookHandler struct {
+	*context.WebhookContext
+	Converter
+}
+
+func (h *conversionWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Converter == nil {
+		panic("converter should never be nil")
+	}
+
+	review := &apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+		h.Logger.Error(err, "failed to decode ConversionReview")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "ConversionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.HandleConvert(review.Request)
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		h.Logger.Error(err, "failed to encode ConversionReview")
+	}
+}
+
+// HandleConvert converts the objects of the request to its desired API version. No objects are returned when any of
+// them fails to convert.
+func (h *conversionWebhookHandler) HandleConvert(req *apiextensionsv1.ConversionRequest) *apiextensionsv1.ConversionResponse {
+	resp := &apiextensionsv1.ConversionResponse{
+		UID: req.UID,
+	}
+
+	converted := make([]runtime.RawExtension, 0, len(req.Objects))
+	for i := range req.Objects {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(req.Objects[i].Raw); err != nil {
+			resp.Result = conversionFailure(err)
+			return resp
+		}
+
+		// Create the webhook request context.
+		webhookRequestContext := &context.WebhookRequestContext{
+			WebhookContext: h.WebhookContext,
+			Obj:            obj,
+			Logger:         h.WebhookContext.Logger.WithName(obj.GetNamespace()).WithName(obj.GetName()),
+		}
+
+		convertedObj, err := h.Convert(webhookRequestContext, req.DesiredAPIVersion)
+		if err != nil {
+			webhookRequestContext.Logger.Error(err, "failed to convert object",
+				"apiVersion", obj.GetAPIVersion(), "desiredAPIVersion", req.DesiredAPIVersion)
+			resp.Result = conversionFailure(err)
+			return resp
+		}
+
+		raw, err := convertedObj.MarshalJSON()
+		if err != nil {
+			resp.Result = conversionFailure(err)
+			return resp
+		}
+		converted = append(converted, runtime.RawExtension{Raw: raw})
+	}
+
+	resp.ConvertedObjects = converted
+	resp.Result = metav1.Status{Status: metav1.StatusSuccess}
+	return resp
+}
+
+func conversionFailure(err error) metav1.Status {
+	return metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: err.Error(),
+	}
+}
+
+func generateConvertName(webhookName string, gk schema.GroupKind) string {
+	return fmt.Sprintf("%s-convert-", webhookName) +
+		strings.ReplaceAll(gk.Group, ".", "-") + "-" + strings.ToLower(gk.Kind)
+}
@@ -113,9 +113,21 @@ func (h *mutatingWebhookHandler) Handle(_ goctx.Context, req admission.Request)
 		return admission.Allowed(AdmitMesgUpdateOnDeleting)
 	}
 
+	// Get the old Object for Update operations.
+	var oldObj *unstructured.Unstructured
+	if req.Operation == admissionv1.Update {
+		oldObj = &unstructured.Unstructured{}
+		if err := h.DecodeRaw(req.OldObject, oldObj); err != nil {
+			return webhook.Errored(http.StatusBadRequest, err)
+		}
+	}
+
 	webhookRequestContext := &context.WebhookRequestContext{
 		WebhookContext: h.WebhookContext,
 		Obj:            obj,
+		OldObj:         oldObj,
+		Logger:         h.WebhookContext.Logger.WithName(obj.GetNamespace()).WithName(obj.GetName()),
+		UserInfo:       &req.UserInfo,
 	}
 
 	return h.Mutate(webhookRequestContext)
@@ -20,6 +20,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/vmware-tanzu/vm-operator/pkg/auth"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
 	"github.com/vmware-tanzu/vm-operator/pkg/record"
 )
@@ -36,17 +37,22 @@ type ValidatingWebhook struct {
 }
 
 // Validator is used to create a new admissions webhook for validating requests.
+//
+// A Validator may return warnings in the Warnings of the admission.Response for
+// configurations that are allowed but are deprecated or risky. The warnings are
+// returned to the client whether or not the request is allowed, and are shown
+// by kubectl.
 type Validator interface {
 	// For returns the GroupVersionKind for which this webhook validates requests.
 	For() schema.GroupVersionKind
 
-	// ValidateCreate returns nil if the request is valid.
+	// ValidateCreate returns an allowed response if the request is valid.
 	ValidateCreate(*context.WebhookRequestContext) admission.Response
 
-	// ValidateDelete returns nil if the request is valid.
+	// ValidateDelete returns an allowed response if the request is valid.
 	ValidateDelete(*context.WebhookRequestContext) admission.Response
 
-	// ValidateUpdate returns nil if the request is valid.
+	// ValidateUpdate returns an allowed response if the request is valid.
 	ValidateUpdate(*context.WebhookRequestContext) admission.Response
 }
 
@@ -94,6 +100,7 @@ func NewValidatingWebhook(
 				WebhookContext: webhookContext,
 				Decoder:        decoder,
 				Validator:      validator,
+				Client:         mgr.GetClient(),
 			},
 		},
 	}, nil
@@ -105,6 +112,7 @@ type validatingWebhookHandler struct {
 	*context.WebhookContext
 	*admission.Decoder
 	Validator
+	Client client.Client
 }
 
 func (h *validatingWebhookHandler) Handle(_ goctx.Context, req admission.Request) admission.Response {
@@ -155,12 +163,17 @@ func (h *validatingWebhookHandler) Handle(_ goctx.Context, req admission.Request
 		UserInfo:       &req.UserInfo,
 	}
 
-	return h.HandleValidate(req, webhookRequestContext)
+	response := h.HandleValidate(req, webhookRequestContext)
+	response.Warnings = uniqueWarnings(response.Warnings)
+	return response
 }
 
 func (h *validatingWebhookHandler) HandleValidate(req admission.Request, ctx *context.WebhookRequestContext) admission.Response {
 	switch req.Operation {
 	case admissionv1.Create:
+		if response := h.ValidatePrivilegedFields(ctx); !response.Allowed {
+			return response
+		}
 		return h.ValidateCreate(ctx)
 	case admissionv1.Update:
 		// Allow the Patch/Update requests if the object is under deletion. This eliminates queueing objects
@@ -168,6 +181,9 @@ func (h *validatingWebhookHandler) HandleValidate(req admission.Request, ctx *co
 		if !ctx.Obj.GetDeletionTimestamp().IsZero() {
 			return admission.Allowed(AdmitMesgUpdateOnDeleting)
 		}
+		if response := h.ValidatePrivilegedFields(ctx); !response.Allowed {
+			return response
+		}
 		return h.ValidateUpdate(ctx)
 	case admissionv1.Delete:
 		return h.ValidateDelete(ctx)
@@ -176,6 +192,52 @@ func (h *validatingWebhookHandler) HandleValidate(req admission.Request, ctx *co
 	}
 }
 
+// ValidatePrivilegedFields denies the request when the user modifies a privileged annotation or field that the
+// privileged fields policy does not grant to the user. It is enforced for all the resources that have a validating
+// webhook, before their Validator is invoked.
+func (h *validatingWebhookHandler) ValidatePrivilegedFields(ctx *context.WebhookRequestContext) admission.Response {
+	if h.Client == nil {
+		return admission.Allowed("")
+	}
+
+	rules, err := auth.GetPrivilegedFieldRules(ctx, h.Client)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	denied := auth.DeniedPrivilegedFields(rules, *ctx.UserInfo, ctx.Obj, ctx.OldObj)
+	if len(denied) == 0 {
+		return admission.Allowed("")
+	}
+
+	messages := make([]string, 0, len(denied))
+	for _, rule := range denied {
+		messages = append(messages, auth.PrivilegedFieldDeniedMessage(rule, *ctx.UserInfo))
+		ctx.Logger.Info("privileged field modification denied",
+			"kind", ctx.Obj.GetKind(), "field", rule.String(),
+			"username", ctx.UserInfo.Username, "groups", ctx.UserInfo.Groups)
+	}
+	return admission.Denied(strings.Join(messages, ", "))
+}
+
+// uniqueWarnings returns the warnings without duplicates, in the order they were first returned.
+func uniqueWarnings(warnings []string) []string {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(warnings))
+	unique := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		if _, ok := seen[w]; ok {
+			continue
+		}
+		seen[w] = struct{}{}
+		unique = append(unique, w)
+	}
+	return unique
+}
+
 func generateValidateName(webhookName string, gvk schema.GroupVersionKind) string {
 	return fmt.Sprintf("%s-validate-", webhookName) +
 		strings.ReplaceAll(gvk.Group, ".", "-") + "-" +
@@ -0,0 +1,175 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package builder
+
+import (
+	goctx "context"
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/auth"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	fakectx "github.com/vmware-tanzu/vm-operator/pkg/context/fake"
+)
+
+// allowingValidator allows all the requests and counts the requests it validates.
+type allowingValidator struct {
+	validated int
+}
+
+func (v *allowingValidator) For() schema.GroupVersionKind {
+	return vmopv1alpha1.SchemeGroupVersion.WithKind("VirtualMachine")
+}
+
+func (v *allowingValidator) ValidateCreate(*context.WebhookRequestContext) admission.Response {
+	v.validated++
+	return admission.Allowed("")
+}
+
+func (v *allowingValidator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
+	v.validated++
+	return admission.Allowed("")
+}
+
+func (v *allowingValidator) ValidateUpdate(*context.WebhookRequestContext) admission.Response {
+	v.validated++
+	return admission.Allowed("")
+}
+
+var _ = Describe("Validating webhook handler", func() {
+	var (
+		initObjects  []client.Object
+		validator    *allowingValidator
+		handler      *validatingWebhookHandler
+		vm           *vmopv1alpha1.VirtualMachine
+		userInfo     authv1.UserInfo
+		response     admission.Response
+		oldNamespace string
+	)
+
+	BeforeEach(func() {
+		oldNamespace = os.Getenv("POD_NAMESPACE")
+		Expect(os.Setenv("POD_NAMESPACE", "vmop-system")).To(Succeed())
+
+		initObjects = nil
+		validator = &allowingValidator{}
+		vm = &vmopv1alpha1.VirtualMachine{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: vmopv1alpha1.SchemeGroupVersion.String(),
+				Kind:       "VirtualMachine",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "dummy-vm",
+				Namespace:   "dummy-ns",
+				Annotations: map[string]string{vmopv1alpha1.PauseAnnotation: ""},
+			},
+		}
+		userInfo = authv1.UserInfo{Username: "sso:devUser1@vsphere.local", Groups: []string{"sso:Developers@vsphere.local"}}
+	})
+
+	AfterEach(func() {
+		Expect(os.Setenv("POD_NAMESPACE", oldNamespace)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(vmopv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		decoder, err := admission.NewDecoder(scheme)
+		Expect(err).ToNot(HaveOccurred())
+
+		handler = &validatingWebhookHandler{
+			WebhookContext: fakectx.NewWebhookContext(fakectx.NewControllerManagerContext(scheme)),
+			Decoder:        decoder,
+			Validator:      validator,
+			Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build(),
+		}
+
+		raw, err := json.Marshal(vm)
+		Expect(err).ToNot(HaveOccurred())
+
+		response = handler.Handle(goctx.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+				UserInfo:  userInfo,
+			},
+		})
+	})
+
+	When("a user creates a VirtualMachine with a privileged annotation", func() {
+		It("denies the request without invoking the validator", func() {
+			Expect(response.Allowed).To(BeFalse())
+			Expect(string(response.Result.Reason)).To(ContainSubstring(
+				`user "sso:devUser1@vsphere.local" with groups [sso:Developers@vsphere.local] is not allowed to modify ` +
+					`annotation ` + vmopv1alpha1.PauseAnnotation))
+			Expect(validator.validated).To(BeZero())
+		})
+	})
+
+	When("the Kubernetes administrator creates a VirtualMachine with a privileged annotation", func() {
+		BeforeEach(func() {
+			userInfo = authv1.UserInfo{Username: auth.KubeAdminUser}
+		})
+
+		It("allows the request", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(validator.validated).To(Equal(1))
+		})
+	})
+
+	When("the ConfigMap grants the privileged annotation to the group of the user", func() {
+		BeforeEach(func() {
+			initObjects = append(initObjects, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vmop-system", Name: auth.PrivilegedFieldsConfigMapName},
+				Data: map[string]string{
+					auth.PrivilegedFieldsRulesKey: "- annotation: " + vmopv1alpha1.PauseAnnotation + "\n" +
+						"  groups: [sso:Developers@vsphere.local]\n",
+				},
+			})
+		})
+
+		It("allows the request", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(validator.validated).To(Equal(1))
+		})
+	})
+
+	When("the ConfigMap has a rule whose field is a path through a list", func() {
+		BeforeEach(func() {
+			initObjects = append(initObjects, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vmop-system", Name: auth.PrivilegedFieldsConfigMapName},
+				Data: map[string]string{
+					auth.PrivilegedFieldsRulesKey: "- field: spec.volumes.name\n" +
+						"  kinds: [VirtualMachine]\n",
+				},
+			})
+		})
+
+		It("fails the request", func() {
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Code).To(BeEquivalentTo(http.StatusInternalServerError))
+			Expect(validator.validated).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,24 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package context
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+// VirtualMachineImageContext is the context used for VirtualMachineImageControllers.
+type VirtualMachineImageContext struct {
+	context.Context
+	Logger  logr.Logger
+	VMImage *vmopv1.VirtualMachineImage
+}
+
+func (v *VirtualMachineImageContext) String() string {
+	return fmt.Sprintf("%s %s", v.VMImage.GroupVersionKind(), v.VMImage.Name)
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package context
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+)
+
+// VirtualMachineImageImportContext is the context used for VirtualMachineImageImportControllers.
+type VirtualMachineImageImportContext struct {
+	context.Context
+	Logger      logr.Logger
+	ImageImport *vmopapiv1alpha1.VirtualMachineImageImport
+}
+
+func (v *VirtualMachineImageImportContext) String() string {
+	return fmt.Sprintf("%s %s", v.ImageImport.GroupVersionKind(), v.ImageImport.Name)
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package context
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+)
+
+// VirtualMachineQuotaContext is the context used for VirtualMachineQuotaControllers.
+type VirtualMachineQuotaContext struct {
+	context.Context
+	Logger logr.Logger
+	Quota  *vmopapiv1alpha1.VirtualMachineQuota
+}
+
+func (v *VirtualMachineQuotaContext) String() string {
+	return fmt.Sprintf("%s %s/%s", v.Quota.GroupVersionKind(), v.Quota.Namespace, v.Quota.Name)
+}
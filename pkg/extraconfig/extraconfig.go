@@ -0,0 +1,187 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package extraconfig
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+// ProtectedKeys are the ExtraConfig keys that only administrators can set, whatever the policy of the namespace.
+// The keys of every PCI passthrough device, such as pciPassthru0.present, are protected by pciPassthru*.
+var ProtectedKeys = []string{
+	"disk.enableUUID",
+	"pciPassthru*",
+	constants.MMPowerOffVMExtraConfigKey,
+	"guestinfo.vmservice.*",
+	"tools.deployPkg.*",
+	"vmware.tools.gosc.*",
+}
+
+// FromAnnotations returns the ExtraConfig of the ExtraConfigAnnotation. It is nil when the annotation is not set.
+func FromAnnotations(annotations map[string]string) (map[string]string, error) {
+	value, ok := annotations[constants.ExtraConfigAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	extraConfig := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &extraConfig); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse annotation %s", constants.ExtraConfigAnnotation)
+	}
+	return extraConfig, nil
+}
+
+// Merge returns the union of the ExtraConfigs. The value of a key is the one of the last ExtraConfig that has it.
+func Merge(extraConfigs ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, extraConfig := range extraConfigs {
+		for k, v := range extraConfig {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// IsKeyAllowed returns true when a non-administrator can set the key under the policy. The policy can be nil. Keys
+// are matched regardless of case, like vSphere treats ExtraConfig keys.
+func IsKeyAllowed(key string, policy *vmopapiv1alpha1.ExtraConfigPolicy) bool {
+	if IsKeyProtected(key) {
+		return false
+	}
+	if policy == nil {
+		return true
+	}
+	if matchesAny(key, policy.DeniedKeys) {
+		return false
+	}
+	return len(policy.AllowedKeys) == 0 || matchesAny(key, policy.AllowedKeys)
+}
+
+// IsKeyProtected returns true when the key is one of the ProtectedKeys.
+func IsKeyProtected(key string) bool {
+	return matchesAny(key, ProtectedKeys)
+}
+
+func matchesAny(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		if matched, err := path.Match(strings.ToLower(pattern), key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDefaults returns the VirtualMachineDefaults of the namespace. It is nil when the namespace has none.
+func GetDefaults(
+	ctx context.Context,
+	client ctrlclient.Client,
+	namespace string) (*vmopapiv1alpha1.VirtualMachineDefaults, error) {
+
+	defaults := &vmopapiv1alpha1.VirtualMachineDefaults{}
+	key := ctrlclient.ObjectKey{Namespace: namespace, Name: vmopapiv1alpha1.VirtualMachineDefaultsName}
+	if err := client.Get(ctx, key, defaults); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get VirtualMachineDefaults %s", key)
+	}
+	return defaults, nil
+}
+
+// NotAllowedKeys returns the sorted keys of the ExtraConfig that are added or changed from the old ExtraConfig, which
+// is nil on create, and that the ExtraConfig policy of the namespace does not allow a non-administrator to set.
+func NotAllowedKeys(
+	ctx context.Context,
+	client ctrlclient.Client,
+	namespace string,
+	extraConfig, oldExtraConfig map[string]string) ([]string, error) {
+
+	var changedKeys []string
+	for k, v := range extraConfig {
+		if oldV, ok := oldExtraConfig[k]; !ok || oldV != v {
+			changedKeys = append(changedKeys, k)
+		}
+	}
+	if len(changedKeys) == 0 {
+		return nil, nil
+	}
+	sort.Strings(changedKeys)
+
+	defaults, err := GetDefaults(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var policy *vmopapiv1alpha1.ExtraConfigPolicy
+	if defaults != nil {
+		policy = defaults.Spec.ExtraConfigPolicy
+	}
+
+	var notAllowed []string
+	for _, k := range changedKeys {
+		if !IsKeyAllowed(k, policy) {
+			notAllowed = append(notAllowed, k)
+		}
+	}
+	return notAllowed, nil
+}
+
+// withoutProtectedKeys returns the ExtraConfig without the ProtectedKeys.
+func withoutProtectedKeys(extraConfig map[string]string) map[string]string {
+	filtered := make(map[string]string, len(extraConfig))
+	for k, v := range extraConfig {
+		if !IsKeyProtected(k) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// ForVM returns the ExtraConfig of the VirtualMachine, merged from the ExtraConfig of the VirtualMachineDefaults of
+// its namespace, of its VirtualMachineClass and of the VirtualMachine. The ProtectedKeys are only taken from the
+// VirtualMachine, whose validation webhook denies them to non-administrators, so that setting them on the
+// namespace or class does not apply them to every VirtualMachine.
+func ForVM(
+	ctx context.Context,
+	client ctrlclient.Client,
+	vm *vmopv1alpha1.VirtualMachine,
+	vmClass *vmopv1alpha1.VirtualMachineClass) (map[string]string, error) {
+
+	defaults, err := GetDefaults(ctx, client, vm.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	var namespaceExtraConfig map[string]string
+	if defaults != nil {
+		namespaceExtraConfig = defaults.Spec.ExtraConfig
+	}
+
+	var classExtraConfig map[string]string
+	if vmClass != nil {
+		if classExtraConfig, err = FromAnnotations(vmClass.Annotations); err != nil {
+			return nil, errors.Wrapf(err, "invalid ExtraConfig of VirtualMachineClass %s", vmClass.Name)
+		}
+	}
+
+	vmExtraConfig, err := FromAnnotations(vm.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	return Merge(withoutProtectedKeys(namespaceExtraConfig), withoutProtectedKeys(classExtraConfig), vmExtraConfig), nil
+}
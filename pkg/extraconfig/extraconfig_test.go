@@ -0,0 +1,161 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package extraconfig_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/extraconfig"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+var _ = Describe("ExtraConfig", func() {
+
+	Context("FromAnnotations", func() {
+		It("returns nil when the annotation is not set", func() {
+			extraConfig, err := extraconfig.FromAnnotations(map[string]string{"foo": "bar"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extraConfig).To(BeNil())
+		})
+
+		It("parses the annotation", func() {
+			extraConfig, err := extraconfig.FromAnnotations(map[string]string{
+				constants.ExtraConfigAnnotation: `{"foo":"bar"}`,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extraConfig).To(Equal(map[string]string{"foo": "bar"}))
+		})
+
+		It("returns an error when the annotation is not a JSON object of strings", func() {
+			_, err := extraconfig.FromAnnotations(map[string]string{
+				constants.ExtraConfigAnnotation: `{"foo":1}`,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Merge", func() {
+		It("takes the value of the last ExtraConfig", func() {
+			merged := extraconfig.Merge(
+				map[string]string{"a": "1", "b": "1"},
+				nil,
+				map[string]string{"b": "2", "c": "2"})
+			Expect(merged).To(Equal(map[string]string{"a": "1", "b": "2", "c": "2"}))
+		})
+	})
+
+	DescribeTable("IsKeyAllowed",
+		func(key string, policy *vmopapiv1alpha1.ExtraConfigPolicy, expected bool) {
+			Expect(extraconfig.IsKeyAllowed(key, policy)).To(Equal(expected))
+		},
+		Entry("key without policy", "foo", nil, true),
+		Entry("protected key", "disk.enableUUID", nil, false),
+		Entry("protected key of a numbered device", "pciPassthru0.present", nil, false),
+		Entry("protected key matching pattern", "pciPassthru.use64bitMMIO", nil, false),
+		Entry("protected key in another case", "Disk.EnableUUID", nil, false),
+		Entry("protected key pattern in another case", "PCIPASSTHRU1.deviceId", nil, false),
+		Entry("denied key in another case", "Foo.bar",
+			&vmopapiv1alpha1.ExtraConfigPolicy{DeniedKeys: []string{"foo.*"}}, false),
+		Entry("denied key", "foo.bar",
+			&vmopapiv1alpha1.ExtraConfigPolicy{DeniedKeys: []string{"foo.*"}}, false),
+		Entry("key not denied", "bar",
+			&vmopapiv1alpha1.ExtraConfigPolicy{DeniedKeys: []string{"foo.*"}}, true),
+		Entry("allowed key", "guestinfo.foo",
+			&vmopapiv1alpha1.ExtraConfigPolicy{AllowedKeys: []string{"guestinfo.*"}}, true),
+		Entry("key not allowed", "foo",
+			&vmopapiv1alpha1.ExtraConfigPolicy{AllowedKeys: []string{"guestinfo.*"}}, false),
+		Entry("allowed and denied key", "guestinfo.foo",
+			&vmopapiv1alpha1.ExtraConfigPolicy{AllowedKeys: []string{"guestinfo.*"}, DeniedKeys: []string{"guestinfo.foo"}},
+			false),
+		Entry("allowed protected key", "disk.enableUUID",
+			&vmopapiv1alpha1.ExtraConfigPolicy{AllowedKeys: []string{"disk.*"}}, false),
+	)
+
+	Context("ForVM", func() {
+		var (
+			vm       *vmopv1alpha1.VirtualMachine
+			vmClass  *vmopv1alpha1.VirtualMachineClass
+			defaults *vmopapiv1alpha1.VirtualMachineDefaults
+		)
+
+		BeforeEach(func() {
+			vm = &vmopv1alpha1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vm",
+					Namespace: "ns",
+					Annotations: map[string]string{
+						constants.ExtraConfigAnnotation: `{"vm":"vm","class":"vm"}`,
+					},
+				},
+			}
+			vmClass = &vmopv1alpha1.VirtualMachineClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "class",
+					Annotations: map[string]string{
+						constants.ExtraConfigAnnotation: `{"class":"class","namespace":"class"}`,
+					},
+				},
+			}
+			defaults = &vmopapiv1alpha1.VirtualMachineDefaults{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vmopapiv1alpha1.VirtualMachineDefaultsName,
+					Namespace: "ns",
+				},
+				Spec: vmopapiv1alpha1.VirtualMachineDefaultsSpec{
+					ExtraConfig: map[string]string{"namespace": "namespace", "default": "namespace"},
+				},
+			}
+		})
+
+		It("merges the namespace, class and VM ExtraConfig", func() {
+			client := builder.NewFakeClient(defaults)
+			extraConfig, err := extraconfig.ForVM(context.Background(), client, vm, vmClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extraConfig).To(Equal(map[string]string{
+				"vm":        "vm",
+				"class":     "vm",
+				"namespace": "class",
+				"default":   "namespace",
+			}))
+		})
+
+		It("does not take the protected keys from the namespace and class", func() {
+			defaults.Spec.ExtraConfig["disk.enableUUID"] = "TRUE"
+			vmClass.Annotations[constants.ExtraConfigAnnotation] = `{"pciPassthru0.present":"TRUE"}`
+			vm.Annotations[constants.ExtraConfigAnnotation] = `{"pciPassthru1.present":"TRUE"}`
+			client := builder.NewFakeClient(defaults)
+			extraConfig, err := extraconfig.ForVM(context.Background(), client, vm, vmClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extraConfig).To(Equal(map[string]string{
+				"namespace":            "namespace",
+				"default":              "namespace",
+				"pciPassthru1.present": "TRUE",
+			}))
+		})
+
+		It("ignores missing namespace defaults and class", func() {
+			client := builder.NewFakeClient()
+			extraConfig, err := extraconfig.ForVM(context.Background(), client, vm, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extraConfig).To(Equal(map[string]string{"vm": "vm", "class": "vm"}))
+		})
+
+		It("returns an error when the class annotation is invalid", func() {
+			vmClass.Annotations[constants.ExtraConfigAnnotation] = "invalid"
+			client := builder.NewFakeClient(defaults)
+			_, err := extraconfig.ForVM(context.Background(), client, vm, vmClass)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -45,6 +45,68 @@ const (
 	InstanceStorageSeedRequeueDurationEnv = "INSTANCE_STORAGE_SEED_REQUEUE_DURATION"
 	// DefaultInstanceStorageSeedRequeueDuration is the default seed requeue duration for instance storage.
 	DefaultInstanceStorageSeedRequeueDuration = 10 * time.Second
+
+	// OrphanedVMScanIntervalEnv is the env variable for setting how often each namespace is scanned for
+	// orphaned VMs.
+	OrphanedVMScanIntervalEnv = "ORPHANED_VM_SCAN_INTERVAL"
+	// DefaultOrphanedVMScanInterval is the default orphaned VM scan interval.
+	DefaultOrphanedVMScanInterval = 10 * time.Minute
+	// OrphanedVMDeleteGracePeriodEnv is the env variable for setting how long a VM must remain orphaned
+	// before it is deleted, when deletion is enabled for the namespace.
+	OrphanedVMDeleteGracePeriodEnv = "ORPHANED_VM_DELETE_GRACE_PERIOD"
+	// DefaultOrphanedVMDeleteGracePeriod is the default orphaned VM delete grace period.
+	DefaultOrphanedVMDeleteGracePeriod = time.Hour
+
+	// VMTerminationGracePeriodEnv is the env variable for setting how long to wait for the guest OS to shut
+	// down before a VM is powered off and deleted.
+	VMTerminationGracePeriodEnv = "VM_TERMINATION_GRACE_PERIOD"
+	// DefaultVMTerminationGracePeriod is the default VM termination grace period.
+	DefaultVMTerminationGracePeriod = 30 * time.Second
+	// MaxVMTerminationGracePeriod is the longest termination grace period that a VM can request with its
+	// annotation, so that a VM cannot hold up its deletion and the resources of its namespace indefinitely.
+	MaxVMTerminationGracePeriod = time.Hour
+
+	// VMRestoreScanIntervalEnv is the env variable for setting how often each namespace is scanned for
+	// restored VMs that have no VirtualMachine resource.
+	VMRestoreScanIntervalEnv = "VM_RESTORE_SCAN_INTERVAL"
+	// DefaultVMRestoreScanInterval is the default restored VM scan interval.
+	DefaultVMRestoreScanInterval = time.Minute
+
+	// ContentSourceResyncIntervalEnv is the env variable for setting how often the images of each ContentSource
+	// are resynced with its content library. A zero interval disables the periodic resync.
+	ContentSourceResyncIntervalEnv = "CONTENT_SOURCE_RESYNC_INTERVAL"
+	// DefaultContentSourceResyncInterval is the default ContentSource resync interval.
+	DefaultContentSourceResyncInterval = 10 * time.Minute
+
+	// ImageTrustBundlePathEnv is the env variable for setting the path of the PEM encoded CA certificates that
+	// the signing certificates of images are verified against. The system roots are used when it is not set.
+	ImageTrustBundlePathEnv = "IMAGE_TRUST_BUNDLE_PATH"
+
+	// ImageTrustPolicyEnv is the env variable for setting the cluster wide policy for deploying VMs from images
+	// that are unsigned or not trusted. See VirtualMachineImageTrustPolicy for its values.
+	ImageTrustPolicyEnv = "IMAGE_TRUST_POLICY"
+
+	// LinkedCloneImageCacheEnv is the env variable for enabling VMs to be linked cloned from template VMs
+	// that cache their image on each datastore, instead of deploying each VM from the content library.
+	LinkedCloneImageCacheEnv = "LINKED_CLONE_IMAGE_CACHE"
+
+	// ImageCacheSyncIntervalEnv is the env variable for setting how often the image cache of each namespace
+	// is refreshed and evicted.
+	ImageCacheSyncIntervalEnv = "IMAGE_CACHE_SYNC_INTERVAL"
+	// DefaultImageCacheSyncInterval is the default image cache sync interval.
+	DefaultImageCacheSyncInterval = 30 * time.Minute
+
+	// ImageCacheMaxUnusedAgeEnv is the env variable for setting how long a cached image may go without a VM
+	// being cloned from it before it is evicted.
+	ImageCacheMaxUnusedAgeEnv = "IMAGE_CACHE_MAX_UNUSED_AGE"
+	// DefaultImageCacheMaxUnusedAge is the default image cache max unused age.
+	DefaultImageCacheMaxUnusedAge = 24 * time.Hour
+
+	// VMClassAvailabilityCheckIntervalEnv is the env variable for setting how often each VirtualMachineClass is
+	// checked for whether its VMs can be realized.
+	VMClassAvailabilityCheckIntervalEnv = "VM_CLASS_AVAILABILITY_CHECK_INTERVAL"
+	// DefaultVMClassAvailabilityCheckInterval is the default VirtualMachineClass availability check interval.
+	DefaultVMClassAvailabilityCheckInterval = 10 * time.Minute
 )
 
 // SetVMOpNamespaceEnv sets the VM Operator pod's namespace in the environment.
@@ -89,6 +151,10 @@ var IsVMServiceBackupRestoreFSSEnabled = func() bool {
 	return os.Getenv(VMServiceBackupRestoreFSS) == trueString
 }
 
+var IsLinkedCloneImageCacheEnabled = func() bool {
+	return os.Getenv(LinkedCloneImageCacheEnv) == trueString
+}
+
 // MaxConcurrentCreateVMsOnProvider returns the percentage of reconciler threads that can be used to create VMs on the provider
 // concurrently. The default is 80.
 // TODO: Remove the env lookup once we have tuned this value from system tests.
@@ -136,3 +202,96 @@ func GetInstanceStorageRequeueDelay() time.Duration {
 
 	return wait.Jitter(seedDuration, maxFactor)
 }
+
+// GetOrphanedVMScanInterval returns the configured interval between orphaned VM scans of a namespace.
+func GetOrphanedVMScanInterval() time.Duration {
+	if interval := os.Getenv(OrphanedVMScanIntervalEnv); len(interval) > 0 {
+		if duration, err := time.ParseDuration(interval); err == nil {
+			return duration
+		}
+	}
+	return DefaultOrphanedVMScanInterval
+}
+
+// GetOrphanedVMDeleteGracePeriod returns the configured time a VM must remain orphaned before it is deleted.
+func GetOrphanedVMDeleteGracePeriod() time.Duration {
+	if gracePeriod := os.Getenv(OrphanedVMDeleteGracePeriodEnv); len(gracePeriod) > 0 {
+		if duration, err := time.ParseDuration(gracePeriod); err == nil {
+			return duration
+		}
+	}
+	return DefaultOrphanedVMDeleteGracePeriod
+}
+
+// GetVMTerminationGracePeriod returns the configured time to wait for the guest OS to shut down before a VM
+// is powered off and deleted.
+func GetVMTerminationGracePeriod() time.Duration {
+	if gracePeriod := os.Getenv(VMTerminationGracePeriodEnv); len(gracePeriod) > 0 {
+		if duration, err := time.ParseDuration(gracePeriod); err == nil {
+			return duration
+		}
+	}
+	return DefaultVMTerminationGracePeriod
+}
+
+// GetVMRestoreScanInterval returns the configured interval between restored VM scans of a namespace.
+func GetVMRestoreScanInterval() time.Duration {
+	if interval := os.Getenv(VMRestoreScanIntervalEnv); len(interval) > 0 {
+		if duration, err := time.ParseDuration(interval); err == nil {
+			return duration
+		}
+	}
+	return DefaultVMRestoreScanInterval
+}
+
+// GetContentSourceResyncInterval returns the configured interval between syncs of the images of a ContentSource.
+func GetContentSourceResyncInterval() time.Duration {
+	if interval := os.Getenv(ContentSourceResyncIntervalEnv); len(interval) > 0 {
+		if duration, err := time.ParseDuration(interval); err == nil {
+			return duration
+		}
+	}
+	return DefaultContentSourceResyncInterval
+}
+
+// GetImageTrustBundlePath returns the configured path of the image trust bundle, or an empty string if the
+// system roots are used.
+func GetImageTrustBundlePath() string {
+	return os.Getenv(ImageTrustBundlePathEnv)
+}
+
+// GetImageTrustPolicy returns the configured cluster wide image trust policy, or an empty string if none is set.
+func GetImageTrustPolicy() string {
+	return os.Getenv(ImageTrustPolicyEnv)
+}
+
+// GetImageCacheSyncInterval returns the configured interval between image cache syncs of a namespace.
+func GetImageCacheSyncInterval() time.Duration {
+	if interval := os.Getenv(ImageCacheSyncIntervalEnv); len(interval) > 0 {
+		if duration, err := time.ParseDuration(interval); err == nil {
+			return duration
+		}
+	}
+	return DefaultImageCacheSyncInterval
+}
+
+// GetImageCacheMaxUnusedAge returns the configured time a cached image may go unused before it is evicted.
+func GetImageCacheMaxUnusedAge() time.Duration {
+	if age := os.Getenv(ImageCacheMaxUnusedAgeEnv); len(age) > 0 {
+		if duration, err := time.ParseDuration(age); err == nil {
+			return duration
+		}
+	}
+	return DefaultImageCacheMaxUnusedAge
+}
+
+// GetVMClassAvailabilityCheckInterval returns the configured interval between availability checks of a
+// VirtualMachineClass.
+func GetVMClassAvailabilityCheckInterval() time.Duration {
+	if interval := os.Getenv(VMClassAvailabilityCheckIntervalEnv); len(interval) > 0 {
+		if duration, err := time.ParseDuration(interval); err == nil {
+			return duration
+		}
+	}
+	return DefaultVMClassAvailabilityCheckInterval
+}
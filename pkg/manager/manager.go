@@ -17,6 +17,9 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	vmopapiv1alpha2 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
 	ncpv1alpha1 "github.com/vmware-tanzu/vm-operator/external/ncp/api/v1alpha1"
 
 	topologyv1 "github.com/vmware-tanzu/vm-operator/external/tanzu-topology/api/v1alpha1"
@@ -43,6 +46,8 @@ func New(opts Options) (Manager, error) {
 
 	_ = clientgoscheme.AddToScheme(opts.Scheme)
 	_ = vmopv1.AddToScheme(opts.Scheme)
+	_ = vmopapiv1alpha1.AddToScheme(opts.Scheme)
+	_ = vmopapiv1alpha2.AddToScheme(opts.Scheme)
 	_ = ncpv1alpha1.AddToScheme(opts.Scheme)
 	_ = cnsv1alpha1.AddToScheme(opts.Scheme)
 	_ = netopv1alpha1.AddToScheme(opts.Scheme)
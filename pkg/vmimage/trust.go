@@ -0,0 +1,77 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmimage
+
+import (
+	"fmt"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+// trustPolicyStrictness orders the image trust policies from the least to the most strict.
+var trustPolicyStrictness = map[vmopapiv1alpha1.VirtualMachineImageTrustPolicy]int{
+	vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy:           0,
+	vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy: 1,
+	vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy:  2,
+}
+
+// StricterTrustPolicy returns the stricter of the image trust policies. Unknown or empty policies are treated
+// as AllowVirtualMachineImageTrustPolicy.
+func StricterTrustPolicy(policies ...string) vmopapiv1alpha1.VirtualMachineImageTrustPolicy {
+	stricter := vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy
+	for _, p := range policies {
+		policy := vmopapiv1alpha1.VirtualMachineImageTrustPolicy(p)
+		if strictness, ok := trustPolicyStrictness[policy]; ok && strictness > trustPolicyStrictness[stricter] {
+			stricter = policy
+		}
+	}
+	return stricter
+}
+
+// isVerifiedForCurrentVersion returns true if the verification recorded in the image's conditions was done for
+// the current content library item version of the image. The conditions of an item that has since changed do
+// not apply to its content.
+func isVerifiedForCurrentVersion(image *vmopv1alpha1.VirtualMachineImage) bool {
+	trustVersion, ok := image.Annotations[vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation]
+	return ok && trustVersion == image.Annotations[constants.VMImageCLVersionAnnotation]
+}
+
+// CheckTrust returns an error when the policy does not allow VMs to be deployed from the image, according to
+// the verification recorded in the image's conditions. An image that has not been verified for its current
+// content library item version is treated as not verified.
+func CheckTrust(image *vmopv1alpha1.VirtualMachineImage, policy vmopapiv1alpha1.VirtualMachineImageTrustPolicy) error {
+	trustConditions := []vmopv1alpha1.ConditionType{
+		vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition,
+		vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+	}
+	verified := isVerifiedForCurrentVersion(image)
+
+	switch policy {
+	case vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy:
+		// Only a failed verification is rejected, so images without a manifest or signature are allowed.
+		if !verified {
+			return nil
+		}
+		for _, t := range trustConditions {
+			if conditions.IsFalse(image, t) && *conditions.GetSeverity(image, t) == vmopv1alpha1.ConditionSeverityError {
+				return fmt.Errorf("image is not trusted: %s", conditions.GetMessage(image, t))
+			}
+		}
+	case vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy:
+		for _, t := range trustConditions {
+			if !verified || !conditions.Has(image, t) {
+				return fmt.Errorf("image has not been verified yet")
+			}
+			if !conditions.IsTrue(image, t) {
+				return fmt.Errorf("image is not trusted: %s", conditions.GetMessage(image, t))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmimage_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+var _ = Describe("StricterTrustPolicy", func() {
+	It("returns the stricter policy", func() {
+		Expect(vmimage.StricterTrustPolicy()).To(Equal(vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy))
+		Expect(vmimage.StricterTrustPolicy("", "RejectUntrusted")).To(
+			Equal(vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy))
+		Expect(vmimage.StricterTrustPolicy("RequireTrusted", "RejectUntrusted")).To(
+			Equal(vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy))
+	})
+
+	It("ignores unknown policies", func() {
+		Expect(vmimage.StricterTrustPolicy("Bogus", "Allow")).To(Equal(vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy))
+	})
+})
+
+var _ = Describe("CheckTrust", func() {
+	var image *vmopv1alpha1.VirtualMachineImage
+
+	BeforeEach(func() {
+		image = &vmopv1alpha1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "image",
+				Annotations: map[string]string{
+					constants.VMImageCLVersionAnnotation:                     "item:1:1:1",
+					vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation: "item:1:1:1",
+				},
+			},
+		}
+	})
+
+	When("the image is verified and signed by a trusted certificate", func() {
+		BeforeEach(func() {
+			conditions.MarkTrue(image, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)
+			conditions.MarkTrue(image, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition)
+		})
+
+		It("is allowed by every policy", func() {
+			Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy)).To(Succeed())
+			Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy)).To(Succeed())
+			Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy)).To(Succeed())
+		})
+
+		When("the library item has changed since it was verified", func() {
+			BeforeEach(func() {
+				image.Annotations[constants.VMImageCLVersionAnnotation] = "item:2:2:1"
+			})
+
+			It("is rejected when a trusted image is required", func() {
+				Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy)).To(Succeed())
+				err := vmimage.CheckTrust(image, vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy)
+				Expect(err).To(MatchError(ContainSubstring("not been verified")))
+			})
+		})
+	})
+
+	When("the image is unsigned", func() {
+		BeforeEach(func() {
+			conditions.MarkTrue(image, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)
+			conditions.MarkFalse(image, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+				vmopapiv1alpha1.VirtualMachineImageUnsignedReason, vmopv1alpha1.ConditionSeverityInfo, "no .cert file")
+		})
+
+		It("is only rejected when a trusted image is required", func() {
+			Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy)).To(Succeed())
+			err := vmimage.CheckTrust(image, vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy)
+			Expect(err).To(MatchError(ContainSubstring("no .cert file")))
+		})
+	})
+
+	When("the image is signed by an untrusted certificate", func() {
+		BeforeEach(func() {
+			conditions.MarkTrue(image, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)
+			conditions.MarkFalse(image, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+				vmopapiv1alpha1.VirtualMachineImageUntrustedCertificateReason, vmopv1alpha1.ConditionSeverityError, "unknown authority")
+		})
+
+		It("is rejected unless every image is allowed", func() {
+			Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy)).To(Succeed())
+			err := vmimage.CheckTrust(image, vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy)
+			Expect(err).To(MatchError(ContainSubstring("unknown authority")))
+		})
+
+		When("the library item has changed since it was verified", func() {
+			BeforeEach(func() {
+				image.Annotations[constants.VMImageCLVersionAnnotation] = "item:2:2:1"
+			})
+
+			It("is treated as not verified", func() {
+				Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy)).To(Succeed())
+				Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy)).ToNot(Succeed())
+			})
+		})
+	})
+
+	When("the image has not been verified", func() {
+		It("is rejected when a trusted image is required", func() {
+			Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RejectUntrustedVirtualMachineImageTrustPolicy)).To(Succeed())
+			Expect(vmimage.CheckTrust(image, vmopapiv1alpha1.RequireTrustedVirtualMachineImageTrustPolicy)).ToNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmimage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+)
+
+const (
+	// libraryHashLength is the number of hex characters of the library hash in the name of an image.
+	libraryHashLength = 8
+
+	// defaultNamePrefix is used for an item whose name has no characters that are valid in an object name.
+	defaultNamePrefix = "image"
+
+	// DisplayNameField is the name of the field index of the VirtualMachineImages by their display name.
+	DisplayNameField = "status.imageName"
+)
+
+// DisplayName returns the display name of the image, which is the name of its content library item. Note that
+// this is different from the name of the VirtualMachineImage object.
+func DisplayName(image *vmopv1alpha1.VirtualMachineImage) string {
+	// This happens if the image was created before the display name was recorded.
+	if image.Status.ImageName == "" {
+		return image.Name
+	}
+	return image.Status.ImageName
+}
+
+// IndexDisplayName adds the DisplayNameField index to the field indexer, so the images with a display name can be
+// listed with a field selector rather than by listing all the images.
+func IndexDisplayName(ctx context.Context, fieldIndexer ctrlclient.FieldIndexer) error {
+	return fieldIndexer.IndexField(ctx, &vmopv1alpha1.VirtualMachineImage{}, DisplayNameField,
+		func(o ctrlclient.Object) []string {
+			image, ok := o.(*vmopv1alpha1.VirtualMachineImage)
+			if !ok {
+				return nil
+			}
+			return []string{DisplayName(image)}
+		})
+}
+
+// NameForLibraryItem returns the name of the VirtualMachineImage object for the content library item with the
+// given name. The name is the item name made valid for an object name, with a hash of the library as a suffix,
+// so items with the same name in different libraries do not collide, and the name does not depend on the order
+// the libraries are synced.
+func NameForLibraryItem(libraryName, itemName string) string {
+	sum := sha256.Sum256([]byte(libraryName))
+	suffix := hex.EncodeToString(sum[:])[:libraryHashLength]
+
+	prefix := sanitizeName(itemName, validation.DNS1123SubdomainMaxLength-len(suffix)-1)
+	return prefix + "-" + suffix
+}
+
+// sanitizeName returns the name with the characters that are not valid in a DNS subdomain replaced, and truncated
+// to maxLen characters.
+func sanitizeName(name string, maxLen int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+
+	isAlphaNumeric := func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+	}
+
+	sanitized := strings.TrimFunc(b.String(), func(r rune) bool { return !isAlphaNumeric(r) })
+	if len(sanitized) > maxLen {
+		sanitized = strings.TrimRightFunc(sanitized[:maxLen], func(r rune) bool { return !isAlphaNumeric(r) })
+	}
+	if sanitized == "" {
+		return defaultNamePrefix
+	}
+
+	return sanitized
+}
+
+// IsReferencedBy returns true if the image name of the VM refers to the image, by either its name or display name.
+func IsReferencedBy(image *vmopv1alpha1.VirtualMachineImage, vm *vmopv1alpha1.VirtualMachine) bool {
+	return vm.Spec.ImageName != "" && (vm.Spec.ImageName == image.Name || vm.Spec.ImageName == DisplayName(image))
+}
+
+// Get returns the VirtualMachineImage with the given name. When there is no image with the name, the image is
+// resolved by its display name instead, which must match exactly one image.
+func Get(ctx context.Context, client ctrlclient.Client, name string) (*vmopv1alpha1.VirtualMachineImage, error) {
+	image := &vmopv1alpha1.VirtualMachineImage{}
+	err := client.Get(ctx, ctrlclient.ObjectKey{Name: name}, image)
+	if err == nil || !apierrors.IsNotFound(err) {
+		return image, err
+	}
+
+	imageList := &vmopv1alpha1.VirtualMachineImageList{}
+	if listErr := client.List(ctx, imageList); listErr != nil {
+		return nil, listErr
+	}
+
+	var matches []vmopv1alpha1.VirtualMachineImage
+	for _, img := range imageList.Items {
+		if img.Status.ImageName == name {
+			matches = append(matches, img)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, err
+	case 1:
+		return &matches[0], nil
+	default:
+		names := make([]string, 0, len(matches))
+		for _, img := range matches {
+			names = append(names, img.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("display name %q matches multiple VirtualMachineImages, use one of their names: %s",
+			name, strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmimage_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+var _ = Describe("NameForLibraryItem", func() {
+	It("is deterministic and scoped to the library", func() {
+		name := vmimage.NameForLibraryItem("library-1", "ubuntu-20.04")
+		Expect(name).To(HavePrefix("ubuntu-20.04-"))
+		Expect(vmimage.NameForLibraryItem("library-1", "ubuntu-20.04")).To(Equal(name))
+		Expect(vmimage.NameForLibraryItem("library-2", "ubuntu-20.04")).ToNot(Equal(name))
+	})
+
+	It("returns a valid object name", func() {
+		for _, itemName := range []string{"Ubuntu 20.04 (LTS)", "--", "", strings.Repeat("a", 300)} {
+			name := vmimage.NameForLibraryItem("library-1", itemName)
+			Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty(), name)
+		}
+		Expect(vmimage.NameForLibraryItem("library-1", "Ubuntu 20.04 (LTS)")).To(HavePrefix("ubuntu-20.04--lts-"))
+		Expect(vmimage.NameForLibraryItem("library-1", "--")).To(HavePrefix("image-"))
+	})
+})
+
+var _ = Describe("Get", func() {
+	var (
+		ctx    context.Context
+		client ctrlclient.Client
+		image  *vmopv1alpha1.VirtualMachineImage
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		image = &vmopv1alpha1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: vmimage.NameForLibraryItem("library-1", "ubuntu"),
+			},
+			Status: vmopv1alpha1.VirtualMachineImageStatus{
+				ImageName: "ubuntu",
+			},
+		}
+		client = builder.NewFakeClient(image)
+	})
+
+	It("resolves an image by its name", func() {
+		img, err := vmimage.Get(ctx, client, image.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(img.Name).To(Equal(image.Name))
+	})
+
+	It("resolves an image by its display name", func() {
+		img, err := vmimage.Get(ctx, client, "ubuntu")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(img.Name).To(Equal(image.Name))
+	})
+
+	It("returns a NotFound error when no image matches", func() {
+		_, err := vmimage.Get(ctx, client, "centos")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	When("the display name is ambiguous", func() {
+		BeforeEach(func() {
+			other := image.DeepCopy()
+			other.Name = vmimage.NameForLibraryItem("library-2", "ubuntu")
+			other.ResourceVersion = ""
+			Expect(client.Create(ctx, other)).To(Succeed())
+		})
+
+		It("returns an error", func() {
+			_, err := vmimage.Get(ctx, client, "ubuntu")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("matches multiple VirtualMachineImages"))
+		})
+	})
+})
+
+// fieldIndexer records the function of an index.
+type fieldIndexer struct {
+	field     string
+	extractFn ctrlclient.IndexerFunc
+}
+
+func (f *fieldIndexer) IndexField(_ context.Context, _ ctrlclient.Object, field string, extractFn ctrlclient.IndexerFunc) error {
+	f.field, f.extractFn = field, extractFn
+	return nil
+}
+
+var _ = Describe("IndexDisplayName", func() {
+	var indexer *fieldIndexer
+
+	BeforeEach(func() {
+		indexer = &fieldIndexer{}
+		Expect(vmimage.IndexDisplayName(context.Background(), indexer)).To(Succeed())
+		Expect(indexer.field).To(Equal(vmimage.DisplayNameField))
+	})
+
+	It("indexes an image by its display name", func() {
+		image := &vmopv1alpha1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Name: vmimage.NameForLibraryItem("library-1", "ubuntu")},
+			Status:     vmopv1alpha1.VirtualMachineImageStatus{ImageName: "ubuntu"},
+		}
+		Expect(indexer.extractFn(image)).To(ConsistOf("ubuntu"))
+	})
+
+	It("indexes an image without a display name by its name", func() {
+		image := &vmopv1alpha1.VirtualMachineImage{ObjectMeta: metav1.ObjectMeta{Name: "ubuntu"}}
+		Expect(indexer.extractFn(image)).To(ConsistOf("ubuntu"))
+	})
+})
@@ -5,12 +5,16 @@ package fake
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 	"sync"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
 )
 
@@ -22,14 +26,25 @@ import (
 // expected to evolve as more tests get added in the future.
 
 type funcs struct {
-	DoesVirtualMachineExistFn         func(ctx context.Context, vm *v1alpha1.VirtualMachine) (bool, error)
-	CreateVirtualMachineFn            func(ctx context.Context, vm *v1alpha1.VirtualMachine, vmConfigArgs vmprovider.VMConfigArgs) error
-	UpdateVirtualMachineFn            func(ctx context.Context, vm *v1alpha1.VirtualMachine, vmConfigArgs vmprovider.VMConfigArgs) error
-	DeleteVirtualMachineFn            func(ctx context.Context, vm *v1alpha1.VirtualMachine) error
-	GetVirtualMachineGuestHeartbeatFn func(ctx context.Context, vm *v1alpha1.VirtualMachine) (v1alpha1.GuestHeartbeatStatus, error)
+	DoesVirtualMachineExistFn            func(ctx context.Context, vm *v1alpha1.VirtualMachine) (bool, error)
+	CreateVirtualMachineFn               func(ctx context.Context, vm *v1alpha1.VirtualMachine, vmConfigArgs vmprovider.VMConfigArgs) error
+	UpdateVirtualMachineFn               func(ctx context.Context, vm *v1alpha1.VirtualMachine, vmConfigArgs vmprovider.VMConfigArgs) error
+	DeleteVirtualMachineFn               func(ctx context.Context, vm *v1alpha1.VirtualMachine) error
+	RetainVirtualMachineFn               func(ctx context.Context, vm *v1alpha1.VirtualMachine) error
+	ShutdownVirtualMachineGuestFn        func(ctx context.Context, vm *v1alpha1.VirtualMachine) (bool, error)
+	GetVirtualMachineGuestHeartbeatFn    func(ctx context.Context, vm *v1alpha1.VirtualMachine) (v1alpha1.GuestHeartbeatStatus, error)
+	GetSupportedGuestOSFamiliesFn        func(ctx context.Context, vm *v1alpha1.VirtualMachine) (map[string]string, error)
+	ListManagedVirtualMachinesFn         func(ctx context.Context, namespace string) ([]vmprovider.ManagedVirtualMachine, error)
+	DeleteManagedVirtualMachineFn        func(ctx context.Context, namespace string, vm vmprovider.ManagedVirtualMachine) error
+	GetVirtualMachineBackupFn            func(ctx context.Context, namespace string, vm vmprovider.ManagedVirtualMachine) (*vmprovider.VirtualMachineBackup, error)
+	SyncImageCacheFn                     func(ctx context.Context, namespace string, maxUnused time.Duration) (*vmprovider.ImageCacheSyncResult, error)
+	GetVirtualMachineClassAvailabilityFn func(ctx context.Context, vmClass *v1alpha1.VirtualMachineClass, namespaces []string) ([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability, error)
 
 	ListVirtualMachineImagesFromContentLibraryFn func(ctx context.Context, cl v1alpha1.ContentLibraryProvider, currentCLImages map[string]v1alpha1.VirtualMachineImage) ([]*v1alpha1.VirtualMachineImage, error)
 	DoesContentLibraryExistFn                    func(ctx context.Context, cl *v1alpha1.ContentLibraryProvider) (bool, error)
+	GetVirtualMachineImageInfoFn                 func(ctx context.Context, image *v1alpha1.VirtualMachineImage) (*vmopapiv1alpha1.VirtualMachineImageInfo, error)
+	ImportLibraryItemFn                          func(ctx context.Context, clUUID, itemName, itemType, importID string, files vmprovider.LibraryItemFiles) (string, error)
+	VerifyVirtualMachineImageFn                  func(ctx context.Context, image *v1alpha1.VirtualMachineImage) (*vmprovider.ImageVerification, error)
 
 	UpdateVcPNIDFn                  func(ctx context.Context, vcPNID, vcPort string) error
 	ClearSessionsAndClientFn        func(ctx context.Context)
@@ -104,6 +119,25 @@ func (s *VMProvider) DeleteVirtualMachine(ctx context.Context, vm *v1alpha1.Virt
 	return nil
 }
 
+func (s *VMProvider) RetainVirtualMachine(ctx context.Context, vm *v1alpha1.VirtualMachine) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.RetainVirtualMachineFn != nil {
+		return s.RetainVirtualMachineFn(ctx, vm)
+	}
+	s.deleteFromVMMap(vm)
+	return nil
+}
+
+func (s *VMProvider) ShutdownVirtualMachineGuest(ctx context.Context, vm *v1alpha1.VirtualMachine) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.ShutdownVirtualMachineGuestFn != nil {
+		return s.ShutdownVirtualMachineGuestFn(ctx, vm)
+	}
+	return true, nil
+}
+
 func (s *VMProvider) GetVirtualMachineGuestHeartbeat(ctx context.Context, vm *v1alpha1.VirtualMachine) (v1alpha1.GuestHeartbeatStatus, error) {
 	s.Lock()
 	defer s.Unlock()
@@ -113,6 +147,71 @@ func (s *VMProvider) GetVirtualMachineGuestHeartbeat(ctx context.Context, vm *v1
 	return "", nil
 }
 
+func (s *VMProvider) GetSupportedGuestOSFamilies(ctx context.Context, vm *v1alpha1.VirtualMachine) (map[string]string, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.GetSupportedGuestOSFamiliesFn != nil {
+		return s.GetSupportedGuestOSFamiliesFn(ctx, vm)
+	}
+	return nil, nil
+}
+
+func (s *VMProvider) ListManagedVirtualMachines(ctx context.Context, namespace string) ([]vmprovider.ManagedVirtualMachine, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.ListManagedVirtualMachinesFn != nil {
+		return s.ListManagedVirtualMachinesFn(ctx, namespace)
+	}
+
+	var managedVMs []vmprovider.ManagedVirtualMachine
+	for key := range s.vmMap {
+		if key.Namespace == namespace {
+			managedVMs = append(managedVMs, vmprovider.ManagedVirtualMachine{Name: key.Name})
+		}
+	}
+	return managedVMs, nil
+}
+
+func (s *VMProvider) DeleteManagedVirtualMachine(ctx context.Context, namespace string, vm vmprovider.ManagedVirtualMachine) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.DeleteManagedVirtualMachineFn != nil {
+		return s.DeleteManagedVirtualMachineFn(ctx, namespace, vm)
+	}
+	delete(s.vmMap, client.ObjectKey{Namespace: namespace, Name: vm.Name})
+	return nil
+}
+
+func (s *VMProvider) GetVirtualMachineBackup(ctx context.Context, namespace string, vm vmprovider.ManagedVirtualMachine) (*vmprovider.VirtualMachineBackup, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.GetVirtualMachineBackupFn != nil {
+		return s.GetVirtualMachineBackupFn(ctx, namespace, vm)
+	}
+	return nil, nil
+}
+
+func (s *VMProvider) SyncImageCache(ctx context.Context, namespace string, maxUnused time.Duration) (*vmprovider.ImageCacheSyncResult, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.SyncImageCacheFn != nil {
+		return s.SyncImageCacheFn(ctx, namespace, maxUnused)
+	}
+	return &vmprovider.ImageCacheSyncResult{}, nil
+}
+
+func (s *VMProvider) GetVirtualMachineClassAvailability(
+	ctx context.Context,
+	vmClass *v1alpha1.VirtualMachineClass,
+	namespaces []string) ([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.GetVirtualMachineClassAvailabilityFn != nil {
+		return s.GetVirtualMachineClassAvailabilityFn(ctx, vmClass, namespaces)
+	}
+	return nil, nil
+}
+
 func (s *VMProvider) Initialize(stop <-chan struct{}) {}
 
 func (s *VMProvider) Name() string {
@@ -224,6 +323,53 @@ func (s *VMProvider) ListVirtualMachineImagesFromContentLibrary(ctx context.Cont
 	return []*v1alpha1.VirtualMachineImage{}, nil
 }
 
+func (s *VMProvider) GetVirtualMachineImageInfo(ctx context.Context, image *v1alpha1.VirtualMachineImage) (*vmopapiv1alpha1.VirtualMachineImageInfo, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.GetVirtualMachineImageInfoFn != nil {
+		return s.GetVirtualMachineImageInfoFn(ctx, image)
+	}
+
+	return &vmopapiv1alpha1.VirtualMachineImageInfo{}, nil
+}
+
+func (s *VMProvider) ImportLibraryItem(ctx context.Context, clUUID, itemName, itemType, importID string, files vmprovider.LibraryItemFiles) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.ImportLibraryItemFn != nil {
+		return s.ImportLibraryItemFn(ctx, clUUID, itemName, itemType, importID, files)
+	}
+
+	// Read all the files, like they would be when they are uploaded.
+	for {
+		file, err := files.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(ioutil.Discard, file.Reader); err != nil {
+			return "", err
+		}
+	}
+
+	return "fake-" + itemName, nil
+}
+
+func (s *VMProvider) VerifyVirtualMachineImage(ctx context.Context, image *v1alpha1.VirtualMachineImage) (*vmprovider.ImageVerification, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.VerifyVirtualMachineImageFn != nil {
+		return s.VerifyVirtualMachineImageFn(ctx, image)
+	}
+
+	return &vmprovider.ImageVerification{}, nil
+}
+
 func (s *VMProvider) ListVirtualMachineImages(ctx context.Context, namespace string) ([]*v1alpha1.VirtualMachineImage, error) {
 	return []*v1alpha1.VirtualMachineImage{}, nil
 }
@@ -150,6 +150,14 @@ var _ = Describe("VMProvider Inventory Tests", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(exists).To(BeTrue())
 
+			managedVMs, err := vmProvider.ListManagedVirtualMachines(ctx, vmNamespace)
+			Expect(err).ToNot(HaveOccurred())
+			var managedVMNames []string
+			for _, managedVM := range managedVMs {
+				managedVMNames = append(managedVMNames, managedVM.Name)
+			}
+			Expect(managedVMNames).To(ContainElement(vmName))
+
 			vm.Spec.PowerState = vmoperatorv1alpha1.VirtualMachinePoweredOn
 			err = vmProvider.UpdateVirtualMachine(context.TODO(), vm, vmConfigArgs)
 			Expect(err).ToNot(HaveOccurred())
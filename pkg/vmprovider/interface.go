@@ -5,10 +5,89 @@ package vmprovider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 )
 
+var (
+	// ErrImageUnsupported occurs when an image is not of a type that can be inspected.
+	ErrImageUnsupported = errors.New("image is not supported")
+
+	// ErrImageCorrupt occurs when the OVF descriptor of an image cannot be parsed.
+	ErrImageCorrupt = errors.New("image is corrupt")
+
+	// ErrLibraryItemExists occurs when a library item cannot be created because the content library already has
+	// an item with the same name.
+	ErrLibraryItemExists = errors.New("library item already exists")
+
+	// ErrImageManifestMissing occurs when an image has no OVF manifest whose digests can be verified.
+	ErrImageManifestMissing = errors.New("image has no OVF manifest")
+
+	// ErrImageDigestMismatch occurs when a file of an image does not match its digest in the OVF manifest.
+	ErrImageDigestMismatch = errors.New("image does not match its OVF manifest")
+
+	// ErrImageUnsigned occurs when an image has no signing certificate.
+	ErrImageUnsigned = errors.New("image is not signed")
+
+	// ErrImageSignatureInvalid occurs when the signature of the OVF manifest of an image is not valid.
+	ErrImageSignatureInvalid = errors.New("image signature is not valid")
+
+	// ErrImageCertificateUntrusted occurs when the signing certificate of an image does not chain to the trust bundle.
+	ErrImageCertificateUntrusted = errors.New("image signing certificate is not trusted")
+)
+
+// ImageVerification is the result of verifying the OVF manifest and signing certificate of an image.
+type ImageVerification struct {
+	// ManifestErr is nil when the files of the image match the digests in its OVF manifest. Otherwise it wraps
+	// ErrImageManifestMissing or ErrImageDigestMismatch.
+	ManifestErr error
+	// SignatureErr is nil when the OVF manifest is signed by a trusted certificate. Otherwise it wraps
+	// ErrImageUnsigned, ErrImageSignatureInvalid or ErrImageCertificateUntrusted.
+	SignatureErr error
+	// Signer is the subject of the signing certificate when the signature is verified.
+	Signer string
+}
+
+// LibraryItemFile is a file that is uploaded to a content library item.
+type LibraryItemFile struct {
+	Name string
+	// Size is the size of the file in bytes, or -1 if it is not known.
+	Size   int64
+	Reader io.Reader
+}
+
+// LibraryItemFiles iterates over the files that are uploaded to a content library item.
+type LibraryItemFiles interface {
+	// Next returns the next file to upload, or io.EOF after the last file. The Reader of a file is read to
+	// its end before Next is called again. An error returned by the Reader aborts the upload.
+	Next() (*LibraryItemFile, error)
+}
+
+// LibraryItemError describes a content library item that could not be listed as a VirtualMachineImage.
+type LibraryItemError struct {
+	ItemID   string
+	ItemName string
+	Err      error
+}
+
+// LibraryItemsError is returned by ListVirtualMachineImagesFromContentLibrary, together with the images
+// that could be listed, when some of the library items could not be.
+type LibraryItemsError struct {
+	Items []LibraryItemError
+}
+
+func (e *LibraryItemsError) Error() string {
+	return fmt.Sprintf("failed to list %d content library items", len(e.Items))
+}
+
 type VMMetadata struct {
 	Data      map[string]string
 	Transport v1alpha1.VirtualMachineMetadataTransport
@@ -21,6 +100,39 @@ type VMConfigArgs struct {
 	VMMetadata         VMMetadata
 	StorageProfileID   string
 	ContentLibraryUUID string
+	// ExtraConfig is the ExtraConfig of the VM, merged from the namespace, class and VM ExtraConfig.
+	ExtraConfig map[string]string
+}
+
+// ManagedVirtualMachine identifies a provider VM that carries the VM Operator managed marker.
+type ManagedVirtualMachine struct {
+	Name string
+	MoID string
+	Zone string
+	// HasBackup is true when the VM carries the data needed to recreate its VirtualMachine resource.
+	HasBackup bool
+	// Restored is true when the VM has a backup that was recorded on another VM, that is the VM was restored
+	// from the backup. A VM whose VirtualMachine was deleted has a backup but was not restored.
+	Restored bool
+}
+
+// VirtualMachineBackup is the Kubernetes state recorded on a provider VM so that its VirtualMachine
+// resource can be recreated after the VM is restored.
+type VirtualMachineBackup struct {
+	VirtualMachine *v1alpha1.VirtualMachine
+	// ConfigMap is the VM metadata ConfigMap, if the VM uses one. Secrets are never recorded.
+	ConfigMap *corev1.ConfigMap
+	Volumes   []v1alpha1.VirtualMachineVolumeStatus
+}
+
+// ImageCacheSyncResult describes the image cache template VMs of a namespace after SyncImageCache.
+type ImageCacheSyncResult struct {
+	// Cached are the names of the template VMs in the cache.
+	Cached []string
+	// Warmed are the names of the template VMs that were created for the current content of their library item.
+	Warmed []string
+	// Evicted are the names of the template VMs that were deleted.
+	Evicted []string
 }
 
 // VirtualMachineProviderInterface is a plugable interface for VM Providers.
@@ -36,7 +148,31 @@ type VirtualMachineProviderInterface interface {
 	CreateVirtualMachine(ctx context.Context, vm *v1alpha1.VirtualMachine, vmConfigArgs VMConfigArgs) error
 	UpdateVirtualMachine(ctx context.Context, vm *v1alpha1.VirtualMachine, vmConfigArgs VMConfigArgs) error
 	DeleteVirtualMachine(ctx context.Context, vm *v1alpha1.VirtualMachine) error
+	RetainVirtualMachine(ctx context.Context, vm *v1alpha1.VirtualMachine) error
+	// ShutdownVirtualMachineGuest requests a guest OS shutdown without waiting for it to complete. It
+	// returns true once there is nothing left to wait for before the VM can be powered off.
+	ShutdownVirtualMachineGuest(ctx context.Context, vm *v1alpha1.VirtualMachine) (bool, error)
 	GetVirtualMachineGuestHeartbeat(ctx context.Context, vm *v1alpha1.VirtualMachine) (v1alpha1.GuestHeartbeatStatus, error)
+	// GetSupportedGuestOSFamilies returns the supported guest OS identifiers of the cluster that the VM is
+	// placed on, mapped to their guest OS family.
+	GetSupportedGuestOSFamilies(ctx context.Context, vm *v1alpha1.VirtualMachine) (map[string]string, error)
+
+	// ListManagedVirtualMachines returns the provider VMs in the namespace that were created by VM Operator,
+	// regardless of whether a VirtualMachine resource still exists for them.
+	ListManagedVirtualMachines(ctx context.Context, namespace string) ([]ManagedVirtualMachine, error)
+	DeleteManagedVirtualMachine(ctx context.Context, namespace string, vm ManagedVirtualMachine) error
+	// GetVirtualMachineBackup returns the backup recorded on the provider VM, or nil if there is none.
+	GetVirtualMachineBackup(ctx context.Context, namespace string, vm ManagedVirtualMachine) (*VirtualMachineBackup, error)
+	// SyncImageCache caches the current content of the library items of the image cache template VMs in the
+	// namespace that were used within maxUnused, and evicts the template VMs that are stale or unused for longer
+	// than maxUnused and have no linked clones.
+	SyncImageCache(ctx context.Context, namespace string, maxUnused time.Duration) (*ImageCacheSyncResult, error)
+	// GetVirtualMachineClassAvailability returns whether VMs of the class can be realized in each availability zone,
+	// and how many more VMs of the class fit in the resource pools of the namespaces in the zone.
+	GetVirtualMachineClassAvailability(
+		ctx context.Context,
+		vmClass *v1alpha1.VirtualMachineClass,
+		namespaces []string) ([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability, error)
 
 	CreateOrUpdateVirtualMachineSetResourcePolicy(ctx context.Context, resourcePolicy *v1alpha1.VirtualMachineSetResourcePolicy) error
 	IsVirtualMachineSetResourcePolicyReady(ctx context.Context, availabilityZoneName string, resourcePolicy *v1alpha1.VirtualMachineSetResourcePolicy) (bool, error)
@@ -48,6 +184,20 @@ type VirtualMachineProviderInterface interface {
 	DeleteNamespaceSessionInCache(ctx context.Context, namespace string) error
 	ComputeClusterCPUMinFrequency(ctx context.Context) error
 
+	// ListVirtualMachineImagesFromContentLibrary lists the images of the content library. When some library items
+	// cannot be listed, the other images are returned with a LibraryItemsError.
 	ListVirtualMachineImagesFromContentLibrary(ctx context.Context, cl v1alpha1.ContentLibraryProvider,
 		currentCLImages map[string]v1alpha1.VirtualMachineImage) ([]*v1alpha1.VirtualMachineImage, error)
+	// GetVirtualMachineImageInfo inspects the OVF descriptor of the image. ErrImageUnsupported or ErrImageCorrupt
+	// is returned when the image cannot be inspected.
+	GetVirtualMachineImageInfo(ctx context.Context, image *v1alpha1.VirtualMachineImage) (*vmopapiv1alpha1.VirtualMachineImageInfo, error)
+	// ImportLibraryItem creates a library item of the given type in the content library, and uploads the files
+	// to it. ErrLibraryItemExists is returned when the library already has an item with the name, unless the item
+	// was left behind by an interrupted import with the same importID, in which case it is replaced. The ID of the
+	// created item is returned. The item is deleted again if the files cannot be uploaded.
+	ImportLibraryItem(ctx context.Context, clUUID, itemName, itemType, importID string, files LibraryItemFiles) (string, error)
+	// VerifyVirtualMachineImage verifies the files of the image against the digests of its OVF manifest, and the
+	// signature of the manifest against the image trust bundle. ErrImageUnsupported is returned when the image
+	// cannot be verified.
+	VerifyVirtualMachineImage(ctx context.Context, image *v1alpha1.VirtualMachineImage) (*ImageVerification, error)
 }
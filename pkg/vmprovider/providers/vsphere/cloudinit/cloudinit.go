@@ -0,0 +1,386 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudinit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// GuestInfoMaxSize is the maximum size of a guestinfo value. The CloudInit transport sets the gzip and base64
+// encoded user-data as a guestinfo value, and the OvfEnv transport sets the OVF environment, that includes the
+// base64 encoded user-data, as a guestinfo value.
+const GuestInfoMaxSize = 64 * 1024
+
+// DecodedMaxSize is the maximum size of the decoded user-data. It bounds the memory of decompressing gzip
+// user-data, while allowing a much greater compression ratio than text has.
+const DecodedMaxSize = 16 * GuestInfoMaxSize
+
+var (
+	// yamlErrorLineRe matches the line number of a YAML syntax error, e.g. "yaml: line 3: could not find
+	// expected ':'".
+	yamlErrorLineRe = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+	// yamlUnmarshalLineRe matches the line number of a YAML value of the wrong type, e.g. "line 2: cannot
+	// unmarshal !!seq into yaml.MapSlice".
+	yamlUnmarshalLineRe = regexp.MustCompile(`line (\d+): cannot unmarshal`)
+
+	// topLevelKeyRe matches a top-level key of a YAML mapping.
+	topLevelKeyRe = regexp.MustCompile(`^([^\s#:'"-][^:]*?)\s*:(\s|$)`)
+)
+
+// headers are the first lines that cloud-init recognizes the type of the user-data by.
+var headers = []string{
+	"#cloud-config-archive",
+	"#cloud-config",
+	"#cloud-boothook",
+	"#include-once",
+	"#include",
+	"#part-handler",
+	"#upstart-job",
+	"## template: jinja",
+	"#!",
+}
+
+// partContentTypes are the content types of multipart MIME parts that cloud-init handles.
+var partContentTypes = map[string]struct{}{
+	"text/cloud-boothook":             {},
+	"text/cloud-config":               {},
+	"text/cloud-config-archive":       {},
+	"text/cloud-config-jsonp":         {},
+	"text/jinja2":                     {},
+	"text/part-handler":               {},
+	"text/plain":                      {},
+	"text/upstart-job":                {},
+	"text/x-include-once-url":         {},
+	"text/x-include-url":              {},
+	"text/x-shellscript":              {},
+	"text/x-shellscript-per-boot":     {},
+	"text/x-shellscript-per-instance": {},
+	"text/x-shellscript-per-once":     {},
+}
+
+// cloudConfigKeys are the top-level keys of #cloud-config user-data that cloud-init and its modules use.
+var cloudConfigKeys = map[string]struct{}{
+	"allow_public_ssh_keys": {}, "apk_repos": {}, "apt": {}, "apt_pipelining": {}, "apt_preserve_sources_list": {},
+	"apt_proxy": {}, "apt_http_proxy": {}, "apt_https_proxy": {}, "apt_ftp_proxy": {}, "apt_mirror": {},
+	"apt_sources": {}, "apt_update": {}, "apt_upgrade": {}, "autoinstall": {}, "bootcmd": {},
+	"byobu_by_default": {}, "ca-certs": {}, "ca_certs": {}, "chef": {}, "chpasswd": {},
+	"cloud_config_modules": {}, "cloud_final_modules": {}, "cloud_init_modules": {}, "datasource": {},
+	"debug": {}, "device_aliases": {}, "disable_ec2_metadata": {}, "disable_root": {}, "disable_root_opts": {},
+	"disk_setup": {}, "drivers": {}, "fan": {}, "final_message": {}, "fqdn": {}, "fs_setup": {}, "groups": {},
+	"growpart": {}, "grub_dpkg": {}, "hostname": {}, "keyboard": {}, "landscape": {}, "locale": {},
+	"locale_configfile": {}, "lxd": {}, "manage_etc_hosts": {}, "manage_resolv_conf": {},
+	"manual_cache_clean": {}, "mcollective": {}, "merge_how": {}, "merge_type": {}, "mount_default_fields": {},
+	"mounts": {}, "network": {}, "no_ssh_fingerprints": {}, "ntp": {}, "output": {},
+	"package_reboot_if_required": {}, "package_update": {}, "package_upgrade": {}, "packages": {},
+	"password": {}, "phone_home": {}, "power_state": {}, "prefer_fqdn_over_hostname": {},
+	"preserve_hostname": {}, "puppet": {}, "random_seed": {}, "reporting": {}, "resize_rootfs": {},
+	"resolv_conf": {}, "rh_subscription": {}, "rsyslog": {}, "runcmd": {}, "salt_minion": {}, "snap": {},
+	"spacewalk": {}, "ssh": {}, "ssh_authorized_keys": {}, "ssh_deletekeys": {}, "ssh_fp_console_blacklist": {},
+	"ssh_genkeytypes": {}, "ssh_import_id": {}, "ssh_key_console_blacklist": {}, "ssh_keys": {},
+	"ssh_publish_hostkeys": {}, "ssh_pwauth": {}, "ssh_quiet_keygen": {}, "ssh_redirect_user": {},
+	"swap": {}, "syslog_fix_perms": {}, "system_info": {}, "timezone": {}, "ubuntu_advantage": {},
+	"updates": {}, "user": {}, "users": {}, "vendor_data": {}, "write_files": {}, "yum_repo_dir": {},
+	"yum_repos": {}, "zypper": {},
+}
+
+// Issue describes a problem with cloud-init user-data. Errors prevent cloud-init from using the user-data,
+// and warnings are likely mistakes that cloud-init ignores.
+type Issue struct {
+	// Part is the 1-based index of the multipart MIME part the issue is in, or zero.
+	Part int
+	// Line is the 1-based line of the decoded user-data, or of the part, the issue is on, or zero.
+	Line    int
+	Message string
+	Warning bool
+}
+
+func (i Issue) Error() string {
+	var location []string
+	if i.Part > 0 {
+		location = append(location, fmt.Sprintf("part %d", i.Part))
+	}
+	if i.Line > 0 {
+		location = append(location, fmt.Sprintf("line %d", i.Line))
+	}
+	if len(location) == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(location, ", "), i.Message)
+}
+
+// ValidateUserData validates cloud-init user-data before it is set on a VM. The user-data may be base64 and
+// gzip encoded, and should be base64 encoded when base64Required is true, as for the OvfEnv transport whose
+// cloud-init datasource always decodes it. The size of the user-data, as it is set in guestinfo, must not
+// exceed GuestInfoMaxSize. The decoded user-data is validated according to its type: the YAML syntax and
+// top-level keys of #cloud-config, and the structure and parts of multipart MIME.
+func ValidateUserData(value string, base64Required bool) []Issue {
+	size := len(value)
+	if !base64Required {
+		size = gzipBase64Size(value)
+	}
+	if size > GuestInfoMaxSize {
+		return []Issue{{Message: fmt.Sprintf("encoded size of %d bytes exceeds the guestinfo maximum of %d bytes",
+			size, GuestInfoMaxSize)}}
+	}
+
+	var issues []Issue
+	if base64Required {
+		// User-data that is not base64 encoded was accepted before it was validated, so it is only warned about.
+		if _, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err != nil {
+			issues = append(issues, Issue{Message: fmt.Sprintf("should be base64 encoded: %v", err), Warning: true})
+			base64Required = false
+		}
+	}
+
+	data, err := Decode(value, base64Required)
+	if err != nil {
+		return append(issues, Issue{Message: err.Error()})
+	}
+
+	return append(issues, validate(data)...)
+}
+
+// Decode returns the user-data decoded from base64 and gzip. Unless base64Required is true, user-data that is
+// not valid base64 is used as is.
+func Decode(value string, base64Required bool) ([]byte, error) {
+	data := []byte(value)
+
+	if base64Required || header(data) == "" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		switch {
+		case err == nil:
+			data = decoded
+		case base64Required:
+			return nil, fmt.Errorf("must be base64 encoded: %v", err)
+		}
+	}
+
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip: %v", err)
+		}
+		defer zr.Close()
+
+		if data, err = ioutil.ReadAll(io.LimitReader(zr, DecodedMaxSize+1)); err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip: %v", err)
+		}
+		if len(data) > DecodedMaxSize {
+			return nil, fmt.Errorf("decompressed size exceeds the maximum of %d bytes", DecodedMaxSize)
+		}
+	}
+
+	return data, nil
+}
+
+// validate validates the decoded user-data according to its type.
+func validate(data []byte) []Issue {
+	if isMultipart(data) {
+		return validateMultipart(data)
+	}
+
+	switch header(data) {
+	case "#cloud-config":
+		return validateCloudConfig(data)
+	case "":
+		return []Issue{{
+			Line:    1,
+			Message: "does not start with a header cloud-init recognizes, such as #cloud-config or #!, and is ignored",
+			Warning: true,
+		}}
+	}
+
+	return nil
+}
+
+// header returns the cloud-init header that the user-data starts with, or an empty string.
+func header(data []byte) string {
+	firstLine := string(data)
+	if i := strings.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+	firstLine = strings.TrimSpace(firstLine)
+
+	for _, h := range headers {
+		if strings.HasPrefix(firstLine, h) {
+			return h
+		}
+	}
+	return ""
+}
+
+// isMultipart returns whether the user-data is a MIME message, that cloud-init recognizes by its headers.
+func isMultipart(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("Content-Type:")) || bytes.HasPrefix(data, []byte("MIME-Version:"))
+}
+
+// validateCloudConfig validates the YAML syntax and top-level keys of #cloud-config user-data.
+func validateCloudConfig(data []byte) []Issue {
+	var config yaml.MapSlice
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return []Issue{yamlIssue(err)}
+	}
+
+	keyLines := topLevelKeyLines(data)
+
+	var issues []Issue
+	for _, item := range config {
+		key, ok := item.Key.(string)
+		if !ok {
+			issues = append(issues, Issue{Message: fmt.Sprintf("top-level key %v is not a string", item.Key)})
+			continue
+		}
+		if _, ok := cloudConfigKeys[key]; !ok {
+			issues = append(issues, Issue{
+				Line:    keyLines[key],
+				Message: fmt.Sprintf("unknown top-level key %q is ignored by cloud-init", key),
+				Warning: true,
+			})
+		}
+	}
+
+	return issues
+}
+
+// yamlIssue returns the issue of a YAML error, on the line of the error when the error has one.
+func yamlIssue(err error) Issue {
+	msg := strings.TrimSpace(err.Error())
+	if m := yamlErrorLineRe.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Issue{Line: line, Message: "invalid YAML: " + m[2]}
+	}
+	if m := yamlUnmarshalLineRe.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Issue{Line: line, Message: "#cloud-config must be a YAML mapping"}
+	}
+	return Issue{Message: "invalid YAML: " + strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// topLevelKeyLines returns the first line of each top-level key of a YAML mapping.
+func topLevelKeyLines(data []byte) map[string]int {
+	lines := map[string]int{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		if m := topLevelKeyRe.FindStringSubmatch(scanner.Text()); m != nil {
+			if _, ok := lines[m[1]]; !ok {
+				lines[m[1]] = n
+			}
+		}
+	}
+
+	return lines
+}
+
+// validateMultipart validates the structure of multipart MIME user-data, and each of its parts according to
+// its content type.
+func validateMultipart(data []byte) []Issue {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return []Issue{{Message: fmt.Sprintf("invalid MIME message: %v", err)}}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return []Issue{{Message: fmt.Sprintf("invalid MIME Content-Type: %v", err)}}
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		// A single part MIME message.
+		return validatePart(0, mediaType, msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	}
+	if params["boundary"] == "" {
+		return []Issue{{Message: "multipart MIME Content-Type has no boundary"}}
+	}
+
+	var issues []Issue
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for n := 1; ; n++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			if n == 1 {
+				issues = append(issues, Issue{Message: "multipart MIME message has no parts"})
+			}
+			break
+		}
+		if err != nil {
+			issues = append(issues, Issue{Part: n, Message: fmt.Sprintf("invalid MIME part: %v", err)})
+			break
+		}
+
+		partType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err != nil {
+			issues = append(issues, Issue{Part: n, Message: fmt.Sprintf("invalid MIME Content-Type: %v", err)})
+			continue
+		}
+
+		issues = append(issues, validatePart(n, partType, part.Header.Get("Content-Transfer-Encoding"), part)...)
+	}
+
+	return issues
+}
+
+// validatePart validates a MIME part according to its content type. Parts of text/plain are validated
+// according to their header, like user-data that is not multipart.
+func validatePart(n int, contentType, transferEncoding string, body io.Reader) []Issue {
+	inPart := func(issues []Issue) []Issue {
+		for i := range issues {
+			issues[i].Part = n
+		}
+		return issues
+	}
+
+	if _, ok := partContentTypes[contentType]; !ok {
+		return inPart([]Issue{{
+			Message: fmt.Sprintf("content type %q is not handled by cloud-init and is ignored", contentType),
+			Warning: true,
+		}})
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return inPart([]Issue{{Message: fmt.Sprintf("failed to read MIME part: %v", err)}})
+	}
+	if strings.EqualFold(transferEncoding, "base64") {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(data), nil)))
+		if err != nil {
+			return inPart([]Issue{{Message: fmt.Sprintf("invalid base64 Content-Transfer-Encoding: %v", err)}})
+		}
+		data = decoded
+	}
+
+	switch contentType {
+	case "text/cloud-config":
+		return inPart(validateCloudConfig(data))
+	case "text/plain":
+		return inPart(validate(data))
+	}
+
+	return nil
+}
+
+// gzipBase64Size returns the size of the value once gzip and base64 encoded.
+func gzipBase64Size(value string) int {
+	var zbuf bytes.Buffer
+	zw := gzip.NewWriter(&zbuf)
+	_, _ = zw.Write([]byte(value))
+	_ = zw.Close()
+	return base64.StdEncoding.EncodedLen(zbuf.Len())
+}
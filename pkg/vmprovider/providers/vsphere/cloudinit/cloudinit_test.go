@@ -0,0 +1,155 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudinit_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"math/rand"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/cloudinit"
+)
+
+func gzipBase64(s string) string {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(zw.Close()).To(Succeed())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+var _ = Describe("ValidateUserData", func() {
+	const validCloudConfig = `#cloud-config
+users:
+- name: vmware
+  ssh_authorized_keys:
+  - ssh-rsa AAAA
+runcmd:
+- echo hello
+`
+
+	It("allows valid #cloud-config", func() {
+		Expect(cloudinit.ValidateUserData(validCloudConfig, false)).To(BeEmpty())
+	})
+
+	It("allows base64 and gzip encoded #cloud-config", func() {
+		Expect(cloudinit.ValidateUserData(base64.StdEncoding.EncodeToString([]byte(validCloudConfig)), false)).To(BeEmpty())
+		Expect(cloudinit.ValidateUserData(gzipBase64(validCloudConfig), false)).To(BeEmpty())
+		Expect(cloudinit.ValidateUserData(gzipBase64(validCloudConfig), true)).To(BeEmpty())
+	})
+
+	It("allows a shell script", func() {
+		Expect(cloudinit.ValidateUserData("#!/bin/sh\necho hello\n", false)).To(BeEmpty())
+	})
+
+	It("warns about user-data that is not base64 encoded when base64 is required", func() {
+		issues := cloudinit.ValidateUserData(validCloudConfig, true)
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Warning).To(BeTrue())
+		Expect(issues[0].Error()).To(HavePrefix("should be base64 encoded"))
+	})
+
+	It("validates user-data that is not base64 encoded when base64 is required", func() {
+		issues := cloudinit.ValidateUserData("#cloud-config\n- runcmd\n", true)
+		Expect(issues).To(HaveLen(2))
+		Expect(issues[0].Warning).To(BeTrue())
+		Expect(issues[1].Error()).To(Equal("line 2: #cloud-config must be a YAML mapping"))
+	})
+
+	It("denies gzip user-data that decompresses beyond the maximum", func() {
+		issues := cloudinit.ValidateUserData(gzipBase64(strings.Repeat("#", cloudinit.DecodedMaxSize+1)), false)
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Warning).To(BeFalse())
+		Expect(issues[0].Error()).To(ContainSubstring("decompressed size exceeds the maximum"))
+	})
+
+	It("returns the line of a YAML syntax error", func() {
+		issues := cloudinit.ValidateUserData("#cloud-config\nusers:\n- name: vmware\n  groups: [sudo\n", false)
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Warning).To(BeFalse())
+		Expect(issues[0].Line).To(BeNumerically(">", 1))
+		Expect(issues[0].Error()).To(ContainSubstring("invalid YAML"))
+	})
+
+	It("requires #cloud-config to be a mapping", func() {
+		issues := cloudinit.ValidateUserData("#cloud-config\n- runcmd\n", false)
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Error()).To(Equal("line 2: #cloud-config must be a YAML mapping"))
+	})
+
+	It("warns about unknown top-level keys", func() {
+		issues := cloudinit.ValidateUserData("#cloud-config\nruncmd:\n- echo hello\nusres:\n- name: vmware\n", false)
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Warning).To(BeTrue())
+		Expect(issues[0].Error()).To(Equal(`line 4: unknown top-level key "usres" is ignored by cloud-init`))
+	})
+
+	It("warns about user-data without a header", func() {
+		issues := cloudinit.ValidateUserData("runcmd:\n- echo hello\n", false)
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Warning).To(BeTrue())
+	})
+
+	It("denies user-data that exceeds the guestinfo maximum", func() {
+		random := make([]byte, cloudinit.GuestInfoMaxSize)
+		_, _ = rand.New(rand.NewSource(0)).Read(random)
+		issues := cloudinit.ValidateUserData("#!/bin/sh\n# "+base64.StdEncoding.EncodeToString(random), false)
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Error()).To(ContainSubstring("exceeds the guestinfo maximum"))
+	})
+
+	Context("multipart MIME", func() {
+		multipart := func(parts ...string) string {
+			var b strings.Builder
+			b.WriteString("Content-Type: multipart/mixed; boundary=\"BOUNDARY\"\nMIME-Version: 1.0\n\n")
+			for _, p := range parts {
+				b.WriteString("--BOUNDARY\n")
+				b.WriteString(p)
+				b.WriteString("\n")
+			}
+			b.WriteString("--BOUNDARY--\n")
+			return b.String()
+		}
+
+		It("allows valid parts", func() {
+			userData := multipart(
+				"Content-Type: text/cloud-config\n\n"+validCloudConfig,
+				"Content-Type: text/x-shellscript\n\n#!/bin/sh\necho hello",
+				"Content-Type: text/cloud-config\nContent-Transfer-Encoding: base64\n\n"+
+					base64.StdEncoding.EncodeToString([]byte(validCloudConfig)))
+			Expect(cloudinit.ValidateUserData(userData, false)).To(BeEmpty())
+		})
+
+		It("returns the part and line of an invalid part", func() {
+			userData := multipart(
+				"Content-Type: text/x-shellscript\n\n#!/bin/sh\necho hello",
+				"Content-Type: text/cloud-config\n\n#cloud-config\nruncmd:\n- echo hello\nusres: []")
+			issues := cloudinit.ValidateUserData(userData, false)
+			Expect(issues).To(HaveLen(1))
+			Expect(issues[0].Error()).To(Equal(`part 2, line 4: unknown top-level key "usres" is ignored by cloud-init`))
+		})
+
+		It("warns about a content type cloud-init does not handle", func() {
+			issues := cloudinit.ValidateUserData(multipart("Content-Type: application/json\n\n{}"), false)
+			Expect(issues).To(HaveLen(1))
+			Expect(issues[0].Warning).To(BeTrue())
+			Expect(issues[0].Part).To(Equal(1))
+		})
+
+		It("denies a multipart message without a boundary", func() {
+			issues := cloudinit.ValidateUserData("Content-Type: multipart/mixed\nMIME-Version: 1.0\n\nbody\n", false)
+			Expect(issues).To(HaveLen(1))
+			Expect(issues[0].Warning).To(BeFalse())
+			Expect(issues[0].Error()).To(ContainSubstring("no boundary"))
+		})
+	})
+})
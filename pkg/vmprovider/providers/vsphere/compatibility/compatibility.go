@@ -0,0 +1,230 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package compatibility
+
+import (
+	"fmt"
+
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+// Subject is the part of a VirtualMachine that an Incompatibility is attributed to.
+type Subject string
+
+const (
+	// SubjectFirmware is the FirmwareOverrideAnnotation of the VM.
+	SubjectFirmware Subject = "Firmware"
+	// SubjectImage is the VirtualMachineImage of the VM.
+	SubjectImage Subject = "Image"
+	// SubjectClass is the VirtualMachineClass of the VM.
+	SubjectClass Subject = "Class"
+)
+
+// Incompatibility describes why a VM cannot be created from its VirtualMachineImage and VirtualMachineClass.
+type Incompatibility struct {
+	Subject Subject
+	Message string
+	// Warning is set when the VM can be created but may not work as expected, like a device that may not be
+	// usable by the guest.
+	Warning bool
+}
+
+func (i Incompatibility) Error() string {
+	return i.Message
+}
+
+// Args are the inputs to Evaluate.
+type Args struct {
+	VM      *vmopv1alpha1.VirtualMachine
+	VMClass *vmopv1alpha1.VirtualMachineClass
+	VMImage *vmopv1alpha1.VirtualMachineImage
+	// VMImageInfo is the inspected VirtualMachineImageInfo of the image, or nil if the image has not been inspected.
+	VMImageInfo *vmopapiv1alpha1.VirtualMachineImageInfo
+	// GuestOSIdsToFamily are the supported guest OS identifiers of the cluster, as returned by
+	// GetClusterVMConfigOptions. The guest OS is not checked when this is empty.
+	GuestOSIdsToFamily map[string]string
+}
+
+// Evaluate returns the reasons why the VM cannot be created from its image and class, or nil if the pairing is
+// compatible. Incompatibilities with Warning set do not prevent the VM from being created. Checks that depend on information that is not available, like an image that has not been inspected,
+// are skipped.
+func Evaluate(args Args) []Incompatibility {
+	var incompatibilities []Incompatibility
+
+	incompatibilities = append(incompatibilities, checkFirmware(args)...)
+	incompatibilities = append(incompatibilities, checkPCIDevices(args)...)
+	incompatibilities = append(incompatibilities, checkInstanceStorage(args)...)
+	incompatibilities = append(incompatibilities, CheckGuestOS(args.VM, args.VMImage, args.GuestOSIdsToFamily)...)
+
+	return incompatibilities
+}
+
+// CheckGuestOS checks that the guest OS of the image is a supported Linux guest of the cluster. The check is skipped
+// when the VMOperatorImageSupportedCheckKey annotation disables it, or the cluster's guest OS identifiers are not known.
+func CheckGuestOS(
+	vm *vmopv1alpha1.VirtualMachine,
+	vmImage *vmopv1alpha1.VirtualMachineImage,
+	guestOSIdsToFamily map[string]string) []Incompatibility {
+
+	if vm.Annotations[constants.VMOperatorImageSupportedCheckKey] == constants.VMOperatorImageSupportedCheckDisable {
+		return nil
+	}
+	if len(guestOSIdsToFamily) == 0 {
+		return nil
+	}
+
+	osType := vmImage.Spec.OSInfo.Type
+	// osFamily will be present for supported OSTypes and support only VirtualMachineGuestOsFamilyLinuxGuest for now
+	if osFamily := guestOSIdsToFamily[osType]; osFamily != string(vimTypes.VirtualMachineGuestOsFamilyLinuxGuest) {
+		return []Incompatibility{{
+			Subject: SubjectImage,
+			Message: fmt.Sprintf("image osType '%s' is not supported by VMService", osType),
+		}}
+	}
+
+	return nil
+}
+
+// firmware returns the firmware the VM will boot with, or an empty string if it is not known.
+func firmware(args Args) string {
+	switch val := args.VM.Annotations[constants.FirmwareOverrideAnnotation]; val {
+	case vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS, vmopapiv1alpha1.VirtualMachineImageFirmwareEFI:
+		return val
+	}
+	if args.VMImageInfo != nil {
+		if args.VMImageInfo.Firmware != "" {
+			return args.VMImageInfo.Firmware
+		}
+		return vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS
+	}
+	return ""
+}
+
+// hardwareVersion returns the virtual hardware version of the image, or zero if it is not known.
+func hardwareVersion(args Args) int32 {
+	if args.VMImage != nil && args.VMImage.Spec.HardwareVersion != 0 {
+		return args.VMImage.Spec.HardwareVersion
+	}
+	if args.VMImageInfo != nil {
+		return args.VMImageInfo.HardwareVersion
+	}
+	return 0
+}
+
+func checkFirmware(args Args) []Incompatibility {
+	var incompatibilities []Incompatibility
+
+	val, ok := args.VM.Annotations[constants.FirmwareOverrideAnnotation]
+	if !ok {
+		return nil
+	}
+
+	switch val {
+	case vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS, vmopapiv1alpha1.VirtualMachineImageFirmwareEFI:
+	default:
+		return append(incompatibilities, Incompatibility{
+			Subject: SubjectFirmware,
+			Message: fmt.Sprintf("firmware %q is not supported, must be one of %q or %q", val,
+				vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS, vmopapiv1alpha1.VirtualMachineImageFirmwareEFI),
+		})
+	}
+
+	// An image installed with EFI has no BIOS boot loader. The reverse is allowed since many images boot with either.
+	if val == vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS && args.VMImageInfo != nil &&
+		args.VMImageInfo.Firmware == vmopapiv1alpha1.VirtualMachineImageFirmwareEFI {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			Subject: SubjectFirmware,
+			Message: fmt.Sprintf("VirtualMachineImage %s requires %q firmware", args.VMImage.Name,
+				vmopapiv1alpha1.VirtualMachineImageFirmwareEFI),
+		})
+	}
+
+	return incompatibilities
+}
+
+func checkPCIDevices(args Args) []Incompatibility {
+	var incompatibilities []Incompatibility
+
+	if args.VMClass == nil {
+		return nil
+	}
+
+	devices := args.VMClass.Spec.Hardware.Devices
+	if len(devices.VGPUDevices) == 0 && len(devices.DynamicDirectPathIODevices) == 0 {
+		return nil
+	}
+
+	if hwVersion := hardwareVersion(args); hwVersion != 0 && hwVersion < constants.MinSupportedHWVersionForPCIPassthruDevices {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			Subject: SubjectClass,
+			Message: fmt.Sprintf("VirtualMachineClass %s has vGPU or DirectPath devices that require a minimum hardware "+
+				"version of %d, but VirtualMachineImage %s has hardware version %d",
+				args.VMClass.Name, constants.MinSupportedHWVersionForPCIPassthruDevices, args.VMImage.Name, hwVersion),
+		})
+	}
+
+	// The 64-bit MMIO that is enabled for the devices is only available with EFI firmware. Devices with small BARs
+	// work without it, so this does not prevent the VM from being created.
+	mmioSize, ok := args.VM.Annotations[constants.PCIPassthruMMIOOverrideAnnotation]
+	if (!ok || mmioSize != "0") && firmware(args) == vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			Subject: SubjectClass,
+			Message: fmt.Sprintf("VirtualMachineClass %s has vGPU or DirectPath devices that may require %q firmware "+
+				"for 64-bit MMIO, which is not available with %q firmware",
+				args.VMClass.Name, vmopapiv1alpha1.VirtualMachineImageFirmwareEFI, vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS),
+			Warning: true,
+		})
+	}
+
+	return incompatibilities
+}
+
+func checkInstanceStorage(args Args) []Incompatibility {
+	var incompatibilities []Incompatibility
+
+	if args.VMClass == nil || !lib.IsInstanceStorageFSSEnabled() {
+		return nil
+	}
+
+	instanceStorage := args.VMClass.Spec.Hardware.InstanceStorage
+	if len(instanceStorage.Volumes) == 0 {
+		return nil
+	}
+
+	if instanceStorage.StorageClass == "" {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			Subject: SubjectClass,
+			Message: fmt.Sprintf("VirtualMachineClass %s has instance storage volumes but no instance storage StorageClass",
+				args.VMClass.Name),
+		})
+	}
+
+	for i, volume := range instanceStorage.Volumes {
+		if volume.Size.Sign() <= 0 {
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Subject: SubjectClass,
+				Message: fmt.Sprintf("VirtualMachineClass %s has instance storage volume %d with a non-positive size",
+					args.VMClass.Name, i),
+			})
+		}
+	}
+
+	// Instance storage volumes are attached as PersistentVolumes.
+	if hwVersion := hardwareVersion(args); hwVersion != 0 && hwVersion < constants.MinSupportedHWVersionForPVC {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			Subject: SubjectClass,
+			Message: fmt.Sprintf("VirtualMachineClass %s has instance storage volumes that require a minimum hardware "+
+				"version of %d, but VirtualMachineImage %s has hardware version %d",
+				args.VMClass.Name, constants.MinSupportedHWVersionForPVC, args.VMImage.Name, hwVersion),
+		})
+	}
+
+	return incompatibilities
+}
@@ -0,0 +1,275 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package compatibility_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/compatibility"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+var _ = Describe("Evaluate", func() {
+	var (
+		args              compatibility.Args
+		incompatibilities []compatibility.Incompatibility
+	)
+
+	BeforeEach(func() {
+		args = compatibility.Args{
+			VM: &vmopv1alpha1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dummy-vm",
+					Annotations: map[string]string{},
+				},
+			},
+			VMClass: &vmopv1alpha1.VirtualMachineClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "dummy-class"},
+			},
+			VMImage: &vmopv1alpha1.VirtualMachineImage{
+				ObjectMeta: metav1.ObjectMeta{Name: "dummy-image"},
+				Spec: vmopv1alpha1.VirtualMachineImageSpec{
+					OSInfo:          vmopv1alpha1.VirtualMachineImageOSInfo{Type: "ubuntu64Guest"},
+					HardwareVersion: 13,
+				},
+			},
+			VMImageInfo: &vmopapiv1alpha1.VirtualMachineImageInfo{
+				Firmware: vmopapiv1alpha1.VirtualMachineImageFirmwareEFI,
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		incompatibilities = compatibility.Evaluate(args)
+	})
+
+	It("is compatible", func() {
+		Expect(incompatibilities).To(BeEmpty())
+	})
+
+	Context("Firmware", func() {
+		When("the firmware override is not supported", func() {
+			BeforeEach(func() {
+				args.VM.Annotations[constants.FirmwareOverrideAnnotation] = "uefi"
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(ConsistOf(compatibility.Incompatibility{
+					Subject: compatibility.SubjectFirmware,
+					Message: `firmware "uefi" is not supported, must be one of "bios" or "efi"`,
+				}))
+			})
+		})
+
+		When("the firmware override is BIOS for an EFI image", func() {
+			BeforeEach(func() {
+				args.VM.Annotations[constants.FirmwareOverrideAnnotation] = "bios"
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(ConsistOf(compatibility.Incompatibility{
+					Subject: compatibility.SubjectFirmware,
+					Message: `VirtualMachineImage dummy-image requires "efi" firmware`,
+				}))
+			})
+		})
+
+		When("the firmware override is EFI for a BIOS image", func() {
+			BeforeEach(func() {
+				args.VMImageInfo.Firmware = vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS
+				args.VM.Annotations[constants.FirmwareOverrideAnnotation] = "efi"
+			})
+
+			It("is compatible", func() {
+				Expect(incompatibilities).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("vGPU and DirectPath devices", func() {
+		BeforeEach(func() {
+			args.VMClass.Spec.Hardware.Devices.VGPUDevices = []vmopv1alpha1.VGPUDevice{{ProfileName: "profile"}}
+			args.VMImage.Spec.HardwareVersion = constants.MinSupportedHWVersionForPCIPassthruDevices
+		})
+
+		It("is compatible", func() {
+			Expect(incompatibilities).To(BeEmpty())
+		})
+
+		When("the image hardware version is too old", func() {
+			BeforeEach(func() {
+				args.VMImage.Spec.HardwareVersion = 15
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(HaveLen(1))
+				Expect(incompatibilities[0].Subject).To(Equal(compatibility.SubjectClass))
+				Expect(incompatibilities[0].Message).To(ContainSubstring("minimum hardware version of 17"))
+			})
+		})
+
+		When("the image hardware version is only known from the image info", func() {
+			BeforeEach(func() {
+				args.VMImage.Spec.HardwareVersion = 0
+				args.VMImageInfo.HardwareVersion = 15
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(HaveLen(1))
+				Expect(incompatibilities[0].Message).To(ContainSubstring("has hardware version 15"))
+			})
+		})
+
+		When("the VM boots with BIOS", func() {
+			BeforeEach(func() {
+				args.VMImageInfo.Firmware = ""
+			})
+
+			It("is compatible with a warning", func() {
+				Expect(incompatibilities).To(ConsistOf(compatibility.Incompatibility{
+					Subject: compatibility.SubjectClass,
+					Message: `VirtualMachineClass dummy-class has vGPU or DirectPath devices that may require "efi" firmware ` +
+						`for 64-bit MMIO, which is not available with "bios" firmware`,
+					Warning: true,
+				}))
+			})
+
+			When("64-bit MMIO is disabled", func() {
+				BeforeEach(func() {
+					args.VM.Annotations[constants.PCIPassthruMMIOOverrideAnnotation] = "0"
+				})
+
+				It("is compatible", func() {
+					Expect(incompatibilities).To(BeEmpty())
+				})
+			})
+		})
+
+		When("the image has not been inspected", func() {
+			BeforeEach(func() {
+				args.VMImageInfo = nil
+			})
+
+			It("is compatible", func() {
+				Expect(incompatibilities).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("Instance storage", func() {
+		var oldInstanceStorageFSSFunc func() bool
+
+		BeforeEach(func() {
+			oldInstanceStorageFSSFunc = lib.IsInstanceStorageFSSEnabled
+			lib.IsInstanceStorageFSSEnabled = func() bool { return true }
+
+			args.VMClass.Spec.Hardware.InstanceStorage = vmopv1alpha1.InstanceStorage{
+				StorageClass: "dummy-storage-class",
+				Volumes: []vmopv1alpha1.InstanceStorageVolume{
+					{Size: resource.MustParse("256Gi")},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			lib.IsInstanceStorageFSSEnabled = oldInstanceStorageFSSFunc
+		})
+
+		It("is compatible", func() {
+			Expect(incompatibilities).To(BeEmpty())
+		})
+
+		When("the class has no instance storage StorageClass", func() {
+			BeforeEach(func() {
+				args.VMClass.Spec.Hardware.InstanceStorage.StorageClass = ""
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(ConsistOf(compatibility.Incompatibility{
+					Subject: compatibility.SubjectClass,
+					Message: "VirtualMachineClass dummy-class has instance storage volumes but no instance storage StorageClass",
+				}))
+			})
+		})
+
+		When("a volume has no size", func() {
+			BeforeEach(func() {
+				args.VMClass.Spec.Hardware.InstanceStorage.Volumes[0].Size = resource.Quantity{}
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(HaveLen(1))
+				Expect(incompatibilities[0].Message).To(ContainSubstring("non-positive size"))
+			})
+		})
+
+		When("the image hardware version is too old", func() {
+			BeforeEach(func() {
+				args.VMImage.Spec.HardwareVersion = 11
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(HaveLen(1))
+				Expect(incompatibilities[0].Message).To(ContainSubstring("minimum hardware version of 13"))
+			})
+		})
+
+		When("the Instance Storage FSS is disabled", func() {
+			BeforeEach(func() {
+				lib.IsInstanceStorageFSSEnabled = func() bool { return false }
+				args.VMClass.Spec.Hardware.InstanceStorage.StorageClass = ""
+			})
+
+			It("is compatible", func() {
+				Expect(incompatibilities).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("Guest OS", func() {
+		BeforeEach(func() {
+			args.GuestOSIdsToFamily = map[string]string{
+				"ubuntu64Guest":    "linuxGuest",
+				"windows9_64Guest": "windowsGuest",
+			}
+		})
+
+		It("is compatible", func() {
+			Expect(incompatibilities).To(BeEmpty())
+		})
+
+		When("the image guest OS is not a supported Linux guest", func() {
+			BeforeEach(func() {
+				args.VMImage.Spec.OSInfo.Type = "windows9_64Guest"
+			})
+
+			It("is not compatible", func() {
+				Expect(incompatibilities).To(ConsistOf(compatibility.Incompatibility{
+					Subject: compatibility.SubjectImage,
+					Message: "image osType 'windows9_64Guest' is not supported by VMService",
+				}))
+			})
+
+			When("the image supported check is disabled", func() {
+				BeforeEach(func() {
+					args.VM.Annotations[constants.VMOperatorImageSupportedCheckKey] = constants.VMOperatorImageSupportedCheckDisable
+				})
+
+				It("is compatible", func() {
+					Expect(incompatibilities).To(BeEmpty())
+				})
+			})
+		})
+	})
+})
@@ -15,6 +15,42 @@ const (
 
 	// VCVMAnnotation Annotation placed on the VM.
 	VCVMAnnotation = "Virtual Machine managed by the vSphere Virtual Machine service"
+	// VCVMRetainedAnnotation Annotation placed on a VM that is no longer managed because it was retained
+	// when its VirtualMachine was deleted.
+	VCVMRetainedAnnotation = "Virtual Machine retained after deletion from the vSphere Virtual Machine service"
+
+	// DeletionPolicyAnnotation is the annotation key that selects what happens to the vSphere VM when
+	// its VirtualMachine is deleted.
+	DeletionPolicyAnnotation = pkg.VMOperatorKey + "/deletion-policy"
+	// DeletionPolicyDelete destroys the vSphere VM. This is the default.
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetain powers off the vSphere VM and removes it from VM Operator management.
+	DeletionPolicyRetain = "Retain"
+	// DeletionPolicyOrphan leaves the vSphere VM untouched.
+	DeletionPolicyOrphan = "Orphan"
+	// RetainedVMFolderAnnotation is the annotation key for the managed object ID of the Folder that a
+	// retained VM is moved into. When not set, the VM is left in its current Folder.
+	RetainedVMFolderAnnotation = pkg.VMOperatorKey + "/retained-vm-folder"
+	// TerminationGracePeriodAnnotation is the annotation key for how long to wait for the guest OS to shut
+	// down before the VM is powered off and deleted, as a duration string of at most one hour. It overrides the
+	// default from the environment.
+	TerminationGracePeriodAnnotation = pkg.VMOperatorKey + "/termination-grace-period"
+
+	// The options of a VM that boots from an ISO image are annotations, like the firmware and MMIO overrides,
+	// since the VirtualMachine and VirtualMachineClass types are defined by vm-operator-api and cannot be extended
+	// here. The VirtualMachine webhook validates the annotations of a VM like it would the fields.
+
+	// BootDiskSizeAnnotation is the annotation key for the size of the blank boot disk of a VM that boots from
+	// an ISO image, as a quantity like "40Gi". It is read from the VirtualMachine, then from its VirtualMachineClass.
+	BootDiskSizeAnnotation = pkg.VMOperatorKey + "/boot-disk-size"
+	BootDiskSizeDefault    = "20Gi"
+	// GuestIDAnnotation is the annotation key for the vSphere guest OS identifier of a VM that boots from an
+	// ISO image, since the ISO image does not describe its guest OS.
+	GuestIDAnnotation = pkg.VMOperatorKey + "/guest-id"
+	GuestIDDefault    = "otherGuest64"
+	// EjectISOAnnotation is the annotation key that, when "true", ejects the ISO image from a VM that booted
+	// from it once the installed guest OS is running VMware Tools.
+	EjectISOAnnotation = pkg.VMOperatorKey + "/eject-iso-after-install"
 
 	// VMOperatorImageSupportedCheckKey Annotation key to skip validation checks of GuestOS Type
 	// TODO: Rename and move to vmoperator-api.
@@ -41,6 +77,39 @@ const (
 	// maintenance mode. This is to ensure the maintenance mode workflow is consistent for VMs with vGPU/DDPIO devices.
 	MMPowerOffVMExtraConfigKey = "maintenance.vm.evacuation.poweroff"
 
+	// BackupVMResourceExtraConfigKey, BackupVMAdditionalResourcesExtraConfigKey and BackupVMDiskDataExtraConfigKey
+	// are the ExtraConfig keys that hold the gzipped and base64 encoded VirtualMachine, its metadata ConfigMap and
+	// its volume attachments, so the VirtualMachine can be recreated after the VM is restored from a backup.
+	BackupVMResourceExtraConfigKey            = "vmservice.virtualmachine.resource"
+	BackupVMAdditionalResourcesExtraConfigKey = "vmservice.virtualmachine.additional.resources"
+	BackupVMDiskDataExtraConfigKey            = "vmservice.virtualmachine.disk.data"
+	// BackupVMInstanceUUIDExtraConfigKey and BackupVMMoIDExtraConfigKey are the ExtraConfig keys of the instance
+	// UUID and the managed object ID of the VM when it was backed up. A VM restored from a backup is a new VM, so
+	// its MoID differs from the recorded one even when the restore keeps the instance UUID.
+	BackupVMInstanceUUIDExtraConfigKey = "vmservice.virtualmachine.instance.uuid"
+	BackupVMMoIDExtraConfigKey         = "vmservice.virtualmachine.moid"
+
+	// ImageCacheFolderName is the name of the child Folder of a namespace's Folder that has the template VMs
+	// caching the images that VMs are linked cloned from.
+	ImageCacheFolderName = "vmop-image-cache"
+	// ImageCacheSnapshotName is the name of the snapshot of an image cache template VM that VMs are linked
+	// cloned from.
+	ImageCacheSnapshotName = "vmop-image-cache"
+	// ImageCacheVMAnnotation is the vSphere annotation of an image cache template VM.
+	ImageCacheVMAnnotation = "Image cache of VM Operator. Linked clones depend on the disks of this VM."
+	// ImageCacheItemIDExtraConfigKey, ImageCacheContentVersionExtraConfigKey and ImageCacheLastUsedExtraConfigKey
+	// are the ExtraConfig keys on an image cache template VM for the library item and content version it caches,
+	// and when a VM was last cloned from it.
+	ImageCacheItemIDExtraConfigKey         = "vmservice.imagecache.itemID"
+	ImageCacheContentVersionExtraConfigKey = "vmservice.imagecache.contentVersion"
+	ImageCacheLastUsedExtraConfigKey       = "vmservice.imagecache.lastUsed"
+	// LinkedCloneSourceExtraConfigKey is the ExtraConfig key on a linked clone for the name of the image cache
+	// template VM it was cloned from.
+	LinkedCloneSourceExtraConfigKey = "vmservice.linkedclone.source"
+
+	// RestoredVMAnnotation is set on a VirtualMachine that was recreated from the backup recorded on a restored VM.
+	RestoredVMAnnotation = pkg.VMOperatorKey + "/restored-vm"
+
 	// NetPlanVersion points to the version used for Network config.
 	// For more information, please see https://cloudinit.readthedocs.io/en/latest/topics/network-config-format-v2.html
 	NetPlanVersion = 2
@@ -57,10 +126,20 @@ const (
 
 	// MinSupportedHWVersionForPVC is the supported virtual hardware version for persistent volumes.
 	MinSupportedHWVersionForPVC = 13
+	// MinSupportedHWVersionForPCIPassthruDevices is the supported virtual hardware version for vGPU and Dynamic
+	// DirectPath I/O devices.
+	MinSupportedHWVersionForPCIPassthruDevices = 17
 
 	// FirmwareOverrideAnnotation is the annotation key used for firmware override.
 	FirmwareOverrideAnnotation = pkg.VMOperatorKey + "/firmware"
 
+	// ExtraConfigAnnotation is the annotation key for a JSON object of ExtraConfig keys and values. On a VM, it is
+	// the ExtraConfig of the VM. On a VirtualMachineClass, it is the ExtraConfig of the VMs of the class.
+	ExtraConfigAnnotation = pkg.VMOperatorKey + "/extra-config"
+	// EffectiveExtraConfigAnnotation is the annotation key for a JSON object of the ExtraConfig that was last set on
+	// the VM, merged from the global, namespace, class and VM ExtraConfig and the keys that VM Operator manages.
+	EffectiveExtraConfigAnnotation = pkg.VMOperatorKey + "/effective-extra-config"
+
 	CloudInitTypeAnnotation         = pkg.VMOperatorKey + "/cloudinit-type"
 	CloudInitTypeValueCloudInitPrep = "cloudinitprep"
 	CloudInitTypeValueGuestInfo     = "guestinfo"
@@ -5,6 +5,9 @@ package contentlibrary
 
 import (
 	"context"
+	"crypto/x509"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -22,7 +25,9 @@ import (
 
 	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
 )
 
@@ -30,6 +35,10 @@ type Provider interface {
 	GetLibraryItems(ctx context.Context, clUUID string) ([]library.Item, error)
 	GetLibraryItem(ctx context.Context, clUUID, itemName string) (*library.Item, error)
 	RetrieveOvfEnvelopeFromLibraryItem(ctx context.Context, item *library.Item) (*ovf.Envelope, error)
+	VirtualMachineImageInfoForLibraryItem(ctx context.Context, itemID string) (*vmopapiv1alpha1.VirtualMachineImageInfo, error)
+	CreateLibraryItemFromFiles(ctx context.Context, libraryItem library.Item, files vmprovider.LibraryItemFiles) (string, error)
+	VerifyLibraryItem(ctx context.Context, itemID string, roots *x509.CertPool) (*vmprovider.ImageVerification, error)
+	GetLibraryItemStorage(ctx context.Context, itemID string) ([]LibraryItemStorage, error)
 
 	// TODO: Testing only. Remove these from this file.
 	CreateLibrary(ctx context.Context, contentSource, datastoreID string) (string, error)
@@ -42,6 +51,13 @@ type Provider interface {
 		currentCLImages map[string]v1alpha1.VirtualMachineImage) ([]*v1alpha1.VirtualMachineImage, error)
 }
 
+// LibraryItemStorage is the storage of a file of a library item.
+type LibraryItemStorage struct {
+	FileName       string                  `json:"file_name"`
+	StorageBacking library.StorageBackings `json:"storage_backing"`
+	StorageURIs    []string                `json:"storage_uris"`
+}
+
 type provider struct {
 	libMgr        *library.Manager
 	retryInterval time.Duration
@@ -50,11 +66,14 @@ type provider struct {
 const (
 	EnvContentLibAPIWaitSecs     = "CONTENT_API_WAIT_SECS" // BMV: Investigate if setting this to 1 actually reduces the integration test time.
 	DefaultContentLibAPIWaitSecs = 5
+
+	// libraryItemStoragePath is the path of the library item storage API, which govmomi does not provide.
+	libraryItemStoragePath = "/com/vmware/content/library/item/storage"
 )
 
 func IsSupportedDeployType(t string) bool {
 	switch t {
-	case library.ItemTypeVMTX, library.ItemTypeOVF:
+	case library.ItemTypeVMTX, library.ItemTypeOVF, library.ItemTypeISO:
 		// Keep in sync with what cloneVMFromContentLibrary() handles.
 		return true
 	default:
@@ -156,6 +175,34 @@ func (cs *provider) RetrieveOvfEnvelopeFromLibraryItem(ctx context.Context, item
 	return envelope, nil
 }
 
+// VirtualMachineImageInfoForLibraryItem inspects the OVF descriptor of the library item.
+func (cs *provider) VirtualMachineImageInfoForLibraryItem(
+	ctx context.Context,
+	itemID string) (*vmopapiv1alpha1.VirtualMachineImageInfo, error) {
+
+	item, err := cs.libMgr.GetLibraryItem(ctx, itemID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get library item: %s", itemID)
+	}
+
+	if item.Type != library.ItemTypeOVF {
+		return nil, errors.Wrapf(vmprovider.ErrImageUnsupported, "library item %s has type %q", item.Name, item.Type)
+	}
+
+	ovfEnvelope, err := cs.RetrieveOvfEnvelopeFromLibraryItem(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+	if ovfEnvelope == nil {
+		return nil, errors.Wrapf(vmprovider.ErrImageCorrupt, "library item %s has an invalid OVF descriptor", item.Name)
+	}
+	if ovfEnvelope.VirtualSystem == nil {
+		return nil, errors.Wrapf(vmprovider.ErrImageUnsupported, "OVF descriptor of library item %s has no VirtualSystem", item.Name)
+	}
+
+	return GetVirtualMachineImageInfoFromOvf(ovfEnvelope), nil
+}
+
 // Only used in testing.
 func (cs *provider) CreateLibrary(ctx context.Context, name, datastoreID string) (string, error) {
 	log.Info("Creating Library", "libraryName", name)
@@ -189,58 +236,142 @@ func (cs *provider) DeleteLibraryItem(ctx context.Context, libraryItem *library.
 func (cs *provider) CreateLibraryItem(ctx context.Context, libraryItem library.Item, path string) error {
 	log.Info("Creating Library Item", "item", libraryItem, "path", path)
 
-	itemID, err := cs.libMgr.CreateLibraryItem(ctx, libraryItem)
+	f, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = f.Close()
+	}()
 
-	sessionID, err := cs.libMgr.CreateLibraryItemUpdateSession(ctx, library.Session{LibraryItemID: itemID})
+	fi, err := f.Stat()
 	if err != nil {
 		return err
 	}
 
-	// Update Library item with library file "ovf"
-	uploadFunc := func(c *rest.Client, path string) error {
-		f, err := os.Open(filepath.Clean(path))
-		if err != nil {
-			return err
-		}
-		defer func() {
-			_ = f.Close()
-		}()
+	files := &libraryItemFileList{
+		files: []vmprovider.LibraryItemFile{{Name: filepath.Base(path), Size: fi.Size(), Reader: f}},
+	}
 
-		fi, err := f.Stat()
+	_, err = cs.CreateLibraryItemFromFiles(ctx, libraryItem, files)
+	return err
+}
+
+// CreateLibraryItemFromFiles creates the library item, and streams the files to it in a single update session.
+// The item is deleted again if the files cannot be uploaded. An existing item with the same name and the same
+// non-empty description was left behind by an interrupted create of the same item, and is replaced.
+func (cs *provider) CreateLibraryItemFromFiles(
+	ctx context.Context,
+	libraryItem library.Item,
+	files vmprovider.LibraryItemFiles) (string, error) {
+
+	itemIDs, err := cs.libMgr.FindLibraryItems(ctx, library.FindItem{LibraryID: libraryItem.LibraryID, Name: libraryItem.Name})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find library item: %s", libraryItem.Name)
+	}
+	for _, itemID := range itemIDs {
+		existing, err := cs.libMgr.GetLibraryItem(ctx, itemID)
 		if err != nil {
-			return err
+			return "", errors.Wrapf(err, "failed to get library item: %s", libraryItem.Name)
+		}
+		if libraryItem.Description == "" || existing.Description != libraryItem.Description {
+			return "", errors.Wrapf(vmprovider.ErrLibraryItemExists, "library item %s", libraryItem.Name)
 		}
 
-		info := library.UpdateFile{
-			Name:       filepath.Base(path),
-			SourceType: "PUSH",
-			Size:       fi.Size(),
+		log.Info("Deleting library item left behind by an interrupted create", "itemID", itemID, "itemName", libraryItem.Name)
+		if err := cs.libMgr.DeleteLibraryItem(ctx, existing); err != nil {
+			return "", errors.Wrapf(err, "failed to delete library item: %s", libraryItem.Name)
 		}
+	}
 
-		update, err := cs.libMgr.AddLibraryItemFile(ctx, sessionID, info)
-		if err != nil {
-			return err
+	itemID, err := cs.libMgr.CreateLibraryItem(ctx, libraryItem)
+	if err != nil {
+		return "", err
+	}
+
+	logger := log.WithValues("itemID", itemID, "itemName", libraryItem.Name)
+
+	if err := cs.uploadLibraryItemFiles(ctx, logger, itemID, files); err != nil {
+		if err := cs.libMgr.DeleteLibraryItem(ctx, &library.Item{ID: itemID}); err != nil {
+			logger.Error(err, "Error deleting library item after failed upload")
 		}
+		return "", err
+	}
 
-		u, err := url.Parse(update.UploadEndpoint.URI)
+	return itemID, nil
+}
+
+// uploadLibraryItemFiles uploads the files to the library item. The update session is failed if a file
+// cannot be uploaded, so no partial content is committed to the item.
+func (cs *provider) uploadLibraryItemFiles(
+	ctx context.Context,
+	logger logr.Logger,
+	itemID string,
+	files vmprovider.LibraryItemFiles) error {
+
+	sessionID, err := cs.libMgr.CreateLibraryItemUpdateSession(ctx, library.Session{LibraryItemID: itemID})
+	if err != nil {
+		return err
+	}
+
+	for {
+		file, err := files.Next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = cs.uploadLibraryItemFile(ctx, sessionID, file)
+		}
 		if err != nil {
+			if err := cs.libMgr.FailLibraryItemUpdateSession(ctx, sessionID); err != nil {
+				logger.Error(err, "Error failing update session", "sessionID", sessionID)
+			}
 			return err
 		}
+		logger.V(4).Info("uploaded library item file", "fileName", file.Name)
+	}
 
-		p := soap.DefaultUpload
-		p.ContentLength = info.Size
+	return cs.libMgr.CompleteLibraryItemUpdateSession(ctx, sessionID)
+}
 
-		return c.Upload(ctx, f, u, &p)
+func (cs *provider) uploadLibraryItemFile(ctx context.Context, sessionID string, file *vmprovider.LibraryItemFile) error {
+	info := library.UpdateFile{
+		Name:       file.Name,
+		SourceType: "PUSH",
+	}
+	if file.Size >= 0 {
+		info.Size = file.Size
 	}
 
-	if err = uploadFunc(cs.libMgr.Client, path); err != nil {
+	update, err := cs.libMgr.AddLibraryItemFile(ctx, sessionID, info)
+	if err != nil {
 		return err
 	}
 
-	return cs.libMgr.CompleteLibraryItemUpdateSession(ctx, sessionID)
+	u, err := url.Parse(update.UploadEndpoint.URI)
+	if err != nil {
+		return err
+	}
+
+	// A negative ContentLength streams a file of unknown size with chunked encoding.
+	p := soap.DefaultUpload
+	p.ContentLength = file.Size
+
+	return cs.libMgr.Client.Upload(ctx, file.Reader, u, &p)
+}
+
+// libraryItemFileList is a LibraryItemFiles of files that are known upfront.
+type libraryItemFileList struct {
+	files []vmprovider.LibraryItemFile
+}
+
+func (l *libraryItemFileList) Next() (*vmprovider.LibraryItemFile, error) {
+	if len(l.files) == 0 {
+		return nil, io.EOF
+	}
+	file := l.files[0]
+	l.files = l.files[1:]
+	return &file, nil
 }
 
 // Lists all the VirtualMachineImages from a CL by a given UUID.
@@ -256,28 +387,42 @@ func (cs *provider) VirtualMachineImageResourcesForLibrary(
 		return nil, err
 	}
 
+	var itemErrs []vmprovider.LibraryItemError
 	images := make([]*v1alpha1.VirtualMachineImage, 0, len(items))
 	for i := range items {
 		var ovfEnvelope *ovf.Envelope
 		item := items[i]
 
-		if curImage, ok := currentCLImages[item.ID]; ok {
+		curImage, hasCurImage := currentCLImages[item.ID]
+		if hasCurImage {
 			// If there is already an VMImage for this item, and it is the same - as determined by _just_ the
 			// annotation - reuse the existing VMImage. This is to avoid repeated CL fetch tasks that would
 			// otherwise be created, spamming the UI. It would be nice if CL provided an external API that
 			// allowed us to silently fetch the OVF.
 			annotations := curImage.GetAnnotations()
-			if ver := annotations[constants.VMImageCLVersionAnnotation]; ver == libItemVersionAnnotation(&item) {
+			ver := annotations[constants.VMImageCLVersionAnnotation]
+			if ver == libItemVersionAnnotation(&item) {
 				images = append(images, &curImage)
 				continue
 			}
+
+			// Only the metadata of the item changed, e.g. it was renamed, so the OVF does not need to be fetched.
+			if isLibItemContentUnchanged(ver, &item) {
+				images = append(images, updateVirtualMachineImageFromLibItem(curImage.DeepCopy(), &item))
+				continue
+			}
 		}
 
 		switch item.Type {
 		case library.ItemTypeOVF:
 			if ovfEnvelope, err = cs.RetrieveOvfEnvelopeFromLibraryItem(ctx, &item); err != nil {
 				log.Error(err, "error extracting the OVF envelope from the library item", "itemName", item.Name)
-				return nil, err
+				itemErrs = append(itemErrs, vmprovider.LibraryItemError{ItemID: item.ID, ItemName: item.Name, Err: err})
+				if hasCurImage {
+					// Keep the existing VMImage so that it is not deleted because of a transient error.
+					images = append(images, &curImage)
+				}
+				continue
 			}
 			if ovfEnvelope == nil {
 				log.Error(err, "no valid OVF envelope found, skipping library item", "itemName", item.Name)
@@ -286,6 +431,8 @@ func (cs *provider) VirtualMachineImageResourcesForLibrary(
 		case library.ItemTypeVMTX:
 			// Do not try to populate VMTX types, but resVm.GetOvfProperties() should return an
 			// OvfEnvelope.
+		case library.ItemTypeISO:
+			// ISO images have no OVF descriptor. VMs boot from them with a blank boot disk.
 		default:
 			// Not a supported type. Keep this in sync with cloneVMFromContentLibrary().
 			continue
@@ -294,6 +441,10 @@ func (cs *provider) VirtualMachineImageResourcesForLibrary(
 		images = append(images, LibItemToVirtualMachineImage(&item, ovfEnvelope))
 	}
 
+	if len(itemErrs) > 0 {
+		return images, &vmprovider.LibraryItemsError{Items: itemErrs}
+	}
+
 	return images, nil
 }
 
@@ -316,7 +467,7 @@ func (cs *provider) generateDownloadURLForLibraryItem(
 	var fileToDownload string
 	for _, file := range files {
 		logger.V(4).Info("Library Item file", "fileName", file.Name)
-		if ext := filepath.Ext(file.Name); ext != "" && IsSupportedDeployType(ext[1:]) {
+		if filepath.Ext(file.Name) == "."+library.ItemTypeOVF {
 			fileToDownload = file.Name
 			break
 		}
@@ -325,7 +476,16 @@ func (cs *provider) generateDownloadURLForLibraryItem(
 		return nil, errors.Errorf("No files with supported deploy type are available for download for %s", item.ID)
 	}
 
-	_, err = cs.libMgr.PrepareLibraryItemDownloadSessionFile(ctx, sessionID, fileToDownload)
+	return cs.prepareDownloadSessionFile(ctx, logger, sessionID, fileToDownload)
+}
+
+// prepareDownloadSessionFile prepares the file of the download session, and returns the URL it can be downloaded from.
+func (cs *provider) prepareDownloadSessionFile(
+	ctx context.Context,
+	logger logr.Logger,
+	sessionID, fileToDownload string) (*url.URL, error) {
+
+	_, err := cs.libMgr.PrepareLibraryItemDownloadSessionFile(ctx, sessionID, fileToDownload)
 	if err != nil {
 		return nil, err
 	}
@@ -374,3 +534,14 @@ func (cs *provider) generateDownloadURLForLibraryItem(
 
 	return url.Parse(fileURL)
 }
+
+// GetLibraryItemStorage returns the storage of the files of the library item.
+func (cs *provider) GetLibraryItemStorage(ctx context.Context, itemID string) ([]LibraryItemStorage, error) {
+	resource := cs.libMgr.Resource(libraryItemStoragePath).WithParam("library_item_id", itemID)
+	var storage []LibraryItemStorage
+	if err := cs.libMgr.Do(ctx, resource.Request(http.MethodGet), &storage); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the storage of library item %s", itemID)
+	}
+
+	return storage, nil
+}
@@ -0,0 +1,238 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package contentlibrary_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/rest"
+	_ "github.com/vmware/govmomi/vapi/simulator" // blank import the VAPI simulator bindings
+	"github.com/vmware/govmomi/vim25"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/contentlibrary"
+)
+
+const testOVF = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1">
+  <VirtualSystem ovf:id="test-vm" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"/>
+</Envelope>
+`
+
+// testFiles is a LibraryItemFiles of the given files.
+type testFiles struct {
+	files []vmprovider.LibraryItemFile
+}
+
+func (t *testFiles) Next() (*vmprovider.LibraryItemFile, error) {
+	if len(t.files) == 0 {
+		return nil, io.EOF
+	}
+	file := t.files[0]
+	t.files = t.files[1:]
+	return &file, nil
+}
+
+// failingReader returns an error after the content of its reader.
+type failingReader struct {
+	io.Reader
+	err error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.Reader.Read(p)
+	if err == io.EOF {
+		return n, f.err
+	}
+	return n, err
+}
+
+var _ = Describe("CreateLibraryItemFromFiles", func() {
+
+	var (
+		clProvider contentlibrary.Provider
+		libMgr     *library.Manager
+		libID      string
+	)
+
+	withLibrary := func(fn func(ctx context.Context)) {
+		simulator.Test(func(ctx context.Context, c *vim25.Client) {
+			restClient := rest.NewClient(c)
+			Expect(restClient.Login(ctx, simulator.DefaultLogin)).To(Succeed())
+
+			ds, err := find.NewFinder(c).DefaultDatastore(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			clProvider = contentlibrary.NewProviderWithWaitSec(restClient, 1)
+			libMgr = library.NewManager(restClient)
+
+			libID, err = clProvider.CreateLibrary(ctx, "import-library", ds.Reference().Value)
+			Expect(err).ToNot(HaveOccurred())
+
+			fn(ctx)
+		})
+	}
+
+	ovfFile := func() vmprovider.LibraryItemFile {
+		return vmprovider.LibraryItemFile{Name: "test.ovf", Size: int64(len(testOVF)), Reader: strings.NewReader(testOVF)}
+	}
+
+	It("streams the files to a new library item", func() {
+		withLibrary(func(ctx context.Context) {
+			// The size of the file is not known, like for a chunked HTTP response.
+			file := ovfFile()
+			file.Size = -1
+
+			item := library.Item{LibraryID: libID, Name: "test-item", Type: library.ItemTypeOVF}
+			itemID, err := clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{file}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(itemID).ToNot(BeEmpty())
+
+			created, err := libMgr.GetLibraryItem(ctx, itemID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.Name).To(Equal("test-item"))
+
+			envelope, err := clProvider.RetrieveOvfEnvelopeFromLibraryItem(ctx, created)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(envelope).ToNot(BeNil())
+			Expect(envelope.VirtualSystem.ID).To(Equal("test-vm"))
+		})
+	})
+
+	It("returns ErrLibraryItemExists when the library has an item with the name", func() {
+		withLibrary(func(ctx context.Context) {
+			item := library.Item{LibraryID: libID, Name: "test-item", Type: library.ItemTypeOVF}
+			_, err := clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{ovfFile()}})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{ovfFile()}})
+			Expect(errors.Is(err, vmprovider.ErrLibraryItemExists)).To(BeTrue())
+		})
+	})
+
+	It("replaces an item with the same description that an interrupted create left behind", func() {
+		withLibrary(func(ctx context.Context) {
+			item := library.Item{LibraryID: libID, Name: "test-item", Type: library.ItemTypeOVF, Description: "import-1"}
+			oldItemID, err := clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{ovfFile()}})
+			Expect(err).ToNot(HaveOccurred())
+
+			itemID, err := clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{ovfFile()}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(itemID).ToNot(Equal(oldItemID))
+
+			items, err := clProvider.GetLibraryItems(ctx, libID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(items).To(HaveLen(1))
+			Expect(items[0].ID).To(Equal(itemID))
+
+			item.Description = "import-2"
+			_, err = clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{ovfFile()}})
+			Expect(errors.Is(err, vmprovider.ErrLibraryItemExists)).To(BeTrue())
+		})
+	})
+
+	It("deletes the library item when a file cannot be read", func() {
+		withLibrary(func(ctx context.Context) {
+			readErr := errors.New("read error")
+			file := ovfFile()
+			file.Size = -1
+			file.Reader = &failingReader{Reader: file.Reader, err: readErr}
+
+			item := library.Item{LibraryID: libID, Name: "test-item", Type: library.ItemTypeOVF}
+			_, err := clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{file}})
+			Expect(err).To(HaveOccurred())
+
+			items, err := clProvider.GetLibraryItems(ctx, libID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(items).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("VirtualMachineImageResourcesForLibrary", func() {
+
+	It("lists ISO library items as ISO images", func() {
+		simulator.Test(func(ctx context.Context, c *vim25.Client) {
+			restClient := rest.NewClient(c)
+			Expect(restClient.Login(ctx, simulator.DefaultLogin)).To(Succeed())
+
+			ds, err := find.NewFinder(c).DefaultDatastore(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			clProvider := contentlibrary.NewProviderWithWaitSec(restClient, 1)
+			libID, err := clProvider.CreateLibrary(ctx, "iso-library", ds.Reference().Value)
+			Expect(err).ToNot(HaveOccurred())
+
+			isoFile := vmprovider.LibraryItemFile{Name: "test.iso", Size: 4, Reader: strings.NewReader("data")}
+			item := library.Item{LibraryID: libID, Name: "test-iso", Type: library.ItemTypeISO}
+			_, err = clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{isoFile}})
+			Expect(err).ToNot(HaveOccurred())
+
+			images, err := clProvider.VirtualMachineImageResourcesForLibrary(ctx, libID, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(images).To(HaveLen(1))
+			Expect(images[0].Spec.Type).To(Equal(library.ItemTypeISO))
+			Expect(images[0].Status.ImageName).To(Equal("test-iso"))
+		})
+	})
+
+	It("reuses an image whose version annotation does not have the content version of the item", func() {
+		simulator.Test(func(ctx context.Context, c *vim25.Client) {
+			restClient := rest.NewClient(c)
+			Expect(restClient.Login(ctx, simulator.DefaultLogin)).To(Succeed())
+
+			ds, err := find.NewFinder(c).DefaultDatastore(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			clProvider := contentlibrary.NewProviderWithWaitSec(restClient, 1)
+			libID, err := clProvider.CreateLibrary(ctx, "ovf-library", ds.Reference().Value)
+			Expect(err).ToNot(HaveOccurred())
+
+			ovfFile := vmprovider.LibraryItemFile{Name: "test.ovf", Size: int64(len(testOVF)), Reader: strings.NewReader(testOVF)}
+			item := library.Item{LibraryID: libID, Name: "test-ovf", Type: library.ItemTypeOVF}
+			itemID, err := clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: []vmprovider.LibraryItemFile{ovfFile}})
+			Expect(err).ToNot(HaveOccurred())
+
+			libItem, err := library.NewManager(restClient).GetLibraryItem(ctx, itemID)
+			Expect(err).ToNot(HaveOccurred())
+
+			curImage := vmopv1alpha1.VirtualMachineImage{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-ovf",
+					Annotations: map[string]string{
+						constants.VMImageCLVersionAnnotation: fmt.Sprintf("%s:%s:%d", libItem.ID, libItem.Version,
+							constants.VMImageCLVersionAnnotationVersion),
+					},
+				},
+				Spec: vmopv1alpha1.VirtualMachineImageSpec{
+					ProductInfo: vmopv1alpha1.VirtualMachineImageProductInfo{Product: "from-ovf"},
+				},
+			}
+
+			images, err := clProvider.VirtualMachineImageResourcesForLibrary(ctx, libID,
+				map[string]vmopv1alpha1.VirtualMachineImage{libItem.ID: curImage})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(images).To(HaveLen(1))
+			Expect(images[0].Spec.ProductInfo.Product).To(Equal("from-ovf"))
+			Expect(images[0].Annotations[constants.VMImageCLVersionAnnotation]).To(Equal(fmt.Sprintf("%s:%s:%s:%d",
+				libItem.ID, libItem.Version, libItem.ContentVersion, constants.VMImageCLVersionAnnotationVersion)))
+		})
+	})
+})
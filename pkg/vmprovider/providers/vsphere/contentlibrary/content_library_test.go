@@ -42,6 +42,92 @@ var _ = Describe("ParseVirtualHardwareVersion", func() {
 	})
 })
 
+var _ = Describe("GetVirtualMachineImageInfoFromOvf", func() {
+	var ovfEnvelope *ovf.Envelope
+
+	BeforeEach(func() {
+		ethernetType, diskType := uint16(10), uint16(17)
+		populatedSize := 1024
+		ovfEnvelope = &ovf.Envelope{
+			Disk: &ovf.DiskSection{
+				Disks: []ovf.VirtualDiskDesc{
+					{DiskID: "vmdisk1", Capacity: "10", CapacityAllocationUnits: pointer.String("byte * 2^30"), PopulatedSize: &populatedSize},
+					{DiskID: "vmdisk2", Capacity: "${disk.size}"},
+				},
+			},
+			VirtualSystem: &ovf.VirtualSystem{
+				OperatingSystem: []ovf.OperatingSystemSection{
+					{OSType: pointer.String("ubuntu64Guest")},
+				},
+				VirtualHardware: []ovf.VirtualHardwareSection{
+					{
+						System: &ovf.VirtualSystemSettingData{CIMVirtualSystemSettingData: ovf.CIMVirtualSystemSettingData{
+							VirtualSystemType: pointer.String("vmx-15"),
+						}},
+						Item: []ovf.ResourceAllocationSettingData{
+							{CIMResourceAllocationSettingData: ovf.CIMResourceAllocationSettingData{ResourceType: &ethernetType}},
+							{CIMResourceAllocationSettingData: ovf.CIMResourceAllocationSettingData{ResourceType: &ethernetType}},
+							{CIMResourceAllocationSettingData: ovf.CIMResourceAllocationSettingData{ResourceType: &diskType}},
+						},
+						Config: []ovf.Config{
+							{Key: "firmware", Value: "efi"},
+						},
+					},
+				},
+				Product: []ovf.ProductSection{
+					{
+						Property: []ovf.Property{
+							{
+								Key:              "hostname",
+								Type:             "string",
+								Qualifiers:       pointer.String("MinLen(1)"),
+								UserConfigurable: pointer.Bool(true),
+								Label:            pointer.String("Hostname"),
+							},
+							{
+								Key:      "password",
+								Type:     "string",
+								Password: pointer.Bool(true),
+								Default:  pointer.String(""),
+							},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("returns the info of the OVF", func() {
+		info := contentlibrary.GetVirtualMachineImageInfoFromOvf(ovfEnvelope)
+		Expect(info.GuestOSID).To(Equal("ubuntu64Guest"))
+		Expect(info.Firmware).To(Equal("efi"))
+		Expect(info.HardwareVersion).To(BeEquivalentTo(15))
+		Expect(info.NICCount).To(BeEquivalentTo(2))
+
+		Expect(info.Disks).To(HaveLen(2))
+		Expect(info.Disks[0].ID).To(Equal("vmdisk1"))
+		Expect(info.Disks[0].Capacity.String()).To(Equal("10Gi"))
+		Expect(info.Disks[0].PopulatedSize.Value()).To(BeEquivalentTo(1024))
+		Expect(info.Disks[1].ID).To(Equal("vmdisk2"))
+		Expect(info.Disks[1].Capacity).To(BeNil())
+
+		Expect(info.OVFProperties).To(HaveLen(2))
+		Expect(info.OVFProperties[0].Key).To(Equal("hostname"))
+		Expect(info.OVFProperties[0].Qualifiers).To(Equal("MinLen(1)"))
+		Expect(info.OVFProperties[0].UserConfigurable).To(BeTrue())
+		Expect(info.OVFProperties[0].Label).To(Equal("Hostname"))
+		Expect(info.OVFProperties[1].Key).To(Equal("password"))
+		Expect(info.OVFProperties[1].UserConfigurable).To(BeFalse())
+		Expect(info.OVFProperties[1].Password).To(BeTrue())
+	})
+
+	It("defaults the firmware to BIOS", func() {
+		ovfEnvelope.VirtualSystem.VirtualHardware[0].Config = nil
+		info := contentlibrary.GetVirtualMachineImageInfoFromOvf(ovfEnvelope)
+		Expect(info.Firmware).To(Equal("bios"))
+	})
+})
+
 var _ = Describe("LibItemToVirtualMachineImage", func() {
 	const (
 		versionKey = "vmware-system-version"
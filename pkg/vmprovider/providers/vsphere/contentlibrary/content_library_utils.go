@@ -5,6 +5,7 @@ package contentlibrary
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
@@ -19,9 +20,11 @@ import (
 
 	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
@@ -158,6 +161,146 @@ func GetUserConfigurablePropertiesFromOvf(ovfEnvelope *ovf.Envelope) map[string]
 	return properties
 }
 
+// ovfEthernetAdapterResourceType is the CIM ResourceType of a network interface in the OVF VirtualHardwareSection.
+const ovfEthernetAdapterResourceType = 10
+
+// ovfCapacityAllocationUnitsRe matches the OVF capacityAllocationUnits of a disk, e.g. "byte * 2^30".
+var ovfCapacityAllocationUnitsRe = regexp.MustCompile(`^byte(?:\s*\*\s*(\d+)\s*\^\s*(\d+))?$`)
+
+// GetVirtualMachineImageInfoFromOvf returns the VirtualMachineImageInfo that describes the contents of the OVF.
+func GetVirtualMachineImageInfoFromOvf(ovfEnvelope *ovf.Envelope) *vmopapiv1alpha1.VirtualMachineImageInfo {
+	info := &vmopapiv1alpha1.VirtualMachineImageInfo{
+		Firmware: vmopapiv1alpha1.VirtualMachineImageFirmwareBIOS,
+	}
+
+	if ovfEnvelope.Disk != nil {
+		for _, disk := range ovfEnvelope.Disk.Disks {
+			info.Disks = append(info.Disks, getDiskInfoFromOvf(disk))
+		}
+	}
+
+	vs := ovfEnvelope.VirtualSystem
+	if vs == nil {
+		return info
+	}
+
+	if os := vs.OperatingSystem; len(os) > 0 && os[0].OSType != nil {
+		info.GuestOSID = *os[0].OSType
+	}
+
+	if virtualHwSection := vs.VirtualHardware; len(virtualHwSection) > 0 {
+		hw := virtualHwSection[0]
+		if hw.System != nil && hw.System.VirtualSystemType != nil {
+			info.HardwareVersion = ParseVirtualHardwareVersion(*hw.System.VirtualSystemType)
+		}
+
+		for _, config := range hw.Config {
+			if config.Key == "firmware" && config.Value != "" {
+				info.Firmware = config.Value
+			}
+		}
+
+		for _, item := range hw.Item {
+			if item.ResourceType != nil && *item.ResourceType == ovfEthernetAdapterResourceType {
+				info.NICCount++
+			}
+		}
+	}
+
+	for _, product := range vs.Product {
+		for _, prop := range product.Property {
+			property := vmopapiv1alpha1.VirtualMachineImageOVFProperty{
+				Key:              prop.Key,
+				Type:             prop.Type,
+				Default:          prop.Default,
+				UserConfigurable: prop.UserConfigurable != nil && *prop.UserConfigurable,
+				Password:         prop.Password != nil && *prop.Password,
+			}
+			if prop.Qualifiers != nil {
+				property.Qualifiers = *prop.Qualifiers
+			}
+			if prop.Label != nil {
+				property.Label = *prop.Label
+			}
+			if prop.Description != nil {
+				property.Description = *prop.Description
+			}
+			info.OVFProperties = append(info.OVFProperties, property)
+		}
+	}
+
+	return info
+}
+
+// GetVirtualMachineImageInfo returns the VirtualMachineImageInfo recorded on the image, or nil if the image
+// has not been inspected.
+func GetVirtualMachineImageInfo(image *v1alpha1.VirtualMachineImage) (*vmopapiv1alpha1.VirtualMachineImageInfo, error) {
+	data, ok := image.Annotations[vmopapiv1alpha1.VirtualMachineImageInfoAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	info := &vmopapiv1alpha1.VirtualMachineImageInfo{}
+	if err := json.Unmarshal([]byte(data), info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal VirtualMachineImageInfo of image %s: %w", image.Name, err)
+	}
+
+	return info, nil
+}
+
+func getDiskInfoFromOvf(disk ovf.VirtualDiskDesc) vmopapiv1alpha1.VirtualMachineImageDiskInfo {
+	diskInfo := vmopapiv1alpha1.VirtualMachineImageDiskInfo{
+		ID: disk.DiskID,
+	}
+
+	// The capacity may also be a reference to a property, in which case it is not known until deployment.
+	if capacity, err := strconv.ParseInt(disk.Capacity, 10, 64); err == nil {
+		if units, ok := parseCapacityAllocationUnits(disk.CapacityAllocationUnits); ok {
+			diskInfo.Capacity = resource.NewQuantity(capacity*units, resource.BinarySI)
+		}
+	}
+
+	if disk.PopulatedSize != nil {
+		diskInfo.PopulatedSize = resource.NewQuantity(int64(*disk.PopulatedSize), resource.BinarySI)
+	}
+
+	return diskInfo
+}
+
+// parseCapacityAllocationUnits returns the number of bytes in a unit of disk capacity. The OVF default is bytes.
+func parseCapacityAllocationUnits(units *string) (int64, bool) {
+	if units == nil {
+		return 1, true
+	}
+
+	m := ovfCapacityAllocationUnitsRe.FindStringSubmatch(strings.TrimSpace(*units))
+	if m == nil {
+		return 0, false
+	}
+	if m[1] == "" {
+		return 1, true
+	}
+
+	base, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	exponent, err := strconv.Atoi(m[2])
+	if err != nil {
+		return 0, false
+	}
+
+	multiplier := int64(1)
+	for i := 0; i < exponent; i++ {
+		multiplier *= base
+		if multiplier <= 0 || multiplier > 1<<50 {
+			return 0, false
+		}
+	}
+
+	return multiplier, true
+}
+
 func GetVmwareSystemPropertiesFromOvf(ovfEnvelope *ovf.Envelope) map[string]string {
 	properties := make(map[string]string)
 
@@ -187,7 +330,37 @@ func readerFromURL(ctx context.Context, c *rest.Client, url *url.URL) (io.ReadCl
 
 // libItemVersionAnnotation returns the version annotation value for the item.
 func libItemVersionAnnotation(item *library.Item) string {
-	return fmt.Sprintf("%s:%s:%d", item.ID, item.Version, constants.VMImageCLVersionAnnotationVersion)
+	return fmt.Sprintf("%s:%s:%s:%d", item.ID, item.Version, item.ContentVersion, constants.VMImageCLVersionAnnotationVersion)
+}
+
+// isLibItemContentUnchanged returns true if the VMImageCLVersionAnnotation of an image was created for the same
+// content version of the library item, so only the metadata of the item may have changed. An annotation without
+// the content version, from before it was recorded, is unchanged when the item has not changed at all, so that
+// the images do not all refetch their OVF once the content version is added to the annotation.
+func isLibItemContentUnchanged(versionAnnotation string, item *library.Item) bool {
+	parts := strings.Split(versionAnnotation, ":")
+	annotationVersion := strconv.Itoa(constants.VMImageCLVersionAnnotationVersion)
+
+	switch len(parts) {
+	case 3:
+		return parts[0] == item.ID && parts[1] == item.Version && parts[2] == annotationVersion
+	case 4:
+		return item.ContentVersion != "" && parts[0] == item.ID && parts[2] == item.ContentVersion &&
+			parts[3] == annotationVersion
+	default:
+		return false
+	}
+}
+
+// updateVirtualMachineImageFromLibItem updates the fields of the image that LibItemToVirtualMachineImage sets from
+// the library item's metadata, rather than from its OVF.
+func updateVirtualMachineImageFromLibItem(image *v1alpha1.VirtualMachineImage, item *library.Item) *v1alpha1.VirtualMachineImage {
+	image.Annotations[constants.VMImageCLVersionAnnotation] = libItemVersionAnnotation(item)
+	image.Spec.Type = item.Type
+	image.Status.InternalId = item.Name
+	image.Status.ImageName = item.Name
+
+	return image
 }
 
 // isImageSupported checks if the image is deemed supported by VM Operator.
@@ -0,0 +1,381 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package contentlibrary
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha1" // register SHA-1 for manifests that use it
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vapi/library"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+)
+
+// maxManifestSize is the maximum size of the OVF manifest and signing certificate files, which are read into memory.
+const maxManifestSize = 1024 * 1024
+
+// digestLineRegex matches a line of an OVF manifest or signing certificate, like "SHA256(disk.vmdk)= 1a2b...".
+var digestLineRegex = regexp.MustCompile(`^(SHA1|SHA256|SHA512)\((.+)\)\s*=\s*([0-9a-fA-F]+)$`)
+
+var digestHashes = map[string]crypto.Hash{
+	"SHA1":   crypto.SHA1,
+	"SHA256": crypto.SHA256,
+	"SHA512": crypto.SHA512,
+}
+
+// manifestDigest is the digest of a file in an OVF manifest.
+type manifestDigest struct {
+	hash  crypto.Hash
+	value []byte
+}
+
+// LoadImageTrustBundle returns the PEM encoded certificates of the file at the path as a pool, or the system
+// roots when the path is empty.
+func LoadImageTrustBundle(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return x509.SystemCertPool()
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read image trust bundle %s", path)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("image trust bundle %s has no PEM encoded certificates", path)
+	}
+
+	return pool, nil
+}
+
+// VerifyLibraryItem downloads the files of the OVF library item and verifies them against the digests of its
+// OVF manifest. When the item has a signing certificate, the signature of the manifest is verified and the
+// certificate chain is verified against the roots.
+func (cs *provider) VerifyLibraryItem(
+	ctx context.Context,
+	itemID string,
+	roots *x509.CertPool) (*vmprovider.ImageVerification, error) {
+
+	item, err := cs.libMgr.GetLibraryItem(ctx, itemID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get library item: %s", itemID)
+	}
+
+	if item.Type != library.ItemTypeOVF {
+		return nil, errors.Wrapf(vmprovider.ErrImageUnsupported, "library item %s has type %q", item.Name, item.Type)
+	}
+
+	sessionID, err := cs.libMgr.CreateLibraryItemDownloadSession(ctx, library.Session{LibraryItemID: item.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.WithValues("sessionID", sessionID, "itemID", item.ID, "itemName", item.Name)
+	defer func() {
+		if err := cs.libMgr.DeleteLibraryItemDownloadSession(ctx, sessionID); err != nil {
+			logger.Error(err, "Error deleting download session")
+		}
+	}()
+
+	files, err := cs.libMgr.ListLibraryItemDownloadSessionFile(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifestName, certName string
+	var fileNames []string
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name)) {
+		case ".mf":
+			manifestName = file.Name
+		case ".cert":
+			certName = file.Name
+		default:
+			fileNames = append(fileNames, file.Name)
+		}
+	}
+
+	result := &vmprovider.ImageVerification{}
+
+	if manifestName == "" {
+		result.ManifestErr = errors.Wrapf(vmprovider.ErrImageManifestMissing, "library item %s has no .mf file", item.Name)
+		result.SignatureErr = errors.Wrapf(vmprovider.ErrImageUnsigned, "library item %s has no OVF manifest to sign", item.Name)
+		return result, nil
+	}
+
+	manifest, err := cs.readSessionFile(ctx, logger, sessionID, manifestName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cs.verifyManifestDigests(ctx, logger, sessionID, manifest, fileNames); err != nil {
+		if !errors.Is(err, vmprovider.ErrImageDigestMismatch) {
+			return nil, err
+		}
+		result.ManifestErr = errors.Wrapf(err, "library item %s", item.Name)
+	}
+
+	if certName == "" {
+		result.SignatureErr = errors.Wrapf(vmprovider.ErrImageUnsigned, "library item %s has no .cert file", item.Name)
+		return result, nil
+	}
+
+	cert, err := cs.readSessionFile(ctx, logger, sessionID, certName)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := verifyManifestSignature(manifestName, manifest, cert, roots)
+	if err != nil {
+		result.SignatureErr = errors.Wrapf(err, "library item %s", item.Name)
+		return result, nil
+	}
+	result.Signer = signer.Subject.String()
+
+	return result, nil
+}
+
+// verifyManifestDigests downloads the files of the download session and compares them with their digests in
+// the manifest. An error wrapping ErrImageDigestMismatch is returned when they do not match.
+func (cs *provider) verifyManifestDigests(
+	ctx context.Context,
+	logger logr.Logger,
+	sessionID string,
+	manifest []byte,
+	fileNames []string) error {
+
+	digests, err := parseManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range fileNames {
+		if _, ok := digests[name]; !ok {
+			return errors.Wrapf(vmprovider.ErrImageDigestMismatch, "file %s is not in the OVF manifest", name)
+		}
+	}
+	if len(digests) != len(fileNames) {
+		for name := range digests {
+			if !containsString(fileNames, name) {
+				return errors.Wrapf(vmprovider.ErrImageDigestMismatch, "file %s of the OVF manifest is missing", name)
+			}
+		}
+	}
+
+	for _, name := range fileNames {
+		digest := digests[name]
+
+		reader, err := cs.openSessionFile(ctx, logger, sessionID, name)
+		if err != nil {
+			return err
+		}
+
+		h := digest.hash.New()
+		_, err = io.Copy(h, reader)
+		_ = reader.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to download %s", name)
+		}
+
+		if !bytes.Equal(h.Sum(nil), digest.value) {
+			return errors.Wrapf(vmprovider.ErrImageDigestMismatch, "file %s does not match its digest", name)
+		}
+		logger.V(4).Info("Verified library item file digest", "fileName", name)
+	}
+
+	return nil
+}
+
+// openSessionFile prepares the file of the download session and downloads it.
+func (cs *provider) openSessionFile(
+	ctx context.Context,
+	logger logr.Logger,
+	sessionID, fileName string) (io.ReadCloser, error) {
+
+	fileURL, err := cs.prepareDownloadSessionFile(ctx, logger, sessionID, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return readerFromURL(ctx, cs.libMgr.Client, fileURL)
+}
+
+// readSessionFile downloads the file of the download session into memory.
+func (cs *provider) readSessionFile(
+	ctx context.Context,
+	logger logr.Logger,
+	sessionID, fileName string) ([]byte, error) {
+
+	reader, err := cs.openSessionFile(ctx, logger, sessionID, fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	data, err := ioutil.ReadAll(io.LimitReader(reader, maxManifestSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", fileName)
+	}
+	if len(data) > maxManifestSize {
+		return nil, errors.Errorf("file %s is larger than %d bytes", fileName, maxManifestSize)
+	}
+
+	return data, nil
+}
+
+// parseManifest returns the digests of the files in the OVF manifest by file name.
+func parseManifest(manifest []byte) (map[string]manifestDigest, error) {
+	digests := map[string]manifestDigest{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		name, digest, err := parseDigestLine(line)
+		if err != nil {
+			return nil, errors.Wrapf(vmprovider.ErrImageDigestMismatch, "invalid OVF manifest: %v", err)
+		}
+		if len(digest.value) != digest.hash.Size() {
+			return nil, errors.Wrapf(vmprovider.ErrImageDigestMismatch, "invalid OVF manifest: line %q has a digest of the wrong length", line)
+		}
+		digests[name] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(vmprovider.ErrImageDigestMismatch, "invalid OVF manifest: %v", err)
+	}
+
+	return digests, nil
+}
+
+// parseDigestLine parses a line like "SHA256(disk.vmdk)= 1a2b..." into the file name and its digest.
+func parseDigestLine(line string) (string, manifestDigest, error) {
+	m := digestLineRegex.FindStringSubmatch(line)
+	if m == nil {
+		return "", manifestDigest{}, errors.Errorf("line %q is not a digest", line)
+	}
+
+	value, err := hex.DecodeString(m[3])
+	if err != nil {
+		return "", manifestDigest{}, errors.Errorf("line %q has an invalid digest", line)
+	}
+
+	return m[2], manifestDigest{hash: digestHashes[m[1]], value: value}, nil
+}
+
+// verifyManifestSignature verifies the signing certificate file of the OVF manifest. The file starts with the
+// signature of the manifest, like "SHA256(image.mf)= 1a2b...", followed by the PEM encoded signing certificate
+// and any intermediate certificates. The signing certificate is returned when the signature is valid and the
+// certificate chains to the roots.
+func verifyManifestSignature(
+	manifestName string,
+	manifest, certFile []byte,
+	roots *x509.CertPool) (*x509.Certificate, error) {
+
+	header, rest := certFile, []byte(nil)
+	if idx := bytes.Index(certFile, []byte("-----BEGIN")); idx >= 0 {
+		header, rest = certFile[:idx], certFile[idx:]
+	}
+
+	line := strings.TrimSpace(string(header))
+	if line == "" {
+		return nil, errors.Wrap(vmprovider.ErrImageSignatureInvalid, "signing certificate file has no signature")
+	}
+	name, signature, err := parseDigestLine(line)
+	if err != nil {
+		return nil, errors.Wrapf(vmprovider.ErrImageSignatureInvalid, "invalid signing certificate file: %v", err)
+	}
+	if name != manifestName {
+		return nil, errors.Wrapf(vmprovider.ErrImageSignatureInvalid, "signature is for %s, not %s", name, manifestName)
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(vmprovider.ErrImageSignatureInvalid, "invalid signing certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.Wrap(vmprovider.ErrImageSignatureInvalid, "signing certificate file has no certificate")
+	}
+	signer := certs[0]
+
+	h := signature.hash.New()
+	_, _ = h.Write(manifest)
+	hashed := h.Sum(nil)
+
+	switch pub := signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, signature.hash, hashed, signature.value); err != nil {
+			return nil, errors.Wrapf(vmprovider.ErrImageSignatureInvalid, "%v", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, hashed, signature.value) {
+			return nil, errors.Wrap(vmprovider.ErrImageSignatureInvalid, "ECDSA verification failure")
+		}
+	default:
+		return nil, errors.Wrapf(vmprovider.ErrImageSignatureInvalid, "unsupported public key type %T", pub)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	// Only a certificate that is issued for code signing can sign images, not, for example, a TLS server
+	// certificate of the same CA.
+	_, err = signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(vmprovider.ErrImageCertificateUntrusted, "%v", err)
+	}
+
+	return signer, nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
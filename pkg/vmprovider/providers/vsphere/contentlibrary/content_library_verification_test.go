@@ -0,0 +1,252 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package contentlibrary_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/contentlibrary"
+)
+
+// testCertificate creates a certificate for the key, signed by the parent, or self-signed when parent is nil. A
+// certificate that is not a CA is issued for code signing, unless other extended key usages are given.
+func testCertificate(
+	commonName string,
+	isCA bool,
+	key crypto.Signer,
+	parent *x509.Certificate,
+	parentKey crypto.Signer,
+	extKeyUsages ...x509.ExtKeyUsage) *x509.Certificate {
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	if isCA {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+	}
+	if len(extKeyUsages) > 0 {
+		template.ExtKeyUsage = extKeyUsages
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+	return cert
+}
+
+func sha256Line(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("SHA256(%s)= %s\n", name, hex.EncodeToString(sum[:]))
+}
+
+// signManifest returns the content of a signing certificate file for the manifest.
+func signManifest(manifest []byte, key crypto.Signer, certs ...*x509.Certificate) []byte {
+	sum := sha256.Sum256(manifest)
+	signature, err := key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	Expect(err).ToNot(HaveOccurred())
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "SHA256(test.mf)= %s\n", hex.EncodeToString(signature))
+	for _, cert := range certs {
+		Expect(pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})).To(Succeed())
+	}
+	return buf.Bytes()
+}
+
+var _ = Describe("VerifyLibraryItem", func() {
+
+	var (
+		caKey   *ecdsa.PrivateKey
+		caCert  *x509.Certificate
+		roots   *x509.CertPool
+		signKey *rsa.PrivateKey
+		signer  *x509.Certificate
+
+		manifest []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		caCert = testCertificate("test-ca", true, caKey, nil, nil)
+
+		roots = x509.NewCertPool()
+		roots.AddCert(caCert)
+
+		signKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		signer = testCertificate("test-signer", false, signKey, caCert, caKey)
+
+		manifest = []byte(sha256Line("test.ovf", []byte(testOVF)))
+	})
+
+	// verifyItem creates a library item with the OVF descriptor and the other files, and verifies it.
+	verifyItem := func(otherFiles map[string][]byte) (*vmprovider.ImageVerification, error) {
+		var (
+			result *vmprovider.ImageVerification
+			err    error
+		)
+
+		simulator.Test(func(ctx context.Context, c *vim25.Client) {
+			restClient := rest.NewClient(c)
+			Expect(restClient.Login(ctx, simulator.DefaultLogin)).To(Succeed())
+
+			ds, findErr := find.NewFinder(c).DefaultDatastore(ctx)
+			Expect(findErr).ToNot(HaveOccurred())
+
+			clProvider := contentlibrary.NewProviderWithWaitSec(restClient, 1)
+			libID, createErr := clProvider.CreateLibrary(ctx, "verify-library", ds.Reference().Value)
+			Expect(createErr).ToNot(HaveOccurred())
+
+			files := []vmprovider.LibraryItemFile{
+				{Name: "test.ovf", Size: int64(len(testOVF)), Reader: bytes.NewReader([]byte(testOVF))},
+			}
+			for _, name := range []string{"test.mf", "test.cert"} {
+				if data, ok := otherFiles[name]; ok {
+					files = append(files, vmprovider.LibraryItemFile{Name: name, Size: int64(len(data)), Reader: bytes.NewReader(data)})
+				}
+			}
+
+			item := library.Item{LibraryID: libID, Name: "test-item", Type: library.ItemTypeOVF}
+			itemID, createErr := clProvider.CreateLibraryItemFromFiles(ctx, item, &testFiles{files: files})
+			Expect(createErr).ToNot(HaveOccurred())
+
+			result, err = clProvider.VerifyLibraryItem(ctx, itemID, roots)
+		})
+
+		return result, err
+	}
+
+	It("reports a missing manifest", func() {
+		result, err := verifyItem(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errors.Is(result.ManifestErr, vmprovider.ErrImageManifestMissing)).To(BeTrue())
+		Expect(errors.Is(result.SignatureErr, vmprovider.ErrImageUnsigned)).To(BeTrue())
+	})
+
+	It("verifies the digests of an unsigned image", func() {
+		result, err := verifyItem(map[string][]byte{"test.mf": manifest})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ManifestErr).ToNot(HaveOccurred())
+		Expect(errors.Is(result.SignatureErr, vmprovider.ErrImageUnsigned)).To(BeTrue())
+	})
+
+	It("reports a digest that does not match", func() {
+		badManifest := []byte(sha256Line("test.ovf", []byte("other content")))
+		result, err := verifyItem(map[string][]byte{"test.mf": badManifest})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errors.Is(result.ManifestErr, vmprovider.ErrImageDigestMismatch)).To(BeTrue())
+	})
+
+	It("reports a file that is not in the manifest", func() {
+		result, err := verifyItem(map[string][]byte{"test.mf": []byte(sha256Line("other.ovf", []byte(testOVF)))})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errors.Is(result.ManifestErr, vmprovider.ErrImageDigestMismatch)).To(BeTrue())
+	})
+
+	It("verifies a signature by a trusted certificate", func() {
+		result, err := verifyItem(map[string][]byte{
+			"test.mf":   manifest,
+			"test.cert": signManifest(manifest, signKey, signer),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ManifestErr).ToNot(HaveOccurred())
+		Expect(result.SignatureErr).ToNot(HaveOccurred())
+		Expect(result.Signer).To(Equal("CN=test-signer"))
+	})
+
+	It("verifies a signature by an ECDSA certificate with an intermediate", func() {
+		intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		intermediate := testCertificate("test-intermediate", true, intermediateKey, caCert, caKey)
+
+		leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		leaf := testCertificate("test-leaf", false, leafKey, intermediate, intermediateKey)
+
+		result, err := verifyItem(map[string][]byte{
+			"test.mf":   manifest,
+			"test.cert": signManifest(manifest, leafKey, leaf, intermediate),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.SignatureErr).ToNot(HaveOccurred())
+		Expect(result.Signer).To(Equal("CN=test-leaf"))
+	})
+
+	It("reports a signature by a certificate that is not trusted", func() {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		otherCA := testCertificate("other-ca", true, otherKey, nil, nil)
+		untrusted := testCertificate("test-signer", false, signKey, otherCA, otherKey)
+
+		result, err := verifyItem(map[string][]byte{
+			"test.mf":   manifest,
+			"test.cert": signManifest(manifest, signKey, untrusted),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errors.Is(result.SignatureErr, vmprovider.ErrImageCertificateUntrusted)).To(BeTrue())
+		Expect(result.Signer).To(BeEmpty())
+	})
+
+	It("reports a signature by a certificate that is not issued for code signing", func() {
+		serverCert := testCertificate("test-server", false, signKey, caCert, caKey, x509.ExtKeyUsageServerAuth)
+
+		result, err := verifyItem(map[string][]byte{
+			"test.mf":   manifest,
+			"test.cert": signManifest(manifest, signKey, serverCert),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errors.Is(result.SignatureErr, vmprovider.ErrImageCertificateUntrusted)).To(BeTrue())
+	})
+
+	It("reports a signature that does not match the manifest", func() {
+		result, err := verifyItem(map[string][]byte{
+			"test.mf":   manifest,
+			"test.cert": signManifest([]byte("other manifest"), signKey, signer),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ManifestErr).ToNot(HaveOccurred())
+		Expect(errors.Is(result.SignatureErr, vmprovider.ErrImageSignatureInvalid)).To(BeTrue())
+	})
+})
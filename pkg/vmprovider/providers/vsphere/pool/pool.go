@@ -98,3 +98,18 @@ func CloneVMRelocateSpec(
 
 	return placeVM(ctx, cluster, placementSpec)
 }
+
+func CreateVMRelocateSpec(
+	ctx context.Context,
+	cluster *object.ClusterComputeResource,
+	configSpec *vimTypes.VirtualMachineConfigSpec,
+	datastores []vimTypes.ManagedObjectReference) (*vimTypes.VirtualMachineRelocateSpec, error) {
+
+	placementSpec := vimTypes.PlacementSpec{
+		PlacementType: string(vimTypes.PlacementSpecPlacementTypeCreate),
+		ConfigSpec:    configSpec,
+		Datastores:    datastores,
+	}
+
+	return placeVM(ctx, cluster, placementSpec)
+}
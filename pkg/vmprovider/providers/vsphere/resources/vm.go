@@ -231,6 +231,18 @@ func (vm *VirtualMachine) SetPowerState(ctx context.Context, desiredPowerState v
 	return nil
 }
 
+// ShutdownGuest requests VMware Tools to shut down the guest OS. It does not wait for the VM to power off.
+func (vm *VirtualMachine) ShutdownGuest(ctx context.Context) error {
+	vm.logger.V(5).Info("ShutdownGuest")
+
+	if err := vm.vcVirtualMachine.ShutdownGuest(ctx); err != nil {
+		vm.logger.Error(err, "Failed to shutdown guest")
+		return err
+	}
+
+	return nil
+}
+
 // GetVirtualDevices returns the VMs VirtualDeviceList.
 func (vm *VirtualMachine) GetVirtualDevices(ctx context.Context) (object.VirtualDeviceList, error) {
 	vm.logger.V(5).Info("GetVirtualDevices")
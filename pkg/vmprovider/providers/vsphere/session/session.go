@@ -210,7 +210,7 @@ func (s *Session) DeleteLibraryItem(ctx goctx.Context, libraryItem *library.Item
 
 func IsSupportedDeployType(t string) bool {
 	switch t {
-	case library.ItemTypeVMTX, library.ItemTypeOVF:
+	case library.ItemTypeVMTX, library.ItemTypeOVF, library.ItemTypeISO:
 		// Keep in sync with what cloneVMFromContentLibrary() handles.
 		return true
 	default:
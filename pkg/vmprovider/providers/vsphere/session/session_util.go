@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/base64"
+	"io/ioutil"
 	"math"
 	"strings"
 
@@ -70,6 +71,19 @@ func MergeExtraConfig(extraConfig []vimTypes.BaseOptionValue, newMap map[string]
 	return merged
 }
 
+// UpdateExtraConfig returns the key/values of the map whose key is not present in the ExtraConfig or has a
+// different value.
+func UpdateExtraConfig(extraConfig []vimTypes.BaseOptionValue, newMap map[string]string) []vimTypes.BaseOptionValue {
+	updated := make([]vimTypes.BaseOptionValue, 0)
+	ecMap := ExtraConfigToMap(extraConfig)
+	for k, v := range newMap {
+		if value, exists := ecMap[k]; !exists || value != v {
+			updated = append(updated, &vimTypes.OptionValue{Key: k, Value: v})
+		}
+	}
+	return updated
+}
+
 // GetMergedvAppConfigSpec prepares a vApp VmConfigSpec which will set the vmMetadata supplied key/value fields. Only
 // fields marked userConfigurable and pre-existing on the VM (ie. originated from the OVF Image)
 // will be set, and all others will be ignored.
@@ -120,3 +134,23 @@ func EncodeGzipBase64(s string) (string, error) {
 	b64 := base64.StdEncoding.EncodeToString(zbuf.Bytes())
 	return b64, nil
 }
+
+func DecodeGzipBase64(s string) (string, error) {
+	zbuf, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(zbuf))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -7,8 +7,14 @@ import (
 	"github.com/pkg/errors"
 	"github.com/vmware/govmomi/object"
 	vimTypes "github.com/vmware/govmomi/vim25/types"
+	ctrlruntime "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/vmware-tanzu/vm-operator/pkg"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/clustermodules"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/config"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	res "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/resources"
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 )
@@ -33,6 +39,148 @@ func (s *Session) DeleteVirtualMachine(vmCtx context.VirtualMachineContext) erro
 	return resVM.Delete(vmCtx)
 }
 
+// ShutdownVirtualMachineGuest requests a guest OS shutdown through VMware Tools. It returns true when there
+// is nothing to wait for: the VM is already powered off, or VMware Tools is not running so a guest shutdown
+// cannot be requested.
+func (s *Session) ShutdownVirtualMachineGuest(vmCtx context.VirtualMachineContext) (bool, error) {
+	resVM, err := s.GetVirtualMachine(vmCtx)
+	if err != nil {
+		return false, transformVMError(vmCtx.VM.NamespacedName(), err)
+	}
+
+	moVM, err := resVM.GetProperties(vmCtx, []string{"summary.runtime", "guest.toolsRunningStatus", "guest.guestState"})
+	if err != nil {
+		return false, err
+	}
+
+	if moVM.Summary.Runtime.PowerState == vimTypes.VirtualMachinePowerStatePoweredOff {
+		return true, nil
+	}
+
+	if moVM.Guest == nil || moVM.Guest.ToolsRunningStatus != string(vimTypes.VirtualMachineToolsRunningStatusGuestToolsRunning) {
+		vmCtx.Logger.Info("VMware Tools is not running, skipping guest shutdown")
+		return true, nil
+	}
+
+	// The shutdown was already requested in an earlier reconcile.
+	if moVM.Guest.GuestState == string(vimTypes.VirtualMachineGuestStateShuttingDown) {
+		return false, nil
+	}
+
+	if err := resVM.ShutdownGuest(vmCtx); err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// RetainVirtualMachine powers off the VM and removes everything that marks it as managed by VM Operator:
+// the annotation, the ManagedBy extension, the backup ExtraConfig, and its tag and cluster module membership.
+// The VM is moved into the Folder from the RetainedVMFolderAnnotation if set.
+func (s *Session) RetainVirtualMachine(vmCtx context.VirtualMachineContext) error {
+	resVM, err := s.GetVirtualMachine(vmCtx)
+	if err != nil {
+		return transformVMError(vmCtx.VM.NamespacedName(), err)
+	}
+
+	// Resolve the Folder before the VM is released, so that the VM stays managed and the retain can be retried
+	// after the annotation is corrected.
+	var folder *object.Folder
+	if folderMoID := vmCtx.VM.Annotations[constants.RetainedVMFolderAnnotation]; folderMoID != "" {
+		folder, err = s.GetFolderByMoID(vmCtx, folderMoID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find retained VM folder %q", folderMoID)
+		}
+	}
+
+	moVM, err := resVM.GetProperties(vmCtx, []string{"summary.runtime"})
+	if err != nil {
+		return err
+	}
+
+	if moVM.Summary.Runtime.PowerState != vimTypes.VirtualMachinePowerStatePoweredOff {
+		if err := resVM.SetPowerState(vmCtx, vmopv1alpha1.VirtualMachinePoweredOff); err != nil {
+			return err
+		}
+	}
+
+	if err := s.detachTagsAndModules(vmCtx, resVM); err != nil {
+		return err
+	}
+
+	if err := resVM.Reconfigure(vmCtx, ReleaseConfigSpec(constants.VCVMRetainedAnnotation)); err != nil {
+		return err
+	}
+
+	if folder != nil {
+		task, err := folder.MoveInto(vmCtx, []vimTypes.ManagedObjectReference{resVM.MoRef()})
+		if err != nil {
+			return err
+		}
+		if err := task.Wait(vmCtx); err != nil {
+			return errors.Wrapf(err, "failed to move VM into folder %q", folder.Reference().Value)
+		}
+	}
+
+	return nil
+}
+
+// ReleaseConfigSpec returns the ConfigSpec that replaces the VM Operator annotation of a VM with the given one, and
+// clears its ManagedBy extension and backup ExtraConfig.
+func ReleaseConfigSpec(annotation string) *vimTypes.VirtualMachineConfigSpec {
+	configSpec := &vimTypes.VirtualMachineConfigSpec{
+		Annotation: annotation,
+		// An empty ManagedByInfo clears the VM's ManagedBy.
+		ManagedBy: &vimTypes.ManagedByInfo{},
+	}
+
+	// An empty value removes the key from the ExtraConfig.
+	for _, key := range []string{
+		constants.BackupVMResourceExtraConfigKey,
+		constants.BackupVMAdditionalResourcesExtraConfigKey,
+		constants.BackupVMDiskDataExtraConfigKey,
+		constants.BackupVMInstanceUUIDExtraConfigKey,
+		constants.BackupVMMoIDExtraConfigKey,
+	} {
+		configSpec.ExtraConfig = append(configSpec.ExtraConfig, &vimTypes.OptionValue{Key: key, Value: ""})
+	}
+
+	return configSpec
+}
+
+// detachTagsAndModules undoes attachTagsAndModules. The cluster module membership and the tag are each detached when
+// their annotation is set.
+func (s *Session) detachTagsAndModules(vmCtx context.VirtualMachineContext, resVM *res.VirtualMachine) error {
+	clusterModuleName := vmCtx.VM.Annotations[pkg.ClusterModuleNameKey]
+	providerTagsName := vmCtx.VM.Annotations[pkg.ProviderTagsAnnotationKey]
+
+	vmRef := resVM.MoRef()
+
+	if policyName := vmCtx.VM.Spec.ResourcePolicyName; clusterModuleName != "" && policyName != "" {
+		resourcePolicy := &vmopv1alpha1.VirtualMachineSetResourcePolicy{}
+		rpKey := ctrlruntime.ObjectKey{Name: policyName, Namespace: vmCtx.VM.Namespace}
+		if err := s.k8sClient.Get(vmCtx, rpKey, resourcePolicy); err != nil {
+			return err
+		}
+
+		_, moduleUUID := clustermodules.FindClusterModuleUUID(clusterModuleName, s.Cluster().Reference(), resourcePolicy)
+		if moduleUUID != "" {
+			if err := s.Client.ClusterModuleClient().RemoveMoRefFromModule(vmCtx, moduleUUID, vmRef); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tagName := s.tagInfo[providerTagsName]; providerTagsName != "" && tagName != "" {
+		tagCategoryName := s.tagInfo[config.ProviderTagCategoryNameKey]
+		if err := s.DetachTagFromVM(vmCtx, tagName, tagCategoryName, vmRef); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *Session) GetVirtualMachineGuestHeartbeat(vmCtx context.VirtualMachineContext) (vmopv1alpha1.GuestHeartbeatStatus, error) {
 	resVM, err := s.GetVirtualMachine(vmCtx)
 	if err != nil {
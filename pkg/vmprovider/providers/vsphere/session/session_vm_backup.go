@@ -0,0 +1,223 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session
+
+import (
+	goctx "context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	res "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/resources"
+)
+
+// lastAppliedConfigAnnotation is not worth restoring since it can be as large as the VirtualMachine itself.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// GetBackupExtraConfig returns the ExtraConfig that records the VirtualMachine, its metadata ConfigMap, its
+// volume attachments and the instance UUID and MoID of its VM. Metadata from a Secret is never recorded.
+func GetBackupExtraConfig(
+	vm *vmopv1alpha1.VirtualMachine,
+	vmMetadata vmprovider.VMMetadata,
+	instanceUUID, moID string) (map[string]string, error) {
+
+	backupVM := &vmopv1alpha1.VirtualMachine{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: vmopv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "VirtualMachine",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            vm.Name,
+			Namespace:       vm.Namespace,
+			Labels:          vm.Labels,
+			Annotations:     map[string]string{},
+			OwnerReferences: vm.OwnerReferences,
+		},
+		Spec: vm.Spec,
+	}
+	for k, v := range vm.Annotations {
+		if k != lastAppliedConfigAnnotation && k != vmopv1alpha1.PauseAnnotation {
+			backupVM.Annotations[k] = v
+		}
+	}
+
+	extraConfig := map[string]string{}
+
+	if err := setEncodedBackup(extraConfig, constants.BackupVMResourceExtraConfigKey, backupVM); err != nil {
+		return nil, err
+	}
+
+	if md := vm.Spec.VmMetadata; md != nil && md.ConfigMapName != "" {
+		cm := &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      md.ConfigMapName,
+				Namespace: vm.Namespace,
+			},
+			Data: vmMetadata.Data,
+		}
+		if err := setEncodedBackup(extraConfig, constants.BackupVMAdditionalResourcesExtraConfigKey, cm); err != nil {
+			return nil, err
+		}
+	} else {
+		extraConfig[constants.BackupVMAdditionalResourcesExtraConfigKey] = ""
+	}
+
+	var volumes []vmopv1alpha1.VirtualMachineVolumeStatus
+	for _, volume := range vm.Status.Volumes {
+		if volume.Attached && volume.DiskUuid != "" {
+			volumes = append(volumes, vmopv1alpha1.VirtualMachineVolumeStatus{
+				Name:     volume.Name,
+				Attached: true,
+				DiskUuid: volume.DiskUuid,
+			})
+		}
+	}
+	if err := setEncodedBackup(extraConfig, constants.BackupVMDiskDataExtraConfigKey, volumes); err != nil {
+		return nil, err
+	}
+
+	extraConfig[constants.BackupVMInstanceUUIDExtraConfigKey] = instanceUUID
+	extraConfig[constants.BackupVMMoIDExtraConfigKey] = moID
+
+	return extraConfig, nil
+}
+
+// IsRestoredFromBackup returns true when the ExtraConfig has a backup that was recorded on a VM with another
+// instance UUID or MoID. A restore can keep the instance UUID of the backed up VM, but the restored VM is a new
+// VM with a new MoID. The identifiers that the backup does not record are not compared.
+func IsRestoredFromBackup(extraConfig map[string]string, instanceUUID, moID string) bool {
+	if extraConfig[constants.BackupVMResourceExtraConfigKey] == "" {
+		return false
+	}
+	if backupUUID := extraConfig[constants.BackupVMInstanceUUIDExtraConfigKey]; backupUUID != "" &&
+		!strings.EqualFold(backupUUID, instanceUUID) {
+		return true
+	}
+	backupMoID := extraConfig[constants.BackupVMMoIDExtraConfigKey]
+	return backupMoID != "" && backupMoID != moID
+}
+
+// GetVirtualMachineBackupFromExtraConfig returns the backup recorded in the ExtraConfig, or nil if there is none.
+func GetVirtualMachineBackupFromExtraConfig(extraConfig map[string]string) (*vmprovider.VirtualMachineBackup, error) {
+	if extraConfig[constants.BackupVMResourceExtraConfigKey] == "" {
+		return nil, nil
+	}
+
+	backup := &vmprovider.VirtualMachineBackup{
+		VirtualMachine: &vmopv1alpha1.VirtualMachine{},
+	}
+
+	if err := getEncodedBackup(extraConfig, constants.BackupVMResourceExtraConfigKey, backup.VirtualMachine); err != nil {
+		return nil, err
+	}
+
+	if extraConfig[constants.BackupVMAdditionalResourcesExtraConfigKey] != "" {
+		backup.ConfigMap = &corev1.ConfigMap{}
+		if err := getEncodedBackup(extraConfig, constants.BackupVMAdditionalResourcesExtraConfigKey, backup.ConfigMap); err != nil {
+			return nil, err
+		}
+	}
+
+	if extraConfig[constants.BackupVMDiskDataExtraConfigKey] != "" {
+		if err := getEncodedBackup(extraConfig, constants.BackupVMDiskDataExtraConfigKey, &backup.Volumes); err != nil {
+			return nil, err
+		}
+	}
+
+	return backup, nil
+}
+
+func setEncodedBackup(extraConfig map[string]string, key string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", key)
+	}
+
+	encoded, err := EncodeGzipBase64(string(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s", key)
+	}
+
+	extraConfig[key] = encoded
+	return nil
+}
+
+func getEncodedBackup(extraConfig map[string]string, key string, obj interface{}) error {
+	data, err := DecodeGzipBase64(extraConfig[key])
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode %s", key)
+	}
+
+	if err := json.Unmarshal([]byte(data), obj); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", key)
+	}
+
+	return nil
+}
+
+// backupVirtualMachine records the VirtualMachine in the VM's ExtraConfig, only reconfiguring the VM
+// when the recorded state has changed.
+func (s *Session) backupVirtualMachine(
+	vmCtx context.VirtualMachineContext,
+	resVM *res.VirtualMachine,
+	config *vimTypes.VirtualMachineConfigInfo,
+	vmMetadata vmprovider.VMMetadata) error {
+
+	if config == nil {
+		return nil
+	}
+
+	backupExtraConfig, err := GetBackupExtraConfig(vmCtx.VM, vmMetadata, config.InstanceUuid, resVM.MoRef().Value)
+	if err != nil {
+		return err
+	}
+
+	curExtraConfig := ExtraConfigToMap(config.ExtraConfig)
+	var changed []vimTypes.BaseOptionValue
+	for k, v := range backupExtraConfig {
+		if curExtraConfig[k] != v {
+			changed = append(changed, &vimTypes.OptionValue{Key: k, Value: v})
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	vmCtx.Logger.V(4).Info("Updating VM backup ExtraConfig")
+	return resVM.Reconfigure(vmCtx, &vimTypes.VirtualMachineConfigSpec{ExtraConfig: changed})
+}
+
+// GetVirtualMachineBackup returns the backup recorded on the VM with the given MoID, or nil if there is none.
+func (s *Session) GetVirtualMachineBackup(ctx goctx.Context, moID string) (*vmprovider.VirtualMachineBackup, error) {
+	resVM, err := s.lookupVMByMoID(ctx, moID)
+	if err != nil {
+		return nil, err
+	}
+
+	moVM, err := resVM.GetProperties(ctx, []string{"config.extraConfig"})
+	if err != nil {
+		return nil, err
+	}
+
+	if moVM.Config == nil {
+		return nil, nil
+	}
+
+	return GetVirtualMachineBackupFromExtraConfig(ExtraConfigToMap(moVM.Config.ExtraConfig))
+}
@@ -0,0 +1,163 @@
+//go:build !integration
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/session"
+)
+
+var _ = Describe("VM Backup ExtraConfig", func() {
+
+	var (
+		vm         *vmopv1alpha1.VirtualMachine
+		vmMetadata vmprovider.VMMetadata
+	)
+
+	BeforeEach(func() {
+		vm = &vmopv1alpha1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dummy-vm",
+				Namespace: "dummy-ns",
+				Labels:    map[string]string{"foo": "bar"},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "vmoperator.vmware.com/v1alpha1", Kind: "VirtualMachineReplicaSet", Name: "dummy-rs", UID: "rs-uid"},
+				},
+				Annotations: map[string]string{
+					"hello":                      "world",
+					vmopv1alpha1.PauseAnnotation: "",
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				},
+			},
+			Spec: vmopv1alpha1.VirtualMachineSpec{
+				ImageName: "dummy-image",
+				ClassName: "dummy-class",
+				VmMetadata: &vmopv1alpha1.VirtualMachineMetadata{
+					ConfigMapName: "dummy-cm",
+					Transport:     vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport,
+				},
+			},
+			Status: vmopv1alpha1.VirtualMachineStatus{
+				Volumes: []vmopv1alpha1.VirtualMachineVolumeStatus{
+					{Name: "attached", Attached: true, DiskUuid: "uuid-1"},
+					{Name: "detached", Attached: false, DiskUuid: "uuid-2"},
+				},
+			},
+		}
+		vmMetadata = vmprovider.VMMetadata{
+			Data:      map[string]string{"key": "value"},
+			Transport: vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport,
+		}
+	})
+
+	It("round trips the VirtualMachine, ConfigMap and attached volumes", func() {
+		extraConfig, err := session.GetBackupExtraConfig(vm, vmMetadata, "instance-uuid", "vm-42")
+		Expect(err).ToNot(HaveOccurred())
+
+		backup, err := session.GetVirtualMachineBackupFromExtraConfig(extraConfig)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backup).ToNot(BeNil())
+
+		Expect(backup.VirtualMachine.Name).To(Equal(vm.Name))
+		Expect(backup.VirtualMachine.Namespace).To(Equal(vm.Namespace))
+		Expect(backup.VirtualMachine.Labels).To(Equal(vm.Labels))
+		Expect(backup.VirtualMachine.Spec).To(Equal(vm.Spec))
+		Expect(backup.VirtualMachine.Annotations).To(Equal(map[string]string{"hello": "world"}))
+		Expect(backup.VirtualMachine.OwnerReferences).To(Equal(vm.OwnerReferences))
+
+		Expect(backup.ConfigMap).ToNot(BeNil())
+		Expect(backup.ConfigMap.Name).To(Equal("dummy-cm"))
+		Expect(backup.ConfigMap.Data).To(Equal(vmMetadata.Data))
+
+		Expect(backup.Volumes).To(HaveLen(1))
+		Expect(backup.Volumes[0].Name).To(Equal("attached"))
+	})
+
+	When("the metadata is from a Secret", func() {
+		BeforeEach(func() {
+			vm.Spec.VmMetadata = &vmopv1alpha1.VirtualMachineMetadata{
+				SecretName: "dummy-secret",
+				Transport:  vmopv1alpha1.VirtualMachineMetadataOvfEnvTransport,
+			}
+		})
+
+		It("does not record the metadata", func() {
+			extraConfig, err := session.GetBackupExtraConfig(vm, vmMetadata, "instance-uuid", "vm-42")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extraConfig).To(HaveKeyWithValue(constants.BackupVMAdditionalResourcesExtraConfigKey, ""))
+
+			backup, err := session.GetVirtualMachineBackupFromExtraConfig(extraConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(backup.ConfigMap).To(BeNil())
+		})
+	})
+
+	Context("IsRestoredFromBackup", func() {
+		var extraConfig map[string]string
+
+		BeforeEach(func() {
+			var err error
+			extraConfig, err = session.GetBackupExtraConfig(vm, vmMetadata, "instance-uuid", "vm-42")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("is false for the VM that recorded the backup", func() {
+			Expect(session.IsRestoredFromBackup(extraConfig, "instance-uuid", "vm-42")).To(BeFalse())
+			Expect(session.IsRestoredFromBackup(extraConfig, "INSTANCE-UUID", "vm-42")).To(BeFalse())
+		})
+
+		It("is true for a VM with another instance UUID", func() {
+			Expect(session.IsRestoredFromBackup(extraConfig, "other-uuid", "vm-42")).To(BeTrue())
+		})
+
+		It("is true for a VM restored with the same instance UUID", func() {
+			Expect(session.IsRestoredFromBackup(extraConfig, "instance-uuid", "vm-43")).To(BeTrue())
+		})
+
+		It("is false for a backup without an instance UUID and MoID", func() {
+			delete(extraConfig, constants.BackupVMInstanceUUIDExtraConfigKey)
+			delete(extraConfig, constants.BackupVMMoIDExtraConfigKey)
+			Expect(session.IsRestoredFromBackup(extraConfig, "other-uuid", "vm-43")).To(BeFalse())
+		})
+
+		It("is false without a backup", func() {
+			Expect(session.IsRestoredFromBackup(map[string]string{}, "other-uuid", "vm-43")).To(BeFalse())
+		})
+	})
+
+	It("returns nil when there is no backup", func() {
+		backup, err := session.GetVirtualMachineBackupFromExtraConfig(map[string]string{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backup).To(BeNil())
+	})
+})
+
+var _ = Describe("Release ConfigSpec", func() {
+
+	It("replaces the annotation and clears the ManagedBy and backup ExtraConfig", func() {
+		configSpec := session.ReleaseConfigSpec(constants.VCVMRetainedAnnotation)
+		Expect(configSpec.Annotation).To(Equal(constants.VCVMRetainedAnnotation))
+		Expect(configSpec.ManagedBy).ToNot(BeNil())
+		Expect(configSpec.ManagedBy.ExtensionKey).To(BeEmpty())
+
+		extraConfig := session.ExtraConfigToMap(configSpec.ExtraConfig)
+		Expect(extraConfig).To(HaveLen(5))
+		Expect(extraConfig).To(HaveKeyWithValue(constants.BackupVMResourceExtraConfigKey, ""))
+		Expect(extraConfig).To(HaveKeyWithValue(constants.BackupVMAdditionalResourcesExtraConfigKey, ""))
+		Expect(extraConfig).To(HaveKeyWithValue(constants.BackupVMDiskDataExtraConfigKey, ""))
+		Expect(extraConfig).To(HaveKeyWithValue(constants.BackupVMInstanceUUIDExtraConfigKey, ""))
+		Expect(extraConfig).To(HaveKeyWithValue(constants.BackupVMMoIDExtraConfigKey, ""))
+	})
+})
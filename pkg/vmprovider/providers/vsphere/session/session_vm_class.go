@@ -0,0 +1,264 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session
+
+import (
+	goctx "context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+
+	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/contentlibrary"
+)
+
+// ClusterCapabilities are the devices and virtual hardware versions that VMs can be created with on a cluster.
+type ClusterCapabilities struct {
+	// VGPUProfiles are the vGPU profiles of the cluster's hosts.
+	VGPUProfiles []string
+	// DirectPathDevices are the Dynamic DirectPath I/O devices of the cluster's hosts.
+	DirectPathDevices []v1alpha1.DynamicDirectPathIODevice
+	// MaxHardwareVersion is the highest virtual hardware version that VMs can be created with.
+	MaxHardwareVersion int32
+}
+
+// ResourcePoolCapacity is the CPU and memory that VMs can still reserve in a resource pool.
+type ResourcePoolCapacity struct {
+	UnreservedCPUMHz   int64
+	UnreservedMemoryMB int64
+	// CPUMinMHz is the minimum CPU frequency of the cluster's hosts, that CPU reservations are converted with.
+	CPUMinMHz uint64
+}
+
+// ClassUnavailability describes why VMs of a VirtualMachineClass cannot be realized on a cluster.
+type ClassUnavailability struct {
+	Reason  string
+	Message string
+}
+
+// RequiredHardwareVersion returns the minimum virtual hardware version of VMs of the class, or zero if the class
+// does not require one.
+func RequiredHardwareVersion(vmClass *v1alpha1.VirtualMachineClass) int32 {
+	var version int32
+
+	hardware := vmClass.Spec.Hardware
+	if len(hardware.InstanceStorage.Volumes) > 0 && version < constants.MinSupportedHWVersionForPVC {
+		version = constants.MinSupportedHWVersionForPVC
+	}
+	if (len(hardware.Devices.VGPUDevices) > 0 || len(hardware.Devices.DynamicDirectPathIODevices) > 0) &&
+		version < constants.MinSupportedHWVersionForPCIPassthruDevices {
+		version = constants.MinSupportedHWVersionForPCIPassthruDevices
+	}
+
+	return version
+}
+
+// CheckClassAvailability returns why VMs of the class cannot be realized on a cluster with the capabilities, or
+// nil if they can.
+func CheckClassAvailability(vmClass *v1alpha1.VirtualMachineClass, caps ClusterCapabilities) []ClassUnavailability {
+	var unavailabilities []ClassUnavailability
+
+	profiles := make(map[string]struct{}, len(caps.VGPUProfiles))
+	for _, profile := range caps.VGPUProfiles {
+		profiles[profile] = struct{}{}
+	}
+	for _, vgpu := range vmClass.Spec.Hardware.Devices.VGPUDevices {
+		if _, ok := profiles[vgpu.ProfileName]; !ok {
+			unavailabilities = append(unavailabilities, ClassUnavailability{
+				Reason:  vmopapiv1alpha1.VirtualMachineClassVGPUProfileNotFoundReason,
+				Message: fmt.Sprintf("no host has vGPU profile %q", vgpu.ProfileName),
+			})
+		}
+	}
+
+	for _, device := range vmClass.Spec.Hardware.Devices.DynamicDirectPathIODevices {
+		found := false
+		for _, d := range caps.DirectPathDevices {
+			if d.VendorID == device.VendorID && d.DeviceID == device.DeviceID &&
+				(device.CustomLabel == "" || d.CustomLabel == device.CustomLabel) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unavailabilities = append(unavailabilities, ClassUnavailability{
+				Reason: vmopapiv1alpha1.VirtualMachineClassDirectPathDeviceNotFoundReason,
+				Message: fmt.Sprintf("no host has Dynamic DirectPath I/O device with vendorID %d, deviceID %d and customLabel %q",
+					device.VendorID, device.DeviceID, device.CustomLabel),
+			})
+		}
+	}
+
+	if required := RequiredHardwareVersion(vmClass); required > caps.MaxHardwareVersion {
+		unavailabilities = append(unavailabilities, ClassUnavailability{
+			Reason: vmopapiv1alpha1.VirtualMachineClassHardwareVersionNotSupportedReason,
+			Message: fmt.Sprintf("devices of the class require hardware version %d, but the cluster supports up to %d",
+				required, caps.MaxHardwareVersion),
+		})
+	}
+
+	return unavailabilities
+}
+
+// AvailableVMs returns how many VMs of the class have CPU and memory reservations that fit in the capacity, or nil
+// if the class has no reservations.
+func AvailableVMs(vmClass *v1alpha1.VirtualMachineClass, capacity ResourcePoolCapacity) *int32 {
+	var available *int64
+
+	fit := func(unreserved, reservation int64) {
+		n := int64(0)
+		if unreserved > 0 {
+			n = unreserved / reservation
+		}
+		if available == nil || n < *available {
+			available = &n
+		}
+	}
+
+	requests := vmClass.Spec.Policies.Resources.Requests
+	if !requests.Cpu.IsZero() {
+		if rsv := CPUQuantityToMhz(requests.Cpu, capacity.CPUMinMHz); rsv > 0 {
+			fit(capacity.UnreservedCPUMHz, rsv)
+		}
+	}
+	if !requests.Memory.IsZero() {
+		if rsv := MemoryQuantityToMb(requests.Memory); rsv > 0 {
+			fit(capacity.UnreservedMemoryMB, rsv)
+		}
+	}
+
+	if available == nil {
+		return nil
+	}
+	if *available > math.MaxInt32 {
+		*available = math.MaxInt32
+	}
+	n := int32(*available)
+	return &n
+}
+
+// NewClassZoneAvailability returns the availability of a VirtualMachineClass in the zone: VMs of the class cannot
+// be realized when the cluster has unavailabilities, and the class is short of capacity when no namespace has room
+// for another VM of the class.
+func NewClassZoneAvailability(
+	zone string,
+	unavailabilities []ClassUnavailability,
+	namespaces []vmopapiv1alpha1.VirtualMachineClassNamespaceCapacity) vmopapiv1alpha1.VirtualMachineClassZoneAvailability {
+
+	availability := vmopapiv1alpha1.VirtualMachineClassZoneAvailability{
+		Zone:       zone,
+		Namespaces: namespaces,
+	}
+
+	if len(unavailabilities) > 0 {
+		messages := make([]string, 0, len(unavailabilities))
+		for _, u := range unavailabilities {
+			messages = append(messages, u.Message)
+		}
+		availability.Conditions = append(availability.Conditions, *conditions.FalseCondition(
+			vmopapiv1alpha1.VirtualMachineClassSchedulableCondition,
+			unavailabilities[0].Reason,
+			v1alpha1.ConditionSeverityError,
+			"%s", strings.Join(messages, "; ")))
+		return availability
+	}
+
+	full := len(namespaces) > 0
+	for _, ns := range namespaces {
+		if ns.AvailableVMs == nil || *ns.AvailableVMs > 0 {
+			full = false
+			break
+		}
+	}
+	if full {
+		availability.Conditions = append(availability.Conditions, *conditions.FalseCondition(
+			vmopapiv1alpha1.VirtualMachineClassSchedulableCondition,
+			vmopapiv1alpha1.VirtualMachineClassInsufficientCapacityReason,
+			v1alpha1.ConditionSeverityWarning,
+			"no namespace has enough unreserved CPU and memory for a VM of the class"))
+		return availability
+	}
+
+	availability.Conditions = append(availability.Conditions,
+		*conditions.TrueCondition(vmopapiv1alpha1.VirtualMachineClassSchedulableCondition))
+	return availability
+}
+
+// GetClusterCapabilities returns the vGPU profiles, Dynamic DirectPath I/O devices and virtual hardware versions
+// that VMs can be created with on the session's cluster.
+func (s *Session) GetClusterCapabilities(ctx goctx.Context) (*ClusterCapabilities, error) {
+	if s.cluster == nil {
+		return nil, fmt.Errorf("no cluster exists, can't get cluster capabilities")
+	}
+
+	var computeResource mo.ComputeResource
+	if err := s.cluster.Properties(ctx, s.cluster.Reference(), []string{"environmentBrowser"}, &computeResource); err != nil {
+		return nil, errors.Wrap(err, "failed to get environment browser for the cluster")
+	}
+	if computeResource.EnvironmentBrowser == nil {
+		return nil, fmt.Errorf("cluster %s has no environment browser", s.cluster.Reference().Value)
+	}
+
+	client := s.Client.VimClient()
+	caps := &ClusterCapabilities{}
+
+	target, err := methods.QueryConfigTarget(ctx, client.RoundTripper, &vimTypes.QueryConfigTarget{
+		This: *computeResource.EnvironmentBrowser,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query config target of the cluster")
+	}
+	if target.Returnval != nil {
+		for _, gpu := range target.Returnval.SharedGpuPassthroughTypes {
+			caps.VGPUProfiles = append(caps.VGPUProfiles, gpu.Vgpu)
+		}
+		for _, device := range target.Returnval.DynamicPassthrough {
+			caps.DirectPathDevices = append(caps.DirectPathDevices, v1alpha1.DynamicDirectPathIODevice{
+				VendorID:    int(device.VendorId),
+				DeviceID:    int(device.DeviceId),
+				CustomLabel: device.CustomLabel,
+			})
+		}
+	}
+
+	descriptors, err := methods.QueryConfigOptionDescriptor(ctx, client.RoundTripper, &vimTypes.QueryConfigOptionDescriptor{
+		This: *computeResource.EnvironmentBrowser,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query config option descriptors of the cluster")
+	}
+	for _, descriptor := range descriptors.Returnval {
+		if descriptor.CreateSupported == nil || !*descriptor.CreateSupported {
+			continue
+		}
+		if version := contentlibrary.ParseVirtualHardwareVersion(descriptor.Key); version > caps.MaxHardwareVersion {
+			caps.MaxHardwareVersion = version
+		}
+	}
+
+	return caps, nil
+}
+
+// GetResourcePoolCapacity returns the CPU and memory that VMs can still reserve in the session's resource pool.
+func (s *Session) GetResourcePoolCapacity(ctx goctx.Context) (*ResourcePoolCapacity, error) {
+	var rp mo.ResourcePool
+	if err := s.resourcePool.Properties(ctx, s.resourcePool.Reference(), []string{"runtime"}, &rp); err != nil {
+		return nil, errors.Wrapf(err, "failed to get runtime of resource pool %s", s.resourcePool.Reference().Value)
+	}
+
+	return &ResourcePoolCapacity{
+		UnreservedCPUMHz:   rp.Runtime.Cpu.UnreservedForVm,
+		UnreservedMemoryMB: rp.Runtime.Memory.UnreservedForVm / (1024 * 1024),
+		CPUMinMHz:          s.GetCPUMinMHzInCluster(),
+	}, nil
+}
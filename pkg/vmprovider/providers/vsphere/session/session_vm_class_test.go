@@ -0,0 +1,163 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/session"
+)
+
+var _ = Describe("VirtualMachineClass availability", func() {
+	var vmClass *vmopv1alpha1.VirtualMachineClass
+
+	BeforeEach(func() {
+		vmClass = &vmopv1alpha1.VirtualMachineClass{}
+	})
+
+	Context("RequiredHardwareVersion", func() {
+		It("does not require a version for a class without devices", func() {
+			Expect(session.RequiredHardwareVersion(vmClass)).To(BeZero())
+		})
+
+		It("requires the PVC version for instance storage", func() {
+			vmClass.Spec.Hardware.InstanceStorage.Volumes = []vmopv1alpha1.InstanceStorageVolume{{}}
+			Expect(session.RequiredHardwareVersion(vmClass)).To(BeEquivalentTo(13))
+		})
+
+		It("requires the PCI passthrough version for vGPU devices", func() {
+			vmClass.Spec.Hardware.InstanceStorage.Volumes = []vmopv1alpha1.InstanceStorageVolume{{}}
+			vmClass.Spec.Hardware.Devices.VGPUDevices = []vmopv1alpha1.VGPUDevice{{ProfileName: "grid_p40-1q"}}
+			Expect(session.RequiredHardwareVersion(vmClass)).To(BeEquivalentTo(17))
+		})
+	})
+
+	Context("CheckClassAvailability", func() {
+		var caps session.ClusterCapabilities
+
+		BeforeEach(func() {
+			vmClass.Spec.Hardware.Devices.VGPUDevices = []vmopv1alpha1.VGPUDevice{{ProfileName: "grid_p40-1q"}}
+			vmClass.Spec.Hardware.Devices.DynamicDirectPathIODevices = []vmopv1alpha1.DynamicDirectPathIODevice{
+				{VendorID: 0x10de, DeviceID: 0x1eb8, CustomLabel: "gpu"},
+			}
+			caps = session.ClusterCapabilities{
+				VGPUProfiles: []string{"grid_p40-1q", "grid_p40-2q"},
+				DirectPathDevices: []vmopv1alpha1.DynamicDirectPathIODevice{
+					{VendorID: 0x10de, DeviceID: 0x1eb8, CustomLabel: "gpu"},
+				},
+				MaxHardwareVersion: 19,
+			}
+		})
+
+		It("is available when the cluster has the devices of the class", func() {
+			Expect(session.CheckClassAvailability(vmClass, caps)).To(BeEmpty())
+		})
+
+		It("matches a device without a custom label on the vendor and device IDs", func() {
+			vmClass.Spec.Hardware.Devices.DynamicDirectPathIODevices[0].CustomLabel = ""
+			Expect(session.CheckClassAvailability(vmClass, caps)).To(BeEmpty())
+		})
+
+		It("reports a missing vGPU profile", func() {
+			caps.VGPUProfiles = []string{"grid_p40-2q"}
+			unavailabilities := session.CheckClassAvailability(vmClass, caps)
+			Expect(unavailabilities).To(HaveLen(1))
+			Expect(unavailabilities[0].Reason).To(Equal(vmopapiv1alpha1.VirtualMachineClassVGPUProfileNotFoundReason))
+			Expect(unavailabilities[0].Message).To(ContainSubstring("grid_p40-1q"))
+		})
+
+		It("reports a missing Dynamic DirectPath I/O device", func() {
+			caps.DirectPathDevices[0].CustomLabel = "other"
+			unavailabilities := session.CheckClassAvailability(vmClass, caps)
+			Expect(unavailabilities).To(HaveLen(1))
+			Expect(unavailabilities[0].Reason).To(Equal(vmopapiv1alpha1.VirtualMachineClassDirectPathDeviceNotFoundReason))
+		})
+
+		It("reports an unsupported hardware version", func() {
+			caps.MaxHardwareVersion = 15
+			unavailabilities := session.CheckClassAvailability(vmClass, caps)
+			Expect(unavailabilities).To(HaveLen(1))
+			Expect(unavailabilities[0].Reason).To(Equal(vmopapiv1alpha1.VirtualMachineClassHardwareVersionNotSupportedReason))
+		})
+	})
+
+	Context("AvailableVMs", func() {
+		var capacity session.ResourcePoolCapacity
+
+		BeforeEach(func() {
+			capacity = session.ResourcePoolCapacity{
+				UnreservedCPUMHz:   10000,
+				UnreservedMemoryMB: 4096,
+				CPUMinMHz:          2000,
+			}
+		})
+
+		It("is not limited for a class without reservations", func() {
+			Expect(session.AvailableVMs(vmClass, capacity)).To(BeNil())
+		})
+
+		It("is limited by the scarcest reservation", func() {
+			vmClass.Spec.Policies.Resources.Requests.Cpu = resource.MustParse("1")
+			vmClass.Spec.Policies.Resources.Requests.Memory = resource.MustParse("1Gi")
+			available := session.AvailableVMs(vmClass, capacity)
+			Expect(available).ToNot(BeNil())
+			Expect(*available).To(BeEquivalentTo(4))
+		})
+
+		It("is zero when the resource pool is overcommitted", func() {
+			vmClass.Spec.Policies.Resources.Requests.Memory = resource.MustParse("1Gi")
+			capacity.UnreservedMemoryMB = -1
+			available := session.AvailableVMs(vmClass, capacity)
+			Expect(available).ToNot(BeNil())
+			Expect(*available).To(BeZero())
+		})
+	})
+
+	Context("NewClassZoneAvailability", func() {
+		zero, one := int32(0), int32(1)
+
+		It("is schedulable when a namespace has capacity", func() {
+			availability := session.NewClassZoneAvailability("zone-1", nil,
+				[]vmopapiv1alpha1.VirtualMachineClassNamespaceCapacity{
+					{Namespace: "ns-1", AvailableVMs: &zero},
+					{Namespace: "ns-2", AvailableVMs: &one},
+				})
+			Expect(availability.Zone).To(Equal("zone-1"))
+			Expect(availability.Namespaces).To(HaveLen(2))
+			Expect(availability.Conditions).To(HaveLen(1))
+			Expect(availability.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+		})
+
+		It("is not schedulable when no namespace has capacity", func() {
+			availability := session.NewClassZoneAvailability("zone-1", nil,
+				[]vmopapiv1alpha1.VirtualMachineClassNamespaceCapacity{{Namespace: "ns-1", AvailableVMs: &zero}})
+			Expect(availability.Conditions).To(HaveLen(1))
+			Expect(availability.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+			Expect(availability.Conditions[0].Reason).To(Equal(vmopapiv1alpha1.VirtualMachineClassInsufficientCapacityReason))
+			Expect(availability.Conditions[0].Severity).To(Equal(vmopv1alpha1.ConditionSeverityWarning))
+		})
+
+		It("is not schedulable when the cluster lacks devices of the class", func() {
+			availability := session.NewClassZoneAvailability("zone-1",
+				[]session.ClassUnavailability{
+					{Reason: vmopapiv1alpha1.VirtualMachineClassVGPUProfileNotFoundReason, Message: "no vGPU"},
+					{Reason: vmopapiv1alpha1.VirtualMachineClassHardwareVersionNotSupportedReason, Message: "old"},
+				},
+				[]vmopapiv1alpha1.VirtualMachineClassNamespaceCapacity{{Namespace: "ns-1", AvailableVMs: &one}})
+			Expect(availability.Conditions).To(HaveLen(1))
+			Expect(availability.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+			Expect(availability.Conditions[0].Reason).To(Equal(vmopapiv1alpha1.VirtualMachineClassVGPUProfileNotFoundReason))
+			Expect(availability.Conditions[0].Message).To(Equal("no vGPU; old"))
+		})
+	})
+})
@@ -23,6 +23,7 @@ import (
 
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/compatibility"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/pool"
 	res "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/resources"
@@ -118,13 +119,8 @@ func CheckVMConfigOptions(
 	vmConfigArgs vmprovider.VMConfigArgs,
 	guestOSIdsToFamily map[string]string) error {
 
-	val := vmCtx.VM.Annotations[constants.VMOperatorImageSupportedCheckKey]
-	if val != constants.VMOperatorImageSupportedCheckDisable && len(guestOSIdsToFamily) > 0 {
-		osType := vmConfigArgs.VMImage.Spec.OSInfo.Type
-		// osFamily will be present for supported OSTypes and support only VirtualMachineGuestOsFamilyLinuxGuest for now
-		if osFamily := guestOSIdsToFamily[osType]; osFamily != string(vimTypes.VirtualMachineGuestOsFamilyLinuxGuest) {
-			return fmt.Errorf("image osType '%s' is not supported by VMService", osType)
-		}
+	if incompatibilities := compatibility.CheckGuestOS(vmCtx.VM, vmConfigArgs.VMImage, guestOSIdsToFamily); len(incompatibilities) > 0 {
+		return incompatibilities[0]
 	}
 
 	return nil
@@ -151,6 +147,16 @@ func (s *Session) deployVMFromCL(vmCtx VirtualMachineCloneContext, vmConfigArgs
 		return nil, errors.Wrapf(err, "deploy VM preCheck failed for image %q", vmCtx.VM.Spec.ImageName)
 	}
 
+	if s.useImageCache(vmCtx) {
+		clonedVM, err := s.linkedCloneFromImageCache(vmCtx, vmConfigArgs, item)
+		if err == nil {
+			return clonedVM, nil
+		}
+		// The image cache is only an optimization, so fall back to deploying the VM.
+		vmCtx.Logger.Info("Cannot linked clone VM from the image cache, deploying it instead",
+			"itemName", item.Name, "reason", err.Error())
+	}
+
 	vmCtx.Logger.Info("Deploying Content Library item", "itemName", item.Name,
 		"itemType", item.Type, "imageName", vmCtx.VM.Spec.ImageName,
 		"resourcePolicyName", vmCtx.VM.Spec.ResourcePolicyName, "storageProfileID", vmConfigArgs.StorageProfileID)
@@ -186,7 +192,7 @@ func (s *Session) cloneVMFromInventory(vmCtx VirtualMachineCloneContext, vmConfi
 		return nil, errors.Wrapf(err, "failed to lookup clone source %q", vmCtx.VM.Spec.ImageName)
 	}
 
-	cloneSpec, err := s.createCloneSpec(vmCtx, sourceVM, vmConfigArgs)
+	cloneSpec, err := s.createCloneSpec(vmCtx, sourceVM, vmConfigArgs, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create clone spec")
 	}
@@ -210,6 +216,8 @@ func (s *Session) cloneVMFromContentLibrary(vmCtx VirtualMachineCloneContext, vm
 		return s.deployVMFromCL(vmCtx, vmConfigArgs, item)
 	case library.ItemTypeVMTX:
 		return s.cloneVMFromInventory(vmCtx, vmConfigArgs)
+	case library.ItemTypeISO:
+		return s.createVMFromISO(vmCtx, vmConfigArgs, item)
 	default:
 		return nil, errors.Errorf("item %v not a supported type: %s", item.Name, item.Type)
 	}
@@ -388,10 +396,37 @@ func (s *Session) createConfigSpec(name string, vmClassSpec *v1alpha1.VirtualMac
 	return configSpec
 }
 
+// placeVMDatastore returns the datastore that a VM created with the configSpec is placed on: the session's
+// datastore, or a datastore compatible with the storage profile, which is then set on the configSpec.
+func (s *Session) placeVMDatastore(
+	ctx goctx.Context,
+	configSpec *vimTypes.VirtualMachineConfigSpec,
+	storageProfileID string) (vimTypes.ManagedObjectReference, error) {
+
+	var datastores []vimTypes.ManagedObjectReference
+	if storageProfileID != "" {
+		configSpec.VmProfile = []vimTypes.BaseVirtualMachineProfileSpec{
+			&vimTypes.VirtualMachineDefinedProfileSpec{ProfileId: storageProfileID},
+		}
+	} else {
+		datastores = append(datastores, s.datastore.Reference())
+	}
+
+	relocateSpec, err := pool.CreateVMRelocateSpec(ctx, s.cluster, configSpec, datastores)
+	if err != nil {
+		return vimTypes.ManagedObjectReference{}, errors.Wrap(err, "failed to place VM")
+	}
+
+	return *relocateSpec.Datastore, nil
+}
+
+// createCloneSpec returns the spec to clone the VM from the sourceVM. When linkedClone is not nil, the VM is
+// linked cloned from its snapshot of the sourceVM instead of being a full clone.
 func (s *Session) createCloneSpec(
 	vmCtx VirtualMachineCloneContext,
 	sourceVM *res.VirtualMachine,
-	vmConfigArgs vmprovider.VMConfigArgs) (*vimTypes.VirtualMachineCloneSpec, error) {
+	vmConfigArgs vmprovider.VMConfigArgs,
+	linkedClone *linkedCloneSource) (*vimTypes.VirtualMachineCloneSpec, error) {
 
 	cloneSpec := &vimTypes.VirtualMachineCloneSpec{
 		Config: s.createConfigSpec(vmCtx.VM.Name, &vmConfigArgs.VMClass.Spec),
@@ -433,6 +468,14 @@ func (s *Session) createCloneSpec(
 		cloneSpec.Location.Datastore = vimTypes.NewReference(s.datastore.Reference())
 	}
 
+	diskMoveType := vimTypes.VirtualMachineRelocateDiskMoveOptionsMoveChildMostDiskBacking
+	if linkedClone != nil {
+		// The child disks of a linked clone must be on the datastore of the snapshot's disks.
+		cloneSpec.Snapshot = vimTypes.NewReference(linkedClone.Snapshot)
+		cloneSpec.Location.Datastore = vimTypes.NewReference(linkedClone.Datastore)
+		diskMoveType = vimTypes.VirtualMachineRelocateDiskMoveOptionsCreateNewChildDiskBacking
+	}
+
 	cloneSpec.Location.Pool = vimTypes.NewReference(vmCtx.ResourcePool.Reference())
 	cloneSpec.Location.Folder = vimTypes.NewReference(vmCtx.Folder.Reference())
 
@@ -441,9 +484,11 @@ func (s *Session) createCloneSpec(
 		return nil, err
 	}
 	cloneSpec.Location.Host = relocateSpec.Host
-	cloneSpec.Location.Datastore = relocateSpec.Datastore
+	if linkedClone == nil {
+		cloneSpec.Location.Datastore = relocateSpec.Datastore
+	}
 
-	diskLocators := cloneVMDiskLocators(vmCtx, virtualDisks, cloneSpec.Location.Datastore, cloneSpec.Location.Profile)
+	diskLocators := cloneVMDiskLocators(vmCtx, virtualDisks, cloneSpec.Location.Datastore, cloneSpec.Location.Profile, diskMoveType)
 	cloneSpec.Location.Disk = diskLocators
 
 	return cloneSpec, nil
@@ -513,7 +558,8 @@ func cloneVMDiskLocators(
 	vmCtx VirtualMachineCloneContext,
 	disks object.VirtualDeviceList,
 	datastore *vimTypes.ManagedObjectReference,
-	profile []vimTypes.BaseVirtualMachineProfileSpec) []vimTypes.VirtualMachineRelocateSpecDiskLocator {
+	profile []vimTypes.BaseVirtualMachineProfileSpec,
+	diskMoveType vimTypes.VirtualMachineRelocateDiskMoveOptions) []vimTypes.VirtualMachineRelocateSpecDiskLocator {
 
 	diskLocators := make([]vimTypes.VirtualMachineRelocateSpecDiskLocator, 0, len(disks))
 	for _, disk := range disks {
@@ -522,7 +568,13 @@ func cloneVMDiskLocators(
 			Datastore: *datastore,
 			Profile:   profile,
 			// TODO: Check if policy is encrypted and use correct DiskMoveType
-			DiskMoveType: string(vimTypes.VirtualMachineRelocateDiskMoveOptionsMoveChildMostDiskBacking),
+			DiskMoveType: string(diskMoveType),
+		}
+
+		// The child disks of a linked clone are always thin provisioned.
+		if diskMoveType == vimTypes.VirtualMachineRelocateDiskMoveOptionsCreateNewChildDiskBacking {
+			diskLocators = append(diskLocators, locator)
+			continue
 		}
 
 		if backing, ok := disk.(*vimTypes.VirtualDisk).Backing.(*vimTypes.VirtualDiskFlatVer2BackingInfo); ok {
@@ -245,6 +245,12 @@ func (s *Session) customize(
 		return err
 	}
 
+	if custSpec != nil && IsISOImage(updateArgs.VMImage) {
+		// There is no guest OS to customize until one is installed from the ISO image.
+		vmCtx.Logger.Info("Skipping vsphere customization of VM that boots from an ISO image")
+		custSpec = nil
+	}
+
 	if configSpec != nil {
 		defaultConfigSpec := &vimTypes.VirtualMachineConfigSpec{}
 		if !apiEquality.Semantic.DeepEqual(configSpec, defaultConfigSpec) {
@@ -0,0 +1,494 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session
+
+import (
+	goctx "context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/vcenter"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	res "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/resources"
+)
+
+// errImageCacheNotReady is returned when another VM is warming up the image cache template VM.
+var errImageCacheNotReady = errors.New("image cache template VM is not ready")
+
+// linkedCloneSource is the snapshot of an image cache template VM that a VM is linked cloned from, and the
+// datastore of the template VM's disks.
+type linkedCloneSource struct {
+	Snapshot  vimTypes.ManagedObjectReference
+	Datastore vimTypes.ManagedObjectReference
+}
+
+// ImageCacheEntry is an image cache template VM.
+type ImageCacheEntry struct {
+	Name           string
+	MoRef          vimTypes.ManagedObjectReference
+	ItemID         string
+	ContentVersion string
+	Datastore      vimTypes.ManagedObjectReference
+	LastUsed       time.Time
+}
+
+// ImageCacheVMName returns the name of the template VM that caches the content version of the library item on
+// the datastore.
+func ImageCacheVMName(itemID, contentVersion, datastoreID string) string {
+	return fmt.Sprintf("image-cache-%s-%s-%s", itemID, contentVersion, datastoreID)
+}
+
+// IsImageCacheEntryStale returns true if the template VM does not cache the current content version of its
+// library item. An empty currentVersion means the library item no longer exists.
+func IsImageCacheEntryStale(entry ImageCacheEntry, currentVersion string) bool {
+	return currentVersion == "" || entry.ContentVersion != currentVersion
+}
+
+// IsImageCacheEntryEvictable returns true if the template VM can be deleted: it has no linked clones, and it is
+// stale or no VM was cloned from it within maxUnused.
+func IsImageCacheEntryEvictable(
+	entry ImageCacheEntry,
+	currentVersion string,
+	linkedClones int,
+	now time.Time,
+	maxUnused time.Duration) bool {
+
+	if linkedClones > 0 {
+		return false
+	}
+	return IsImageCacheEntryStale(entry, currentVersion) || now.Sub(entry.LastUsed) > maxUnused
+}
+
+// useImageCache returns true if the VM should be linked cloned from the image cache. Linked clones share the
+// template VM's disks, so only VMs with thin provisioned disks are linked cloned.
+func (s *Session) useImageCache(vmCtx VirtualMachineCloneContext) bool {
+	return lib.IsLinkedCloneImageCacheEnabled() &&
+		s.cluster != nil &&
+		vmCtx.StorageProvisioning == string(vimTypes.OvfCreateImportSpecParamsDiskProvisioningTypeThin)
+}
+
+// imageCacheFolder returns the image cache Folder, creating it when create is true. Nil is returned when the
+// Folder does not exist and is not created.
+func (s *Session) imageCacheFolder(ctx goctx.Context, create bool) (*object.Folder, error) {
+	folder, err := s.ChildFolder(ctx, constants.ImageCacheFolderName)
+	if err == nil {
+		return folder, nil
+	}
+	if _, ok := err.(*find.NotFoundError); !ok {
+		return nil, err
+	}
+	if !create {
+		return nil, nil
+	}
+
+	folder, err = s.folder.CreateFolder(ctx, constants.ImageCacheFolderName)
+	if err != nil {
+		// Another VM may have created the Folder concurrently.
+		if existing, findErr := s.ChildFolder(ctx, constants.ImageCacheFolderName); findErr == nil {
+			return existing, nil
+		}
+		return nil, errors.Wrap(err, "failed to create image cache folder")
+	}
+
+	return folder, nil
+}
+
+// warmImageCache returns the template VM, and its snapshot, that caches the current content of the library item
+// on the datastore. The template VM is deployed from the library item when it does not exist yet.
+func (s *Session) warmImageCache(
+	ctx goctx.Context,
+	folder *object.Folder,
+	resourcePool *object.ResourcePool,
+	item *library.Item,
+	datastore vimTypes.ManagedObjectReference) (*object.VirtualMachine, *vimTypes.ManagedObjectReference, error) {
+
+	name := ImageCacheVMName(item.ID, item.ContentVersion, datastore.Value)
+
+	ref, err := s.findChildEntity(ctx, folder, name)
+	if err == nil {
+		vm, ok := ref.(*object.VirtualMachine)
+		if !ok {
+			return nil, nil, fmt.Errorf("image cache entity %q is not a VirtualMachine but a %T", name, ref)
+		}
+		// The snapshot is taken last, so the template VM is still being warmed up without it.
+		snapshot, err := vm.FindSnapshot(ctx, constants.ImageCacheSnapshotName)
+		if err != nil {
+			return nil, nil, errImageCacheNotReady
+		}
+		return vm, snapshot, nil
+	}
+	if _, ok := err.(*find.NotFoundError); !ok {
+		return nil, nil, err
+	}
+
+	deploy := vcenter.Deploy{
+		DeploymentSpec: vcenter.DeploymentSpec{
+			Name:                name,
+			DefaultDatastoreID:  datastore.Value,
+			StorageProvisioning: string(vimTypes.OvfCreateImportSpecParamsDiskProvisioningTypeThin),
+			AcceptAllEULA:       true,
+		},
+		Target: vcenter.Target{
+			ResourcePoolID: resourcePool.Reference().Value,
+			FolderID:       folder.Reference().Value,
+		},
+	}
+
+	log.Info("Warming up image cache", "itemID", item.ID, "contentVersion", item.ContentVersion,
+		"datastore", datastore.Value, "name", name)
+	deployedVM, err := vcenter.NewManager(s.Client.RestClient()).DeployLibraryItem(ctx, item.ID, deploy)
+	if err != nil {
+		// Another VM may be warming up the same template VM concurrently.
+		if _, findErr := s.findChildEntity(ctx, folder, name); findErr == nil {
+			return nil, nil, errImageCacheNotReady
+		}
+		return nil, nil, errors.Wrapf(err, "failed to deploy image cache template VM %q", name)
+	}
+
+	ref, err = s.Finder.ObjectReference(ctx, deployedVM.Reference())
+	if err != nil {
+		return nil, nil, err
+	}
+	vm := ref.(*object.VirtualMachine)
+
+	snapshot, err := prepareImageCacheVM(ctx, vm, item)
+	if err != nil {
+		// Without the snapshot, the template VM would be considered still being warmed up forever, so delete it
+		// for the next VM to warm it up again.
+		if resVM, resErr := res.NewVMFromObject(vm); resErr == nil {
+			if deleteErr := resVM.Delete(ctx); deleteErr != nil {
+				log.Error(deleteErr, "Failed to delete partially warmed up image cache template VM", "name", name)
+			}
+		}
+		return nil, nil, errors.Wrapf(err, "failed to warm up image cache template VM %q", name)
+	}
+
+	return vm, snapshot, nil
+}
+
+// prepareImageCacheVM records the library item content that the deployed template VM caches, and takes the
+// snapshot that VMs are linked cloned from.
+func prepareImageCacheVM(
+	ctx goctx.Context,
+	vm *object.VirtualMachine,
+	item *library.Item) (*vimTypes.ManagedObjectReference, error) {
+
+	configSpec := vimTypes.VirtualMachineConfigSpec{
+		Annotation: constants.ImageCacheVMAnnotation,
+		ExtraConfig: []vimTypes.BaseOptionValue{
+			&vimTypes.OptionValue{Key: constants.ImageCacheItemIDExtraConfigKey, Value: item.ID},
+			&vimTypes.OptionValue{Key: constants.ImageCacheContentVersionExtraConfigKey, Value: item.ContentVersion},
+			&vimTypes.OptionValue{Key: constants.ImageCacheLastUsedExtraConfigKey, Value: time.Now().UTC().Format(time.RFC3339)},
+		},
+	}
+	task, err := vm.Reconfigure(ctx, configSpec)
+	if err == nil {
+		err = task.Wait(ctx)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconfigure")
+	}
+
+	task, err = vm.CreateSnapshot(ctx, constants.ImageCacheSnapshotName, "", false, false)
+	if err == nil {
+		err = task.Wait(ctx)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to snapshot")
+	}
+
+	return vm.FindSnapshot(ctx, constants.ImageCacheSnapshotName)
+}
+
+// touchImageCache records that a VM was just cloned from the template VM.
+func touchImageCache(ctx goctx.Context, vm *object.VirtualMachine) error {
+	configSpec := vimTypes.VirtualMachineConfigSpec{
+		ExtraConfig: []vimTypes.BaseOptionValue{
+			&vimTypes.OptionValue{Key: constants.ImageCacheLastUsedExtraConfigKey, Value: time.Now().UTC().Format(time.RFC3339)},
+		},
+	}
+
+	task, err := vm.Reconfigure(ctx, configSpec)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// linkedCloneFromImageCache linked clones the VM from the template VM that caches the library item on the
+// datastore the VM is placed on, warming up the template VM first if needed.
+func (s *Session) linkedCloneFromImageCache(
+	vmCtx VirtualMachineCloneContext,
+	vmConfigArgs vmprovider.VMConfigArgs,
+	item *library.Item) (*res.VirtualMachine, error) {
+
+	configSpec := s.createConfigSpec(vmCtx.VM.Name, &vmConfigArgs.VMClass.Spec)
+	datastore, err := s.placeVMDatastore(vmCtx, configSpec, vmConfigArgs.StorageProfileID)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := s.imageCacheFolder(vmCtx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheVM, snapshot, err := s.warmImageCache(vmCtx, folder, vmCtx.ResourcePool, item, datastore)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceVM, err := res.NewVMFromObject(cacheVM)
+	if err != nil {
+		return nil, err
+	}
+
+	cloneSpec, err := s.createCloneSpec(vmCtx, sourceVM, vmConfigArgs, &linkedCloneSource{
+		Snapshot:  *snapshot,
+		Datastore: datastore,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create linked clone spec")
+	}
+	cloneSpec.Config.ExtraConfig = append(cloneSpec.Config.ExtraConfig,
+		&vimTypes.OptionValue{Key: constants.LinkedCloneSourceExtraConfigKey, Value: sourceVM.Name})
+
+	vmCtx.Logger.Info("Linked cloning VM from image cache", "itemName", item.Name,
+		"imageName", vmCtx.VM.Spec.ImageName, "templateVM", sourceVM.Name)
+	clonedVM, err := s.cloneVM(vmCtx.VirtualMachineContext, sourceVM, cloneSpec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to linked clone %q from %q", vmCtx.VM.Name, sourceVM.Name)
+	}
+
+	if err := touchImageCache(vmCtx, cacheVM); err != nil {
+		vmCtx.Logger.Error(err, "Failed to update last used time of image cache template VM", "templateVM", sourceVM.Name)
+	}
+
+	return clonedVM, nil
+}
+
+// listImageCacheEntries returns the template VMs in the image cache Folder.
+func (s *Session) listImageCacheEntries(ctx goctx.Context, folder *object.Folder) ([]ImageCacheEntry, error) {
+	v, err := view.NewManager(s.Client.VimClient()).CreateContainerView(ctx, folder.Reference(), []string{"VirtualMachine"}, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create image cache container view")
+	}
+
+	var vms []mo.VirtualMachine
+	err = v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"name", "config.annotation", "config.extraConfig", "datastore"}, &vms)
+	_ = v.Destroy(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve image cache VMs")
+	}
+
+	entries := make([]ImageCacheEntry, 0, len(vms))
+	for _, vm := range vms {
+		if vm.Config == nil || vm.Config.Annotation != constants.ImageCacheVMAnnotation || len(vm.Datastore) == 0 {
+			continue
+		}
+
+		extraConfig := ExtraConfigToMap(vm.Config.ExtraConfig)
+		// A zero LastUsed is older than any max unused age.
+		lastUsed, _ := time.Parse(time.RFC3339, extraConfig[constants.ImageCacheLastUsedExtraConfigKey])
+
+		entries = append(entries, ImageCacheEntry{
+			Name:           vm.Name,
+			MoRef:          vm.Reference(),
+			ItemID:         extraConfig[constants.ImageCacheItemIDExtraConfigKey],
+			ContentVersion: extraConfig[constants.ImageCacheContentVersionExtraConfigKey],
+			Datastore:      vm.Datastore[0],
+			LastUsed:       lastUsed,
+		})
+	}
+
+	return entries, nil
+}
+
+// diskBackingFiles returns the files of the disk backing and of its parent backings.
+func diskBackingFiles(backing vimTypes.BaseVirtualDeviceBackingInfo) []string {
+	var files []string
+	switch b := backing.(type) {
+	case *vimTypes.VirtualDiskFlatVer2BackingInfo:
+		for ; b != nil; b = b.Parent {
+			files = append(files, b.FileName)
+		}
+	case *vimTypes.VirtualDiskSeSparseBackingInfo:
+		for ; b != nil; b = b.Parent {
+			files = append(files, b.FileName)
+		}
+	case *vimTypes.VirtualDiskSparseVer2BackingInfo:
+		for ; b != nil; b = b.Parent {
+			files = append(files, b.FileName)
+		}
+	}
+	return files
+}
+
+// vmDiskBackingFiles returns the files of the backings of all the disks of the VM.
+func vmDiskBackingFiles(vm mo.VirtualMachine) []string {
+	if vm.Config == nil {
+		return nil
+	}
+
+	var files []string
+	for _, device := range vm.Config.Hardware.Device {
+		if disk, ok := device.(*vimTypes.VirtualDisk); ok {
+			files = append(files, diskBackingFiles(disk.Backing)...)
+		}
+	}
+	return files
+}
+
+// CountLinkedClones returns the number of VMs linked cloned from each template VM, by template VM name. A VM is
+// a linked clone of a template VM when one of its disks has a parent backing that is a disk of the template VM,
+// regardless of who created the VM or where it is placed.
+func CountLinkedClones(entries []ImageCacheEntry, vms []mo.VirtualMachine) map[string]int {
+	templates := map[vimTypes.ManagedObjectReference]string{}
+	for _, entry := range entries {
+		templates[entry.MoRef] = entry.Name
+	}
+
+	templateFiles := map[string]string{}
+	for _, vm := range vms {
+		if name, ok := templates[vm.Reference()]; ok {
+			for _, file := range vmDiskBackingFiles(vm) {
+				templateFiles[file] = name
+			}
+		}
+	}
+
+	linkedClones := map[string]int{}
+	for _, vm := range vms {
+		if _, ok := templates[vm.Reference()]; ok {
+			continue
+		}
+
+		sources := map[string]struct{}{}
+		for _, file := range vmDiskBackingFiles(vm) {
+			if name, ok := templateFiles[file]; ok {
+				sources[name] = struct{}{}
+			}
+		}
+		for name := range sources {
+			linkedClones[name]++
+		}
+	}
+
+	return linkedClones
+}
+
+// countLinkedClones returns the number of VMs in the datacenter linked cloned from each template VM, by template
+// VM name.
+func (s *Session) countLinkedClones(ctx goctx.Context, entries []ImageCacheEntry) (map[string]int, error) {
+	root := s.datacenter.Reference()
+	v, err := view.NewManager(s.Client.VimClient()).CreateContainerView(ctx, root, []string{"VirtualMachine"}, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create container view for %s", root.Value)
+	}
+
+	var vms []mo.VirtualMachine
+	err = v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"config.hardware.device"}, &vms)
+	_ = v.Destroy(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve VMs in %s", root.Value)
+	}
+
+	return CountLinkedClones(entries, vms), nil
+}
+
+// SyncImageCache warms up the current content version of the library items of the stale template VMs that were
+// used within maxUnused, on the same datastores, and evicts the template VMs that are stale or unused for longer
+// than maxUnused and have no linked clones.
+func (s *Session) SyncImageCache(ctx goctx.Context, maxUnused time.Duration) (*vmprovider.ImageCacheSyncResult, error) {
+	result := &vmprovider.ImageCacheSyncResult{}
+
+	folder, err := s.imageCacheFolder(ctx, false)
+	if err != nil || folder == nil {
+		return result, err
+	}
+
+	entries, err := s.listImageCacheEntries(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	linkedClones, err := s.countLinkedClones(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := map[string]struct{}{}
+	for _, entry := range entries {
+		cached[entry.Name] = struct{}{}
+	}
+
+	libMgr := library.NewManager(s.Client.RestClient())
+	items := map[string]*library.Item{}
+	now := time.Now()
+
+	for _, entry := range entries {
+		item, ok := items[entry.ItemID]
+		if !ok {
+			item, err = libMgr.GetLibraryItem(ctx, entry.ItemID)
+			if err != nil {
+				if !lib.IsNotFoundError(err) {
+					return nil, errors.Wrapf(err, "failed to get library item %s", entry.ItemID)
+				}
+				item = nil
+			}
+			items[entry.ItemID] = item
+		}
+
+		currentVersion := ""
+		if item != nil {
+			currentVersion = item.ContentVersion
+		}
+
+		if item != nil && IsImageCacheEntryStale(entry, currentVersion) && now.Sub(entry.LastUsed) <= maxUnused {
+			name := ImageCacheVMName(item.ID, item.ContentVersion, entry.Datastore.Value)
+			if _, ok := cached[name]; !ok {
+				_, _, err := s.warmImageCache(ctx, folder, s.resourcePool, item, entry.Datastore)
+				if err != nil && err != errImageCacheNotReady {
+					return nil, err
+				}
+				cached[name] = struct{}{}
+				result.Warmed = append(result.Warmed, name)
+			}
+		}
+
+		if IsImageCacheEntryEvictable(entry, currentVersion, linkedClones[entry.Name], now, maxUnused) {
+			log.Info("Evicting image cache template VM", "name", entry.Name, "itemID", entry.ItemID,
+				"contentVersion", entry.ContentVersion, "currentVersion", currentVersion, "lastUsed", entry.LastUsed)
+
+			resVM, err := res.NewVMFromObject(object.NewVirtualMachine(s.Client.VimClient(), entry.MoRef))
+			if err != nil {
+				return nil, err
+			}
+			if err := resVM.Delete(ctx); err != nil {
+				return nil, errors.Wrapf(err, "failed to delete image cache template VM %q", entry.Name)
+			}
+
+			delete(cached, entry.Name)
+			result.Evicted = append(result.Evicted, entry.Name)
+		}
+	}
+
+	for name := range cached {
+		result.Cached = append(result.Cached, name)
+	}
+	sort.Strings(result.Cached)
+
+	return result, nil
+}
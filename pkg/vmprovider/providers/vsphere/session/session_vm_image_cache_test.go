@@ -0,0 +1,133 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/vim25/mo"
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/session"
+)
+
+var _ = Describe("Image cache", func() {
+
+	Context("ImageCacheVMName", func() {
+		It("returns a name unique to the item, content version and datastore", func() {
+			Expect(session.ImageCacheVMName("item-1", "2", "datastore-1")).To(Equal("image-cache-item-1-2-datastore-1"))
+			Expect(session.ImageCacheVMName("item-1", "3", "datastore-1")).ToNot(Equal(session.ImageCacheVMName("item-1", "2", "datastore-1")))
+			Expect(session.ImageCacheVMName("item-1", "2", "datastore-2")).ToNot(Equal(session.ImageCacheVMName("item-1", "2", "datastore-1")))
+		})
+	})
+
+	Context("IsImageCacheEntryEvictable", func() {
+		const maxUnused = time.Hour
+
+		var (
+			now   time.Time
+			entry session.ImageCacheEntry
+		)
+
+		BeforeEach(func() {
+			now = time.Now()
+			entry = session.ImageCacheEntry{
+				Name:           "image-cache-item-1-2-datastore-1",
+				ItemID:         "item-1",
+				ContentVersion: "2",
+				LastUsed:       now.Add(-time.Minute),
+			}
+		})
+
+		It("keeps a current, recently used entry", func() {
+			Expect(session.IsImageCacheEntryStale(entry, "2")).To(BeFalse())
+			Expect(session.IsImageCacheEntryEvictable(entry, "2", 0, now, maxUnused)).To(BeFalse())
+		})
+
+		It("evicts an entry unused for longer than the max unused age", func() {
+			entry.LastUsed = now.Add(-2 * maxUnused)
+			Expect(session.IsImageCacheEntryEvictable(entry, "2", 0, now, maxUnused)).To(BeTrue())
+		})
+
+		It("evicts an entry that was never used", func() {
+			entry.LastUsed = time.Time{}
+			Expect(session.IsImageCacheEntryEvictable(entry, "2", 0, now, maxUnused)).To(BeTrue())
+		})
+
+		It("evicts an entry for an old content version", func() {
+			Expect(session.IsImageCacheEntryStale(entry, "3")).To(BeTrue())
+			Expect(session.IsImageCacheEntryEvictable(entry, "3", 0, now, maxUnused)).To(BeTrue())
+		})
+
+		It("evicts an entry whose library item was deleted", func() {
+			Expect(session.IsImageCacheEntryStale(entry, "")).To(BeTrue())
+			Expect(session.IsImageCacheEntryEvictable(entry, "", 0, now, maxUnused)).To(BeTrue())
+		})
+
+		It("never evicts an entry with linked clones", func() {
+			entry.LastUsed = now.Add(-2 * maxUnused)
+			Expect(session.IsImageCacheEntryEvictable(entry, "", 1, now, maxUnused)).To(BeFalse())
+		})
+	})
+
+	Context("CountLinkedClones", func() {
+		var (
+			template session.ImageCacheEntry
+			vms      []mo.VirtualMachine
+		)
+
+		newVM := func(id string, disks ...*vimTypes.VirtualDiskFlatVer2BackingInfo) mo.VirtualMachine {
+			vm := mo.VirtualMachine{
+				Config: &vimTypes.VirtualMachineConfigInfo{},
+			}
+			vm.Self = vimTypes.ManagedObjectReference{Type: "VirtualMachine", Value: id}
+			for _, disk := range disks {
+				vm.Config.Hardware.Device = append(vm.Config.Hardware.Device, &vimTypes.VirtualDisk{
+					VirtualDevice: vimTypes.VirtualDevice{Backing: disk},
+				})
+			}
+			return vm
+		}
+
+		newDisk := func(fileName string, parent *vimTypes.VirtualDiskFlatVer2BackingInfo) *vimTypes.VirtualDiskFlatVer2BackingInfo {
+			disk := &vimTypes.VirtualDiskFlatVer2BackingInfo{Parent: parent}
+			disk.FileName = fileName
+			return disk
+		}
+
+		BeforeEach(func() {
+			template = session.ImageCacheEntry{
+				Name:  "image-cache-item-1-2-datastore-1",
+				MoRef: vimTypes.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+			}
+			templateDisk := newDisk("[ds] image-cache/disk.vmdk", nil)
+			vms = []mo.VirtualMachine{
+				newVM("vm-1", newDisk("[ds] image-cache/disk-000001.vmdk", templateDisk)),
+			}
+		})
+
+		It("counts the VMs with a disk whose parent is a disk of the template VM", func() {
+			parent := newDisk("[ds] image-cache/disk.vmdk", nil)
+			vms = append(vms,
+				newVM("vm-2", newDisk("[ds] vm-2/disk-000001.vmdk", parent)),
+				newVM("vm-3", newDisk("[ds] vm-3/disk-000001.vmdk", parent), newDisk("[ds] vm-3/disk-000002.vmdk", parent)))
+			Expect(session.CountLinkedClones([]session.ImageCacheEntry{template}, vms)).To(Equal(map[string]int{template.Name: 2}))
+		})
+
+		It("counts a VM cloned from a linked clone of the template VM", func() {
+			parent := newDisk("[ds] vm-2/disk-000001.vmdk", newDisk("[ds] image-cache/disk.vmdk", nil))
+			vms = append(vms, newVM("vm-3", newDisk("[ds] vm-3/disk-000001.vmdk", parent)))
+			Expect(session.CountLinkedClones([]session.ImageCacheEntry{template}, vms)).To(Equal(map[string]int{template.Name: 1}))
+		})
+
+		It("does not count VMs that do not share the disks of the template VM", func() {
+			vms = append(vms, newVM("vm-2", newDisk("[ds] vm-2/disk.vmdk", nil)), newVM("vm-3"))
+			Expect(session.CountLinkedClones([]session.ImageCacheEntry{template}, vms)).To(BeEmpty())
+		})
+	})
+})
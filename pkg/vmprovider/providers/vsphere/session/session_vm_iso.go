@@ -0,0 +1,192 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session
+
+import (
+	goctx "context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vim25/mo"
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	res "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/resources"
+)
+
+// IsISOImage returns true if VMs boot from the image as an ISO, rather than being deployed or cloned from it.
+func IsISOImage(image *v1alpha1.VirtualMachineImage) bool {
+	return image != nil && image.Spec.Type == library.ItemTypeISO
+}
+
+// BootDiskSize returns the size of the blank boot disk of a VM that boots from an ISO image.
+func BootDiskSize(vm *v1alpha1.VirtualMachine, vmClass *v1alpha1.VirtualMachineClass) (resource.Quantity, error) {
+	size := constants.BootDiskSizeDefault
+	if val, ok := vmClass.Annotations[constants.BootDiskSizeAnnotation]; ok {
+		size = val
+	}
+	if val, ok := vm.Annotations[constants.BootDiskSizeAnnotation]; ok {
+		size = val
+	}
+
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return resource.Quantity{}, errors.Wrapf(err, "invalid boot disk size %q", size)
+	}
+	if quantity.Sign() <= 0 {
+		return resource.Quantity{}, fmt.Errorf("invalid boot disk size %q", size)
+	}
+
+	return quantity, nil
+}
+
+// DatastorePathFromURI returns the datastore path, like "[datastore1] dir/file.iso", of the file at the storage
+// URI of a library item file, like "ds:///vmfs/volumes/uuid/dir/file.iso", on the datastore with the URL.
+func DatastorePathFromURI(uri, datastoreURL, datastoreName string) (string, error) {
+	prefix := strings.TrimSuffix(datastoreURL, "/") + "/"
+	if datastoreURL == "" || !strings.HasPrefix(uri, prefix) {
+		return "", fmt.Errorf("storage URI %q is not on datastore %q", uri, datastoreName)
+	}
+
+	path, err := url.PathUnescape(strings.TrimPrefix(uri, prefix))
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid storage URI %q", uri)
+	}
+
+	return (&object.DatastorePath{Datastore: datastoreName, Path: path}).String(), nil
+}
+
+// AddISODeviceChanges adds the devices of a VM that boots from the ISO image at the datastore path to the
+// configSpec: a blank boot disk on the datastore, and a CD-ROM with the ISO image. The VM boots from the CD-ROM
+// until an OS is installed on the disk.
+func AddISODeviceChanges(
+	configSpec *vimTypes.VirtualMachineConfigSpec,
+	datastore vimTypes.ManagedObjectReference,
+	isoPath string,
+	bootDiskSize resource.Quantity) error {
+
+	var devices object.VirtualDeviceList
+
+	scsi, err := devices.CreateSCSIController("pvscsi")
+	if err != nil {
+		return err
+	}
+	devices = append(devices, scsi)
+
+	disk := devices.CreateDisk(scsi.(vimTypes.BaseVirtualController), datastore, "")
+	disk.CapacityInBytes = bootDiskSize.Value()
+	disk.CapacityInKB = bootDiskSize.Value() / 1024
+	devices = append(devices, disk)
+
+	ide, err := devices.CreateIDEController()
+	if err != nil {
+		return err
+	}
+	devices = append(devices, ide)
+
+	cdrom, err := devices.CreateCdrom(ide.(*vimTypes.VirtualIDEController))
+	if err != nil {
+		return err
+	}
+	devices = append(devices, devices.InsertIso(cdrom, isoPath))
+
+	deviceChanges, err := devices.ConfigSpec(vimTypes.VirtualDeviceConfigSpecOperationAdd)
+	if err != nil {
+		return err
+	}
+	configSpec.DeviceChange = append(configSpec.DeviceChange, deviceChanges...)
+
+	configSpec.BootOptions = &vimTypes.VirtualMachineBootOptions{
+		BootOrder: []vimTypes.BaseVirtualMachineBootOptionsBootableDevice{
+			&vimTypes.VirtualMachineBootOptionsBootableDiskDevice{DeviceKey: disk.Key},
+			&vimTypes.VirtualMachineBootOptionsBootableCdromDevice{},
+		},
+	}
+
+	return nil
+}
+
+// isoDatastorePath returns the datastore path of the ISO image file of the library item.
+func (s *Session) isoDatastorePath(ctx goctx.Context, itemID string) (string, error) {
+	storage, err := s.Client.ContentLibClient().GetLibraryItemStorage(ctx, itemID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, st := range storage {
+		if !strings.EqualFold(filepath.Ext(st.FileName), "."+library.ItemTypeISO) ||
+			st.StorageBacking.Type != "DATASTORE" || len(st.StorageURIs) == 0 {
+			continue
+		}
+
+		dsRef := vimTypes.ManagedObjectReference{Type: "Datastore", Value: st.StorageBacking.DatastoreID}
+		var ds mo.Datastore
+		if err := object.NewDatastore(s.Client.VimClient(), dsRef).Properties(ctx, dsRef, []string{"name", "summary"}, &ds); err != nil {
+			return "", errors.Wrapf(err, "failed to get datastore %s", dsRef.Value)
+		}
+
+		return DatastorePathFromURI(st.StorageURIs[0], ds.Summary.Url, ds.Name)
+	}
+
+	return "", fmt.Errorf("library item %s does not have an ISO image file on a datastore", itemID)
+}
+
+// createVMFromISO creates a VM that boots from the ISO library item, with a blank boot disk.
+func (s *Session) createVMFromISO(
+	vmCtx VirtualMachineCloneContext,
+	vmConfigArgs vmprovider.VMConfigArgs,
+	item *library.Item) (*res.VirtualMachine, error) {
+
+	vmCtx.Logger.Info("Creating VM from ISO library item", "itemName", item.Name,
+		"imageName", vmCtx.VM.Spec.ImageName, "storageProfileID", vmConfigArgs.StorageProfileID)
+
+	bootDiskSize, err := BootDiskSize(vmCtx.VM, &vmConfigArgs.VMClass)
+	if err != nil {
+		return nil, err
+	}
+
+	isoPath, err := s.isoDatastorePath(vmCtx, item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	configSpec := s.createConfigSpec(vmCtx.VM.Name, &vmConfigArgs.VMClass.Spec)
+	configSpec.GuestId = constants.GuestIDDefault
+	if guestID := vmCtx.VM.Annotations[constants.GuestIDAnnotation]; guestID != "" {
+		configSpec.GuestId = guestID
+	}
+
+	dsRef, err := s.placeVMDatastore(vmCtx, configSpec, vmConfigArgs.StorageProfileID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds mo.Datastore
+	if err := object.NewDatastore(s.Client.VimClient(), dsRef).Properties(vmCtx, dsRef, []string{"name"}, &ds); err != nil {
+		return nil, errors.Wrapf(err, "failed to get datastore %s", dsRef.Value)
+	}
+	configSpec.Files = &vimTypes.VirtualMachineFileInfo{
+		VmPathName: (&object.DatastorePath{Datastore: ds.Name}).String(),
+	}
+
+	if err := AddISODeviceChanges(configSpec, dsRef, isoPath, bootDiskSize); err != nil {
+		return nil, err
+	}
+
+	resVM := res.NewVMForCreate(vmCtx.VM.Name)
+	if err := resVM.Create(vmCtx, vmCtx.Folder, vmCtx.ResourcePool, configSpec); err != nil {
+		return nil, errors.Wrapf(err, "create VM from ISO image %q failed", vmCtx.VM.Spec.ImageName)
+	}
+
+	return resVM, nil
+}
@@ -0,0 +1,138 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session_test
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/library"
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/session"
+)
+
+var _ = Describe("ISO images", func() {
+
+	Context("IsISOImage", func() {
+		It("returns true only for ISO images", func() {
+			image := &vmopv1alpha1.VirtualMachineImage{}
+			image.Spec.Type = library.ItemTypeISO
+			Expect(session.IsISOImage(image)).To(BeTrue())
+
+			image.Spec.Type = library.ItemTypeOVF
+			Expect(session.IsISOImage(image)).To(BeFalse())
+			Expect(session.IsISOImage(nil)).To(BeFalse())
+		})
+	})
+
+	Context("BootDiskSize", func() {
+		var (
+			vm      *vmopv1alpha1.VirtualMachine
+			vmClass *vmopv1alpha1.VirtualMachineClass
+		)
+
+		BeforeEach(func() {
+			vm = &vmopv1alpha1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			vmClass = &vmopv1alpha1.VirtualMachineClass{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+		})
+
+		It("returns the default size", func() {
+			size, err := session.BootDiskSize(vm, vmClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(size.String()).To(Equal(constants.BootDiskSizeDefault))
+		})
+
+		It("returns the size from the VM class", func() {
+			vmClass.Annotations[constants.BootDiskSizeAnnotation] = "40Gi"
+			size, err := session.BootDiskSize(vm, vmClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(size.String()).To(Equal("40Gi"))
+		})
+
+		It("prefers the size from the VM", func() {
+			vmClass.Annotations[constants.BootDiskSizeAnnotation] = "40Gi"
+			vm.Annotations[constants.BootDiskSizeAnnotation] = "60Gi"
+			size, err := session.BootDiskSize(vm, vmClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(size.String()).To(Equal("60Gi"))
+		})
+
+		It("returns an error for an invalid size", func() {
+			vm.Annotations[constants.BootDiskSizeAnnotation] = "large"
+			_, err := session.BootDiskSize(vm, vmClass)
+			Expect(err).To(HaveOccurred())
+
+			vm.Annotations[constants.BootDiskSizeAnnotation] = "0"
+			_, err = session.BootDiskSize(vm, vmClass)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("DatastorePathFromURI", func() {
+		const dsURL = "ds:///vmfs/volumes/5f1c-ab/"
+
+		It("returns the datastore path of the file", func() {
+			path, err := session.DatastorePathFromURI(dsURL+"contentlib-1/item-1/my%20image.iso", dsURL, "datastore1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("[datastore1] contentlib-1/item-1/my image.iso"))
+		})
+
+		It("accepts a datastore URL without a trailing slash", func() {
+			path, err := session.DatastorePathFromURI(dsURL+"item-1/image.iso", "ds:///vmfs/volumes/5f1c-ab", "datastore1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("[datastore1] item-1/image.iso"))
+		})
+
+		It("returns an error when the file is on another datastore", func() {
+			_, err := session.DatastorePathFromURI("ds:///vmfs/volumes/other/item-1/image.iso", dsURL, "datastore1")
+			Expect(err).To(HaveOccurred())
+
+			_, err = session.DatastorePathFromURI(dsURL+"item-1/image.iso", "", "datastore1")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("AddISODeviceChanges", func() {
+		It("adds a blank boot disk and a CD-ROM with the ISO image", func() {
+			configSpec := &vimTypes.VirtualMachineConfigSpec{}
+			dsRef := vimTypes.ManagedObjectReference{Type: "Datastore", Value: "datastore-1"}
+			size := resource.MustParse("20Gi")
+
+			Expect(session.AddISODeviceChanges(configSpec, dsRef, "[datastore1] item-1/image.iso", size)).To(Succeed())
+
+			var devices object.VirtualDeviceList
+			for _, change := range configSpec.DeviceChange {
+				spec := change.GetVirtualDeviceConfigSpec()
+				Expect(spec.Operation).To(Equal(vimTypes.VirtualDeviceConfigSpecOperationAdd))
+				devices = append(devices, spec.Device)
+			}
+
+			disks := devices.SelectByType((*vimTypes.VirtualDisk)(nil))
+			Expect(disks).To(HaveLen(1))
+			disk := disks[0].(*vimTypes.VirtualDisk)
+			Expect(disk.CapacityInBytes).To(Equal(size.Value()))
+			Expect(disk.Backing.(*vimTypes.VirtualDiskFlatVer2BackingInfo).Datastore).To(Equal(&dsRef))
+			Expect(devices.FindByKey(disk.ControllerKey)).To(BeAssignableToTypeOf(&vimTypes.ParaVirtualSCSIController{}))
+
+			cdroms := devices.SelectByType((*vimTypes.VirtualCdrom)(nil))
+			Expect(cdroms).To(HaveLen(1))
+			backing, ok := cdroms[0].GetVirtualDevice().Backing.(*vimTypes.VirtualCdromIsoBackingInfo)
+			Expect(ok).To(BeTrue())
+			Expect(backing.FileName).To(Equal("[datastore1] item-1/image.iso"))
+
+			Expect(configSpec.BootOptions).ToNot(BeNil())
+			Expect(configSpec.BootOptions.BootOrder).To(HaveLen(2))
+			Expect(configSpec.BootOptions.BootOrder[0]).To(Equal(&vimTypes.VirtualMachineBootOptionsBootableDiskDevice{DeviceKey: disk.Key}))
+		})
+	})
+})
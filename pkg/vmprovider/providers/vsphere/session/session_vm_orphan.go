@@ -0,0 +1,89 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package session
+
+import (
+	goctx "context"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	vimTypes "github.com/vmware/govmomi/vim25/types"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+)
+
+// ListManagedVirtualMachines returns the VMs under the session's Folder and ResourcePool
+// that carry the VM Operator managed annotation.
+func (s *Session) ListManagedVirtualMachines(ctx goctx.Context) ([]vmprovider.ManagedVirtualMachine, error) {
+	viewMgr := view.NewManager(s.Client.VimClient())
+
+	seen := map[string]struct{}{}
+	var managedVMs []vmprovider.ManagedVirtualMachine
+
+	for _, container := range []vimTypes.ManagedObjectReference{s.folder.Reference(), s.resourcePool.Reference()} {
+		v, err := viewMgr.CreateContainerView(ctx, container, []string{"VirtualMachine"}, true)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create container view for %s", container.Value)
+		}
+
+		var vms []mo.VirtualMachine
+		err = v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"name", "config.annotation", "config.extraConfig", "config.instanceUuid"}, &vms)
+		_ = v.Destroy(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve VMs in %s", container.Value)
+		}
+
+		for _, vm := range vms {
+			if vm.Config == nil || vm.Config.Annotation != constants.VCVMAnnotation {
+				continue
+			}
+
+			moID := vm.Reference().Value
+			if _, ok := seen[moID]; ok {
+				continue
+			}
+			seen[moID] = struct{}{}
+
+			extraConfig := ExtraConfigToMap(vm.Config.ExtraConfig)
+			managedVMs = append(managedVMs, vmprovider.ManagedVirtualMachine{
+				Name:      vm.Name,
+				MoID:      moID,
+				HasBackup: extraConfig[constants.BackupVMResourceExtraConfigKey] != "",
+				Restored:  IsRestoredFromBackup(extraConfig, vm.Config.InstanceUuid, moID),
+			})
+		}
+	}
+
+	return managedVMs, nil
+}
+
+// DeleteManagedVirtualMachine powers off and destroys the VM with the given MoID. The VM's
+// annotation is rechecked so that a VM no longer managed by VM Operator is never deleted.
+func (s *Session) DeleteManagedVirtualMachine(ctx goctx.Context, moID string) error {
+	resVM, err := s.lookupVMByMoID(ctx, moID)
+	if err != nil {
+		return err
+	}
+
+	moVM, err := resVM.GetProperties(ctx, []string{"config.annotation", "summary.runtime"})
+	if err != nil {
+		return err
+	}
+
+	if moVM.Config == nil || moVM.Config.Annotation != constants.VCVMAnnotation {
+		return errors.Errorf("VM %s is not managed by VM Operator", moID)
+	}
+
+	if moVM.Summary.Runtime.PowerState != vimTypes.VirtualMachinePowerStatePoweredOff {
+		if err := resVM.SetPowerState(ctx, vmopv1alpha1.VirtualMachinePoweredOff); err != nil {
+			return err
+		}
+	}
+
+	return resVM.Delete(ctx)
+}
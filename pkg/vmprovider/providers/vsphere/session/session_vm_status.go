@@ -13,8 +13,10 @@ import (
 
 	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
 	res "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/resources"
 )
 
@@ -86,13 +88,23 @@ func MarkCustomizationInfoCondition(vm *v1alpha1.VirtualMachine, guestInfo *vimT
 	}
 }
 
+// MarkLinkedCloneCondition sets the linked clone condition when the VM's ExtraConfig records the image cache
+// template VM it was linked cloned from, and removes it otherwise.
+func MarkLinkedCloneCondition(vm *v1alpha1.VirtualMachine, extraConfig []vimTypes.BaseOptionValue) {
+	if ExtraConfigToMap(extraConfig)[constants.LinkedCloneSourceExtraConfigKey] != "" {
+		conditions.MarkTrue(vm, vmopapiv1alpha1.VirtualMachineLinkedCloneCondition)
+	} else {
+		conditions.Delete(vm, vmopapiv1alpha1.VirtualMachineLinkedCloneCondition)
+	}
+}
+
 func (s *Session) updateVMStatus(
 	vmCtx context.VirtualMachineContext,
 	resVM *res.VirtualMachine) error {
 
 	// TODO: We could be smarter about not re-fetching the config: if we didn't do a
 	// reconfigure or power change, the prior config is still entirely valid.
-	moVM, err := resVM.GetProperties(vmCtx, []string{"config.changeTrackingEnabled", "guest", "summary"})
+	moVM, err := resVM.GetProperties(vmCtx, []string{"config.changeTrackingEnabled", "config.extraConfig", "guest", "summary"})
 	if err != nil {
 		// Leave the current Status unchanged.
 		return err
@@ -139,8 +151,10 @@ func (s *Session) updateVMStatus(
 
 	if config := moVM.Config; config != nil {
 		vm.Status.ChangeBlockTracking = config.ChangeTrackingEnabled
+		MarkLinkedCloneCondition(vm, config.ExtraConfig)
 	} else {
 		vm.Status.ChangeBlockTracking = nil
+		MarkLinkedCloneCondition(vm, nil)
 	}
 
 	return k8serrors.NewAggregate(errs)
@@ -12,7 +12,9 @@ import (
 
 	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/session"
 )
 
@@ -243,3 +245,31 @@ var _ = Describe("VSphere Customization Status to VM Status Condition", func() {
 		})
 	})
 })
+
+var _ = Describe("Linked clone VM Status Condition", func() {
+	Context("MarkLinkedCloneCondition", func() {
+		var vm *vmopv1alpha1.VirtualMachine
+
+		BeforeEach(func() {
+			vm = &vmopv1alpha1.VirtualMachine{}
+		})
+
+		It("sets condition true for a linked clone", func() {
+			extraConfig := []vimTypes.BaseOptionValue{
+				&vimTypes.OptionValue{Key: constants.LinkedCloneSourceExtraConfigKey, Value: "image-cache-item-1-1-datastore-1"},
+			}
+			session.MarkLinkedCloneCondition(vm, extraConfig)
+
+			expectedConditions := vmopv1alpha1.Conditions{
+				*conditions.TrueCondition(vmopapiv1alpha1.VirtualMachineLinkedCloneCondition),
+			}
+			Expect(vm.Status.Conditions).To(conditions.MatchConditions(expectedConditions))
+		})
+
+		It("removes the condition when the VM is not a linked clone", func() {
+			conditions.MarkTrue(vm, vmopapiv1alpha1.VirtualMachineLinkedCloneCondition)
+			session.MarkLinkedCloneCondition(vm, nil)
+			Expect(conditions.Has(vm, vmopapiv1alpha1.VirtualMachineLinkedCloneCondition)).To(BeFalse())
+		})
+	})
+})
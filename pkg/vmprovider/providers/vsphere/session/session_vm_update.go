@@ -4,6 +4,7 @@
 package session
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -19,6 +20,7 @@ import (
 	"github.com/vmware-tanzu/vm-operator/pkg"
 	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/clustermodules"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/config"
@@ -310,7 +312,8 @@ func UpdateConfigSpecExtraConfig(
 	vmImage *v1alpha1.VirtualMachineImage,
 	vmClassSpec *v1alpha1.VirtualMachineClassSpec,
 	vm *v1alpha1.VirtualMachine,
-	globalExtraConfig map[string]string) {
+	globalExtraConfig map[string]string,
+	vmExtraConfig map[string]string) {
 
 	// The only use of this is for the global JSON_EXTRA_CONFIG to set the image name.
 	renderTemplateFn := func(name, text string) string {
@@ -327,22 +330,40 @@ func UpdateConfigSpecExtraConfig(
 
 	extraConfig := make(map[string]string)
 	for k, v := range globalExtraConfig {
-		extraConfig[k] = renderTemplateFn(k, v)
+		if _, ok := vmExtraConfig[k]; !ok {
+			extraConfig[k] = renderTemplateFn(k, v)
+		}
 	}
 
+	// The keys that VM Operator manages below take precedence over the VM ExtraConfig.
+	managedExtraConfig := make(map[string]string)
+
 	virtualDevices := vmClassSpec.Hardware.Devices
 	if len(virtualDevices.VGPUDevices) > 0 || len(virtualDevices.DynamicDirectPathIODevices) > 0 {
 		// Add "maintenance.vm.evacuation.poweroff" extraConfig key when GPU devices are present in the VMClass Spec.
-		extraConfig[constants.MMPowerOffVMExtraConfigKey] = constants.ExtraConfigTrue
-		setMMIOExtraConfig(vm, extraConfig)
+		managedExtraConfig[constants.MMPowerOffVMExtraConfigKey] = constants.ExtraConfigTrue
+		setMMIOExtraConfig(vm, managedExtraConfig)
 	}
 
 	// If VM has InstanceStorage configured, add "maintenance.vm.evacuation.poweroff" to extraConfig
 	if instancestorage.IsConfigured(vm) {
-		extraConfig[constants.MMPowerOffVMExtraConfigKey] = constants.ExtraConfigTrue
+		managedExtraConfig[constants.MMPowerOffVMExtraConfigKey] = constants.ExtraConfigTrue
+	}
+
+	// Unlike the global and managed ExtraConfig, the VM ExtraConfig is kept in sync with the VM.
+	userExtraConfig := make(map[string]string)
+	for k, v := range vmExtraConfig {
+		if _, ok := managedExtraConfig[k]; !ok {
+			userExtraConfig[k] = v
+		}
+	}
+	for k, v := range managedExtraConfig {
+		extraConfig[k] = v
 	}
 
 	configSpec.ExtraConfig = MergeExtraConfig(config.ExtraConfig, extraConfig)
+	configSpec.ExtraConfig = append(configSpec.ExtraConfig, UpdateExtraConfig(config.ExtraConfig, userExtraConfig)...)
+	setEffectiveExtraConfig(vm, extraConfig, userExtraConfig)
 
 	if conditions.IsTrue(vmImage, v1alpha1.VirtualMachineImageV1Alpha1CompatibleCondition) {
 		ecMap := ExtraConfigToMap(config.ExtraConfig)
@@ -354,6 +375,30 @@ func UpdateConfigSpecExtraConfig(
 	}
 }
 
+// setEffectiveExtraConfig sets the annotation of the ExtraConfig that VM Operator set on the VM.
+func setEffectiveExtraConfig(vm *v1alpha1.VirtualMachine, extraConfigs ...map[string]string) {
+	effective := make(map[string]string)
+	for _, extraConfig := range extraConfigs {
+		for k, v := range extraConfig {
+			effective[k] = v
+		}
+	}
+
+	if len(effective) == 0 {
+		delete(vm.Annotations, constants.EffectiveExtraConfigAnnotation)
+		return
+	}
+
+	data, err := json.Marshal(effective)
+	if err != nil {
+		return
+	}
+	if vm.Annotations == nil {
+		vm.Annotations = make(map[string]string)
+	}
+	vm.Annotations[constants.EffectiveExtraConfigAnnotation] = string(data)
+}
+
 func setMMIOExtraConfig(vm *v1alpha1.VirtualMachine, extraConfig map[string]string) {
 	mmioSize := vm.Annotations[constants.PCIPassthruMMIOOverrideAnnotation]
 	if mmioSize == "" {
@@ -416,6 +461,40 @@ func UpdateConfigSpecFirmware(
 	}
 }
 
+// UpdateConfigSpecEjectISO ejects the ISO images from the CD-ROMs of a VM with the EjectISOAnnotation once the
+// guest OS installed from the ISO image is running VMware Tools.
+func UpdateConfigSpecEjectISO(
+	config *vimTypes.VirtualMachineConfigInfo,
+	guest *vimTypes.GuestInfo,
+	configSpec *vimTypes.VirtualMachineConfigSpec,
+	vm *v1alpha1.VirtualMachine) {
+
+	if vm.Annotations[constants.EjectISOAnnotation] != "true" || guest == nil ||
+		guest.ToolsRunningStatus != string(vimTypes.VirtualMachineToolsRunningStatusGuestToolsRunning) {
+		return
+	}
+
+	devices := object.VirtualDeviceList(config.Hardware.Device)
+	for _, dev := range devices.SelectByType((*vimTypes.VirtualCdrom)(nil)) {
+		cdrom := *dev.(*vimTypes.VirtualCdrom)
+		if _, ok := cdrom.Backing.(*vimTypes.VirtualCdromIsoBackingInfo); !ok {
+			continue
+		}
+
+		if cdrom.Connectable != nil {
+			connectable := *cdrom.Connectable
+			connectable.Connected = false
+			connectable.StartConnected = false
+			cdrom.Connectable = &connectable
+		}
+
+		configSpec.DeviceChange = append(configSpec.DeviceChange, &vimTypes.VirtualDeviceConfigSpec{
+			Operation: vimTypes.VirtualDeviceConfigSpecOperationEdit,
+			Device:    devices.EjectIso(&cdrom),
+		})
+	}
+}
+
 // TODO: Fix parameter explosion.
 func updateConfigSpec(
 	vmCtx context.VirtualMachineContext,
@@ -431,7 +510,7 @@ func updateConfigSpec(
 	UpdateHardwareConfigSpec(config, configSpec, &vmClassSpec)
 	UpdateConfigSpecCPUAllocation(config, configSpec, &vmClassSpec, minCPUFreq)
 	UpdateConfigSpecMemoryAllocation(config, configSpec, &vmClassSpec)
-	UpdateConfigSpecExtraConfig(config, configSpec, vmImage, &vmClassSpec, vmCtx.VM, globalExtraConfig)
+	UpdateConfigSpecExtraConfig(config, configSpec, vmImage, &vmClassSpec, vmCtx.VM, globalExtraConfig, updateArgs.ExtraConfig)
 	UpdateConfigSpecChangeBlockTracking(config, configSpec, vmCtx.VM.Spec)
 	UpdateConfigSpecFirmware(config, configSpec, vmCtx.VM)
 
@@ -637,10 +716,12 @@ func (s *Session) prepareVMForPowerOn(
 func (s *Session) poweredOnVMReconfigure(
 	vmCtx context.VirtualMachineContext,
 	resVM *res.VirtualMachine,
-	config *vimTypes.VirtualMachineConfigInfo) error {
+	config *vimTypes.VirtualMachineConfigInfo,
+	guest *vimTypes.GuestInfo) error {
 
 	configSpec := &vimTypes.VirtualMachineConfigSpec{}
 	UpdateConfigSpecChangeBlockTracking(config, configSpec, vmCtx.VM.Spec)
+	UpdateConfigSpecEjectISO(config, guest, configSpec, vmCtx.VM)
 
 	defaultConfigSpec := &vimTypes.VirtualMachineConfigSpec{}
 	if !apiEquality.Semantic.DeepEqual(configSpec, defaultConfigSpec) {
@@ -708,7 +789,7 @@ func (s *Session) UpdateVirtualMachine(
 		return err
 	}
 
-	moVM, err := resVM.GetProperties(vmCtx, []string{"config", "runtime"})
+	moVM, err := resVM.GetProperties(vmCtx, []string{"config", "runtime", "guest"})
 	if err != nil {
 		return err
 	}
@@ -757,13 +838,20 @@ func (s *Session) UpdateVirtualMachine(
 				return err
 			}
 		} else {
-			err := s.poweredOnVMReconfigure(vmCtx, resVM, config)
+			err := s.poweredOnVMReconfigure(vmCtx, resVM, config, moVM.Guest)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	if lib.IsVMServiceBackupRestoreFSSEnabled() {
+		if err := s.backupVirtualMachine(vmCtx, resVM, moVM.Config, vmConfigArgs.VMMetadata); err != nil {
+			vmCtx.Logger.Error(err, "Failed to backup VirtualMachine")
+			return err
+		}
+	}
+
 	// TODO: Find a better place for this?
 	return s.attachTagsAndModules(vmCtx, resVM, vmConfigArgs.ResourcePolicy)
 }
@@ -6,6 +6,7 @@
 package session_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync/atomic"
 
@@ -249,6 +250,7 @@ var _ = Describe("Update ConfigSpec", func() {
 		var vmClassSpec *vmopv1alpha1.VirtualMachineClassSpec
 		var vm *vmopv1alpha1.VirtualMachine
 		var globalExtraConfig map[string]string
+		var vmExtraConfig map[string]string
 		var ecMap map[string]string
 
 		BeforeEach(func() {
@@ -260,6 +262,7 @@ var _ = Describe("Update ConfigSpec", func() {
 				},
 			}
 			globalExtraConfig = make(map[string]string)
+			vmExtraConfig = make(map[string]string)
 		})
 
 		JustBeforeEach(func() {
@@ -269,7 +272,8 @@ var _ = Describe("Update ConfigSpec", func() {
 				vmImage,
 				vmClassSpec,
 				vm,
-				globalExtraConfig)
+				globalExtraConfig,
+				vmExtraConfig)
 
 			ecMap = make(map[string]string)
 			for _, ec := range configSpec.ExtraConfig {
@@ -317,6 +321,53 @@ var _ = Describe("Update ConfigSpec", func() {
 			})
 		})
 
+		Context("VM ExtraConfig", func() {
+			BeforeEach(func() {
+				config.ExtraConfig = append(config.ExtraConfig,
+					&vimTypes.OptionValue{Key: "same", Value: "value"},
+					&vimTypes.OptionValue{Key: "changed", Value: "old"})
+				globalExtraConfig["global"] = "global"
+				globalExtraConfig["overridden"] = "global"
+				vmExtraConfig["overridden"] = "vm"
+				vmExtraConfig["same"] = "value"
+				vmExtraConfig["changed"] = "new"
+				vmExtraConfig[constants.MMPowerOffVMExtraConfigKey] = "false"
+				vm.Spec.Volumes = append(vm.Spec.Volumes, vmopv1alpha1.VirtualMachineVolume{
+					Name: "pvc-volume-1",
+					PersistentVolumeClaim: &vmopv1alpha1.PersistentVolumeClaimVolumeSource{
+						PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: "pvc-volume-1",
+						},
+						InstanceVolumeClaim: &vmopv1alpha1.InstanceVolumeClaimVolumeSource{
+							StorageClass: "dummyStorageClass",
+							Size:         resource.MustParse("256Gi"),
+						},
+					},
+				})
+			})
+
+			It("Expected configSpec.ExtraConfig", func() {
+				Expect(ecMap).To(HaveKeyWithValue("global", "global"))
+				Expect(ecMap).To(HaveKeyWithValue("overridden", "vm"))
+				Expect(ecMap).To(HaveKeyWithValue("changed", "new"))
+				Expect(ecMap).ToNot(HaveKey("same"))
+				Expect(ecMap).To(HaveKeyWithValue(constants.MMPowerOffVMExtraConfigKey, constants.ExtraConfigTrue))
+			})
+
+			It("Sets the effective ExtraConfig annotation", func() {
+				Expect(vm.Annotations).To(HaveKey(constants.EffectiveExtraConfigAnnotation))
+				effective := map[string]string{}
+				Expect(json.Unmarshal([]byte(vm.Annotations[constants.EffectiveExtraConfigAnnotation]), &effective)).To(Succeed())
+				Expect(effective).To(Equal(map[string]string{
+					"global":                             "global",
+					"overridden":                         "vm",
+					"same":                               "value",
+					"changed":                            "new",
+					constants.MMPowerOffVMExtraConfigKey: constants.ExtraConfigTrue,
+				}))
+			})
+		})
+
 		Context("InstanceStorage related tests", func() {
 
 			Context("When InstanceStorage is NOT configured on VM", func() {
@@ -498,6 +549,67 @@ var _ = Describe("Update ConfigSpec", func() {
 
 	})
 
+	Context("Eject ISO", func() {
+		var vm *vmopv1alpha1.VirtualMachine
+		var guest *vimTypes.GuestInfo
+		var cdrom *vimTypes.VirtualCdrom
+
+		BeforeEach(func() {
+			vm = &vmopv1alpha1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.EjectISOAnnotation: "true"},
+				},
+			}
+			guest = &vimTypes.GuestInfo{
+				ToolsRunningStatus: string(vimTypes.VirtualMachineToolsRunningStatusGuestToolsRunning),
+			}
+
+			var devices object.VirtualDeviceList
+			ide, err := devices.CreateIDEController()
+			Expect(err).ToNot(HaveOccurred())
+			cdrom, err = devices.CreateCdrom(ide.(*vimTypes.VirtualIDEController))
+			Expect(err).ToNot(HaveOccurred())
+			cdrom = devices.InsertIso(cdrom, "[datastore1] dir/image.iso")
+
+			config.Hardware.Device = []vimTypes.BaseVirtualDevice{ide, cdrom}
+		})
+
+		It("ejects the ISO image when VMware Tools is running", func() {
+			session.UpdateConfigSpecEjectISO(config, guest, configSpec, vm)
+			Expect(configSpec.DeviceChange).To(HaveLen(1))
+
+			change := configSpec.DeviceChange[0].GetVirtualDeviceConfigSpec()
+			Expect(change.Operation).To(Equal(vimTypes.VirtualDeviceConfigSpecOperationEdit))
+			ejected := change.Device.(*vimTypes.VirtualCdrom)
+			Expect(ejected.Key).To(Equal(cdrom.Key))
+			Expect(ejected.Backing).ToNot(BeAssignableToTypeOf(&vimTypes.VirtualCdromIsoBackingInfo{}))
+			Expect(ejected.Connectable.StartConnected).To(BeFalse())
+
+			By("not changing the current config", func() {
+				Expect(cdrom.Backing).To(BeAssignableToTypeOf(&vimTypes.VirtualCdromIsoBackingInfo{}))
+				Expect(cdrom.Connectable.StartConnected).To(BeTrue())
+			})
+		})
+
+		It("does not eject the ISO image without the annotation", func() {
+			delete(vm.Annotations, constants.EjectISOAnnotation)
+			session.UpdateConfigSpecEjectISO(config, guest, configSpec, vm)
+			Expect(configSpec.DeviceChange).To(BeEmpty())
+		})
+
+		It("does not eject the ISO image before VMware Tools is running", func() {
+			guest.ToolsRunningStatus = string(vimTypes.VirtualMachineToolsRunningStatusGuestToolsNotRunning)
+			session.UpdateConfigSpecEjectISO(config, guest, configSpec, vm)
+			Expect(configSpec.DeviceChange).To(BeEmpty())
+		})
+
+		It("does not change a CD-ROM without an ISO image", func() {
+			object.VirtualDeviceList(config.Hardware.Device).EjectIso(cdrom)
+			session.UpdateConfigSpecEjectISO(config, guest, configSpec, vm)
+			Expect(configSpec.DeviceChange).To(BeEmpty())
+		})
+	})
+
 	Context("Ethernet Card Changes", func() {
 		var expectedList object.VirtualDeviceList
 		var currentList object.VirtualDeviceList
@@ -0,0 +1,156 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vapp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+)
+
+var (
+	// rangeQualifierRe matches the OVF MinLen, MaxLen, MinValue and MaxValue qualifiers, e.g. "MaxLen(64)".
+	rangeQualifierRe = regexp.MustCompile(`(MinLen|MaxLen|MinValue|MaxValue)\(\s*([^)]*?)\s*\)`)
+
+	// valueMapQualifierRe matches the OVF ValueMap qualifier, e.g. `ValueMap{"small","large"}`.
+	valueMapQualifierRe = regexp.MustCompile(`ValueMap\{([^}]*)\}`)
+)
+
+// PropertyError describes a VM metadata key whose value is not valid for the image's vApp properties.
+type PropertyError struct {
+	Key     string
+	Message string
+}
+
+func (e PropertyError) Error() string {
+	return fmt.Sprintf("key %q %s", e.Key, e.Message)
+}
+
+// ValidateMetadata validates the VM metadata of the OvfEnv transport against the vApp properties of the
+// image. Every key must be a user configurable property, its value must be valid for the property's type
+// and qualifiers, and passwords may only be set when the metadata is from a Secret. The errors are sorted
+// by key.
+func ValidateMetadata(
+	properties []vmopapiv1alpha1.VirtualMachineImageOVFProperty,
+	data map[string]string,
+	fromSecret bool) []PropertyError {
+
+	propertiesByKey := make(map[string]vmopapiv1alpha1.VirtualMachineImageOVFProperty, len(properties))
+	for _, p := range properties {
+		propertiesByKey[p.Key] = p
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []PropertyError
+	for _, key := range keys {
+		property, ok := propertiesByKey[key]
+		switch {
+		case !ok:
+			errs = append(errs, PropertyError{Key: key, Message: "is not a vApp property of the image"})
+		case !property.UserConfigurable:
+			errs = append(errs, PropertyError{Key: key, Message: "is not a user configurable vApp property of the image"})
+		case property.Password && !fromSecret:
+			errs = append(errs, PropertyError{Key: key, Message: "is a password and must be specified in a Secret"})
+		default:
+			if msg := validateValue(property, data[key]); msg != "" {
+				errs = append(errs, PropertyError{Key: key, Message: msg})
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateValue returns why the value is not valid for the property, or an empty string if it is valid.
+func validateValue(property vmopapiv1alpha1.VirtualMachineImageOVFProperty, value string) string {
+	if msg := validateType(property.Type, value); msg != "" {
+		return msg
+	}
+
+	if m := valueMapQualifierRe.FindStringSubmatch(property.Qualifiers); m != nil {
+		var allowed []string
+		for _, v := range strings.Split(m[1], ",") {
+			allowed = append(allowed, strings.Trim(strings.TrimSpace(v), `"`))
+		}
+		found := false
+		for _, v := range allowed {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("must be one of %q", allowed)
+		}
+	}
+
+	for _, m := range rangeQualifierRe.FindAllStringSubmatch(property.Qualifiers, -1) {
+		qualifier := m[1]
+		limit, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			// The qualifier is malformed in the OVF so there is nothing to enforce.
+			continue
+		}
+
+		switch qualifier {
+		case "MinLen":
+			if float64(len(value)) < limit {
+				return fmt.Sprintf("must be at least %s characters", m[2])
+			}
+		case "MaxLen":
+			if float64(len(value)) > limit {
+				return fmt.Sprintf("must be at most %s characters", m[2])
+			}
+		case "MinValue", "MaxValue":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return "must be a number"
+			}
+			if qualifier == "MinValue" && v < limit {
+				return fmt.Sprintf("must be at least %s", m[2])
+			}
+			if qualifier == "MaxValue" && v > limit {
+				return fmt.Sprintf("must be at most %s", m[2])
+			}
+		}
+	}
+
+	return ""
+}
+
+// validateType returns why the value is not valid for the OVF property type, or an empty string if it is valid.
+func validateType(propertyType, value string) string {
+	switch propertyType {
+	case "boolean":
+		if !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false") {
+			return "must be a boolean"
+		}
+	case "uint8", "uint16", "uint32", "uint64":
+		bitSize, _ := strconv.Atoi(strings.TrimPrefix(propertyType, "uint"))
+		if _, err := strconv.ParseUint(value, 10, bitSize); err != nil {
+			return fmt.Sprintf("must be an unsigned %d-bit integer", bitSize)
+		}
+	case "sint8", "sint16", "sint32", "sint64":
+		bitSize, _ := strconv.Atoi(strings.TrimPrefix(propertyType, "sint"))
+		if _, err := strconv.ParseInt(value, 10, bitSize); err != nil {
+			return fmt.Sprintf("must be a signed %d-bit integer", bitSize)
+		}
+	case "real32", "real64":
+		bitSize, _ := strconv.Atoi(strings.TrimPrefix(propertyType, "real"))
+		if _, err := strconv.ParseFloat(value, bitSize); err != nil {
+			return "must be a real number"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,18 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vapp_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVApp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "vSphere Provider vApp Suite")
+}
@@ -0,0 +1,70 @@
+// +build !integration
+
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vapp_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/vapp"
+)
+
+var _ = Describe("ValidateMetadata", func() {
+	properties := []vmopapiv1alpha1.VirtualMachineImageOVFProperty{
+		{Key: "hostname", Type: "string", Qualifiers: "MinLen(1) MaxLen(8)", UserConfigurable: true},
+		{Key: "size", Type: "string", Qualifiers: `ValueMap{"small","large"}`, UserConfigurable: true},
+		{Key: "enabled", Type: "boolean", UserConfigurable: true},
+		{Key: "count", Type: "uint8", Qualifiers: "MinValue(1) MaxValue(10)", UserConfigurable: true},
+		{Key: "offset", Type: "sint16", UserConfigurable: true},
+		{Key: "ratio", Type: "real32", UserConfigurable: true},
+		{Key: "password", Type: "string", Password: true, UserConfigurable: true},
+		{Key: "fixed", Type: "string"},
+	}
+
+	DescribeTable("single key",
+		func(key, value string, fromSecret bool, expectedMsg string) {
+			errs := vapp.ValidateMetadata(properties, map[string]string{key: value}, fromSecret)
+			if expectedMsg == "" {
+				Expect(errs).To(BeEmpty())
+			} else {
+				Expect(errs).To(ConsistOf(vapp.PropertyError{Key: key, Message: expectedMsg}))
+			}
+		},
+		Entry("valid string", "hostname", "vm1", false, ""),
+		Entry("string too short", "hostname", "", false, "must be at least 1 characters"),
+		Entry("string too long", "hostname", "too-long-name", false, "must be at most 8 characters"),
+		Entry("valid enum", "size", "large", false, ""),
+		Entry("invalid enum", "size", "medium", false, `must be one of ["small" "large"]`),
+		Entry("valid boolean", "enabled", "True", false, ""),
+		Entry("invalid boolean", "enabled", "yes", false, "must be a boolean"),
+		Entry("valid unsigned integer", "count", "5", false, ""),
+		Entry("unsigned integer out of type range", "count", "256", false, "must be an unsigned 8-bit integer"),
+		Entry("unsigned integer below minimum", "count", "0", false, "must be at least 1"),
+		Entry("unsigned integer above maximum", "count", "11", false, "must be at most 10"),
+		Entry("valid signed integer", "offset", "-10", false, ""),
+		Entry("invalid signed integer", "offset", "ten", false, "must be a signed 16-bit integer"),
+		Entry("valid real number", "ratio", "0.5", false, ""),
+		Entry("invalid real number", "ratio", "half", false, "must be a real number"),
+		Entry("password from Secret", "password", "secret", true, ""),
+		Entry("password from ConfigMap", "password", "secret", false, "is a password and must be specified in a Secret"),
+		Entry("property that is not user configurable", "fixed", "value", false, "is not a user configurable vApp property of the image"),
+		Entry("unknown key", "bogus", "value", false, "is not a vApp property of the image"),
+	)
+
+	It("returns the errors sorted by key", func() {
+		errs := vapp.ValidateMetadata(properties, map[string]string{
+			"zzz":      "value",
+			"enabled":  "maybe",
+			"hostname": "vm1",
+		}, false)
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Key).To(Equal("enabled"))
+		Expect(errs[1].Key).To(Equal("zzz"))
+		Expect(errs[1].Error()).To(Equal(`key "zzz" is not a vApp property of the image`))
+	})
+})
@@ -6,10 +6,14 @@ package vsphere
 import (
 	goctx "context"
 	"crypto/rand"
+	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vapi/library"
 	vimtypes "github.com/vmware/govmomi/vim25/types"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,6 +22,8 @@ import (
 
 	"github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/record"
 	"github.com/vmware-tanzu/vm-operator/pkg/topology"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider"
@@ -25,11 +31,16 @@ import (
 
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
 	vcclient "github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/client"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/contentlibrary"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/session"
 )
 
 const (
 	VsphereVMProviderName = "vsphere"
+
+	// importedItemDescriptionFmt is the description of a library item that is created by a
+	// VirtualMachineImageImport, with the UID of the import.
+	importedItemDescriptionFmt = "Imported by VirtualMachineImageImport %s"
 )
 
 var log = logf.Log.WithName(VsphereVMProviderName)
@@ -102,6 +113,77 @@ func (vs *vSphereVMProvider) ListVirtualMachineImagesFromContentLibrary(
 		currentCLImages)
 }
 
+// GetVirtualMachineImageInfo inspects the OVF descriptor of the image's content library item.
+func (vs *vSphereVMProvider) GetVirtualMachineImageInfo(
+	ctx goctx.Context,
+	image *v1alpha1.VirtualMachineImage) (*vmopapiv1alpha1.VirtualMachineImageInfo, error) {
+
+	itemID := image.Spec.ImageID
+	if itemID == "" {
+		// Images created before Spec.ImageID was added only have the item ID in the deprecated Status.Uuid.
+		itemID = image.Status.Uuid
+	}
+	if itemID == "" {
+		return nil, errors.Wrapf(vmprovider.ErrImageUnsupported, "image %s has no content library item ID", image.Name)
+	}
+
+	client, err := vs.sessions.GetClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ContentLibClient().VirtualMachineImageInfoForLibraryItem(ctx, itemID)
+}
+
+// ImportLibraryItem creates the library item in the content library and uploads the files to it. The import ID is
+// recorded in the description of the item, so the item of an interrupted import is replaced when it is retried.
+func (vs *vSphereVMProvider) ImportLibraryItem(
+	ctx goctx.Context,
+	clUUID, itemName, itemType, importID string,
+	files vmprovider.LibraryItemFiles) (string, error) {
+
+	client, err := vs.sessions.GetClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	item := library.Item{
+		LibraryID:   clUUID,
+		Name:        itemName,
+		Type:        itemType,
+		Description: fmt.Sprintf(importedItemDescriptionFmt, importID),
+	}
+
+	return client.ContentLibClient().CreateLibraryItemFromFiles(ctx, item, files)
+}
+
+// VerifyVirtualMachineImage verifies the OVF manifest and signing certificate of the image's content library
+// item against the configured image trust bundle.
+func (vs *vSphereVMProvider) VerifyVirtualMachineImage(
+	ctx goctx.Context,
+	image *v1alpha1.VirtualMachineImage) (*vmprovider.ImageVerification, error) {
+
+	itemID := image.Spec.ImageID
+	if itemID == "" {
+		itemID = image.Status.Uuid
+	}
+	if itemID == "" {
+		return nil, errors.Wrapf(vmprovider.ErrImageUnsupported, "image %s has no content library item ID", image.Name)
+	}
+
+	roots, err := contentlibrary.LoadImageTrustBundle(lib.GetImageTrustBundlePath())
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := vs.sessions.GetClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ContentLibClient().VerifyLibraryItem(ctx, itemID, roots)
+}
+
 func (vs *vSphereVMProvider) DoesVirtualMachineExist(ctx goctx.Context, vm *v1alpha1.VirtualMachine) (bool, error) {
 	vmCtx := context.VirtualMachineContext{
 		Context: ctx,
@@ -219,6 +301,44 @@ func (vs *vSphereVMProvider) DeleteVirtualMachine(ctx goctx.Context, vm *v1alpha
 	return nil
 }
 
+func (vs *vSphereVMProvider) ShutdownVirtualMachineGuest(ctx goctx.Context, vm *v1alpha1.VirtualMachine) (bool, error) {
+	vmCtx := context.VirtualMachineContext{
+		Context: goctx.WithValue(ctx, vimtypes.ID{}, vs.getOpID(ctx, vm, "shutdownGuest")),
+		Logger:  log.WithValues("vmName", vm.NamespacedName()),
+		VM:      vm,
+	}
+
+	ses, err := vs.sessions.GetSessionForVM(vmCtx)
+	if err != nil {
+		return false, err
+	}
+
+	return ses.ShutdownVirtualMachineGuest(vmCtx)
+}
+
+func (vs *vSphereVMProvider) RetainVirtualMachine(ctx goctx.Context, vm *v1alpha1.VirtualMachine) error {
+	vmCtx := context.VirtualMachineContext{
+		Context: goctx.WithValue(ctx, vimtypes.ID{}, vs.getOpID(ctx, vm, "retain")),
+		Logger:  log.WithValues("vmName", vm.NamespacedName()),
+		VM:      vm,
+	}
+
+	vmCtx.Logger.Info("Retaining VirtualMachine")
+
+	ses, err := vs.sessions.GetSessionForVM(vmCtx)
+	if err != nil {
+		return err
+	}
+
+	err = ses.RetainVirtualMachine(vmCtx)
+	if err != nil {
+		vmCtx.Logger.Error(err, "Failed to retain VM")
+		return err
+	}
+
+	return nil
+}
+
 func (vs *vSphereVMProvider) GetVirtualMachineGuestHeartbeat(ctx goctx.Context, vm *v1alpha1.VirtualMachine) (v1alpha1.GuestHeartbeatStatus, error) {
 	vmCtx := context.VirtualMachineContext{
 		Context: goctx.WithValue(ctx, vimtypes.ID{}, vs.getOpID(ctx, vm, "heartbeat")),
@@ -239,6 +359,191 @@ func (vs *vSphereVMProvider) GetVirtualMachineGuestHeartbeat(ctx goctx.Context,
 	return status, nil
 }
 
+// GetSupportedGuestOSFamilies returns the supported guest OS identifiers of the cluster of the VM's zone.
+func (vs *vSphereVMProvider) GetSupportedGuestOSFamilies(ctx goctx.Context, vm *v1alpha1.VirtualMachine) (map[string]string, error) {
+	vmCtx := context.VirtualMachineContext{
+		Context: goctx.WithValue(ctx, vimtypes.ID{}, vs.getOpID(ctx, vm, "guestOSFamilies")),
+		Logger:  log.WithValues("vmName", vm.NamespacedName()),
+		VM:      vm,
+	}
+
+	ses, err := vs.sessions.GetSessionForVM(vmCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.GetClusterVMConfigOptions(vmCtx, ses.Cluster(), ses.Client.VimClient())
+}
+
+// ListManagedVirtualMachines lists the VMs created by VM Operator in each zone's Folder and
+// ResourcePool for the namespace.
+func (vs *vSphereVMProvider) ListManagedVirtualMachines(
+	ctx goctx.Context,
+	namespace string) ([]vmprovider.ManagedVirtualMachine, error) {
+
+	availabilityZones, err := topology.GetAvailabilityZones(ctx, vs.sessions.KubeClient())
+	if err != nil {
+		return nil, err
+	}
+
+	var managedVMs []vmprovider.ManagedVirtualMachine
+	for _, az := range availabilityZones {
+		if _, ok := az.Spec.Namespaces[namespace]; !ok {
+			continue
+		}
+
+		ses, err := vs.sessions.GetSession(ctx, az.Name, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		vms, err := ses.ListManagedVirtualMachines(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range vms {
+			vms[i].Zone = az.Name
+		}
+		managedVMs = append(managedVMs, vms...)
+	}
+
+	return managedVMs, nil
+}
+
+func (vs *vSphereVMProvider) DeleteManagedVirtualMachine(
+	ctx goctx.Context,
+	namespace string,
+	vm vmprovider.ManagedVirtualMachine) error {
+
+	log.Info("Deleting managed VM", "namespace", namespace, "name", vm.Name, "moID", vm.MoID, "zone", vm.Zone)
+
+	ses, err := vs.sessions.GetSession(ctx, vm.Zone, namespace)
+	if err != nil {
+		return err
+	}
+
+	return ses.DeleteManagedVirtualMachine(ctx, vm.MoID)
+}
+
+func (vs *vSphereVMProvider) GetVirtualMachineBackup(
+	ctx goctx.Context,
+	namespace string,
+	vm vmprovider.ManagedVirtualMachine) (*vmprovider.VirtualMachineBackup, error) {
+
+	ses, err := vs.sessions.GetSession(ctx, vm.Zone, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return ses.GetVirtualMachineBackup(ctx, vm.MoID)
+}
+
+// SyncImageCache syncs the image cache in each zone's Folder for the namespace.
+func (vs *vSphereVMProvider) SyncImageCache(
+	ctx goctx.Context,
+	namespace string,
+	maxUnused time.Duration) (*vmprovider.ImageCacheSyncResult, error) {
+
+	availabilityZones, err := topology.GetAvailabilityZones(ctx, vs.sessions.KubeClient())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &vmprovider.ImageCacheSyncResult{}
+	for _, az := range availabilityZones {
+		if _, ok := az.Spec.Namespaces[namespace]; !ok {
+			continue
+		}
+
+		ses, err := vs.sessions.GetSession(ctx, az.Name, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		zoneResult, err := ses.SyncImageCache(ctx, maxUnused)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Cached = append(result.Cached, zoneResult.Cached...)
+		result.Warmed = append(result.Warmed, zoneResult.Warmed...)
+		result.Evicted = append(result.Evicted, zoneResult.Evicted...)
+	}
+
+	return result, nil
+}
+
+// GetVirtualMachineClassAvailability checks the class against the cluster of each availability zone that has
+// namespaces, and the resource pools of the namespaces in the zone.
+func (vs *vSphereVMProvider) GetVirtualMachineClassAvailability(
+	ctx goctx.Context,
+	vmClass *v1alpha1.VirtualMachineClass,
+	namespaces []string) ([]vmopapiv1alpha1.VirtualMachineClassZoneAvailability, error) {
+
+	availabilityZones, err := topology.GetAvailabilityZones(ctx, vs.sessions.KubeClient())
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []vmopapiv1alpha1.VirtualMachineClassZoneAvailability
+	for _, az := range availabilityZones {
+		var zoneNamespaces []string
+		for _, namespace := range namespaces {
+			if _, ok := az.Spec.Namespaces[namespace]; ok {
+				zoneNamespaces = append(zoneNamespaces, namespace)
+			}
+		}
+
+		// The cluster can be checked through the session of any namespace in the zone.
+		sessionNamespace := ""
+		if len(zoneNamespaces) > 0 {
+			sessionNamespace = zoneNamespaces[0]
+		} else {
+			for namespace := range az.Spec.Namespaces {
+				if sessionNamespace == "" || namespace < sessionNamespace {
+					sessionNamespace = namespace
+				}
+			}
+		}
+		if sessionNamespace == "" {
+			continue
+		}
+
+		ses, err := vs.sessions.GetSession(ctx, az.Name, sessionNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		caps, err := ses.GetClusterCapabilities(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		capacities := make([]vmopapiv1alpha1.VirtualMachineClassNamespaceCapacity, 0, len(zoneNamespaces))
+		for _, namespace := range zoneNamespaces {
+			nsSes, err := vs.sessions.GetSession(ctx, az.Name, namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			capacity, err := nsSes.GetResourcePoolCapacity(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			capacities = append(capacities, vmopapiv1alpha1.VirtualMachineClassNamespaceCapacity{
+				Namespace:    namespace,
+				AvailableVMs: session.AvailableVMs(vmClass, *capacity),
+			})
+		}
+
+		zones = append(zones, session.NewClassZoneAvailability(az.Name, session.CheckClassAvailability(vmClass, *caps), capacities))
+	}
+
+	return zones, nil
+}
+
 func (vs *vSphereVMProvider) ComputeClusterCPUMinFrequency(ctx goctx.Context) error {
 	return vs.sessions.ComputeClusterCPUMinFrequency(ctx)
 }
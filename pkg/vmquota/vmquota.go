@@ -0,0 +1,151 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmquota
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+)
+
+// ClassResourceName returns the quota resource that is the number of VirtualMachines of the class.
+func ClassResourceName(className string) corev1.ResourceName {
+	return corev1.ResourceName(className + vmopapiv1alpha1.VirtualMachineQuotaClassSuffix)
+}
+
+// Usage returns the quota resources used by a VirtualMachine of the class. When the class does not exist, the
+// VirtualMachine is only counted.
+func Usage(vm *vmopv1alpha1.VirtualMachine, vmClass *vmopv1alpha1.VirtualMachineClass) corev1.ResourceList {
+	usage := corev1.ResourceList{
+		vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines: *resource.NewQuantity(1, resource.DecimalSI),
+		ClassResourceName(vm.Spec.ClassName):               *resource.NewQuantity(1, resource.DecimalSI),
+	}
+
+	if vmClass == nil {
+		return usage
+	}
+
+	hardware := vmClass.Spec.Hardware
+	usage[vmopapiv1alpha1.VirtualMachineQuotaCPU] = *resource.NewQuantity(hardware.Cpus, resource.DecimalSI)
+	usage[vmopapiv1alpha1.VirtualMachineQuotaMemory] = hardware.Memory.DeepCopy()
+
+	resources := vmClass.Spec.Policies.Resources
+	usage[vmopapiv1alpha1.VirtualMachineQuotaRequestsCPU] = resources.Requests.Cpu.DeepCopy()
+	usage[vmopapiv1alpha1.VirtualMachineQuotaRequestsMemory] = resources.Requests.Memory.DeepCopy()
+	usage[vmopapiv1alpha1.VirtualMachineQuotaLimitsCPU] = resources.Limits.Cpu.DeepCopy()
+	usage[vmopapiv1alpha1.VirtualMachineQuotaLimitsMemory] = resources.Limits.Memory.DeepCopy()
+
+	gpus := len(hardware.Devices.VGPUDevices) + len(hardware.Devices.DynamicDirectPathIODevices)
+	usage[vmopapiv1alpha1.VirtualMachineQuotaGPUs] = *resource.NewQuantity(int64(gpus), resource.DecimalSI)
+
+	instanceStorage := resource.NewQuantity(0, resource.BinarySI)
+	for _, volume := range hardware.InstanceStorage.Volumes {
+		instanceStorage.Add(volume.Size)
+	}
+	usage[vmopapiv1alpha1.VirtualMachineQuotaInstanceStorage] = *instanceStorage
+
+	return usage
+}
+
+// Add returns the sum of the resource lists.
+func Add(a, b corev1.ResourceList) corev1.ResourceList {
+	sum := make(corev1.ResourceList, len(a))
+	for name, quantity := range a {
+		sum[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range b {
+		if q, ok := sum[name]; ok {
+			q.Add(quantity)
+			sum[name] = q
+		} else {
+			sum[name] = quantity.DeepCopy()
+		}
+	}
+	return sum
+}
+
+// Mask returns the resources of the list that are limited by hard. Resources without usage are zero.
+func Mask(list, hard corev1.ResourceList) corev1.ResourceList {
+	masked := make(corev1.ResourceList, len(hard))
+	for name := range hard {
+		if quantity, ok := list[name]; ok {
+			masked[name] = quantity.DeepCopy()
+		} else {
+			masked[name] = *resource.NewQuantity(0, resource.DecimalSI)
+		}
+	}
+	return masked
+}
+
+// Exceeded returns the resources, in sorted order, whose hard limit the used resources exceed once the requested
+// resources are added. Only resources that are requested are checked, so a VirtualMachine that does not use an
+// already exceeded resource is allowed.
+func Exceeded(hard, used, requested corev1.ResourceList) []corev1.ResourceName {
+	var exceeded []corev1.ResourceName
+
+	total := Add(used, requested)
+	for name, limit := range hard {
+		if q, ok := requested[name]; !ok || q.IsZero() {
+			continue
+		}
+		if quantity := total[name]; quantity.Cmp(limit) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+
+	sort.Slice(exceeded, func(i, j int) bool { return exceeded[i] < exceeded[j] })
+	return exceeded
+}
+
+// NamespaceUsage returns the quota resources used by the VirtualMachines of the namespace. VirtualMachines for
+// which skip returns true are not counted.
+func NamespaceUsage(
+	ctx context.Context,
+	client ctrlclient.Client,
+	namespace string,
+	skip func(vm *vmopv1alpha1.VirtualMachine) bool) (corev1.ResourceList, error) {
+
+	vmList := &vmopv1alpha1.VirtualMachineList{}
+	if err := client.List(ctx, vmList, ctrlclient.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list VirtualMachines in namespace %s", namespace)
+	}
+
+	classList := &vmopv1alpha1.VirtualMachineClassList{}
+	if err := client.List(ctx, classList); err != nil {
+		return nil, errors.Wrap(err, "failed to list VirtualMachineClasses")
+	}
+	classes := make(map[string]*vmopv1alpha1.VirtualMachineClass, len(classList.Items))
+	for i := range classList.Items {
+		classes[classList.Items[i].Name] = &classList.Items[i]
+	}
+
+	used := corev1.ResourceList{}
+	for i := range vmList.Items {
+		vm := &vmList.Items[i]
+		if skip != nil && skip(vm) {
+			continue
+		}
+		used = Add(used, Usage(vm, classes[vm.Spec.ClassName]))
+	}
+
+	return used, nil
+}
+
+// List returns the VirtualMachineQuotas of the namespace.
+func List(ctx context.Context, client ctrlclient.Client, namespace string) ([]vmopapiv1alpha1.VirtualMachineQuota, error) {
+	quotaList := &vmopapiv1alpha1.VirtualMachineQuotaList{}
+	if err := client.List(ctx, quotaList, ctrlclient.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list VirtualMachineQuotas in namespace %s", namespace)
+	}
+	return quotaList.Items, nil
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmquota_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVMQuota(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VMQuota Suite")
+}
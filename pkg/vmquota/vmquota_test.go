@@ -0,0 +1,153 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmquota_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmquota"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+var _ = Describe("VirtualMachineQuota", func() {
+	var (
+		vmClass *vmopv1alpha1.VirtualMachineClass
+		vm      *vmopv1alpha1.VirtualMachine
+	)
+
+	quantity := func(list corev1.ResourceList, name corev1.ResourceName) string {
+		q := list[name]
+		return q.String()
+	}
+
+	BeforeEach(func() {
+		vmClass = &vmopv1alpha1.VirtualMachineClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu-class"},
+			Spec: vmopv1alpha1.VirtualMachineClassSpec{
+				Hardware: vmopv1alpha1.VirtualMachineClassHardware{
+					Cpus:   4,
+					Memory: resource.MustParse("8Gi"),
+					Devices: vmopv1alpha1.VirtualDevices{
+						VGPUDevices: []vmopv1alpha1.VGPUDevice{{ProfileName: "grid_p40-1q"}},
+					},
+					InstanceStorage: vmopv1alpha1.InstanceStorage{
+						Volumes: []vmopv1alpha1.InstanceStorageVolume{
+							{Size: resource.MustParse("100Gi")},
+							{Size: resource.MustParse("50Gi")},
+						},
+					},
+				},
+				Policies: vmopv1alpha1.VirtualMachineClassPolicies{
+					Resources: vmopv1alpha1.VirtualMachineClassResources{
+						Requests: vmopv1alpha1.VirtualMachineResourceSpec{
+							Cpu:    resource.MustParse("2"),
+							Memory: resource.MustParse("4Gi"),
+						},
+					},
+				},
+			},
+		}
+		vm = &vmopv1alpha1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "dummy-ns", Name: "vm-1"},
+			Spec:       vmopv1alpha1.VirtualMachineSpec{ClassName: vmClass.Name},
+		}
+	})
+
+	Context("Usage", func() {
+		It("returns the resources of the class", func() {
+			usage := vmquota.Usage(vm, vmClass)
+			Expect(quantity(usage, vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines)).To(Equal("1"))
+			Expect(quantity(usage, vmquota.ClassResourceName("gpu-class"))).To(Equal("1"))
+			Expect(quantity(usage, vmopapiv1alpha1.VirtualMachineQuotaCPU)).To(Equal("4"))
+			Expect(quantity(usage, vmopapiv1alpha1.VirtualMachineQuotaMemory)).To(Equal("8Gi"))
+			Expect(quantity(usage, vmopapiv1alpha1.VirtualMachineQuotaRequestsCPU)).To(Equal("2"))
+			Expect(quantity(usage, vmopapiv1alpha1.VirtualMachineQuotaRequestsMemory)).To(Equal("4Gi"))
+			Expect(quantity(usage, vmopapiv1alpha1.VirtualMachineQuotaGPUs)).To(Equal("1"))
+			Expect(quantity(usage, vmopapiv1alpha1.VirtualMachineQuotaInstanceStorage)).To(Equal("150Gi"))
+		})
+
+		It("only counts a VM whose class does not exist", func() {
+			usage := vmquota.Usage(vm, nil)
+			Expect(usage).To(HaveLen(2))
+			Expect(usage).To(HaveKey(vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines))
+			Expect(usage).To(HaveKey(vmquota.ClassResourceName("gpu-class")))
+		})
+	})
+
+	Context("Exceeded", func() {
+		var hard corev1.ResourceList
+
+		BeforeEach(func() {
+			hard = corev1.ResourceList{
+				vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines: resource.MustParse("2"),
+				vmopapiv1alpha1.VirtualMachineQuotaGPUs:            resource.MustParse("1"),
+				vmopapiv1alpha1.VirtualMachineQuotaInstanceStorage: resource.MustParse("100Gi"),
+			}
+		})
+
+		It("allows usage up to the limits", func() {
+			hard[vmopapiv1alpha1.VirtualMachineQuotaInstanceStorage] = resource.MustParse("150Gi")
+			Expect(vmquota.Exceeded(hard, nil, vmquota.Usage(vm, vmClass))).To(BeEmpty())
+		})
+
+		It("returns the exceeded resources in sorted order", func() {
+			used := vmquota.Usage(vm, vmClass)
+			Expect(vmquota.Exceeded(hard, used, vmquota.Usage(vm, vmClass))).To(Equal([]corev1.ResourceName{
+				vmopapiv1alpha1.VirtualMachineQuotaGPUs,
+				vmopapiv1alpha1.VirtualMachineQuotaInstanceStorage,
+			}))
+		})
+
+		It("ignores exceeded resources that are not requested", func() {
+			used := vmquota.Add(vmquota.Usage(vm, vmClass), vmquota.Usage(vm, vmClass))
+			vmClass.Spec.Hardware.Devices.VGPUDevices = nil
+			vmClass.Spec.Hardware.InstanceStorage.Volumes = nil
+			hard[vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines] = resource.MustParse("3")
+			Expect(vmquota.Exceeded(hard, used, vmquota.Usage(vm, vmClass))).To(BeEmpty())
+		})
+	})
+
+	Context("Mask", func() {
+		It("returns the limited resources", func() {
+			hard := corev1.ResourceList{
+				vmopapiv1alpha1.VirtualMachineQuotaCPU:  resource.MustParse("16"),
+				vmopapiv1alpha1.VirtualMachineQuotaGPUs: resource.MustParse("4"),
+			}
+			vmClass.Spec.Hardware.Devices.VGPUDevices = nil
+			masked := vmquota.Mask(vmquota.Usage(vm, vmClass), hard)
+			Expect(masked).To(HaveLen(2))
+			Expect(quantity(masked, vmopapiv1alpha1.VirtualMachineQuotaCPU)).To(Equal("4"))
+			Expect(quantity(masked, vmopapiv1alpha1.VirtualMachineQuotaGPUs)).To(Equal("0"))
+		})
+	})
+
+	Context("NamespaceUsage", func() {
+		It("sums the usage of the VMs of the namespace", func() {
+			vm2 := vm.DeepCopy()
+			vm2.Name = "vm-2"
+			vm3 := vm.DeepCopy()
+			vm3.Name = "vm-3"
+			otherVM := vm.DeepCopy()
+			otherVM.Namespace = "other-ns"
+			client := builder.NewFakeClient(vmClass, vm, vm2, vm3, otherVM)
+
+			used, err := vmquota.NamespaceUsage(context.Background(), client, "dummy-ns",
+				func(o *vmopv1alpha1.VirtualMachine) bool { return o.Name == "vm-3" })
+			Expect(err).ToNot(HaveOccurred())
+			Expect(quantity(used, vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines)).To(Equal("2"))
+			Expect(quantity(used, vmopapiv1alpha1.VirtualMachineQuotaCPU)).To(Equal("8"))
+			Expect(quantity(used, vmopapiv1alpha1.VirtualMachineQuotaInstanceStorage)).To(Equal("300Gi"))
+		})
+	})
+})
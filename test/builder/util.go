@@ -218,6 +218,32 @@ func DummyVirtualMachineImage(imageName string) *vmopv1.VirtualMachineImage {
 	}
 }
 
+func DummyContentLibraryProvider(name, uuid string) *vmopv1.ContentLibraryProvider {
+	return &vmopv1.ContentLibraryProvider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: vmopv1.ContentLibraryProviderSpec{
+			UUID: uuid,
+		},
+	}
+}
+
+func DummyContentSource(name, providerName string) *vmopv1.ContentSource {
+	return &vmopv1.ContentSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: vmopv1.ContentSourceSpec{
+			ProviderRef: vmopv1.ContentProviderReference{
+				APIVersion: vmopv1.SchemeGroupVersion.String(),
+				Kind:       "ContentLibraryProvider",
+				Name:       providerName,
+			},
+		},
+	}
+}
+
 func DummyStorageClass() *storagev1.StorageClass {
 	return &storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -16,14 +16,19 @@ import (
 )
 
 // BuildValidationResponse creates the response from one or more validation errors and any
-// errors returned attempting to validate the ingress data.
+// errors returned attempting to validate the ingress data. The validation warnings are returned
+// to the client whether or not the request is allowed.
 func BuildValidationResponse(
 	ctx *context.WebhookRequestContext,
 	validationErrs []string,
+	validationWarnings []string,
 	err error,
 	additionalValidationErrors ...string) (response admission.Response) {
 	// Log the response on the way out.
 	defer func() {
+		if len(response.Warnings) > 0 {
+			ctx.Logger.V(4).Info("validation warnings", "warnings", response.Warnings)
+		}
 		if response.Allowed {
 			ctx.Logger.V(4).Info("validation allowed")
 		} else {
@@ -72,9 +77,10 @@ func BuildValidationResponse(
 					Reason: metav1.StatusReason(reason),
 					Code:   http.StatusUnprocessableEntity,
 				},
+				Warnings: validationWarnings,
 			},
 		}
 	}
 
-	return admission.Allowed("")
+	return admission.Allowed("").WithWarnings(validationWarnings...)
 }
@@ -0,0 +1,154 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/pkg/errors"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/builder"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/webhooks/common"
+)
+
+const (
+	webHookName = "default"
+
+	invalidUUIDMsg = "must be a UUID in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+	uuidInUseFmt   = "content library %s is already provided by ContentLibraryProvider %s"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-contentlibraryprovider,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=contentlibraryproviders,versions=v1alpha1,name=default.validating.contentlibraryprovider.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentlibraryproviders,verbs=get;list
+
+// AddToManager adds the webhook to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	hook, err := builder.NewValidatingWebhook(ctx, mgr, webHookName, NewValidator(mgr.GetClient()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create ContentLibraryProvider validation webhook")
+	}
+	mgr.GetWebhookServer().Register(hook.Path, hook)
+
+	return nil
+}
+
+// NewValidator returns the package's Validator.
+func NewValidator(client client.Client) builder.Validator {
+	return validator{
+		client:    client,
+		converter: runtime.DefaultUnstructuredConverter,
+	}
+}
+
+type validator struct {
+	client    client.Client
+	converter runtime.UnstructuredConverter
+}
+
+func (v validator) For() schema.GroupVersionKind {
+	return vmopv1.SchemeGroupVersion.WithKind(reflect.TypeOf(vmopv1.ContentLibraryProvider{}).Name())
+}
+
+func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.Response {
+	clProvider, err := v.clProviderFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+
+	fieldErrs = append(fieldErrs, v.validateUUID(ctx, clProvider)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
+	return admission.Allowed("")
+}
+
+func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.Response {
+	clProvider, err := v.clProviderFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	oldCLProvider, err := v.clProviderFromUnstructured(ctx.OldObj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+
+	// The VirtualMachineImages of the provider are listed from the content library of its UUID.
+	uuidPath := field.NewPath("spec", "uuid")
+	fieldErrs = append(fieldErrs, validation.ValidateImmutableField(clProvider.Spec.UUID, oldCLProvider.Spec.UUID, uuidPath)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+// validateUUID validates that the UUID of the content library is well-formed and is not the UUID of another
+// ContentLibraryProvider.
+func (v validator) validateUUID(ctx *context.WebhookRequestContext, clProvider *vmopv1.ContentLibraryProvider) field.ErrorList {
+	var allErrs field.ErrorList
+
+	clUUID := clProvider.Spec.UUID
+	uuidPath := field.NewPath("spec", "uuid")
+
+	if clUUID == "" {
+		return append(allErrs, field.Required(uuidPath, ""))
+	}
+	if _, err := uuid.Parse(clUUID); err != nil || len(clUUID) != 36 {
+		return append(allErrs, field.Invalid(uuidPath, clUUID, invalidUUIDMsg))
+	}
+
+	clProviderList := &vmopv1.ContentLibraryProviderList{}
+	if err := v.client.List(ctx, clProviderList); err != nil {
+		return append(allErrs, field.InternalError(uuidPath, err))
+	}
+
+	for _, other := range clProviderList.Items {
+		if other.Name != clProvider.Name && strings.EqualFold(other.Spec.UUID, clUUID) {
+			allErrs = append(allErrs, field.Duplicate(uuidPath, fmt.Sprintf(uuidInUseFmt, clUUID, other.Name)))
+		}
+	}
+
+	return allErrs
+}
+
+// clProviderFromUnstructured returns the ContentLibraryProvider from the unstructured object.
+func (v validator) clProviderFromUnstructured(obj runtime.Unstructured) (*vmopv1.ContentLibraryProvider, error) {
+	clProvider := &vmopv1.ContentLibraryProvider{}
+	if err := v.converter.FromUnstructured(obj.UnstructuredContent(), clProvider); err != nil {
+		return nil, err
+	}
+	return clProvider, nil
+}
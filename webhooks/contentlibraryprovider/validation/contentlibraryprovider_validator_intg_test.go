@@ -0,0 +1,126 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	Describe("Invoking Create", intgTestsValidateCreate)
+	Describe("Invoking Update", intgTestsValidateUpdate)
+	Describe("Invoking Delete", intgTestsValidateDelete)
+}
+
+type intgValidatingWebhookContext struct {
+	builder.IntegrationTestContext
+	clProvider *vmopv1.ContentLibraryProvider
+}
+
+func newIntgValidatingWebhookContext() *intgValidatingWebhookContext {
+	ctx := &intgValidatingWebhookContext{
+		IntegrationTestContext: *suite.NewIntegrationTestContext(),
+	}
+
+	ctx.clProvider = builder.DummyContentLibraryProvider("dummy-cl", dummyUUID)
+
+	return ctx
+}
+
+func intgTestsValidateCreate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Create(ctx, ctx.clProvider)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.clProvider)
+		err = nil
+		ctx = nil
+	})
+
+	When("create is performed", func() {
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("create is performed with an invalid UUID", func() {
+		BeforeEach(func() {
+			ctx.clProvider.Spec.UUID = "not-a-uuid"
+		})
+		It("should deny the request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.uuid"))
+		})
+	})
+}
+
+func intgTestsValidateUpdate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+		err = ctx.Client.Create(ctx, ctx.clProvider)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Update(suite, ctx.clProvider)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.clProvider)
+		err = nil
+		ctx = nil
+	})
+
+	When("update is performed with changed UUID", func() {
+		BeforeEach(func() {
+			ctx.clProvider.Spec.UUID = "0f1e2d3c-4b5a-4e6a-9b7d-8a2b9e3d4c1f"
+		})
+		It("should deny the request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("field is immutable"))
+		})
+	})
+}
+
+func intgTestsValidateDelete() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+		err = ctx.Client.Create(ctx, ctx.clProvider)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Delete(suite, ctx.clProvider)
+	})
+	AfterEach(func() {
+		err = nil
+		ctx = nil
+	})
+
+	When("delete is performed", func() {
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+const (
+	dummyUUID = "8a2b9e3d-4c1f-4e6a-9b7d-0f1e2d3c4b5a"
+)
+
+func unitTests() {
+	Describe("Invoking ValidateCreate", unitTestsValidateCreate)
+	Describe("Invoking ValidateUpdate", unitTestsValidateUpdate)
+	Describe("Invoking ValidateDelete", unitTestsValidateDelete)
+}
+
+type unitValidatingWebhookContext struct {
+	builder.UnitTestContextForValidatingWebhook
+	clProvider    *vmopv1.ContentLibraryProvider
+	oldCLProvider *vmopv1.ContentLibraryProvider
+}
+
+func newUnitTestContextForValidatingWebhook(isUpdate bool) *unitValidatingWebhookContext {
+	clProvider := builder.DummyContentLibraryProvider("dummy-cl", dummyUUID)
+	obj, err := builder.ToUnstructured(clProvider)
+	Expect(err).ToNot(HaveOccurred())
+
+	var oldCLProvider *vmopv1.ContentLibraryProvider
+	var oldObj *unstructured.Unstructured
+
+	if isUpdate {
+		oldCLProvider = clProvider.DeepCopy()
+		oldObj, err = builder.ToUnstructured(oldCLProvider)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	return &unitValidatingWebhookContext{
+		UnitTestContextForValidatingWebhook: *suite.NewUnitTestContextForValidatingWebhook(obj, oldObj),
+		clProvider:                          clProvider,
+		oldCLProvider:                       oldCLProvider,
+	}
+}
+
+func unitTestsValidateCreate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	type createArgs struct {
+		uuid          string
+		duplicateUUID bool
+	}
+
+	validateCreate := func(args createArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
+		var err error
+
+		if args.uuid != "" {
+			ctx.clProvider.Spec.UUID = args.uuid
+		}
+		if args.duplicateUUID {
+			Expect(ctx.Client.Create(ctx, builder.DummyContentLibraryProvider("other-cl", dummyUUID))).To(Succeed())
+		}
+
+		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.clProvider)
+		Expect(err).ToNot(HaveOccurred())
+
+		response := ctx.ValidateCreate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(Equal(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	uuidPath := field.NewPath("spec", "uuid")
+	invalidUUIDMsg := "must be a UUID in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+	DescribeTable("create table", validateCreate,
+		Entry("should allow valid", createArgs{}, true, nil, nil),
+		Entry("should deny an invalid UUID", createArgs{uuid: "not-a-uuid"}, false,
+			field.Invalid(uuidPath, "not-a-uuid", invalidUUIDMsg).Error(), nil),
+		Entry("should deny a UUID without dashes", createArgs{uuid: "8a2b9e3d4c1f4e6a9b7d0f1e2d3c4b5a"}, false,
+			field.Invalid(uuidPath, "8a2b9e3d4c1f4e6a9b7d0f1e2d3c4b5a", invalidUUIDMsg).Error(), nil),
+		Entry("should deny a UUID of another provider", createArgs{duplicateUUID: true}, false,
+			field.Duplicate(uuidPath, "content library "+dummyUUID+" is already provided by ContentLibraryProvider other-cl").Error(), nil),
+	)
+
+	When("the UUID is empty", func() {
+		It("should deny the request", func() {
+			ctx.clProvider.Spec.UUID = ""
+			obj, err := builder.ToUnstructured(ctx.clProvider)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.WebhookRequestContext.Obj = obj
+
+			response := ctx.ValidateCreate(&ctx.WebhookRequestContext)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(string(response.Result.Reason)).To(Equal(field.Required(uuidPath, "").Error()))
+		})
+	})
+}
+
+func unitTestsValidateUpdate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	type updateArgs struct {
+		changeUUID   bool
+		changeLabels bool
+	}
+
+	validateUpdate := func(args updateArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
+		var err error
+
+		if args.changeUUID {
+			ctx.clProvider.Spec.UUID = "0f1e2d3c-4b5a-4e6a-9b7d-8a2b9e3d4c1f"
+		}
+		if args.changeLabels {
+			ctx.clProvider.Labels = map[string]string{"foo": "bar"}
+		}
+
+		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.clProvider)
+		Expect(err).ToNot(HaveOccurred())
+
+		response := ctx.ValidateUpdate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(ContainSubstring(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(true)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	DescribeTable("update table", validateUpdate,
+		Entry("should allow", updateArgs{}, true, nil, nil),
+		Entry("should allow a label change", updateArgs{changeLabels: true}, true, nil, nil),
+		Entry("should deny a UUID change", updateArgs{changeUUID: true}, false, "field is immutable", nil),
+	)
+}
+
+func unitTestsValidateDelete() {
+	var (
+		ctx      *unitValidatingWebhookContext
+		response admission.Response
+	)
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	When("the delete is performed", func() {
+		JustBeforeEach(func() {
+			response = ctx.ValidateDelete(&ctx.WebhookRequestContext)
+		})
+
+		It("should allow the request", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(response.Result).ToNot(BeNil())
+		})
+	})
+}
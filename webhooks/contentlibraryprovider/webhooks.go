@@ -0,0 +1,20 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package contentlibraryprovider
+
+import (
+	"github.com/pkg/errors"
+
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/webhooks/contentlibraryprovider/validation"
+)
+
+func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	if err := validation.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize validation webhook")
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/pkg/errors"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/builder"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
+	"github.com/vmware-tanzu/vm-operator/webhooks/common"
+)
+
+const (
+	webHookName = "default"
+
+	contentLibraryProviderKind = "ContentLibraryProvider"
+
+	providerRefInUseFmt = "%s %s is already referenced by ContentSource %s"
+	imagesInUseFmt      = "ContentSource %s cannot be deleted because VirtualMachines use its VirtualMachineImages: %s"
+)
+
+// +kubebuilder:webhook:verbs=create;update;delete,path=/default-validate-vmoperator-vmware-com-v1alpha1-contentsource,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=contentsources,versions=v1alpha1,name=default.validating.contentsource.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=contentsources,verbs=get;list
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimages,verbs=get;list
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list
+
+// AddToManager adds the webhook to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	hook, err := builder.NewValidatingWebhook(ctx, mgr, webHookName, NewValidator(mgr.GetClient()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create ContentSource validation webhook")
+	}
+	mgr.GetWebhookServer().Register(hook.Path, hook)
+
+	return nil
+}
+
+// NewValidator returns the package's Validator.
+func NewValidator(client client.Client) builder.Validator {
+	return validator{
+		client:    client,
+		converter: runtime.DefaultUnstructuredConverter,
+	}
+}
+
+type validator struct {
+	client    client.Client
+	converter runtime.UnstructuredConverter
+}
+
+func (v validator) For() schema.GroupVersionKind {
+	return vmopv1.SchemeGroupVersion.WithKind(reflect.TypeOf(vmopv1.ContentSource{}).Name())
+}
+
+func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.Response {
+	contentSource, err := v.contentSourceFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+
+	fieldErrs = append(fieldErrs, v.validateProviderRef(ctx, contentSource)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+// ValidateDelete denies the deletion of a ContentSource whose VirtualMachineImages are used by VirtualMachines,
+// since its images are deleted with it.
+func (v validator) ValidateDelete(ctx *context.WebhookRequestContext) admission.Response {
+	contentSource, err := v.contentSourceFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	inUse, err := v.imagesInUse(ctx, contentSource)
+	if err != nil {
+		return common.BuildValidationResponse(ctx, nil, nil, err)
+	}
+
+	var validationErrs []string
+	if len(inUse) > 0 {
+		validationErrs = append(validationErrs, fmt.Sprintf(imagesInUseFmt, contentSource.Name, strings.Join(inUse, ", ")))
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.Response {
+	contentSource, err := v.contentSourceFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	oldContentSource, err := v.contentSourceFromUnstructured(ctx.OldObj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+
+	providerRefPath := field.NewPath("spec", "providerRef")
+	fieldErrs = append(fieldErrs, validation.ValidateImmutableField(contentSource.Spec.ProviderRef,
+		oldContentSource.Spec.ProviderRef, providerRefPath)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+// validateProviderRef validates that the ContentSource refers to a ContentLibraryProvider, the only supported
+// provider, that no other ContentSource refers to.
+func (v validator) validateProviderRef(ctx *context.WebhookRequestContext, contentSource *vmopv1.ContentSource) field.ErrorList {
+	var allErrs field.ErrorList
+
+	providerRef := contentSource.Spec.ProviderRef
+	providerRefPath := field.NewPath("spec", "providerRef")
+
+	if providerRef.Kind != contentLibraryProviderKind {
+		allErrs = append(allErrs, field.NotSupported(providerRefPath.Child("kind"), providerRef.Kind,
+			[]string{contentLibraryProviderKind}))
+	}
+	if providerRef.Name == "" {
+		allErrs = append(allErrs, field.Required(providerRefPath.Child("name"), ""))
+	}
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	contentSourceList := &vmopv1.ContentSourceList{}
+	if err := v.client.List(ctx, contentSourceList); err != nil {
+		return append(allErrs, field.InternalError(providerRefPath, err))
+	}
+
+	for _, other := range contentSourceList.Items {
+		otherRef := other.Spec.ProviderRef
+		if other.Name != contentSource.Name && otherRef.Kind == providerRef.Kind && otherRef.Name == providerRef.Name {
+			allErrs = append(allErrs, field.Duplicate(providerRefPath.Child("name"),
+				fmt.Sprintf(providerRefInUseFmt, providerRef.Kind, providerRef.Name, other.Name)))
+		}
+	}
+
+	return allErrs
+}
+
+// imagesInUse returns, in sorted order, the VirtualMachineImages of the ContentSource that VirtualMachines refer
+// to, by either their name or their display name.
+func (v validator) imagesInUse(ctx *context.WebhookRequestContext, contentSource *vmopv1.ContentSource) ([]string, error) {
+	imageList := &vmopv1.VirtualMachineImageList{}
+	if err := v.client.List(ctx, imageList); err != nil {
+		return nil, errors.Wrap(err, "failed to list VirtualMachineImages")
+	}
+
+	providerRef := contentSource.Spec.ProviderRef
+	images := map[string]struct{}{}
+	for i := range imageList.Items {
+		img := &imageList.Items[i]
+		if img.Spec.ProviderRef.Kind == providerRef.Kind && img.Spec.ProviderRef.Name == providerRef.Name {
+			images[img.Name] = struct{}{}
+		}
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	vmList := &vmopv1.VirtualMachineList{}
+	if err := v.client.List(ctx, vmList); err != nil {
+		return nil, errors.Wrap(err, "failed to list VirtualMachines")
+	}
+
+	// The image of a VM is resolved like the VM controller does, so that a display name of an image of the
+	// ContentSource that is also the name of another image does not count as a use.
+	resolved := map[string]string{}
+	inUse := map[string]struct{}{}
+	for _, vm := range vmList.Items {
+		if vm.Spec.ImageName == "" {
+			continue
+		}
+
+		name, ok := resolved[vm.Spec.ImageName]
+		if !ok {
+			image, err := vmimage.Get(ctx, v.client, vm.Spec.ImageName)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to resolve the VirtualMachineImage of VirtualMachine %s/%s",
+					vm.Namespace, vm.Name)
+			}
+			if err == nil {
+				name = image.Name
+			}
+			resolved[vm.Spec.ImageName] = name
+		}
+
+		if _, ok := images[name]; ok {
+			inUse[name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(inUse))
+	for name := range inUse {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// contentSourceFromUnstructured returns the ContentSource from the unstructured object.
+func (v validator) contentSourceFromUnstructured(obj runtime.Unstructured) (*vmopv1.ContentSource, error) {
+	contentSource := &vmopv1.ContentSource{}
+	if err := v.converter.FromUnstructured(obj.UnstructuredContent(), contentSource); err != nil {
+		return nil, err
+	}
+	return contentSource, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	Describe("Invoking Create", intgTestsValidateCreate)
+	Describe("Invoking Update", intgTestsValidateUpdate)
+	Describe("Invoking Delete", intgTestsValidateDelete)
+}
+
+type intgValidatingWebhookContext struct {
+	builder.IntegrationTestContext
+	contentSource *vmopv1.ContentSource
+}
+
+func newIntgValidatingWebhookContext() *intgValidatingWebhookContext {
+	ctx := &intgValidatingWebhookContext{
+		IntegrationTestContext: *suite.NewIntegrationTestContext(),
+	}
+
+	ctx.contentSource = builder.DummyContentSource("dummy-cs", "dummy-cl")
+
+	return ctx
+}
+
+func intgTestsValidateCreate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Create(ctx, ctx.contentSource)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.contentSource)
+		err = nil
+		ctx = nil
+	})
+
+	When("create is performed", func() {
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("create is performed with an unsupported provider kind", func() {
+		BeforeEach(func() {
+			ctx.contentSource.Spec.ProviderRef.Kind = "OtherProvider"
+		})
+		It("should deny the request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.providerRef.kind"))
+		})
+	})
+}
+
+func intgTestsValidateUpdate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+		err = ctx.Client.Create(ctx, ctx.contentSource)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Update(suite, ctx.contentSource)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.contentSource)
+		err = nil
+		ctx = nil
+	})
+
+	When("update is performed with changed provider", func() {
+		BeforeEach(func() {
+			ctx.contentSource.Spec.ProviderRef.Name = "other-cl"
+		})
+		It("should deny the request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("field is immutable"))
+		})
+	})
+}
+
+func intgTestsValidateDelete() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+		err = ctx.Client.Create(ctx, ctx.contentSource)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Delete(suite, ctx.contentSource)
+	})
+	AfterEach(func() {
+		err = nil
+		ctx = nil
+	})
+
+	When("delete is performed", func() {
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+}
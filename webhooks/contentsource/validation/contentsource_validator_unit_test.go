@@ -0,0 +1,231 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking ValidateCreate", unitTestsValidateCreate)
+	Describe("Invoking ValidateUpdate", unitTestsValidateUpdate)
+	Describe("Invoking ValidateDelete", unitTestsValidateDelete)
+}
+
+type unitValidatingWebhookContext struct {
+	builder.UnitTestContextForValidatingWebhook
+	contentSource    *vmopv1.ContentSource
+	oldContentSource *vmopv1.ContentSource
+}
+
+func newUnitTestContextForValidatingWebhook(isUpdate bool) *unitValidatingWebhookContext {
+	contentSource := builder.DummyContentSource("dummy-cs", "dummy-cl")
+	obj, err := builder.ToUnstructured(contentSource)
+	Expect(err).ToNot(HaveOccurred())
+
+	var oldContentSource *vmopv1.ContentSource
+	var oldObj *unstructured.Unstructured
+
+	if isUpdate {
+		oldContentSource = contentSource.DeepCopy()
+		oldObj, err = builder.ToUnstructured(oldContentSource)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	return &unitValidatingWebhookContext{
+		UnitTestContextForValidatingWebhook: *suite.NewUnitTestContextForValidatingWebhook(obj, oldObj),
+		contentSource:                       contentSource,
+		oldContentSource:                    oldContentSource,
+	}
+}
+
+func unitTestsValidateCreate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	type createArgs struct {
+		invalidKind         bool
+		emptyName           bool
+		duplicateProvider   bool
+		otherProviderSource bool
+	}
+
+	validateCreate := func(args createArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
+		var err error
+
+		if args.invalidKind {
+			ctx.contentSource.Spec.ProviderRef.Kind = "OtherProvider"
+		}
+		if args.emptyName {
+			ctx.contentSource.Spec.ProviderRef.Name = ""
+		}
+		if args.duplicateProvider {
+			Expect(ctx.Client.Create(ctx, builder.DummyContentSource("other-cs", "dummy-cl"))).To(Succeed())
+		}
+		if args.otherProviderSource {
+			Expect(ctx.Client.Create(ctx, builder.DummyContentSource("other-cs", "other-cl"))).To(Succeed())
+		}
+
+		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.contentSource)
+		Expect(err).ToNot(HaveOccurred())
+
+		response := ctx.ValidateCreate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(Equal(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	providerRefPath := field.NewPath("spec", "providerRef")
+	DescribeTable("create table", validateCreate,
+		Entry("should allow valid", createArgs{}, true, nil, nil),
+		Entry("should allow a source of another provider", createArgs{otherProviderSource: true}, true, nil, nil),
+		Entry("should deny an unsupported provider kind", createArgs{invalidKind: true}, false,
+			field.NotSupported(providerRefPath.Child("kind"), "OtherProvider", []string{"ContentLibraryProvider"}).Error(), nil),
+		Entry("should deny an empty provider name", createArgs{emptyName: true}, false,
+			field.Required(providerRefPath.Child("name"), "").Error(), nil),
+		Entry("should deny a provider referenced by another source", createArgs{duplicateProvider: true}, false,
+			field.Duplicate(providerRefPath.Child("name"),
+				"ContentLibraryProvider dummy-cl is already referenced by ContentSource other-cs").Error(), nil),
+	)
+}
+
+func unitTestsValidateUpdate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	type updateArgs struct {
+		changeProviderName bool
+		changeLabels       bool
+	}
+
+	validateUpdate := func(args updateArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
+		var err error
+
+		if args.changeProviderName {
+			ctx.contentSource.Spec.ProviderRef.Name = "other-cl"
+		}
+		if args.changeLabels {
+			ctx.contentSource.Labels = map[string]string{"foo": "bar"}
+		}
+
+		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.contentSource)
+		Expect(err).ToNot(HaveOccurred())
+
+		response := ctx.ValidateUpdate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(ContainSubstring(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(true)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	DescribeTable("update table", validateUpdate,
+		Entry("should allow", updateArgs{}, true, nil, nil),
+		Entry("should allow a label change", updateArgs{changeLabels: true}, true, nil, nil),
+		Entry("should deny a provider change", updateArgs{changeProviderName: true}, false, "field is immutable", nil),
+	)
+}
+
+func unitTestsValidateDelete() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	type deleteArgs struct {
+		withImage          bool
+		imageInUse         bool
+		imageInUseByOthers bool
+		displayNameInUse   bool
+		displayNameIsName  bool
+	}
+
+	validateDelete := func(args deleteArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
+		if args.withImage || args.imageInUse || args.imageInUseByOthers {
+			image := builder.DummyVirtualMachineImage("dummy-image")
+			image.Spec.ProviderRef = ctx.contentSource.Spec.ProviderRef
+			if args.imageInUseByOthers {
+				image.Spec.ProviderRef.Name = "other-cl"
+			}
+			Expect(ctx.Client.Create(ctx, image)).To(Succeed())
+		}
+		if args.displayNameInUse || args.displayNameIsName {
+			image := builder.DummyVirtualMachineImage("dummy-image")
+			image.Spec.ProviderRef = ctx.contentSource.Spec.ProviderRef
+			image.Status.ImageName = "photon"
+			Expect(ctx.Client.Create(ctx, image)).To(Succeed())
+		}
+		if args.displayNameIsName {
+			image := builder.DummyVirtualMachineImage("photon")
+			image.Spec.ProviderRef.Name = "other-cl"
+			Expect(ctx.Client.Create(ctx, image)).To(Succeed())
+		}
+		if args.imageInUse || args.imageInUseByOthers || args.displayNameInUse || args.displayNameIsName {
+			vm := builder.DummyVirtualMachine()
+			vm.Name, vm.Namespace = "dummy-vm", "dummy-ns"
+			vm.Spec.ImageName = "dummy-image"
+			if args.displayNameInUse || args.displayNameIsName {
+				vm.Spec.ImageName = "photon"
+			}
+			Expect(ctx.Client.Create(ctx, vm)).To(Succeed())
+		}
+
+		response := ctx.ValidateDelete(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(Equal(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	DescribeTable("delete table", validateDelete,
+		Entry("should allow a source without images", deleteArgs{}, true, nil, nil),
+		Entry("should allow a source whose images are not used", deleteArgs{withImage: true}, true, nil, nil),
+		Entry("should allow a source when the images of another source are used", deleteArgs{imageInUseByOthers: true}, true, nil, nil),
+		Entry("should deny a source whose images are used", deleteArgs{imageInUse: true}, false,
+			"ContentSource dummy-cs cannot be deleted because VirtualMachines use its VirtualMachineImages: dummy-image", nil),
+		Entry("should deny a source whose images are used by their display name", deleteArgs{displayNameInUse: true}, false,
+			"ContentSource dummy-cs cannot be deleted because VirtualMachines use its VirtualMachineImages: dummy-image", nil),
+		Entry("should allow a source when the display name of its image is the name of another image", deleteArgs{displayNameIsName: true}, true, nil, nil),
+	)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conversion
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha2 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	"github.com/vmware-tanzu/vm-operator/pkg/builder"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+)
+
+const (
+	webHookName = "default"
+)
+
+// AddToManager adds the webhook to the provided manager. The webhook is configured as the conversion webhook of the
+// VirtualMachine CRD by config/crd/patches/webhook_in_virtualmachines.yaml. The CRD only serves v1alpha2 when
+// config/crd/patches/v1alpha2_in_virtualmachines.yaml is applied, along with setting VMServiceV1Alpha2FSS.
+func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	hook, err := builder.NewConversionWebhook(ctx, mgr, webHookName, NewConverter())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create VirtualMachine conversion webhook")
+	}
+	mgr.GetWebhookServer().Register(hook.Path, hook)
+
+	return nil
+}
+
+// NewConverter returns the package's Converter.
+func NewConverter() builder.Converter {
+	return converter{
+		converter: runtime.DefaultUnstructuredConverter,
+	}
+}
+
+type converter struct {
+	converter runtime.UnstructuredConverter
+}
+
+func (c converter) For() schema.GroupKind {
+	return vmopv1.SchemeGroupVersion.WithKind(reflect.TypeOf(vmopv1.VirtualMachine{}).Name()).GroupKind()
+}
+
+// Convert converts the VirtualMachine between the v1alpha1 storage version and v1alpha2.
+func (c converter) Convert(ctx *context.WebhookRequestContext, desiredAPIVersion string) (*unstructured.Unstructured, error) {
+	apiVersion := ctx.Obj.GetAPIVersion()
+	if apiVersion == desiredAPIVersion {
+		return ctx.Obj, nil
+	}
+	if !lib.IsVMServiceV1Alpha2FSSEnabled() {
+		return nil, fmt.Errorf("v1alpha2 is not enabled, %s is not set", lib.VMServiceV1Alpha2FSS)
+	}
+
+	var (
+		converted runtime.Object
+		v1alpha1  = vmopv1.SchemeGroupVersion.String()
+		v1alpha2  = vmopapiv1alpha2.GroupVersion.String()
+	)
+
+	switch {
+	case apiVersion == v1alpha1 && desiredAPIVersion == v1alpha2:
+		src := &vmopv1.VirtualMachine{}
+		if err := c.converter.FromUnstructured(ctx.Obj.UnstructuredContent(), src); err != nil {
+			return nil, err
+		}
+		dst := &vmopapiv1alpha2.VirtualMachine{}
+		if err := dst.ConvertFromV1alpha1(src); err != nil {
+			return nil, err
+		}
+		dst.APIVersion, dst.Kind = desiredAPIVersion, src.Kind
+		converted = dst
+
+	case apiVersion == v1alpha2 && desiredAPIVersion == v1alpha1:
+		src := &vmopapiv1alpha2.VirtualMachine{}
+		if err := c.converter.FromUnstructured(ctx.Obj.UnstructuredContent(), src); err != nil {
+			return nil, err
+		}
+		dst := &vmopv1.VirtualMachine{}
+		if err := src.ConvertToV1alpha1(dst); err != nil {
+			return nil, err
+		}
+		dst.APIVersion, dst.Kind = desiredAPIVersion, src.Kind
+		converted = dst
+
+	default:
+		return nil, fmt.Errorf("unsupported conversion from %s to %s", apiVersion, desiredAPIVersion)
+	}
+
+	content, err := c.converter.ToUnstructured(converted)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
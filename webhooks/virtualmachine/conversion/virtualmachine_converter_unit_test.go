@@ -0,0 +1,242 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conversion_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha2 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	"github.com/vmware-tanzu/vm-operator/pkg/builder"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/lib"
+	testbuilder "github.com/vmware-tanzu/vm-operator/test/builder"
+	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachine/conversion"
+)
+
+var (
+	v1alpha1 = vmopv1.SchemeGroupVersion.String()
+	v1alpha2 = vmopapiv1alpha2.GroupVersion.String()
+)
+
+var _ = Describe("VirtualMachine conversion", func() {
+
+	var (
+		converter      builder.Converter
+		oldV1alpha2FSS func() bool
+	)
+
+	BeforeEach(func() {
+		converter = conversion.NewConverter()
+		oldV1alpha2FSS = lib.IsVMServiceV1Alpha2FSSEnabled
+		lib.IsVMServiceV1Alpha2FSSEnabled = func() bool { return true }
+	})
+
+	AfterEach(func() {
+		lib.IsVMServiceV1Alpha2FSSEnabled = oldV1alpha2FSS
+	})
+
+	convert := func(obj runtime.Object, desiredAPIVersion string) *unstructured.Unstructured {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx := &context.WebhookRequestContext{
+			Obj:    &unstructured.Unstructured{Object: content},
+			Logger: ctrllog.Log.WithName("convert"),
+		}
+		converted, err := converter.Convert(ctx, desiredAPIVersion)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(converted.GetAPIVersion()).To(Equal(desiredAPIVersion))
+		return converted
+	}
+
+	toV1alpha1 := func(obj runtime.Object) *vmopv1.VirtualMachine {
+		vm := &vmopv1.VirtualMachine{}
+		Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(convert(obj, v1alpha1).Object, vm)).To(Succeed())
+		return vm
+	}
+
+	toV1alpha2 := func(obj runtime.Object) *vmopapiv1alpha2.VirtualMachine {
+		vm := &vmopapiv1alpha2.VirtualMachine{}
+		Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(convert(obj, v1alpha2).Object, vm)).To(Succeed())
+		return vm
+	}
+
+	It("does not convert to v1alpha2 when v1alpha2 is not enabled", func() {
+		lib.IsVMServiceV1Alpha2FSSEnabled = func() bool { return false }
+
+		vm := testbuilder.DummyVirtualMachine()
+		vm.APIVersion, vm.Kind = v1alpha1, "VirtualMachine"
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(vm)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx := &context.WebhookRequestContext{
+			Obj:    &unstructured.Unstructured{Object: content},
+			Logger: ctrllog.Log.WithName("convert"),
+		}
+		_, err = converter.Convert(ctx, v1alpha2)
+		Expect(err).To(MatchError(ContainSubstring(lib.VMServiceV1Alpha2FSS)))
+	})
+
+	Context("v1alpha1 VirtualMachine", func() {
+		var vm *vmopv1.VirtualMachine
+
+		BeforeEach(func() {
+			vm = testbuilder.DummyVirtualMachine()
+			vm.APIVersion, vm.Kind = v1alpha1, "VirtualMachine"
+			vm.Name = "dummy-vm"
+			vm.Labels, vm.Annotations = nil, nil
+			vm.Spec.VmMetadata.Transport = vmopv1.VirtualMachineMetadataCloudInitTransport
+			vm.Status.VmIp = "192.0.2.10"
+		})
+
+		It("converts to v1alpha2", func() {
+			vm2 := toV1alpha2(vm)
+			Expect(vm2.Name).To(Equal(vm.Name))
+			Expect(vm2.Spec.Image).To(Equal(&vmopapiv1alpha2.VirtualMachineImageRef{
+				Kind: vmopapiv1alpha2.VirtualMachineImageKind,
+				Name: vm.Spec.ImageName,
+			}))
+			Expect(vm2.Spec.Bootstrap).ToNot(BeNil())
+			Expect(vm2.Spec.Bootstrap.CloudInit).ToNot(BeNil())
+			Expect(vm2.Spec.Bootstrap.CloudInit.ConfigMapName).To(Equal(vm.Spec.VmMetadata.ConfigMapName))
+			Expect(vm2.Spec.Bootstrap.VAppConfig).To(BeNil())
+			Expect(vm2.Spec.Bootstrap.ExtraConfig).To(BeNil())
+			Expect(vm2.Spec.Network).ToNot(BeNil())
+			Expect(vm2.Spec.Network.Interfaces).To(HaveLen(len(vm.Spec.NetworkInterfaces)))
+			Expect(vm2.Spec.Network.Interfaces[0].NetworkName).To(Equal(vm.Spec.NetworkInterfaces[0].NetworkName))
+			Expect(vm2.Spec.Volumes).To(HaveLen(len(vm.Spec.Volumes)))
+			Expect(vm2.Status.VMIP).To(Equal(vm.Status.VmIp))
+			Expect(vm2.Annotations).ToNot(HaveKey(vmopapiv1alpha2.V1alpha1SpecAnnotation))
+		})
+
+		It("round-trips through v1alpha2", func() {
+			Expect(toV1alpha1(toV1alpha2(vm))).To(Equal(vm))
+		})
+
+		When("it has fields that v1alpha2 cannot represent", func() {
+			BeforeEach(func() {
+				vm.Spec.Ports = []vmopv1.VirtualMachinePort{{Port: 22, Name: "ssh", Protocol: "TCP"}}
+			})
+
+			It("saves the v1alpha1 spec in an annotation and round-trips", func() {
+				vm2 := toV1alpha2(vm)
+				Expect(vm2.Annotations).To(HaveKey(vmopapiv1alpha2.V1alpha1SpecAnnotation))
+				Expect(toV1alpha1(vm2)).To(Equal(vm))
+			})
+
+			It("restores them when the v1alpha2 spec was changed", func() {
+				vm2 := toV1alpha2(vm)
+				vm2.Spec.ClassName = "another-class"
+
+				vm1 := toV1alpha1(vm2)
+				Expect(vm1.Spec.ClassName).To(Equal("another-class"))
+				Expect(vm1.Spec.Ports).To(Equal(vm.Spec.Ports))
+				Expect(vm1.Annotations).ToNot(HaveKey(vmopapiv1alpha2.V1alpha1SpecAnnotation))
+			})
+		})
+	})
+
+	Context("v1alpha2 VirtualMachine", func() {
+		var vm *vmopapiv1alpha2.VirtualMachine
+
+		BeforeEach(func() {
+			vm = &vmopapiv1alpha2.VirtualMachine{}
+			vm.APIVersion, vm.Kind = v1alpha2, "VirtualMachine"
+			vm.Name = "dummy-vm"
+			vm.Spec = vmopapiv1alpha2.VirtualMachineSpec{
+				Image: &vmopapiv1alpha2.VirtualMachineImageRef{
+					Kind: vmopapiv1alpha2.VirtualMachineImageKind,
+					Name: testbuilder.DummyImageName,
+				},
+				ClassName:  testbuilder.DummyClassName,
+				PowerState: vmopapiv1alpha2.VirtualMachinePoweredOn,
+				Bootstrap: &vmopapiv1alpha2.VirtualMachineBootstrapSpec{
+					VAppConfig: &vmopapiv1alpha2.VirtualMachineBootstrapVAppConfigSpec{
+						VirtualMachineBootstrapDataSource: vmopapiv1alpha2.VirtualMachineBootstrapDataSource{
+							SecretName: "dummy-secret",
+						},
+					},
+				},
+				Network: &vmopapiv1alpha2.VirtualMachineNetworkSpec{
+					Interfaces: []vmopapiv1alpha2.VirtualMachineNetworkInterfaceSpec{
+						{
+							NetworkName: testbuilder.DummyNetworkName,
+						},
+					},
+				},
+			}
+		})
+
+		It("converts to v1alpha1", func() {
+			vm1 := toV1alpha1(vm)
+			Expect(vm1.Spec.ImageName).To(Equal(vm.Spec.Image.Name))
+			Expect(vm1.Spec.VmMetadata).To(Equal(&vmopv1.VirtualMachineMetadata{
+				SecretName: "dummy-secret",
+				Transport:  vmopv1.VirtualMachineMetadataOvfEnvTransport,
+			}))
+			Expect(vm1.Spec.NetworkInterfaces).To(HaveLen(1))
+			Expect(vm1.Spec.NetworkInterfaces[0].NetworkName).To(Equal(testbuilder.DummyNetworkName))
+			Expect(vm1.Annotations).ToNot(HaveKey(vmopapiv1alpha2.V1alpha2SpecAnnotation))
+		})
+
+		It("round-trips through v1alpha1", func() {
+			Expect(toV1alpha2(toV1alpha1(vm))).To(Equal(vm))
+		})
+
+		When("it has fields that v1alpha1 cannot represent", func() {
+			BeforeEach(func() {
+				vm.Spec.Network.Interfaces[0].IPConfig = &vmopapiv1alpha2.VirtualMachineNetworkInterfaceIPConfig{
+					Addresses:   []string{"192.0.2.10/24"},
+					Gateway4:    "192.0.2.1",
+					Nameservers: []string{"192.0.2.2"},
+				}
+			})
+
+			It("saves the v1alpha2 spec in an annotation and round-trips", func() {
+				vm1 := toV1alpha1(vm)
+				Expect(vm1.Annotations).To(HaveKey(vmopapiv1alpha2.V1alpha2SpecAnnotation))
+				Expect(toV1alpha2(vm1)).To(Equal(vm))
+			})
+
+			It("restores them when the v1alpha1 spec was changed", func() {
+				vm1 := toV1alpha1(vm)
+				vm1.Spec.ClassName = "another-class"
+
+				vm2 := toV1alpha2(vm1)
+				Expect(vm2.Spec.ClassName).To(Equal("another-class"))
+				Expect(vm2.Spec.Network.Interfaces[0].IPConfig).To(Equal(vm.Spec.Network.Interfaces[0].IPConfig))
+				Expect(vm2.Annotations).ToNot(HaveKey(vmopapiv1alpha2.V1alpha2SpecAnnotation))
+			})
+
+			It("does not restore them on an interface that was moved to another network", func() {
+				vm1 := toV1alpha1(vm)
+				vm1.Spec.NetworkInterfaces[0].NetworkName = "another-network"
+
+				vm2 := toV1alpha2(vm1)
+				Expect(vm2.Spec.Network.Interfaces[0].IPConfig).To(BeNil())
+			})
+		})
+	})
+
+	It("returns an error for an unsupported version", func() {
+		vm := testbuilder.DummyVirtualMachine()
+		vm.APIVersion, vm.Kind = v1alpha1, "VirtualMachine"
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(vm)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx := &context.WebhookRequestContext{
+			Obj:    &unstructured.Unstructured{Object: content},
+			Logger: ctrllog.Log.WithName("convert"),
+		}
+		_, err = converter.Convert(ctx, "vmoperator.vmware.com/v1beta1")
+		Expect(err).To(HaveOccurred())
+	})
+})
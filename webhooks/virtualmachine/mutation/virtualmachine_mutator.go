@@ -8,10 +8,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"reflect"
+	"strings"
 	"sync"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/builder"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
 	"github.com/vmware-tanzu/vm-operator/pkg/lib"
@@ -35,6 +38,7 @@ const (
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachine/status,verbs=get
 // +kubebuilder:rbac:groups=topology.tanzu.vmware.com,resources=availabilityzones,verbs=get;list;watch
 // +kubebuilder:rbac:groups=topology.tanzu.vmware.com,resources=availabilityzones/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinedefaults,verbs=get;list;watch
 
 // AddToManager adds the webhook to the provided manager.
 func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
@@ -84,6 +88,17 @@ func (m mutator) Mutate(ctx *context.WebhookRequestContext) admission.Response {
 		wasMutated = true
 	}
 
+	// The namespace defaults are only applied on create, so that a field that is cleared on update stays cleared.
+	if ctx.OldObj == nil {
+		mutatedDefaults, err := m.applyNamespaceDefaults(ctx.Context, modified)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if mutatedDefaults {
+			wasMutated = true
+		}
+	}
+
 	if !wasMutated {
 		return admission.Allowed("")
 	}
@@ -158,3 +173,81 @@ func (m mutator) mutateAvailabilityZone(
 	vmNew.Labels[topology.KubernetesTopologyZoneLabelKey] = zoneName
 	return true, nil
 }
+
+// applyNamespaceDefaults sets the fields of the new VM that are not set from the VirtualMachineDefaults of its
+// namespace, and records the defaulted fields in the DefaultedFieldsAnnotation.
+func (m mutator) applyNamespaceDefaults(
+	ctx goctx.Context, vmNew *vmopv1.VirtualMachine) (bool, error) {
+	var defaultedFields []string
+
+	defaults := &vmopapiv1alpha1.VirtualMachineDefaults{}
+	key := client.ObjectKey{Namespace: vmNew.Namespace, Name: vmopapiv1alpha1.VirtualMachineDefaultsName}
+	if err := m.client.Get(ctx, key, defaults); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+	} else {
+		defaultedFields = applyDefaults(&vmNew.Spec, &defaults.Spec)
+	}
+
+	// The annotation is set only by the webhook, so it is removed from a VM that is created with it.
+	if len(defaultedFields) == 0 {
+		if _, ok := vmNew.Annotations[vmopapiv1alpha1.DefaultedFieldsAnnotation]; !ok {
+			return false, nil
+		}
+		delete(vmNew.Annotations, vmopapiv1alpha1.DefaultedFieldsAnnotation)
+		return true, nil
+	}
+
+	if vmNew.Annotations == nil {
+		vmNew.Annotations = map[string]string{}
+	}
+	vmNew.Annotations[vmopapiv1alpha1.DefaultedFieldsAnnotation] = strings.Join(defaultedFields, ",")
+	return true, nil
+}
+
+// applyDefaults sets the fields of the spec that are not set from the defaults, and returns the paths of the fields
+// that were set.
+func applyDefaults(spec *vmopv1.VirtualMachineSpec, defaults *vmopapiv1alpha1.VirtualMachineDefaultsSpec) []string {
+	var fields []string
+
+	if spec.ClassName == "" && defaults.ClassName != "" {
+		spec.ClassName = defaults.ClassName
+		fields = append(fields, "spec.className")
+	}
+
+	if spec.ImageName == "" && defaults.ImageName != "" {
+		spec.ImageName = defaults.ImageName
+		fields = append(fields, "spec.imageName")
+	}
+
+	if spec.PowerState == "" && defaults.PowerState != "" {
+		spec.PowerState = defaults.PowerState
+		fields = append(fields, "spec.powerState")
+	}
+
+	if spec.StorageClass == "" && defaults.StorageClass != "" {
+		spec.StorageClass = defaults.StorageClass
+		fields = append(fields, "spec.storageClass")
+	}
+
+	if len(spec.NetworkInterfaces) == 0 && len(defaults.NetworkInterfaces) != 0 {
+		spec.NetworkInterfaces = make([]vmopv1.VirtualMachineNetworkInterface, len(defaults.NetworkInterfaces))
+		for i := range defaults.NetworkInterfaces {
+			defaults.NetworkInterfaces[i].DeepCopyInto(&spec.NetworkInterfaces[i])
+		}
+		fields = append(fields, "spec.networkInterfaces")
+	}
+
+	if spec.VmMetadata != nil && spec.VmMetadata.Transport == "" && defaults.MetadataTransport != "" {
+		spec.VmMetadata.Transport = defaults.MetadataTransport
+		fields = append(fields, "spec.vmMetadata.transport")
+	}
+
+	if spec.ReadinessProbe == nil && defaults.ReadinessProbe != nil {
+		spec.ReadinessProbe = defaults.ReadinessProbe.DeepCopy()
+		fields = append(fields, "spec.readinessProbe")
+	}
+
+	return fields
+}
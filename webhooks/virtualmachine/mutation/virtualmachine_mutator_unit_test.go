@@ -12,9 +12,11 @@ import (
 
 	admissionv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/topology"
 	"github.com/vmware-tanzu/vm-operator/test/builder"
@@ -249,4 +251,111 @@ func unitTestsMutating() {
 			})
 		})
 	})
+
+	Describe("Namespace defaults", func() {
+		var (
+			defaults *vmopapiv1alpha1.VirtualMachineDefaults
+		)
+
+		BeforeEach(func() {
+			ctx.vm.Namespace = "dummy-ns"
+			ctx.vm.Labels[topology.KubernetesTopologyZoneLabelKey] = topology.DefaultAvailabilityZoneName
+			ctx.vm.Spec.ClassName = ""
+			ctx.vm.Spec.ImageName = ""
+			ctx.vm.Spec.PowerState = ""
+			ctx.vm.Spec.NetworkInterfaces = nil
+			ctx.vm.Spec.VmMetadata.Transport = ""
+
+			defaults = &vmopapiv1alpha1.VirtualMachineDefaults{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ctx.vm.Namespace,
+					Name:      vmopapiv1alpha1.VirtualMachineDefaultsName,
+				},
+				Spec: vmopapiv1alpha1.VirtualMachineDefaultsSpec{
+					ClassName:    "default-class",
+					ImageName:    "default-image",
+					PowerState:   vmopv1.VirtualMachinePoweredOn,
+					StorageClass: "default-storage-class",
+					NetworkInterfaces: []vmopv1.VirtualMachineNetworkInterface{
+						{NetworkType: "nsx-t"},
+					},
+					MetadataTransport: vmopv1.VirtualMachineMetadataCloudInitTransport,
+					ReadinessProbe: &vmopv1.Probe{
+						TCPSocket: &vmopv1.TCPSocketAction{Port: intstr.FromInt(22)},
+					},
+				},
+			}
+		})
+
+		// mutate returns the values of the patches of the response by their path.
+		mutate := func() map[string]interface{} {
+			var err error
+			ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.vm)
+			Expect(err).ToNot(HaveOccurred())
+
+			response := ctx.Mutate(&ctx.WebhookRequestContext)
+			Expect(response.Allowed).To(BeTrue())
+
+			patches := map[string]interface{}{}
+			for _, patch := range response.Patches {
+				patches[patch.Path] = patch.Value
+			}
+			return patches
+		}
+
+		When("the namespace has no defaults", func() {
+			It("does not mutate the VM", func() {
+				Expect(mutate()).To(BeEmpty())
+			})
+
+			It("removes the defaulted fields annotation", func() {
+				ctx.vm.Annotations[vmopapiv1alpha1.DefaultedFieldsAnnotation] = "spec.className"
+				Expect(mutate()).To(HaveKey("/metadata/annotations"))
+			})
+		})
+
+		When("the namespace has defaults", func() {
+			JustBeforeEach(func() {
+				Expect(ctx.Client.Create(ctx, defaults)).To(Succeed())
+			})
+
+			It("applies the defaults to the fields that are not set on create", func() {
+				patches := mutate()
+				Expect(patches).To(HaveKeyWithValue("/spec/className", "default-class"))
+				Expect(patches).To(HaveKeyWithValue("/spec/imageName", "default-image"))
+				Expect(patches).To(HaveKeyWithValue("/spec/powerState", "poweredOn"))
+				Expect(patches).To(HaveKeyWithValue("/spec/storageClass", "default-storage-class"))
+				Expect(patches).To(HaveKey("/spec/networkInterfaces"))
+				Expect(patches).To(HaveKeyWithValue("/spec/vmMetadata/transport", "CloudInit"))
+				Expect(patches).To(HaveKey("/spec/readinessProbe"))
+				Expect(patches).To(HaveKeyWithValue("/metadata/annotations", HaveKeyWithValue(
+					vmopapiv1alpha1.DefaultedFieldsAnnotation,
+					"spec.className,spec.imageName,spec.powerState,spec.storageClass,"+
+						"spec.networkInterfaces,spec.vmMetadata.transport,spec.readinessProbe")))
+			})
+
+			It("does not override the fields that are set", func() {
+				ctx.vm.Spec.ClassName = "my-class"
+				ctx.vm.Spec.ImageName = "my-image"
+				ctx.vm.Spec.PowerState = vmopv1.VirtualMachinePoweredOff
+				ctx.vm.Spec.StorageClass = "my-storage-class"
+				ctx.vm.Spec.NetworkInterfaces = []vmopv1.VirtualMachineNetworkInterface{{NetworkName: "my-network"}}
+				ctx.vm.Spec.VmMetadata.Transport = vmopv1.VirtualMachineMetadataOvfEnvTransport
+
+				patches := mutate()
+				Expect(patches).To(HaveLen(2))
+				Expect(patches).To(HaveKey("/spec/readinessProbe"))
+				Expect(patches).To(HaveKeyWithValue("/metadata/annotations", HaveKeyWithValue(
+					vmopapiv1alpha1.DefaultedFieldsAnnotation, "spec.readinessProbe")))
+			})
+
+			It("does not apply the defaults on update", func() {
+				var err error
+				ctx.WebhookRequestContext.OldObj, err = builder.ToUnstructured(ctx.vm)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mutate()).To(BeEmpty())
+			})
+		})
+	})
 }
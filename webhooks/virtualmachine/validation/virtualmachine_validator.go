@@ -4,15 +4,19 @@
 package validation
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/api/validation"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -29,17 +33,26 @@ import (
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	vmopapiv1alpha2 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
 	"github.com/vmware-tanzu/vm-operator/controllers/volume"
 	netopv1alpha1 "github.com/vmware-tanzu/vm-operator/external/net-operator/api/v1alpha1"
 	"github.com/vmware-tanzu/vm-operator/pkg/auth"
 	"github.com/vmware-tanzu/vm-operator/pkg/builder"
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/extraconfig"
 	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/topology"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmimage"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/cloudinit"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/compatibility"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/config"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/contentlibrary"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/instancestorage"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/network"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/vapp"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmquota"
 	"github.com/vmware-tanzu/vm-operator/webhooks/common"
 )
 
@@ -53,6 +66,11 @@ const (
 	readinessProbeOnlyOneAction               = "only one action can be specified"
 	updatesNotAllowedWhenPowerOn              = "updates to this filed is not allowed when VM power is on"
 	virtualMachineImageNotSupported           = "VirtualMachineImage is not compatible with v1alpha1 or is not a TKG Image"
+	deprecatedImageWarningFmt                 = "VirtualMachineImage %s is deprecated because its content library item was removed"
+	extraConfigTransportWarning               = "the ExtraConfig transport is deprecated and its guestinfo keys can be read by any process in the guest, use the CloudInit transport instead"
+	tcpProbeHostWarningFmt                    = "host %s is probed instead of the VM's IP, so the VM can be reported ready when it is not"
+	vSphereVolumeNoCapacityWarning            = "ephemeral-storage capacity is not set, so the disk would be shrunk to zero bytes and the VM cannot be reconciled"
+	imageNotTrustedFmt                        = "VirtualMachineImage is not allowed by the %s image trust policy: %v"
 	storageClassNotAssignedFmt                = "Storage policy is not associated with the namespace %s"
 	storageClassNotFoundFmt                   = "Storage policy is not associated with the namespace %s"
 	pvcHardwareVersionNotSupportedFmt         = "VirtualMachineImage has an unsupported hardware version %d for PersistentVolumes. Minimum supported hardware version %d"
@@ -62,11 +80,31 @@ const (
 	addingModifyingInstanceVolumesNotAllowed  = "adding or modifying instance storage volume(s) is not allowed"
 	metadataTransportResourcesEmpty           = "must specify either %s or %s, but not both"
 	metadataTransportResourcesInvalid         = "%s and %s cannot be specified simultaneously"
+	invalidTerminationGracePeriod             = "must be a non-negative duration"
+	terminationGracePeriodTooLongFmt          = "must be at most %s"
+	invalidBootDiskSize                       = "must be a positive quantity"
+	invalidRetainedVMFolder                   = "must be the managed object ID of a Folder"
+	ipConfigNotSupported                      = "static IP configuration of network interfaces is not supported yet"
+	quotaExceededFmt                          = "exceeded VirtualMachineQuota %s: %s"
+	extraConfigKeyNotAllowedFmt               = "ExtraConfig key %s is not allowed by the policy of the namespace"
+	userDataIssueFmt                          = "%s %s"
 )
 
+// folderMoIDRegex matches the managed object ID of a vSphere Folder.
+var folderMoIDRegex = regexp.MustCompile(`^group-[a-z]?[0-9]+$`)
+
+var supportedDeletionPolicies = []string{
+	constants.DeletionPolicyDelete,
+	constants.DeletionPolicyRetain,
+	constants.DeletionPolicyOrphan,
+}
+
 // +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-virtualmachine,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=virtualmachines,versions=v1alpha1,name=default.validating.virtualmachine.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines/status,verbs=get
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinequotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinedefaults,verbs=get;list;watch
 
 // AddToManager adds the webhook to the provided manager.
 func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
@@ -105,10 +143,18 @@ func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.
 
 	var fieldErrs field.ErrorList
 
-	fieldErrs = append(fieldErrs, v.validateMetadata(ctx, vm)...)
+	metadataErrs, metadataWarnings := v.validateMetadata(ctx, vm, nil)
+	fieldErrs = append(fieldErrs, metadataErrs...)
+	fieldErrs = append(fieldErrs, v.validateMetadataOvfProperties(ctx, vm, nil)...)
+	fieldErrs = append(fieldErrs, v.validateAnnotations(ctx, vm)...)
+	fieldErrs = append(fieldErrs, v.validateExtraConfig(ctx, vm, nil)...)
 	fieldErrs = append(fieldErrs, v.validateAvailabilityZone(ctx, vm, nil)...)
 	fieldErrs = append(fieldErrs, v.validateImage(ctx, vm)...)
+	fieldErrs = append(fieldErrs, v.validateImageTrust(ctx, vm)...)
 	fieldErrs = append(fieldErrs, v.validateClass(ctx, vm)...)
+	compatibilityErrs, compatibilityWarnings := v.validateImageClassCompatibility(ctx, vm, nil)
+	fieldErrs = append(fieldErrs, compatibilityErrs...)
+	fieldErrs = append(fieldErrs, v.validateQuota(ctx, vm)...)
 	fieldErrs = append(fieldErrs, v.validateStorageClass(ctx, vm)...)
 	fieldErrs = append(fieldErrs, v.validateNetwork(ctx, vm)...)
 	fieldErrs = append(fieldErrs, v.validateVolumes(ctx, vm)...)
@@ -123,7 +169,15 @@ func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	var warnings []string
+	warnings = append(warnings, metadataWarnings...)
+	warnings = append(warnings, v.deprecatedImageWarnings(ctx, vm)...)
+	warnings = append(warnings, compatibilityWarnings...)
+	warnings = append(warnings, v.metadataTransportWarnings(vm, nil)...)
+	warnings = append(warnings, v.readinessProbeWarnings(vm, nil)...)
+	warnings = append(warnings, v.volumeWarnings(vm, nil)...)
+
+	return common.BuildValidationResponse(ctx, validationErrs, warnings, nil)
 }
 
 func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
@@ -176,8 +230,14 @@ func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.
 
 	// Validations for allowed updates. Return validation responses here for conditional updates regardless
 	// of whether the update is allowed or not.
-	fieldErrs = append(fieldErrs, v.validateMetadata(ctx, vm)...)
+	metadataErrs, metadataWarnings := v.validateMetadata(ctx, vm, oldVM)
+	fieldErrs = append(fieldErrs, metadataErrs...)
+	fieldErrs = append(fieldErrs, v.validateMetadataOvfProperties(ctx, vm, oldVM)...)
+	fieldErrs = append(fieldErrs, v.validateAnnotations(ctx, vm)...)
+	fieldErrs = append(fieldErrs, v.validateExtraConfig(ctx, vm, oldVM)...)
 	fieldErrs = append(fieldErrs, v.validateAvailabilityZone(ctx, vm, oldVM)...)
+	compatibilityErrs, compatibilityWarnings := v.validateImageClassCompatibility(ctx, vm, oldVM)
+	fieldErrs = append(fieldErrs, compatibilityErrs...)
 	fieldErrs = append(fieldErrs, v.validateNetwork(ctx, vm)...)
 	fieldErrs = append(fieldErrs, v.validateVolumes(ctx, vm)...)
 	fieldErrs = append(fieldErrs, v.validateVMVolumeProvisioningOptions(ctx, vm)...)
@@ -191,14 +251,24 @@ func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	var warnings []string
+	warnings = append(warnings, metadataWarnings...)
+	warnings = append(warnings, compatibilityWarnings...)
+	warnings = append(warnings, v.metadataTransportWarnings(vm, oldVM)...)
+	warnings = append(warnings, v.readinessProbeWarnings(vm, oldVM)...)
+	warnings = append(warnings, v.volumeWarnings(vm, oldVM)...)
+
+	return common.BuildValidationResponse(ctx, validationErrs, warnings, nil)
 }
 
-func (v validator) validateMetadata(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine) field.ErrorList {
+// validateMetadata validates the VM metadata, and the cloud-init user-data of the CloudInit and OvfEnv transports.
+// Issues with the user-data that cloud-init ignores are returned as warnings. On update, the user-data is only
+// validated when the VM metadata has changed.
+func (v validator) validateMetadata(ctx *context.WebhookRequestContext, vm, oldVM *vmopv1.VirtualMachine) (field.ErrorList, []string) {
 	var allErrs field.ErrorList
 
 	if vm.Spec.VmMetadata == nil {
-		return allErrs
+		return allErrs, nil
 	}
 
 	mdPath := field.NewPath("spec", "vmMetadata")
@@ -213,6 +283,226 @@ func (v validator) validateMetadata(ctx *context.WebhookRequestContext, vm *vmop
 			fmt.Sprintf(metadataTransportResourcesInvalid, mdPath.Child("configMapName"), mdPath.Child("secretName"))))
 	}
 
+	if len(allErrs) > 0 || (oldVM != nil && equality.Semantic.DeepEqual(vm.Spec.VmMetadata, oldVM.Spec.VmMetadata)) {
+		return allErrs, nil
+	}
+
+	var (
+		key            = "user-data"
+		base64Required bool
+	)
+	switch vm.Spec.VmMetadata.Transport {
+	case vmopv1.VirtualMachineMetadataCloudInitTransport:
+	case vmopv1.VirtualMachineMetadataOvfEnvTransport:
+		// The OvfEnv datasource of cloud-init always base64 decodes the user-data.
+		base64Required = true
+	default:
+		return allErrs, nil
+	}
+
+	// A ConfigMap or Secret that does not exist yet is waited for by the controller.
+	data, dataPath, dataName, _, ok := v.getMetadata(ctx, vm)
+	if !ok {
+		return allErrs, nil
+	}
+
+	value := data[key]
+	if value == "" && vm.Spec.VmMetadata.Transport == vmopv1.VirtualMachineMetadataCloudInitTransport {
+		// The CloudInit transport falls back to the 'value' key of CAPBK bootstrap data Secrets.
+		key, value = "value", data["value"]
+	}
+	if value == "" {
+		return allErrs, nil
+	}
+
+	var warnings []string
+	for _, issue := range cloudinit.ValidateUserData(value, base64Required) {
+		msg := fmt.Sprintf(userDataIssueFmt, key, issue.Error())
+		if issue.Warning {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", dataPath, msg))
+		} else {
+			allErrs = append(allErrs, field.Invalid(dataPath, dataName, msg))
+		}
+	}
+
+	return allErrs, warnings
+}
+
+// getMetadata returns the data of the ConfigMap or Secret of the VM metadata, the path and name of the resource,
+// and whether the data is from a Secret. It returns false when the resource does not exist, or the VM metadata
+// does not refer to exactly one resource.
+func (v validator) getMetadata(
+	ctx *context.WebhookRequestContext,
+	vm *vmopv1.VirtualMachine) (data map[string]string, dataPath *field.Path, dataName string, fromSecret, ok bool) {
+
+	mdPath := field.NewPath("spec", "vmMetadata")
+
+	switch {
+	case vm.Spec.VmMetadata.ConfigMapName != "" && vm.Spec.VmMetadata.SecretName == "":
+		cm := &corev1.ConfigMap{}
+		key := types.NamespacedName{Namespace: vm.Namespace, Name: vm.Spec.VmMetadata.ConfigMapName}
+		if err := v.client.Get(ctx, key, cm); err != nil {
+			return nil, nil, "", false, false
+		}
+		return cm.Data, mdPath.Child("configMapName"), cm.Name, false, true
+	case vm.Spec.VmMetadata.SecretName != "" && vm.Spec.VmMetadata.ConfigMapName == "":
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{Namespace: vm.Namespace, Name: vm.Spec.VmMetadata.SecretName}
+		if err := v.client.Get(ctx, key, secret); err != nil {
+			return nil, nil, "", false, false
+		}
+		data = make(map[string]string, len(secret.Data))
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+		return data, mdPath.Child("secretName"), secret.Name, true, true
+	}
+
+	return nil, nil, "", false, false
+}
+
+// validateMetadataOvfProperties validates the keys and values of the OvfEnv transport metadata against the
+// vApp properties of the VM's image. On update, this is only done when the VM metadata has changed so that
+// an update to the image does not prevent unrelated updates to existing VMs. Nothing is validated when the
+// image has not been inspected yet, or the ConfigMap or Secret does not exist yet.
+func (v validator) validateMetadataOvfProperties(ctx *context.WebhookRequestContext, vm, oldVM *vmopv1.VirtualMachine) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if vm.Spec.VmMetadata == nil || vm.Spec.VmMetadata.Transport != vmopv1.VirtualMachineMetadataOvfEnvTransport {
+		return allErrs
+	}
+
+	if oldVM != nil && equality.Semantic.DeepEqual(vm.Spec.VmMetadata, oldVM.Spec.VmMetadata) {
+		return allErrs
+	}
+
+	image, err := vmimage.Get(ctx, v.client, vm.Spec.ImageName)
+	if err != nil {
+		// Missing images are reported by validateImage.
+		return allErrs
+	}
+
+	info, err := contentlibrary.GetVirtualMachineImageInfo(image)
+	if err != nil || info == nil {
+		return allErrs
+	}
+
+	// A ConfigMap or Secret that does not exist yet is waited for by the controller, and a VM metadata that does
+	// not refer to exactly one is reported by validateMetadata.
+	data, dataPath, dataName, fromSecret, ok := v.getMetadata(ctx, vm)
+	if !ok {
+		return allErrs
+	}
+
+	for _, propErr := range vapp.ValidateMetadata(info.OVFProperties, data, fromSecret) {
+		allErrs = append(allErrs, field.Invalid(dataPath, dataName, propErr.Error()))
+	}
+
+	return allErrs
+}
+
+func (v validator) validateAnnotations(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine) field.ErrorList {
+	var allErrs field.ErrorList
+
+	annotationsPath := field.NewPath("metadata", "annotations")
+
+	if policy, ok := vm.Annotations[constants.DeletionPolicyAnnotation]; ok {
+		found := false
+		for _, p := range supportedDeletionPolicies {
+			if policy == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allErrs = append(allErrs, field.NotSupported(annotationsPath.Key(constants.DeletionPolicyAnnotation),
+				policy, supportedDeletionPolicies))
+		}
+	}
+
+	// The VM is already released when it is moved into this Folder, so an invalid MoID must be rejected up front.
+	if val, ok := vm.Annotations[constants.RetainedVMFolderAnnotation]; ok && !folderMoIDRegex.MatchString(val) {
+		allErrs = append(allErrs, field.Invalid(annotationsPath.Key(constants.RetainedVMFolderAnnotation),
+			val, invalidRetainedVMFolder))
+	}
+
+	if val, ok := vm.Annotations[constants.TerminationGracePeriodAnnotation]; ok {
+		if gracePeriod, err := time.ParseDuration(val); err != nil || gracePeriod < 0 {
+			allErrs = append(allErrs, field.Invalid(annotationsPath.Key(constants.TerminationGracePeriodAnnotation),
+				val, invalidTerminationGracePeriod))
+		} else if gracePeriod > lib.MaxVMTerminationGracePeriod {
+			allErrs = append(allErrs, field.Invalid(annotationsPath.Key(constants.TerminationGracePeriodAnnotation),
+				val, fmt.Sprintf(terminationGracePeriodTooLongFmt, lib.MaxVMTerminationGracePeriod)))
+		}
+	}
+
+	if val, ok := vm.Annotations[constants.BootDiskSizeAnnotation]; ok {
+		if size, err := resource.ParseQuantity(val); err != nil || size.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(annotationsPath.Key(constants.BootDiskSizeAnnotation),
+				val, invalidBootDiskSize))
+		}
+	}
+
+	if val, ok := vm.Annotations[constants.EjectISOAnnotation]; ok && val != "true" && val != "false" {
+		allErrs = append(allErrs, field.NotSupported(annotationsPath.Key(constants.EjectISOAnnotation),
+			val, []string{"true", "false"}))
+	}
+
+	// The v1alpha2 spec that v1alpha1 cannot represent is only kept in this annotation, so the controllers do not
+	// act on it. Deny the v1alpha2 fields that they do not support yet rather than silently ignoring them.
+	if val, ok := vm.Annotations[vmopapiv1alpha2.V1alpha2SpecAnnotation]; ok {
+		spec := vmopapiv1alpha2.VirtualMachineSpec{}
+		if err := json.Unmarshal([]byte(val), &spec); err != nil {
+			allErrs = append(allErrs, field.Invalid(annotationsPath.Key(vmopapiv1alpha2.V1alpha2SpecAnnotation),
+				val, err.Error()))
+		} else if spec.Network != nil {
+			interfacesPath := field.NewPath("spec", "network", "interfaces")
+			for i, nif := range spec.Network.Interfaces {
+				if nif.IPConfig != nil {
+					allErrs = append(allErrs, field.Forbidden(interfacesPath.Index(i).Child("ipConfig"),
+						ipConfigNotSupported))
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateExtraConfig validates the ExtraConfig annotation of the VM. Only the keys that are added or changed by a
+// non-administrator are checked against the ExtraConfig policy of the namespace, so that an administrator can set
+// any key and the other users can still update the VM.
+func (v validator) validateExtraConfig(ctx *context.WebhookRequestContext, vm, oldVM *vmopv1.VirtualMachine) field.ErrorList {
+	var allErrs field.ErrorList
+
+	extraConfigPath := field.NewPath("metadata", "annotations").Key(constants.ExtraConfigAnnotation)
+
+	extraConfig, err := extraconfig.FromAnnotations(vm.Annotations)
+	if err != nil {
+		return append(allErrs, field.Invalid(extraConfigPath, vm.Annotations[constants.ExtraConfigAnnotation], err.Error()))
+	}
+	if len(extraConfig) == 0 {
+		return allErrs
+	}
+
+	if auth.IsPODServiceAccountUser(*ctx.UserInfo) || auth.IsKubernetesAdmin(*ctx.UserInfo) {
+		return allErrs
+	}
+
+	var oldExtraConfig map[string]string
+	if oldVM != nil {
+		// The old annotation is ignored when it is invalid, so all the keys are checked.
+		oldExtraConfig, _ = extraconfig.FromAnnotations(oldVM.Annotations)
+	}
+
+	notAllowed, err := extraconfig.NotAllowedKeys(ctx, v.client, vm.Namespace, extraConfig, oldExtraConfig)
+	if err != nil {
+		return append(allErrs, field.InternalError(extraConfigPath, err))
+	}
+	for _, k := range notAllowed {
+		allErrs = append(allErrs, field.Forbidden(extraConfigPath, fmt.Sprintf(extraConfigKeyNotAllowedFmt, k)))
+	}
+
 	return allErrs
 }
 
@@ -234,9 +524,10 @@ func (v validator) validateImage(ctx *context.WebhookRequestContext, vm *vmopv1.
 		return allErrs
 	}
 
-	image := vmopv1.VirtualMachineImage{}
+	// The image may be referred to by either its name or its display name.
 	imageName := vm.Spec.ImageName
-	if err := v.client.Get(ctx, types.NamespacedName{Name: imageName}, &image); err != nil {
+	image, err := vmimage.Get(ctx, v.client, imageName)
+	if err != nil {
 		return append(allErrs, field.Invalid(imageNamePath, imageName, err.Error()))
 	}
 	if image.Status.ImageSupported != nil && !*image.Status.ImageSupported {
@@ -246,6 +537,76 @@ func (v validator) validateImage(ctx *context.WebhookRequestContext, vm *vmopv1.
 	return allErrs
 }
 
+// validateImageTrust validates that the VM's image is allowed by the stricter of the namespace's and the cluster's
+// image trust policy.
+func (v validator) validateImageTrust(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine) field.ErrorList {
+	var allErrs field.ErrorList
+
+	imageNamePath := field.NewPath("spec", "imageName")
+
+	if vm.Spec.ImageName == "" {
+		return allErrs
+	}
+
+	policies := []string{lib.GetImageTrustPolicy()}
+	namespace := &corev1.Namespace{}
+	if err := v.client.Get(ctx, types.NamespacedName{Name: vm.Namespace}, namespace); err == nil {
+		policies = append(policies, namespace.Annotations[vmopapiv1alpha1.VirtualMachineImageTrustPolicyAnnotation])
+	} else if !apierrors.IsNotFound(err) {
+		return append(allErrs, field.InternalError(imageNamePath, err))
+	}
+
+	policy := vmimage.StricterTrustPolicy(policies...)
+	if policy == vmopapiv1alpha1.AllowVirtualMachineImageTrustPolicy {
+		return allErrs
+	}
+
+	// Missing images are reported by validateImage.
+	image, err := vmimage.Get(ctx, v.client, vm.Spec.ImageName)
+	if err != nil {
+		return allErrs
+	}
+
+	if err := vmimage.CheckTrust(image, policy); err != nil {
+		allErrs = append(allErrs, field.Forbidden(imageNamePath, fmt.Sprintf(imageNotTrustedFmt, policy, err)))
+	}
+
+	return allErrs
+}
+
+// deprecatedImageWarnings returns a warning when the VM's image is deprecated because its content library item
+// was removed.
+func (v validator) deprecatedImageWarnings(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine) []string {
+	if vm.Spec.ImageName == "" {
+		return nil
+	}
+
+	image, err := vmimage.Get(ctx, v.client, vm.Spec.ImageName)
+	if err != nil {
+		return nil
+	}
+
+	if _, ok := image.Annotations[vmopapiv1alpha1.VirtualMachineImageDeprecatedAnnotation]; !ok {
+		return nil
+	}
+
+	return []string{fmt.Sprintf(deprecatedImageWarningFmt, image.Name)}
+}
+
+// metadataTransportWarnings returns a warning when the VM metadata uses the ExtraConfig transport. On update, the
+// warning is only returned when the transport has changed.
+func (v validator) metadataTransportWarnings(vm, oldVM *vmopv1.VirtualMachine) []string {
+	if vm.Spec.VmMetadata == nil || vm.Spec.VmMetadata.Transport != vmopv1.VirtualMachineMetadataExtraConfigTransport {
+		return nil
+	}
+	if oldVM != nil && oldVM.Spec.VmMetadata != nil && oldVM.Spec.VmMetadata.Transport == vm.Spec.VmMetadata.Transport {
+		return nil
+	}
+
+	transportPath := field.NewPath("spec", "vmMetadata", "transport")
+	return []string{fmt.Sprintf("%s: %s", transportPath, extraConfigTransportWarning)}
+}
+
 func (v validator) validateClass(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine) field.ErrorList {
 	var allErrs field.ErrorList
 
@@ -256,6 +617,115 @@ func (v validator) validateClass(ctx *context.WebhookRequestContext, vm *vmopv1.
 	return allErrs
 }
 
+// validateImageClassCompatibility validates that the VM can be created from its image and class. The guest OS
+// is not checked since the cluster's guest OS identifiers are only known to the provider. On update, this is
+// only done when the annotations that the compatibility depends on have changed, since the image and class
+// cannot be changed. Incompatibilities that do not prevent the VM from being created are returned as warnings.
+func (v validator) validateImageClassCompatibility(ctx *context.WebhookRequestContext, vm, oldVM *vmopv1.VirtualMachine) (field.ErrorList, []string) {
+	var allErrs field.ErrorList
+	var warnings []string
+
+	if vm.Spec.ImageName == "" || vm.Spec.ClassName == "" {
+		return allErrs, warnings
+	}
+
+	if oldVM != nil &&
+		vm.Annotations[constants.FirmwareOverrideAnnotation] == oldVM.Annotations[constants.FirmwareOverrideAnnotation] &&
+		vm.Annotations[constants.PCIPassthruMMIOOverrideAnnotation] == oldVM.Annotations[constants.PCIPassthruMMIOOverrideAnnotation] {
+		return allErrs, warnings
+	}
+
+	// Missing images and classes are reported by validateImage and the controller.
+	image, err := vmimage.Get(ctx, v.client, vm.Spec.ImageName)
+	if err != nil {
+		return allErrs, warnings
+	}
+	class := &vmopv1.VirtualMachineClass{}
+	if err := v.client.Get(ctx, types.NamespacedName{Name: vm.Spec.ClassName}, class); err != nil {
+		return allErrs, warnings
+	}
+
+	// An image whose info cannot be read is treated as not inspected.
+	info, _ := contentlibrary.GetVirtualMachineImageInfo(image)
+
+	for _, i := range compatibility.Evaluate(compatibility.Args{VM: vm, VMClass: class, VMImage: image, VMImageInfo: info}) {
+		var err *field.Error
+		switch i.Subject {
+		case compatibility.SubjectFirmware:
+			err = field.Invalid(field.NewPath("metadata", "annotations").Key(constants.FirmwareOverrideAnnotation),
+				vm.Annotations[constants.FirmwareOverrideAnnotation], i.Message)
+		case compatibility.SubjectClass:
+			err = field.Invalid(field.NewPath("spec", "className"), vm.Spec.ClassName, i.Message)
+		default:
+			err = field.Invalid(field.NewPath("spec", "imageName"), vm.Spec.ImageName, i.Message)
+		}
+		if i.Warning {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", err.Field, i.Message))
+			continue
+		}
+		allErrs = append(allErrs, err)
+	}
+
+	return allErrs, warnings
+}
+
+// validateQuota validates that a new VM does not exceed the VirtualMachineQuotas of its namespace. The usage of the
+// other VMs is computed from the VMs themselves rather than from the quota status, which the controller updates
+// asynchronously. Updates are not checked, since the usage of a VM only depends on its class, which is immutable.
+//
+// This is best-effort: the other VMs are listed from the informer cache and nothing is reserved, so VMs that are
+// created concurrently may together exceed a quota. The quota status then reports the usage above the limit.
+func (v validator) validateQuota(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if vm.Spec.ClassName == "" {
+		return allErrs
+	}
+
+	classNamePath := field.NewPath("spec", "className")
+
+	quotas, err := vmquota.List(ctx, v.client, vm.Namespace)
+	if err != nil {
+		return append(allErrs, field.InternalError(classNamePath, err))
+	}
+	if len(quotas) == 0 {
+		return allErrs
+	}
+
+	// A missing class is reported by the controller, so the VM is then only counted.
+	var vmClass *vmopv1.VirtualMachineClass
+	class := &vmopv1.VirtualMachineClass{}
+	if err := v.client.Get(ctx, types.NamespacedName{Name: vm.Spec.ClassName}, class); err == nil {
+		vmClass = class
+	}
+	requested := vmquota.Usage(vm, vmClass)
+
+	used, err := vmquota.NamespaceUsage(ctx, v.client, vm.Namespace, func(other *vmopv1.VirtualMachine) bool {
+		return vm.Name != "" && other.Name == vm.Name
+	})
+	if err != nil {
+		return append(allErrs, field.InternalError(classNamePath, err))
+	}
+
+	for _, quota := range quotas {
+		exceeded := vmquota.Exceeded(quota.Spec.Hard, used, requested)
+		if len(exceeded) == 0 {
+			continue
+		}
+
+		details := make([]string, 0, len(exceeded))
+		for _, name := range exceeded {
+			requestedQuantity, usedQuantity, limit := requested[name], used[name], quota.Spec.Hard[name]
+			details = append(details, fmt.Sprintf("%s: requested %s, used %s, limited %s",
+				name, requestedQuantity.String(), usedQuantity.String(), limit.String()))
+		}
+		allErrs = append(allErrs, field.Forbidden(classNamePath,
+			fmt.Sprintf(quotaExceededFmt, quota.Name, strings.Join(details, ", "))))
+	}
+
+	return allErrs
+}
+
 func (v validator) validateStorageClass(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine) field.ErrorList {
 	var allErrs field.ErrorList
 
@@ -401,20 +871,46 @@ func (v validator) validateVolumes(ctx *context.WebhookRequestContext, vm *vmopv
 	return allErrs
 }
 
+// volumeWarnings returns a warning for each vSphere volume of a disk that has no capacity, since the disk is
+// resized to the capacity. On update, the warning is only returned for volumes that are added.
+func (v validator) volumeWarnings(vm, oldVM *vmopv1.VirtualMachine) []string {
+	var warnings []string
+
+	oldVolumes := map[string]struct{}{}
+	if oldVM != nil {
+		for _, vol := range oldVM.Spec.Volumes {
+			oldVolumes[vol.Name] = struct{}{}
+		}
+	}
+
+	volumesPath := field.NewPath("spec", "volumes")
+	for i, vol := range vm.Spec.Volumes {
+		if vol.VsphereVolume == nil || vol.VsphereVolume.DeviceKey == nil || vol.PersistentVolumeClaim != nil {
+			continue
+		}
+		if _, ok := oldVolumes[vol.Name]; ok {
+			continue
+		}
+		if _, ok := vol.VsphereVolume.Capacity[corev1.ResourceEphemeralStorage]; !ok {
+			capacityPath := volumesPath.Index(i).Child("vsphereVolume", "capacity")
+			warnings = append(warnings, fmt.Sprintf("%s: %s", capacityPath, vSphereVolumeNoCapacityWarning))
+		}
+	}
+
+	return warnings
+}
+
 func (v validator) validateVolumeWithPVC(ctx *context.WebhookRequestContext, vm *vmopv1.VirtualMachine,
 	vol vmopv1.VirtualMachineVolume, volPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 
 	imageNamePath := field.NewPath("spec", "imageName")
-	image := vmopv1.VirtualMachineImage{}
-	err := v.client.Get(ctx, types.NamespacedName{Name: vm.Spec.ImageName}, &image)
+	image, err := vmimage.Get(ctx, v.client, vm.Spec.ImageName)
 	if err != nil {
 		allErrs = append(allErrs, field.Invalid(imageNamePath, vm.Spec.ImageName,
 			fmt.Sprintf("error validating image for PVC: %v", err)))
-	}
-
-	// Check that the VirtualMachineImage's hardware version is at least the minimum supported virtual hardware version
-	if image.Spec.HardwareVersion != 0 && image.Spec.HardwareVersion < constants.MinSupportedHWVersionForPVC {
+	} else if image.Spec.HardwareVersion != 0 && image.Spec.HardwareVersion < constants.MinSupportedHWVersionForPVC {
+		// Check that the VirtualMachineImage's hardware version is at least the minimum supported virtual hardware version
 		allErrs = append(allErrs, field.Invalid(imageNamePath, vm.Spec.ImageName,
 			fmt.Sprintf(pvcHardwareVersionNotSupportedFmt, image.Spec.HardwareVersion, constants.MinSupportedHWVersionForPVC)))
 	}
@@ -501,6 +997,22 @@ func (v validator) validateReadinessProbe(ctx *context.WebhookRequestContext, vm
 	return allErrs
 }
 
+// readinessProbeWarnings returns a warning when the TCP readiness probe connects to a host other than the VM. On
+// update, the warning is only returned when the host has changed.
+func (v validator) readinessProbeWarnings(vm, oldVM *vmopv1.VirtualMachine) []string {
+	probe := vm.Spec.ReadinessProbe
+	if probe == nil || probe.TCPSocket == nil || probe.TCPSocket.Host == "" {
+		return nil
+	}
+	if oldVM != nil && oldVM.Spec.ReadinessProbe != nil && oldVM.Spec.ReadinessProbe.TCPSocket != nil &&
+		oldVM.Spec.ReadinessProbe.TCPSocket.Host == probe.TCPSocket.Host {
+		return nil
+	}
+
+	hostPath := field.NewPath("spec", "readinessProbe", "tcpSocket", "host")
+	return []string{fmt.Sprintf("%s: %s", hostPath, fmt.Sprintf(tcpProbeHostWarningFmt, probe.TCPSocket.Host))}
+}
+
 func (v validator) validateUpdatesWhenPoweredOn(ctx *context.WebhookRequestContext, vm, oldVM *vmopv1.VirtualMachine) field.ErrorList {
 	var allErrs field.ErrorList
 
@@ -4,6 +4,7 @@
 package validation_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
@@ -24,6 +25,9 @@ import (
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
 
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	vmopapiv1alpha2 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	"github.com/vmware-tanzu/vm-operator/pkg/conditions"
 	"github.com/vmware-tanzu/vm-operator/pkg/lib"
 	"github.com/vmware-tanzu/vm-operator/pkg/topology"
 	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/config"
@@ -94,6 +98,15 @@ func setConfigMap(isRestrictedEnv bool) *corev1.ConfigMap {
 	return configMapIn
 }
 
+func setImageOVFProperties(image *vmopv1.VirtualMachineImage, properties ...vmopapiv1alpha1.VirtualMachineImageOVFProperty) {
+	data, err := json.Marshal(vmopapiv1alpha1.VirtualMachineImageInfo{OVFProperties: properties})
+	Expect(err).ToNot(HaveOccurred())
+	if image.Annotations == nil {
+		image.Annotations = map[string]string{}
+	}
+	image.Annotations[vmopapiv1alpha1.VirtualMachineImageInfoAnnotation] = string(data)
+}
+
 func setReadinessProbe(validPortProbe bool) *vmopv1.Probe {
 	portValue := 6443
 	if !validPortProbe {
@@ -117,6 +130,8 @@ func unitTestsValidateCreate() {
 	type createArgs struct {
 		invalidImageName                     bool
 		imageNotFound                        bool
+		imageByDisplayName                   bool
+		ambiguousImageDisplayName            bool
 		invalidClassName                     bool
 		invalidNetworkName                   bool
 		invalidNetworkType                   bool
@@ -152,6 +167,33 @@ func unitTestsValidateCreate() {
 		isWCPInstanceStorageFSSEnabled       bool
 		isServiceUser                        bool
 		addInstanceStorageVolumes            bool
+		invalidDeletionPolicy                bool
+		retainDeletionPolicy                 bool
+		invalidTerminationGracePeriod        bool
+		longTerminationGracePeriod           bool
+		invalidRetainedVMFolder              bool
+		invalidBootDiskSize                  bool
+		invalidEjectISO                      bool
+		v1alpha2IPConfig                     bool
+		invalidOvfEnvMetadata                bool
+		invalidFirmwareOverride              bool
+		vGPUClassWithOldHardwareVersion      bool
+		vGPUClassWithBIOSFirmware            bool
+		deprecatedImage                      bool
+		namespaceTrustPolicy                 string
+		clusterTrustPolicy                   string
+		unsignedImage                        bool
+		untrustedImage                       bool
+		quotaWithRoom                        bool
+		quotaExceeded                        bool
+		invalidCloudInitUserData             bool
+		unknownKeyCloudInitUserData          bool
+		unencodedOvfEnvUserData              bool
+		tcpProbeHost                         bool
+		vSphereVolumeWithoutCapacity         bool
+		invalidExtraConfig                   bool
+		extraConfigKey                       string
+		extraConfigPolicy                    *vmopapiv1alpha1.ExtraConfigPolicy
 	}
 
 	validateCreate := func(args createArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
@@ -166,6 +208,16 @@ func unitTestsValidateCreate() {
 		if args.imageNotFound {
 			ctx.vm.Spec.ImageName = "image-does-not-exist"
 		}
+		if args.imageByDisplayName || args.ambiguousImageDisplayName {
+			ctx.vmImage.Status.ImageName = "image-display-name"
+			Expect(ctx.Client.Status().Update(ctx, ctx.vmImage)).To(Succeed())
+			ctx.vm.Spec.ImageName = ctx.vmImage.Status.ImageName
+		}
+		if args.ambiguousImageDisplayName {
+			otherImage := builder.DummyVirtualMachineImage("other-image")
+			otherImage.Status.ImageName = ctx.vmImage.Status.ImageName
+			Expect(ctx.Client.Create(ctx, otherImage)).To(Succeed())
+		}
 		if args.imageNonCompatible {
 			ctx.vmImage.Status.ImageSupported = &[]bool{false}[0]
 			Expect(ctx.Client.Status().Update(ctx, ctx.vmImage)).ToNot(HaveOccurred())
@@ -280,6 +332,17 @@ func unitTestsValidateCreate() {
 			ctx.vm.Spec.ReadinessProbe = setReadinessProbe(args.isRestrictedNetworkValidProbePort)
 			Expect(ctx.Client.Create(ctx, configMapIn)).To(Succeed())
 		}
+		if args.tcpProbeHost {
+			ctx.vm.Spec.ReadinessProbe = &vmopv1.Probe{
+				TCPSocket: &vmopv1.TCPSocketAction{Port: intstr.FromInt(6443), Host: "10.0.0.1"},
+			}
+			Expect(ctx.Client.Create(ctx, setConfigMap(false))).To(Succeed())
+		}
+		if args.vSphereVolumeWithoutCapacity {
+			deviceKey := 2000
+			ctx.vm.Spec.Volumes[0].PersistentVolumeClaim = nil
+			ctx.vm.Spec.Volumes[0].VsphereVolume = &vmopv1.VsphereVolumeSource{DeviceKey: &deviceKey}
+		}
 		if args.isServiceUser {
 			Expect(os.Setenv("POD_SERVICE_ACCOUNT_NAME", "default")).To(Succeed())
 			Expect(os.Setenv("POD_NAMESPACE", "vmware-system-vmop")).To(Succeed())
@@ -289,6 +352,152 @@ func unitTestsValidateCreate() {
 			instanceStorageVolume := builder.DummyInstanceStorageVirtualMachineVolumes()
 			ctx.vm.Spec.Volumes = append(ctx.vm.Spec.Volumes, instanceStorageVolume...)
 		}
+		if args.invalidDeletionPolicy {
+			ctx.vm.Annotations[constants.DeletionPolicyAnnotation] = "bogus"
+		}
+		if args.retainDeletionPolicy {
+			ctx.vm.Annotations[constants.DeletionPolicyAnnotation] = constants.DeletionPolicyRetain
+		}
+		if args.invalidTerminationGracePeriod {
+			ctx.vm.Annotations[constants.TerminationGracePeriodAnnotation] = "-1m"
+		}
+		if args.longTerminationGracePeriod {
+			ctx.vm.Annotations[constants.TerminationGracePeriodAnnotation] = "24h"
+		}
+		if args.invalidRetainedVMFolder {
+			ctx.vm.Annotations[constants.RetainedVMFolderAnnotation] = "vm-42"
+		}
+		if args.invalidBootDiskSize {
+			ctx.vm.Annotations[constants.BootDiskSizeAnnotation] = "40G1"
+		}
+		if args.invalidEjectISO {
+			ctx.vm.Annotations[constants.EjectISOAnnotation] = "yes"
+		}
+		if args.v1alpha2IPConfig {
+			ctx.vm.Annotations[vmopapiv1alpha2.V1alpha2SpecAnnotation] =
+				`{"network":{"interfaces":[{"networkName":"dummy-nw"},{"networkName":"dummy-nw","ipConfig":{"addresses":["192.0.2.10/24"]}}]}}`
+		}
+		if args.invalidOvfEnvMetadata {
+			ctx.vm.Spec.VmMetadata.Transport = vmopv1.VirtualMachineMetadataOvfEnvTransport
+			setImageOVFProperties(ctx.vmImage, vmopapiv1alpha1.VirtualMachineImageOVFProperty{
+				Key: "hostname", Type: "string", UserConfigurable: true,
+			})
+			Expect(ctx.Client.Update(ctx, ctx.vmImage)).To(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: ctx.vm.Spec.VmMetadata.ConfigMapName, Namespace: ctx.vm.Namespace},
+				Data:       map[string]string{"bogus": "value"},
+			}
+			Expect(ctx.Client.Create(ctx, cm)).To(Succeed())
+		}
+		if args.invalidFirmwareOverride || args.vGPUClassWithOldHardwareVersion || args.vGPUClassWithBIOSFirmware {
+			vmClass := builder.DummyVirtualMachineClass()
+			vmClass.GenerateName = ""
+			vmClass.Name = ctx.vm.Spec.ClassName
+			if args.vGPUClassWithOldHardwareVersion {
+				vmClass.Spec.Hardware.Devices.VGPUDevices = []vmopv1.VGPUDevice{{ProfileName: "dummy-profile"}}
+				ctx.vmImage.Spec.HardwareVersion = 15
+				Expect(ctx.Client.Update(ctx, ctx.vmImage)).To(Succeed())
+				ctx.vm.Annotations[constants.PCIPassthruMMIOOverrideAnnotation] = "0"
+			}
+			if args.vGPUClassWithBIOSFirmware {
+				vmClass.Spec.Hardware.Devices.VGPUDevices = []vmopv1.VGPUDevice{{ProfileName: "dummy-profile"}}
+				ctx.vm.Annotations[constants.FirmwareOverrideAnnotation] = "bios"
+			}
+			Expect(ctx.Client.Create(ctx, vmClass)).To(Succeed())
+		}
+		if args.invalidFirmwareOverride {
+			ctx.vm.Annotations[constants.FirmwareOverrideAnnotation] = "uefi"
+		}
+		if args.invalidCloudInitUserData || args.unknownKeyCloudInitUserData || args.unencodedOvfEnvUserData {
+			userData := "#cloud-config\nruncmd:\n- echo hello\n"
+			ctx.vm.Spec.VmMetadata.Transport = vmopv1.VirtualMachineMetadataCloudInitTransport
+			switch {
+			case args.invalidCloudInitUserData:
+				userData = "#cloud-config\nruncmd:\n\t- echo hello\n"
+			case args.unknownKeyCloudInitUserData:
+				userData = "#cloud-config\nusres:\n- name: vmware\n"
+			case args.unencodedOvfEnvUserData:
+				ctx.vm.Spec.VmMetadata.Transport = vmopv1.VirtualMachineMetadataOvfEnvTransport
+			}
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: ctx.vm.Spec.VmMetadata.ConfigMapName, Namespace: ctx.vm.Namespace},
+				Data:       map[string]string{"user-data": userData},
+			}
+			Expect(ctx.Client.Create(ctx, cm)).To(Succeed())
+		}
+		if args.quotaWithRoom || args.quotaExceeded {
+			limit := "2"
+			if args.quotaExceeded {
+				limit = "1"
+			}
+			quota := &vmopapiv1alpha1.VirtualMachineQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ctx.vm.Namespace, Name: "dummy-quota"},
+				Spec: vmopapiv1alpha1.VirtualMachineQuotaSpec{
+					Hard: corev1.ResourceList{
+						vmopapiv1alpha1.VirtualMachineQuotaVirtualMachines: resource.MustParse(limit),
+					},
+				},
+			}
+			Expect(ctx.Client.Create(ctx, quota)).To(Succeed())
+			otherVM := builder.DummyVirtualMachine()
+			otherVM.Namespace = ctx.vm.Namespace
+			otherVM.Name = "other-vm"
+			Expect(ctx.Client.Create(ctx, otherVM)).To(Succeed())
+		}
+		if args.invalidExtraConfig {
+			ctx.vm.Annotations[constants.ExtraConfigAnnotation] = "invalid"
+		}
+		if args.extraConfigKey != "" {
+			ctx.vm.Annotations[constants.ExtraConfigAnnotation] = fmt.Sprintf(`{%q:"value"}`, args.extraConfigKey)
+		}
+		if args.extraConfigPolicy != nil {
+			defaults := &vmopapiv1alpha1.VirtualMachineDefaults{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ctx.vm.Namespace, Name: vmopapiv1alpha1.VirtualMachineDefaultsName},
+				Spec: vmopapiv1alpha1.VirtualMachineDefaultsSpec{
+					ExtraConfigPolicy: args.extraConfigPolicy,
+				},
+			}
+			Expect(ctx.Client.Create(ctx, defaults)).To(Succeed())
+		}
+		if args.deprecatedImage {
+			ctx.vmImage.Annotations = map[string]string{
+				vmopapiv1alpha1.VirtualMachineImageDeprecatedAnnotation: "2021-01-01T00:00:00Z",
+			}
+			Expect(ctx.Client.Update(ctx, ctx.vmImage)).To(Succeed())
+		}
+		if args.namespaceTrustPolicy != "" {
+			ctx.vm.Namespace = "trust-policy-namespace"
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: ctx.vm.Namespace,
+					Annotations: map[string]string{
+						vmopapiv1alpha1.VirtualMachineImageTrustPolicyAnnotation: args.namespaceTrustPolicy,
+					},
+				},
+			}
+			Expect(ctx.Client.Create(ctx, namespace)).To(Succeed())
+		}
+		if args.clusterTrustPolicy != "" {
+			Expect(os.Setenv(lib.ImageTrustPolicyEnv, args.clusterTrustPolicy)).To(Succeed())
+		}
+		if args.unsignedImage || args.untrustedImage {
+			// The image was verified for its current content library item version.
+			if ctx.vmImage.Annotations == nil {
+				ctx.vmImage.Annotations = map[string]string{}
+			}
+			ctx.vmImage.Annotations[vmopapiv1alpha1.VirtualMachineImageTrustVersionAnnotation] =
+				ctx.vmImage.Annotations[constants.VMImageCLVersionAnnotation]
+			Expect(ctx.Client.Update(ctx, ctx.vmImage)).To(Succeed())
+			conditions.MarkTrue(ctx.vmImage, vmopapiv1alpha1.VirtualMachineImageManifestVerifiedCondition)
+			if args.unsignedImage {
+				conditions.MarkFalse(ctx.vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+					vmopapiv1alpha1.VirtualMachineImageUnsignedReason, vmopv1.ConditionSeverityInfo, "no .cert file")
+			} else {
+				conditions.MarkFalse(ctx.vmImage, vmopapiv1alpha1.VirtualMachineImageSignatureVerifiedCondition,
+					vmopapiv1alpha1.VirtualMachineImageUntrustedCertificateReason, vmopv1.ConditionSeverityError, "unknown authority")
+			}
+			Expect(ctx.Client.Status().Update(ctx, ctx.vmImage)).To(Succeed())
+		}
 		lib.IsInstanceStorageFSSEnabled = func() bool {
 			return args.isWCPInstanceStorageFSSEnabled
 		}
@@ -330,6 +539,38 @@ func unitTestsValidateCreate() {
 		if expectedErr != nil {
 			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
 		}
+
+		var expectedWarnings []interface{}
+		if ctx.vm.Spec.VmMetadata != nil && ctx.vm.Spec.VmMetadata.Transport == vmopv1.VirtualMachineMetadataExtraConfigTransport {
+			expectedWarnings = append(expectedWarnings,
+				HavePrefix("spec.vmMetadata.transport: the ExtraConfig transport is deprecated"))
+		}
+		if args.deprecatedImage {
+			expectedWarnings = append(expectedWarnings,
+				ContainSubstring("VirtualMachineImage %s is deprecated", ctx.vmImage.Name))
+		}
+		if args.unknownKeyCloudInitUserData {
+			expectedWarnings = append(expectedWarnings,
+				`spec.vmMetadata.configMapName: user-data line 2: unknown top-level key "usres" is ignored by cloud-init`)
+		}
+		if args.unencodedOvfEnvUserData {
+			expectedWarnings = append(expectedWarnings,
+				HavePrefix("spec.vmMetadata.configMapName: user-data should be base64 encoded"))
+		}
+		if args.tcpProbeHost {
+			expectedWarnings = append(expectedWarnings,
+				"spec.readinessProbe.tcpSocket.host: host 10.0.0.1 is probed instead of the VM's IP, so the VM can be reported ready when it is not")
+		}
+		if args.vSphereVolumeWithoutCapacity {
+			expectedWarnings = append(expectedWarnings,
+				HavePrefix("spec.volumes[0].vsphereVolume.capacity: ephemeral-storage capacity is not set"))
+		}
+		if args.vGPUClassWithBIOSFirmware {
+			expectedWarnings = append(expectedWarnings,
+				HavePrefix(fmt.Sprintf(`spec.className: VirtualMachineClass %s has vGPU or DirectPath devices that may require "efi" firmware`,
+					builder.DummyClassName)))
+		}
+		Expect(response.Warnings).To(ConsistOf(expectedWarnings...))
 	}
 
 	BeforeEach(func() {
@@ -349,6 +590,7 @@ func unitTestsValidateCreate() {
 			Expect(os.Unsetenv("POD_NAMESPACE")).To(Succeed())
 		}
 		Expect(os.Unsetenv(lib.VmopNamespaceEnv)).To(Succeed())
+		Expect(os.Unsetenv(lib.ImageTrustPolicyEnv)).To(Succeed())
 	})
 
 	specPath := field.NewPath("spec")
@@ -362,6 +604,9 @@ func unitTestsValidateCreate() {
 			field.Required(specPath.Child("imageName"), "").Error(), nil),
 		Entry("should deny image that does not exist", createArgs{imageNotFound: true}, false,
 			field.Invalid(specPath.Child("imageName"), "image-does-not-exist", "").Error(), nil),
+		Entry("should allow an image referred to by its display name", createArgs{imageByDisplayName: true}, true, nil, nil),
+		Entry("should deny an ambiguous image display name", createArgs{ambiguousImageDisplayName: true}, false,
+			`display name "image-display-name" matches multiple VirtualMachineImages`, nil),
 		Entry("should fail when Readiness probe has multiple actions", createArgs{invalidReadinessProbe: true}, false,
 			field.Forbidden(specPath.Child("readinessProbe"), "only one action can be specified").Error(), nil),
 		Entry("should fail when Readiness probe has no actions", createArgs{invalidReadinessNoProbe: true}, false,
@@ -433,6 +678,78 @@ func unitTestsValidateCreate() {
 		Entry("should deny when there are instance storage volumes with WCP Instance Storage FSS enabled and user is SSO user", createArgs{addInstanceStorageVolumes: true, isWCPInstanceStorageFSSEnabled: true}, false,
 			field.Forbidden(volPath, "adding or modifying instance storage volume(s) is not allowed").Error(), nil),
 		Entry("should allow when there are instance storage volumes with WCP Instance Storage FSS enabled and user is service user", createArgs{addInstanceStorageVolumes: true, isWCPInstanceStorageFSSEnabled: true, isServiceUser: true}, true, nil, nil),
+		Entry("should allow a supported deletion policy", createArgs{retainDeletionPolicy: true}, true, nil, nil),
+		Entry("should deny an unsupported deletion policy", createArgs{invalidDeletionPolicy: true}, false,
+			field.NotSupported(field.NewPath("metadata", "annotations").Key(constants.DeletionPolicyAnnotation), "bogus",
+				[]string{constants.DeletionPolicyDelete, constants.DeletionPolicyRetain, constants.DeletionPolicyOrphan}).Error(), nil),
+		Entry("should deny a negative termination grace period", createArgs{invalidTerminationGracePeriod: true}, false,
+			field.Invalid(field.NewPath("metadata", "annotations").Key(constants.TerminationGracePeriodAnnotation), "-1m",
+				"must be a non-negative duration").Error(), nil),
+		Entry("should deny a termination grace period longer than the maximum", createArgs{longTerminationGracePeriod: true}, false,
+			field.Invalid(field.NewPath("metadata", "annotations").Key(constants.TerminationGracePeriodAnnotation), "24h",
+				"must be at most 1h0m0s").Error(), nil),
+		Entry("should deny a retained VM folder that is not a Folder MoID", createArgs{invalidRetainedVMFolder: true}, false,
+			field.Invalid(field.NewPath("metadata", "annotations").Key(constants.RetainedVMFolderAnnotation), "vm-42",
+				"must be the managed object ID of a Folder").Error(), nil),
+		Entry("should deny an invalid boot disk size", createArgs{invalidBootDiskSize: true}, false,
+			field.Invalid(field.NewPath("metadata", "annotations").Key(constants.BootDiskSizeAnnotation), "40G1",
+				"must be a positive quantity").Error(), nil),
+		Entry("should deny an unsupported eject ISO value", createArgs{invalidEjectISO: true}, false,
+			field.NotSupported(field.NewPath("metadata", "annotations").Key(constants.EjectISOAnnotation), "yes",
+				[]string{"true", "false"}).Error(), nil),
+		Entry("should deny the IP configuration of a v1alpha2 network interface", createArgs{v1alpha2IPConfig: true}, false,
+			field.Forbidden(specPath.Child("network", "interfaces").Index(1).Child("ipConfig"),
+				"static IP configuration of network interfaces is not supported yet").Error(), nil),
+		Entry("should deny OvfEnv metadata keys that are not vApp properties of the image", createArgs{invalidOvfEnvMetadata: true}, false,
+			field.Invalid(specPath.Child("vmMetadata", "configMapName"), builder.DummyMetadataCMName,
+				`key "bogus" is not a vApp property of the image`).Error(), nil),
+		Entry("should deny an unsupported firmware override", createArgs{invalidFirmwareOverride: true}, false,
+			field.Invalid(field.NewPath("metadata", "annotations").Key(constants.FirmwareOverrideAnnotation), "uefi",
+				`firmware "uefi" is not supported, must be one of "bios" or "efi"`).Error(), nil),
+		Entry("should deny a class with vGPU devices for an image with an old hardware version", createArgs{vGPUClassWithOldHardwareVersion: true}, false,
+			fmt.Sprintf("VirtualMachineClass %s has vGPU or DirectPath devices that require a minimum hardware version of %d",
+				builder.DummyClassName, constants.MinSupportedHWVersionForPCIPassthruDevices), nil),
+		Entry("should allow a class with vGPU devices with BIOS firmware with a warning", createArgs{vGPUClassWithBIOSFirmware: true}, true, nil, nil),
+		Entry("should allow a deprecated image with a warning", createArgs{deprecatedImage: true}, true, nil, nil),
+		Entry("should allow an untrusted image without an image trust policy", createArgs{untrustedImage: true}, true, nil, nil),
+		Entry("should allow an unsigned image when the namespace rejects untrusted images",
+			createArgs{unsignedImage: true, namespaceTrustPolicy: "RejectUntrusted"}, true, nil, nil),
+		Entry("should deny an untrusted image when the namespace rejects untrusted images",
+			createArgs{untrustedImage: true, namespaceTrustPolicy: "RejectUntrusted"}, false,
+			field.Forbidden(specPath.Child("imageName"),
+				"VirtualMachineImage is not allowed by the RejectUntrusted image trust policy: image is not trusted: unknown authority").Error(), nil),
+		Entry("should deny an unsigned image when the cluster requires trusted images",
+			createArgs{unsignedImage: true, clusterTrustPolicy: "RequireTrusted", namespaceTrustPolicy: "Allow"}, false,
+			field.Forbidden(specPath.Child("imageName"),
+				"VirtualMachineImage is not allowed by the RequireTrusted image trust policy: image is not trusted: no .cert file").Error(), nil),
+		Entry("should deny an image that has not been verified when the cluster requires trusted images",
+			createArgs{clusterTrustPolicy: "RequireTrusted"}, false,
+			field.Forbidden(specPath.Child("imageName"),
+				"VirtualMachineImage is not allowed by the RequireTrusted image trust policy: image has not been verified yet").Error(), nil),
+		Entry("should allow a VM within the VirtualMachineQuota of the namespace", createArgs{quotaWithRoom: true}, true, nil, nil),
+		Entry("should deny a VM that exceeds the VirtualMachineQuota of the namespace", createArgs{quotaExceeded: true}, false,
+			field.Forbidden(specPath.Child("className"),
+				"exceeded VirtualMachineQuota dummy-quota: virtualmachines: requested 1, used 1, limited 1").Error(), nil),
+		Entry("should deny cloud-init user-data with invalid YAML", createArgs{invalidCloudInitUserData: true}, false,
+			"user-data line 3: invalid YAML: found character that cannot start any token", nil),
+		Entry("should allow cloud-init user-data with an unknown key with a warning", createArgs{unknownKeyCloudInitUserData: true}, true, nil, nil),
+		Entry("should allow OvfEnv user-data that is not base64 encoded with a warning", createArgs{unencodedOvfEnvUserData: true}, true, nil, nil),
+		Entry("should allow a TCP readiness probe of another host with a warning", createArgs{tcpProbeHost: true}, true, nil, nil),
+		Entry("should allow a vSphere volume without a capacity with a warning", createArgs{vSphereVolumeWithoutCapacity: true}, true, nil, nil),
+		Entry("should deny an ExtraConfig annotation that is not a JSON object", createArgs{invalidExtraConfig: true}, false, nil, nil),
+		Entry("should allow an ExtraConfig key without a policy", createArgs{extraConfigKey: "foo"}, true, nil, nil),
+		Entry("should deny a protected ExtraConfig key", createArgs{extraConfigKey: "disk.enableUUID"}, false,
+			field.Forbidden(field.NewPath("metadata", "annotations").Key(constants.ExtraConfigAnnotation),
+				"ExtraConfig key disk.enableUUID is not allowed by the policy of the namespace").Error(), nil),
+		Entry("should allow a protected ExtraConfig key for a service user", createArgs{extraConfigKey: "disk.enableUUID", isServiceUser: true}, true, nil, nil),
+		Entry("should deny an ExtraConfig key denied by the namespace", createArgs{extraConfigKey: "foo.bar",
+			extraConfigPolicy: &vmopapiv1alpha1.ExtraConfigPolicy{DeniedKeys: []string{"foo.*"}}}, false,
+			field.Forbidden(field.NewPath("metadata", "annotations").Key(constants.ExtraConfigAnnotation),
+				"ExtraConfig key foo.bar is not allowed by the policy of the namespace").Error(), nil),
+		Entry("should allow an ExtraConfig key allowed by the namespace", createArgs{extraConfigKey: "guestinfo.foo",
+			extraConfigPolicy: &vmopapiv1alpha1.ExtraConfigPolicy{AllowedKeys: []string{"guestinfo.*"}}}, true, nil, nil),
+		Entry("should deny an ExtraConfig key not allowed by the namespace", createArgs{extraConfigKey: "foo",
+			extraConfigPolicy: &vmopapiv1alpha1.ExtraConfigPolicy{AllowedKeys: []string{"guestinfo.*"}}}, false, nil, nil),
 	)
 }
 
@@ -454,6 +771,10 @@ func unitTestsValidateUpdate() {
 		isServiceUser                   bool
 		isWCPInstanceStorageFSSEnabled  bool
 		addInstanceStorageVolume        bool
+		addVSphereVolumeWithoutCapacity bool
+		changeToCloudInitTransport      bool
+		keepProtectedExtraConfigKey     bool
+		addProtectedExtraConfigKey      bool
 	}
 
 	validateUpdate := func(args updateArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
@@ -489,6 +810,31 @@ func unitTestsValidateUpdate() {
 			instanceStorageVolumes[0].Name += updateSuffix
 			ctx.vm.Spec.Volumes = append(ctx.vm.Spec.Volumes, instanceStorageVolumes...)
 		}
+		if args.addVSphereVolumeWithoutCapacity || args.changeToCloudInitTransport {
+			ctx.oldVM.Spec.PowerState = vmopv1.VirtualMachinePoweredOff
+			ctx.vm.Spec.PowerState = vmopv1.VirtualMachinePoweredOff
+		}
+		if args.addVSphereVolumeWithoutCapacity {
+			deviceKey := 2000
+			ctx.vm.Spec.Volumes = append(ctx.vm.Spec.Volumes, vmopv1.VirtualMachineVolume{
+				Name:          "vsphere-volume",
+				VsphereVolume: &vmopv1.VsphereVolumeSource{DeviceKey: &deviceKey},
+			})
+		}
+		if args.changeToCloudInitTransport {
+			ctx.vm.Spec.VmMetadata.Transport = vmopv1.VirtualMachineMetadataCloudInitTransport
+		}
+		if args.keepProtectedExtraConfigKey || args.addProtectedExtraConfigKey {
+			ctx.oldVM.Annotations[constants.ExtraConfigAnnotation] = `{"foo":"bar"}`
+			ctx.vm.Annotations[constants.ExtraConfigAnnotation] = `{"foo":"baz"}`
+		}
+		if args.keepProtectedExtraConfigKey {
+			ctx.oldVM.Annotations[constants.ExtraConfigAnnotation] = `{"foo":"bar","disk.enableUUID":"TRUE"}`
+			ctx.vm.Annotations[constants.ExtraConfigAnnotation] = `{"foo":"baz","disk.enableUUID":"TRUE"}`
+		}
+		if args.addProtectedExtraConfigKey {
+			ctx.vm.Annotations[constants.ExtraConfigAnnotation] = `{"foo":"baz","disk.enableUUID":"TRUE"}`
+		}
 		lib.IsInstanceStorageFSSEnabled = func() bool {
 			return args.isWCPInstanceStorageFSSEnabled
 		}
@@ -505,6 +851,12 @@ func unitTestsValidateUpdate() {
 		if expectedErr != nil {
 			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
 		}
+		// The ExtraConfig transport of the VM is unchanged, so it is not warned about again.
+		if args.addVSphereVolumeWithoutCapacity {
+			Expect(response.Warnings).To(ConsistOf(HavePrefix("spec.volumes[1].vsphereVolume.capacity: ")))
+		} else {
+			Expect(response.Warnings).To(BeEmpty())
+		}
 	}
 
 	BeforeEach(func() {
@@ -538,6 +890,10 @@ func unitTestsValidateUpdate() {
 			field.Forbidden(volumesPath, "adding or modifying instance storage volume(s) is not allowed").Error(), nil),
 		Entry("should allow adding new instance storage volume, when WCP Instance Storage FSS is enabled and user type is service user", updateArgs{isWCPInstanceStorageFSSEnabled: true, addInstanceStorageVolume: true, isServiceUser: true}, true, nil, nil),
 		Entry("should allow instance storage volume name change, when WCP Instance Storage FSS is enabled and user type is service user", updateArgs{isWCPInstanceStorageFSSEnabled: true, changeInstanceStorageVolumeName: true, isServiceUser: true}, true, nil, nil),
+		Entry("should allow adding a vSphere volume without a capacity with a warning", updateArgs{addVSphereVolumeWithoutCapacity: true}, true, nil, nil),
+		Entry("should allow changing from the ExtraConfig transport without a warning", updateArgs{changeToCloudInitTransport: true}, true, nil, nil),
+		Entry("should allow changing an ExtraConfig key when a protected key is unchanged", updateArgs{keepProtectedExtraConfigKey: true}, true, nil, nil),
+		Entry("should deny adding a protected ExtraConfig key", updateArgs{addProtectedExtraConfigKey: true}, false, nil, nil),
 	)
 
 	When("the update is performed while object deletion", func() {
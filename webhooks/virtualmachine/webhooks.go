@@ -9,6 +9,7 @@ import (
 	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachine/conversion"
 	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachine/mutation"
 	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachine/validation"
 )
@@ -20,5 +21,8 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) er
 	if err := mutation.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize mutation webhook")
 	}
+	if err := conversion.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize conversion webhook")
+	}
 	return nil
 }
@@ -4,9 +4,11 @@
 package validation
 
 import (
+	"fmt"
 	"net/http"
 	"reflect"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/api/validation"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,6 +35,8 @@ const (
 
 	invalidCPUReqMsg    = "CPU request must not be larger than the CPU limit"
 	invalidMemoryReqMsg = "memory request must not be larger than the memory limit"
+
+	noReservationWarning = "neither CPU nor memory is reserved, so VMs of the class can be starved of resources when the cluster is overcommitted"
 )
 
 // +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-virtualmachineclass,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=virtualmachineclasses,versions=v1alpha1,name=default.validating.virtualmachineclass.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
@@ -80,7 +84,9 @@ func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	warnings := v.policiesWarnings(vmClass, nil, field.NewPath("spec", "policies"))
+
+	return common.BuildValidationResponse(ctx, validationErrs, warnings, nil)
 }
 
 func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
@@ -111,7 +117,9 @@ func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	warnings := v.policiesWarnings(vmClass, oldVMClass, field.NewPath("spec", "policies"))
+
+	return common.BuildValidationResponse(ctx, validationErrs, warnings, nil)
 }
 
 func (v validator) validatePolicies(ctx *context.WebhookRequestContext, vmClass *vmopv1.VirtualMachineClass,
@@ -136,6 +144,21 @@ func (v validator) validatePolicies(ctx *context.WebhookRequestContext, vmClass
 	return allErrs
 }
 
+// policiesWarnings returns a warning when the class reserves neither CPU nor memory. On update, the warning is only
+// returned when the requests have changed.
+func (v validator) policiesWarnings(vmClass, oldVMClass *vmopv1.VirtualMachineClass, polPath *field.Path) []string {
+	requests := vmClass.Spec.Policies.Resources.Requests
+	if !requests.Cpu.IsZero() || !requests.Memory.IsZero() {
+		return nil
+	}
+	if oldVMClass != nil && equality.Semantic.DeepEqual(requests, oldVMClass.Spec.Policies.Resources.Requests) {
+		return nil
+	}
+
+	reqPath := polPath.Child("resources", "requests")
+	return []string{fmt.Sprintf("%s: %s", reqPath, noReservationWarning)}
+}
+
 // vmClassFromUnstructured returns the VirtualMachineClass from the unstructured object.
 func (v validator) vmClassFromUnstructured(obj runtime.Unstructured) (*vmopv1.VirtualMachineClass, error) {
 	vmClass := &vmopv1.VirtualMachineClass{}
@@ -65,6 +65,7 @@ func unitTestsValidateCreate() {
 		invalidMemoryRequest bool
 		noCPULimit           bool
 		noMemoryLimit        bool
+		noReservations       bool
 	}
 
 	validateCreate := func(args createArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
@@ -84,6 +85,9 @@ func unitTestsValidateCreate() {
 		if args.noMemoryLimit {
 			ctx.vmClass.Spec.Policies.Resources.Limits.Memory = resource.MustParse("0")
 		}
+		if args.noReservations {
+			ctx.vmClass.Spec.Policies.Resources.Requests = vmopv1.VirtualMachineResourceSpec{}
+		}
 
 		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.vmClass)
 		Expect(err).ToNot(HaveOccurred())
@@ -96,6 +100,12 @@ func unitTestsValidateCreate() {
 		if expectedErr != nil {
 			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
 		}
+		if args.noReservations {
+			Expect(response.Warnings).To(ConsistOf("spec.policies.resources.requests: neither CPU nor memory is reserved, " +
+				"so VMs of the class can be starved of resources when the cluster is overcommitted"))
+		} else {
+			Expect(response.Warnings).To(BeEmpty())
+		}
 	}
 
 	BeforeEach(func() {
@@ -114,6 +124,7 @@ func unitTestsValidateCreate() {
 		Entry("should allow no memory limit", createArgs{noMemoryLimit: true}, true, nil, nil),
 		Entry("should deny invalid cpu request", createArgs{invalidCPURequest: true}, false, invalidCPUField.Error(), nil),
 		Entry("should deny invalid memory request", createArgs{invalidMemoryRequest: true}, false, invalidMemField.Error(), nil),
+		Entry("should allow no reservations with a warning", createArgs{noReservations: true}, true, nil, nil),
 	)
 }
 
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/builder"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/webhooks/common"
+)
+
+const (
+	webHookName = "default"
+
+	invalidKeyPattern = "must be a key or a pattern of keys with the * wildcard"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-virtualmachinedefaults,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=virtualmachinedefaults,versions=v1alpha1,name=default.validating.virtualmachinedefaults.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+// AddToManager adds the webhook to the provided manager. Besides the validation of its Validator, the webhook
+// limits the modification of the ExtraConfig and ExtraConfigPolicy of the VirtualMachineDefaults to administrators
+// through the privileged fields policy.
+func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	hook, err := builder.NewValidatingWebhook(ctx, mgr, webHookName, NewValidator(mgr.GetClient()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create VirtualMachineDefaults validation webhook")
+	}
+	mgr.GetWebhookServer().Register(hook.Path, hook)
+
+	return nil
+}
+
+// NewValidator returns the package's Validator.
+func NewValidator(_ client.Client) builder.Validator {
+	return validator{
+		converter: runtime.DefaultUnstructuredConverter,
+	}
+}
+
+type validator struct {
+	converter runtime.UnstructuredConverter
+}
+
+func (v validator) For() schema.GroupVersionKind {
+	return vmopapiv1alpha1.GroupVersion.WithKind(reflect.TypeOf(vmopapiv1alpha1.VirtualMachineDefaults{}).Name())
+}
+
+func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.Response {
+	defaults, err := v.defaultsFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+
+	fieldErrs = append(fieldErrs, v.validateExtraConfigPolicy(defaults)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
+	return admission.Allowed("")
+}
+
+func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.Response {
+	defaults, err := v.defaultsFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+
+	fieldErrs = append(fieldErrs, v.validateExtraConfigPolicy(defaults)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+// validateExtraConfigPolicy validates that the keys of the ExtraConfigPolicy are valid patterns, since a key that
+// is not would never match and silently not restrict anything.
+func (v validator) validateExtraConfigPolicy(defaults *vmopapiv1alpha1.VirtualMachineDefaults) field.ErrorList {
+	var allErrs field.ErrorList
+
+	policy := defaults.Spec.ExtraConfigPolicy
+	if policy == nil {
+		return allErrs
+	}
+
+	policyPath := field.NewPath("spec", "extraConfigPolicy")
+	validateKeys := func(keysPath *field.Path, keys []string) {
+		for i, key := range keys {
+			if _, err := path.Match(key, ""); err != nil || key == "" {
+				allErrs = append(allErrs, field.Invalid(keysPath.Index(i), key, invalidKeyPattern))
+			}
+		}
+	}
+	validateKeys(policyPath.Child("allowedKeys"), policy.AllowedKeys)
+	validateKeys(policyPath.Child("deniedKeys"), policy.DeniedKeys)
+
+	return allErrs
+}
+
+// defaultsFromUnstructured returns the VirtualMachineDefaults from the unstructured object.
+func (v validator) defaultsFromUnstructured(obj runtime.Unstructured) (*vmopapiv1alpha1.VirtualMachineDefaults, error) {
+	defaults := &vmopapiv1alpha1.VirtualMachineDefaults{}
+	if err := v.converter.FromUnstructured(obj.UnstructuredContent(), defaults); err != nil {
+		return nil, err
+	}
+	return defaults, nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	Describe("Invoking Create", intgTestsValidateCreate)
+	Describe("Invoking Update", intgTestsValidateUpdate)
+}
+
+type intgValidatingWebhookContext struct {
+	builder.IntegrationTestContext
+	defaults *vmopapiv1alpha1.VirtualMachineDefaults
+}
+
+func newIntgValidatingWebhookContext() *intgValidatingWebhookContext {
+	ctx := &intgValidatingWebhookContext{
+		IntegrationTestContext: *suite.NewIntegrationTestContext(),
+	}
+
+	ctx.defaults = dummyVirtualMachineDefaults()
+	ctx.defaults.Namespace = ctx.Namespace
+
+	return ctx
+}
+
+func intgTestsValidateCreate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Create(ctx, ctx.defaults)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.defaults)
+		err = nil
+		ctx = nil
+	})
+
+	When("create is performed", func() {
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("create is performed with an invalid key pattern", func() {
+		BeforeEach(func() {
+			ctx.defaults.Spec.ExtraConfigPolicy.DeniedKeys = []string{"["}
+		})
+		It("should deny the request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.extraConfigPolicy.deniedKeys[0]"))
+		})
+	})
+}
+
+func intgTestsValidateUpdate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+		err = ctx.Client.Create(ctx, ctx.defaults)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Update(suite, ctx.defaults)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.defaults)
+		err = nil
+		ctx = nil
+	})
+
+	When("update is performed with changed ExtraConfig", func() {
+		BeforeEach(func() {
+			ctx.defaults.Spec.ExtraConfig["foo"] = "baz"
+		})
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking ValidateCreate", unitTestsValidateCreate)
+	Describe("Invoking ValidateUpdate", unitTestsValidateUpdate)
+	Describe("Invoking ValidateDelete", unitTestsValidateDelete)
+}
+
+type unitValidatingWebhookContext struct {
+	builder.UnitTestContextForValidatingWebhook
+	defaults    *vmopapiv1alpha1.VirtualMachineDefaults
+	oldDefaults *vmopapiv1alpha1.VirtualMachineDefaults
+}
+
+func dummyVirtualMachineDefaults() *vmopapiv1alpha1.VirtualMachineDefaults {
+	return &vmopapiv1alpha1.VirtualMachineDefaults{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vmopapiv1alpha1.VirtualMachineDefaultsName,
+			Namespace: "dummy-ns",
+		},
+		Spec: vmopapiv1alpha1.VirtualMachineDefaultsSpec{
+			ExtraConfig: map[string]string{"foo": "bar"},
+			ExtraConfigPolicy: &vmopapiv1alpha1.ExtraConfigPolicy{
+				AllowedKeys: []string{"guestinfo.*"},
+			},
+		},
+	}
+}
+
+func newUnitTestContextForValidatingWebhook(isUpdate bool) *unitValidatingWebhookContext {
+	defaults := dummyVirtualMachineDefaults()
+	obj, err := builder.ToUnstructured(defaults)
+	Expect(err).ToNot(HaveOccurred())
+
+	var oldDefaults *vmopapiv1alpha1.VirtualMachineDefaults
+	var oldObj *unstructured.Unstructured
+
+	if isUpdate {
+		oldDefaults = defaults.DeepCopy()
+		oldObj, err = builder.ToUnstructured(oldDefaults)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	return &unitValidatingWebhookContext{
+		UnitTestContextForValidatingWebhook: *suite.NewUnitTestContextForValidatingWebhook(obj, oldObj),
+		defaults:                            defaults,
+		oldDefaults:                         oldDefaults,
+	}
+}
+
+func unitTestsValidateCreate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	type createArgs struct {
+		noPolicy          bool
+		invalidAllowedKey bool
+		emptyDeniedKey    bool
+	}
+
+	validateCreate := func(args createArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
+		var err error
+
+		if args.noPolicy {
+			ctx.defaults.Spec.ExtraConfigPolicy = nil
+		}
+		if args.invalidAllowedKey {
+			ctx.defaults.Spec.ExtraConfigPolicy.AllowedKeys = append(ctx.defaults.Spec.ExtraConfigPolicy.AllowedKeys, "pciPassthru[")
+		}
+		if args.emptyDeniedKey {
+			ctx.defaults.Spec.ExtraConfigPolicy.DeniedKeys = []string{""}
+		}
+
+		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.defaults)
+		Expect(err).ToNot(HaveOccurred())
+
+		response := ctx.ValidateCreate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(Equal(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	policyPath := field.NewPath("spec", "extraConfigPolicy")
+	invalidKeyPattern := "must be a key or a pattern of keys with the * wildcard"
+	DescribeTable("create table", validateCreate,
+		Entry("should allow valid", createArgs{}, true, nil, nil),
+		Entry("should allow no policy", createArgs{noPolicy: true}, true, nil, nil),
+		Entry("should deny an invalid allowed key pattern", createArgs{invalidAllowedKey: true}, false,
+			field.Invalid(policyPath.Child("allowedKeys").Index(1), "pciPassthru[", invalidKeyPattern).Error(), nil),
+		Entry("should deny an empty denied key", createArgs{emptyDeniedKey: true}, false,
+			field.Invalid(policyPath.Child("deniedKeys").Index(0), "", invalidKeyPattern).Error(), nil),
+	)
+}
+
+func unitTestsValidateUpdate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	type updateArgs struct {
+		changeExtraConfig bool
+		invalidDeniedKey  bool
+	}
+
+	validateUpdate := func(args updateArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
+		var err error
+
+		if args.changeExtraConfig {
+			ctx.defaults.Spec.ExtraConfig["foo"] = "baz"
+		}
+		if args.invalidDeniedKey {
+			ctx.defaults.Spec.ExtraConfigPolicy.DeniedKeys = []string{"["}
+		}
+
+		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.defaults)
+		Expect(err).ToNot(HaveOccurred())
+
+		response := ctx.ValidateUpdate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(ContainSubstring(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(true)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	DescribeTable("update table", validateUpdate,
+		Entry("should allow", updateArgs{}, true, nil, nil),
+		Entry("should allow an ExtraConfig change", updateArgs{changeExtraConfig: true}, true, nil, nil),
+		Entry("should deny an invalid denied key pattern", updateArgs{invalidDeniedKey: true}, false,
+			"spec.extraConfigPolicy.deniedKeys[0]", nil),
+	)
+}
+
+func unitTestsValidateDelete() {
+	var (
+		ctx      *unitValidatingWebhookContext
+		response admission.Response
+	)
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	When("the delete is performed", func() {
+		JustBeforeEach(func() {
+			response = ctx.ValidateDelete(&ctx.WebhookRequestContext)
+		})
+
+		It("should allow the request", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(response.Result).ToNot(BeNil())
+		})
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/pkg/errors"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/pkg/auth"
+	"github.com/vmware-tanzu/vm-operator/pkg/builder"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/webhooks/common"
+)
+
+const (
+	webHookName = "default"
+
+	createNotAllowedFmt = "user %q is not allowed to create VirtualMachineImages, which are created by VM Operator from the content libraries of the ContentSources"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-virtualmachineimage,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=virtualmachineimages,versions=v1alpha1,name=default.validating.virtualmachineimage.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimages,verbs=get;list
+
+// AddToManager adds the webhook to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	hook, err := builder.NewValidatingWebhook(ctx, mgr, webHookName, NewValidator(mgr.GetClient()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create VirtualMachineImage validation webhook")
+	}
+	mgr.GetWebhookServer().Register(hook.Path, hook)
+
+	return nil
+}
+
+// NewValidator returns the package's Validator.
+func NewValidator(_ client.Client) builder.Validator {
+	return validator{}
+}
+
+type validator struct{}
+
+func (v validator) For() schema.GroupVersionKind {
+	return vmopv1.SchemeGroupVersion.WithKind(reflect.TypeOf(vmopv1.VirtualMachineImage{}).Name())
+}
+
+// ValidateCreate only allows VM Operator and the Kubernetes administrator to create VirtualMachineImages, so that
+// the images of the cluster are the images of its content libraries.
+func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.Response {
+	var validationErrs []string
+
+	if !auth.IsPODServiceAccountUser(*ctx.UserInfo) && !auth.IsKubernetesAdmin(*ctx.UserInfo) {
+		validationErrs = append(validationErrs, fmt.Sprintf(createNotAllowedFmt, ctx.UserInfo.Username))
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
+	return admission.Allowed("")
+}
+
+func (v validator) ValidateUpdate(*context.WebhookRequestContext) admission.Response {
+	return admission.Allowed("")
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	Describe("Invoking Create", intgTestsValidateCreate)
+}
+
+type intgValidatingWebhookContext struct {
+	builder.IntegrationTestContext
+	vmImage *vmopv1.VirtualMachineImage
+}
+
+func newIntgValidatingWebhookContext() *intgValidatingWebhookContext {
+	ctx := &intgValidatingWebhookContext{
+		IntegrationTestContext: *suite.NewIntegrationTestContext(),
+	}
+
+	ctx.vmImage = builder.DummyVirtualMachineImage("dummy-image")
+
+	return ctx
+}
+
+func intgTestsValidateCreate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Create(ctx, ctx.vmImage)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.vmImage)
+		err = nil
+		ctx = nil
+	})
+
+	// The integration test client authenticates as an administrator of the test API server.
+	When("create is performed by an administrator", func() {
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+}
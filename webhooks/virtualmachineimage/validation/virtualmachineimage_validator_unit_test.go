@@ -0,0 +1,125 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	authv1 "k8s.io/api/authentication/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking ValidateCreate", unitTestsValidateCreate)
+	Describe("Invoking ValidateUpdate", unitTestsValidateUpdate)
+	Describe("Invoking ValidateDelete", unitTestsValidateDelete)
+}
+
+type unitValidatingWebhookContext struct {
+	builder.UnitTestContextForValidatingWebhook
+	vmImage *vmopv1.VirtualMachineImage
+}
+
+func newUnitTestContextForValidatingWebhook(isUpdate bool) *unitValidatingWebhookContext {
+	vmImage := builder.DummyVirtualMachineImage("dummy-image")
+	obj, err := builder.ToUnstructured(vmImage)
+	Expect(err).ToNot(HaveOccurred())
+
+	ctx := &unitValidatingWebhookContext{
+		UnitTestContextForValidatingWebhook: *suite.NewUnitTestContextForValidatingWebhook(obj, nil),
+		vmImage:                             vmImage,
+	}
+	if isUpdate {
+		ctx.WebhookRequestContext.OldObj, err = builder.ToUnstructured(vmImage.DeepCopy())
+		Expect(err).ToNot(HaveOccurred())
+	}
+	return ctx
+}
+
+func unitTestsValidateCreate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	validateCreate := func(username string, expectedAllowed bool, expectedReason string, expectedErr error) {
+		ctx.WebhookRequestContext.UserInfo = &authv1.UserInfo{Username: username}
+
+		response := ctx.ValidateCreate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(Equal(expectedReason))
+		}
+		if expectedErr != nil {
+			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	DescribeTable("create table", validateCreate,
+		Entry("should allow the Kubernetes administrator", "kubernetes-admin", true, nil, nil),
+		Entry("should deny other users", "sso:user@vsphere.local", false,
+			`user "sso:user@vsphere.local" is not allowed to create VirtualMachineImages, which are created by VM Operator from the content libraries of the ContentSources`, nil),
+	)
+}
+
+func unitTestsValidateUpdate() {
+	var (
+		ctx      *unitValidatingWebhookContext
+		response admission.Response
+	)
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(true)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	When("the update is performed", func() {
+		JustBeforeEach(func() {
+			response = ctx.ValidateUpdate(&ctx.WebhookRequestContext)
+		})
+
+		It("should allow the request", func() {
+			Expect(response.Allowed).To(BeTrue())
+		})
+	})
+}
+
+func unitTestsValidateDelete() {
+	var (
+		ctx      *unitValidatingWebhookContext
+		response admission.Response
+	)
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	When("the delete is performed", func() {
+		JustBeforeEach(func() {
+			response = ctx.ValidateDelete(&ctx.WebhookRequestContext)
+		})
+
+		It("should allow the request", func() {
+			Expect(response.Allowed).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/auth"
+	"github.com/vmware-tanzu/vm-operator/pkg/builder"
+	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/pkg/extraconfig"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/webhooks/common"
+)
+
+const (
+	webHookName = "default"
+
+	emptySelector               = "must not be empty"
+	selectorNotMatchingTemplate = "selector does not match the template labels"
+	extraConfigKeyNotAllowedFmt = "ExtraConfig key %s is not allowed by the policy of the namespace"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-virtualmachinereplicaset,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=virtualmachinereplicasets,versions=v1alpha1,name=default.validating.virtualmachinereplicaset.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachinedefaults,verbs=get;list;watch
+
+// AddToManager adds the webhook to the provided manager.
+func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
+	hook, err := builder.NewValidatingWebhook(ctx, mgr, webHookName, NewValidator(mgr.GetClient()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create VirtualMachineReplicaSet validation webhook")
+	}
+	mgr.GetWebhookServer().Register(hook.Path, hook)
+
+	return nil
+}
+
+// NewValidator returns the package's Validator.
+func NewValidator(client client.Client) builder.Validator {
+	return validator{
+		client:    client,
+		converter: runtime.DefaultUnstructuredConverter,
+	}
+}
+
+type validator struct {
+	client    client.Client
+	converter runtime.UnstructuredConverter
+}
+
+func (v validator) For() schema.GroupVersionKind {
+	return vmopapiv1alpha1.GroupVersion.WithKind(reflect.TypeOf(vmopapiv1alpha1.VirtualMachineReplicaSet{}).Name())
+}
+
+func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.Response {
+	rs, err := v.rsFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+	fieldErrs = append(fieldErrs, v.validateSelector(rs)...)
+	fieldErrs = append(fieldErrs, v.validateTemplatePrivilegedFields(ctx, rs, nil)...)
+	fieldErrs = append(fieldErrs, v.validateTemplateExtraConfig(ctx, rs, nil)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
+	return admission.Allowed("")
+}
+
+func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.Response {
+	rs, err := v.rsFromUnstructured(ctx.Obj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	oldRS, err := v.rsFromUnstructured(ctx.OldObj)
+	if err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	var fieldErrs field.ErrorList
+	fieldErrs = append(fieldErrs, v.validateSelector(rs)...)
+	fieldErrs = append(fieldErrs, v.validateTemplatePrivilegedFields(ctx, rs, oldRS)...)
+	fieldErrs = append(fieldErrs, v.validateTemplateExtraConfig(ctx, rs, oldRS)...)
+
+	validationErrs := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		validationErrs = append(validationErrs, fieldErr.Error())
+	}
+
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
+}
+
+// validateSelector validates that the selector matches the labels of the template, since the VirtualMachines that
+// are created would otherwise not be selected and the controller would fail on every reconcile.
+func (v validator) validateSelector(rs *vmopapiv1alpha1.VirtualMachineReplicaSet) field.ErrorList {
+	var allErrs field.ErrorList
+
+	selectorPath := field.NewPath("spec", "selector")
+
+	selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
+	if err != nil {
+		return append(allErrs, field.Invalid(selectorPath, rs.Spec.Selector, err.Error()))
+	}
+	if selector.Empty() {
+		return append(allErrs, field.Invalid(selectorPath, rs.Spec.Selector, emptySelector))
+	}
+	if !selector.Matches(labels.Set(rs.Spec.Template.ObjectMeta.Labels)) {
+		labelsPath := field.NewPath("spec", "template", "metadata", "labels")
+		allErrs = append(allErrs, field.Invalid(labelsPath, rs.Spec.Template.ObjectMeta.Labels, selectorNotMatchingTemplate))
+	}
+
+	return allErrs
+}
+
+// validateTemplatePrivilegedFields validates that the user is allowed to modify the privileged annotations and
+// fields of the VirtualMachines created from the template. The VirtualMachines are created by VM Operator, which
+// is allowed to set any of them, so the policy is enforced on the template instead.
+func (v validator) validateTemplatePrivilegedFields(
+	ctx *context.WebhookRequestContext,
+	rs, oldRS *vmopapiv1alpha1.VirtualMachineReplicaSet) field.ErrorList {
+
+	var allErrs field.ErrorList
+
+	templatePath := field.NewPath("spec", "template")
+
+	vm, err := v.templateVM(rs)
+	if err != nil {
+		return append(allErrs, field.InternalError(templatePath, err))
+	}
+	var oldVM *unstructured.Unstructured
+	if oldRS != nil {
+		if oldVM, err = v.templateVM(oldRS); err != nil {
+			return append(allErrs, field.InternalError(templatePath, err))
+		}
+	}
+
+	rules, err := auth.GetPrivilegedFieldRules(ctx, v.client)
+	if err != nil {
+		return append(allErrs, field.InternalError(templatePath, err))
+	}
+
+	for _, rule := range auth.DeniedPrivilegedFields(rules, *ctx.UserInfo, vm, oldVM) {
+		allErrs = append(allErrs, field.Forbidden(templatePath, auth.PrivilegedFieldDeniedMessage(rule, *ctx.UserInfo)))
+	}
+
+	return allErrs
+}
+
+// validateTemplateExtraConfig validates the ExtraConfig annotation of the template like the one of a VirtualMachine
+// is validated, so that a non-administrator cannot set through the template the keys the ExtraConfig policy of
+// the namespace denies.
+func (v validator) validateTemplateExtraConfig(
+	ctx *context.WebhookRequestContext,
+	rs, oldRS *vmopapiv1alpha1.VirtualMachineReplicaSet) field.ErrorList {
+
+	var allErrs field.ErrorList
+
+	annotations := rs.Spec.Template.ObjectMeta.Annotations
+	extraConfigPath := field.NewPath("spec", "template", "metadata", "annotations").Key(constants.ExtraConfigAnnotation)
+
+	extraConfig, err := extraconfig.FromAnnotations(annotations)
+	if err != nil {
+		return append(allErrs, field.Invalid(extraConfigPath, annotations[constants.ExtraConfigAnnotation], err.Error()))
+	}
+	if len(extraConfig) == 0 {
+		return allErrs
+	}
+
+	if auth.IsPODServiceAccountUser(*ctx.UserInfo) || auth.IsKubernetesAdmin(*ctx.UserInfo) {
+		return allErrs
+	}
+
+	var oldExtraConfig map[string]string
+	if oldRS != nil {
+		// The old annotation is ignored when it is invalid, so all the keys are checked.
+		oldExtraConfig, _ = extraconfig.FromAnnotations(oldRS.Spec.Template.ObjectMeta.Annotations)
+	}
+
+	notAllowed, err := extraconfig.NotAllowedKeys(ctx, v.client, rs.Namespace, extraConfig, oldExtraConfig)
+	if err != nil {
+		return append(allErrs, field.InternalError(extraConfigPath, err))
+	}
+	for _, k := range notAllowed {
+		allErrs = append(allErrs, field.Forbidden(extraConfigPath, fmt.Sprintf(extraConfigKeyNotAllowedFmt, k)))
+	}
+
+	return allErrs
+}
+
+// templateVM returns the unstructured VirtualMachine of the labels, annotations and spec of the template.
+func (v validator) templateVM(rs *vmopapiv1alpha1.VirtualMachineReplicaSet) (*unstructured.Unstructured, error) {
+	vm := &vmopv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   rs.Namespace,
+			Labels:      rs.Spec.Template.ObjectMeta.Labels,
+			Annotations: rs.Spec.Template.ObjectMeta.Annotations,
+		},
+		Spec: rs.Spec.Template.Spec,
+	}
+
+	content, err := v.converter.ToUnstructured(vm)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(vmopv1.SchemeGroupVersion.WithKind(reflect.TypeOf(vmopv1.VirtualMachine{}).Name()))
+	return obj, nil
+}
+
+// rsFromUnstructured returns the VirtualMachineReplicaSet from the unstructured object.
+func (v validator) rsFromUnstructured(obj runtime.Unstructured) (*vmopapiv1alpha1.VirtualMachineReplicaSet, error) {
+	rs := &vmopapiv1alpha1.VirtualMachineReplicaSet{}
+	if err := v.converter.FromUnstructured(obj.UnstructuredContent(), rs); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func intgTests() {
+	Describe("Invoking Create", intgTestsValidateCreate)
+	Describe("Invoking Update", intgTestsValidateUpdate)
+}
+
+type intgValidatingWebhookContext struct {
+	builder.IntegrationTestContext
+	rs *vmopapiv1alpha1.VirtualMachineReplicaSet
+}
+
+func newIntgValidatingWebhookContext() *intgValidatingWebhookContext {
+	ctx := &intgValidatingWebhookContext{
+		IntegrationTestContext: *suite.NewIntegrationTestContext(),
+	}
+
+	ctx.rs = dummyVirtualMachineReplicaSet()
+	ctx.rs.Namespace = ctx.Namespace
+
+	return ctx
+}
+
+func intgTestsValidateCreate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Create(ctx, ctx.rs)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.rs)
+		err = nil
+		ctx = nil
+	})
+
+	When("create is performed", func() {
+		It("should allow the request", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("create is performed with a selector that does not match the template labels", func() {
+		BeforeEach(func() {
+			ctx.rs.Spec.Template.ObjectMeta.Labels = map[string]string{"app": "other"}
+		})
+		It("should deny the request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("selector does not match the template labels"))
+		})
+	})
+}
+
+func intgTestsValidateUpdate() {
+	var (
+		err error
+		ctx *intgValidatingWebhookContext
+	)
+
+	BeforeEach(func() {
+		ctx = newIntgValidatingWebhookContext()
+		err = ctx.Client.Create(ctx, ctx.rs)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	JustBeforeEach(func() {
+		err = ctx.Client.Update(suite, ctx.rs)
+	})
+	AfterEach(func() {
+		_ = ctx.Client.Delete(ctx, ctx.rs)
+		err = nil
+		ctx = nil
+	})
+
+	When("update is performed with a selector that does not match the template labels", func() {
+		BeforeEach(func() {
+			ctx.rs.Spec.Template.ObjectMeta.Labels = map[string]string{"app": "other"}
+		})
+		It("should deny the request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("selector does not match the template labels"))
+		})
+	})
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2021 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator-api/api/v1alpha1"
+
+	vmopapiv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	"github.com/vmware-tanzu/vm-operator/pkg/auth"
+	"github.com/vmware-tanzu/vm-operator/pkg/vmprovider/providers/vsphere/constants"
+	"github.com/vmware-tanzu/vm-operator/test/builder"
+)
+
+func unitTests() {
+	Describe("Invoking ValidateCreate", unitTestsValidateCreate)
+	Describe("Invoking ValidateUpdate", unitTestsValidateUpdate)
+	Describe("Invoking ValidateDelete", unitTestsValidateDelete)
+}
+
+type unitValidatingWebhookContext struct {
+	builder.UnitTestContextForValidatingWebhook
+	rs    *vmopapiv1alpha1.VirtualMachineReplicaSet
+	oldRS *vmopapiv1alpha1.VirtualMachineReplicaSet
+}
+
+func dummyVirtualMachineReplicaSet() *vmopapiv1alpha1.VirtualMachineReplicaSet {
+	return &vmopapiv1alpha1.VirtualMachineReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dummy-rs",
+			Namespace: "dummy-ns",
+		},
+		Spec: vmopapiv1alpha1.VirtualMachineReplicaSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "dummy"},
+			},
+			Template: vmopapiv1alpha1.VirtualMachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "dummy"},
+				},
+				Spec: vmopv1.VirtualMachineSpec{
+					ImageName:  "dummy-image",
+					ClassName:  "dummy-class",
+					PowerState: vmopv1.VirtualMachinePoweredOn,
+				},
+			},
+		},
+	}
+}
+
+func newUnitTestContextForValidatingWebhook(isUpdate bool) *unitValidatingWebhookContext {
+	rs := dummyVirtualMachineReplicaSet()
+	obj, err := builder.ToUnstructured(rs)
+	Expect(err).ToNot(HaveOccurred())
+
+	var oldRS *vmopapiv1alpha1.VirtualMachineReplicaSet
+	var oldObj *unstructured.Unstructured
+
+	if isUpdate {
+		oldRS = rs.DeepCopy()
+		oldObj, err = builder.ToUnstructured(oldRS)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	ctx := &unitValidatingWebhookContext{
+		UnitTestContextForValidatingWebhook: *suite.NewUnitTestContextForValidatingWebhook(obj, oldObj),
+		rs:                                  rs,
+		oldRS:                               oldRS,
+	}
+	ctx.WebhookRequestContext.UserInfo = &authv1.UserInfo{Username: "sso:devUser1@vsphere.local"}
+	return ctx
+}
+
+type validateArgs struct {
+	emptySelector        bool
+	mismatchedSelector   bool
+	privilegedAnnotation bool
+	protectedExtraConfig bool
+	allowedExtraConfig   bool
+	invalidExtraConfig   bool
+	isAdmin              bool
+	unchangedTemplate    bool
+}
+
+func (ctx *unitValidatingWebhookContext) apply(args validateArgs) {
+	template := &ctx.rs.Spec.Template
+	if args.emptySelector {
+		ctx.rs.Spec.Selector = &metav1.LabelSelector{}
+	}
+	if args.mismatchedSelector {
+		template.ObjectMeta.Labels = map[string]string{"app": "other"}
+	}
+	if args.privilegedAnnotation {
+		template.ObjectMeta.Annotations = map[string]string{vmopv1.PauseAnnotation: ""}
+	}
+	if args.protectedExtraConfig {
+		template.ObjectMeta.Annotations = map[string]string{constants.ExtraConfigAnnotation: `{"pciPassthru0.present": "TRUE"}`}
+	}
+	if args.allowedExtraConfig {
+		template.ObjectMeta.Annotations = map[string]string{constants.ExtraConfigAnnotation: `{"foo": "bar"}`}
+	}
+	if args.invalidExtraConfig {
+		template.ObjectMeta.Annotations = map[string]string{constants.ExtraConfigAnnotation: "invalid"}
+	}
+	if args.isAdmin {
+		ctx.WebhookRequestContext.UserInfo = &authv1.UserInfo{Username: auth.KubeAdminUser}
+	}
+	if args.unchangedTemplate && ctx.oldRS != nil {
+		ctx.oldRS.Spec.Template.ObjectMeta.Annotations = template.ObjectMeta.Annotations
+	}
+
+	var err error
+	ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.rs)
+	Expect(err).ToNot(HaveOccurred())
+	if ctx.oldRS != nil {
+		ctx.WebhookRequestContext.OldObj, err = builder.ToUnstructured(ctx.oldRS)
+		Expect(err).ToNot(HaveOccurred())
+	}
+}
+
+func unitTestsValidateCreate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	validateCreate := func(args validateArgs, expectedAllowed bool, expectedReason string) {
+		ctx.apply(args)
+
+		response := ctx.ValidateCreate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(ContainSubstring(expectedReason))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	DescribeTable("create table", validateCreate,
+		Entry("should allow valid", validateArgs{}, true, ""),
+		Entry("should deny an empty selector", validateArgs{emptySelector: true}, false,
+			"spec.selector: Invalid value"),
+		Entry("should deny a selector that does not match the template labels", validateArgs{mismatchedSelector: true}, false,
+			"spec.template.metadata.labels: Invalid value: map[string]string{\"app\":\"other\"}: selector does not match the template labels"),
+		Entry("should deny a privileged template annotation", validateArgs{privilegedAnnotation: true}, false,
+			"spec.template: Forbidden: user \"sso:devUser1@vsphere.local\" with groups [] is not allowed to modify annotation "+vmopv1.PauseAnnotation),
+		Entry("should allow a privileged template annotation for the Kubernetes administrator", validateArgs{privilegedAnnotation: true, isAdmin: true}, true, ""),
+		Entry("should deny a protected template ExtraConfig key", validateArgs{protectedExtraConfig: true}, false,
+			"ExtraConfig key pciPassthru0.present is not allowed by the policy of the namespace"),
+		Entry("should allow a protected template ExtraConfig key for the Kubernetes administrator", validateArgs{protectedExtraConfig: true, isAdmin: true}, true, ""),
+		Entry("should allow an allowed template ExtraConfig key", validateArgs{allowedExtraConfig: true}, true, ""),
+		Entry("should deny an invalid template ExtraConfig", validateArgs{invalidExtraConfig: true}, false,
+			"spec.template.metadata.annotations["+constants.ExtraConfigAnnotation+"]: Invalid value"),
+	)
+}
+
+func unitTestsValidateUpdate() {
+	var (
+		ctx *unitValidatingWebhookContext
+	)
+
+	validateUpdate := func(args validateArgs, expectedAllowed bool, expectedReason string) {
+		ctx.apply(args)
+
+		response := ctx.ValidateUpdate(&ctx.WebhookRequestContext)
+		Expect(response.Allowed).To(Equal(expectedAllowed))
+		if expectedReason != "" {
+			Expect(string(response.Result.Reason)).To(ContainSubstring(expectedReason))
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(true)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	DescribeTable("update table", validateUpdate,
+		Entry("should allow", validateArgs{}, true, ""),
+		Entry("should deny adding a privileged template annotation", validateArgs{privilegedAnnotation: true}, false,
+			"is not allowed to modify annotation "+vmopv1.PauseAnnotation),
+		Entry("should allow an unchanged privileged template annotation", validateArgs{privilegedAnnotation: true, unchangedTemplate: true}, true, ""),
+		Entry("should deny adding a protected template ExtraConfig key", validateArgs{protectedExtraConfig: true}, false,
+			"ExtraConfig key pciPassthru0.present is not allowed by the policy of the namespace"),
+		Entry("should allow an unchanged protected template ExtraConfig key", validateArgs{protectedExtraConfig: true, unchangedTemplate: true}, true, ""),
+	)
+}
+
+func unitTestsValidateDelete() {
+	var (
+		ctx      *unitValidatingWebhookContext
+		response admission.Response
+	)
+
+	BeforeEach(func() {
+		ctx = newUnitTestContextForValidatingWebhook(false)
+	})
+	AfterEach(func() {
+		ctx = nil
+	})
+
+	When("the delete is performed", func() {
+		JustBeforeEach(func() {
+			response = ctx.ValidateDelete(&ctx.WebhookRequestContext)
+		})
+
+		It("should allow the request", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(response.Result).ToNot(BeNil())
+		})
+	})
+}
@@ -33,6 +33,11 @@ import (
 
 const (
 	webHookName = "default"
+
+	noSourceRangesWarning = "is not set, so the load balancer is reachable from any address"
+	noSelectedVMsFmt      = "matches no VirtualMachines in namespace %s"
+	otherNamespaceVMsFmt  = "matches no VirtualMachines in namespace %s but matches VirtualMachines in other namespaces, " +
+		"and a VirtualMachineService only selects the VirtualMachines of its namespace"
 )
 
 var (
@@ -52,6 +57,7 @@ var (
 // +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-virtualmachineservice,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=virtualmachineservices,versions=v1alpha1,name=default.validating.virtualmachineservice.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineservices,verbs=get;list
 // +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineservices/status,verbs=get
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachines,verbs=get;list
 
 // AddToManager adds the webhook to the provided manager.
 func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) error {
@@ -65,8 +71,9 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) er
 }
 
 // NewValidator returns the package's Validator.
-func NewValidator(_ client.Client) builder.Validator {
+func NewValidator(client client.Client) builder.Validator {
 	return validator{
+		client:    client,
 		converter: runtime.DefaultUnstructuredConverter,
 	}
 }
@@ -76,6 +83,7 @@ func NewValidator(_ client.Client) builder.Validator {
 // be transformed into a valid Service.
 
 type validator struct {
+	client    client.Client
 	converter runtime.UnstructuredConverter
 }
 
@@ -98,7 +106,10 @@ func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	warnings := v.specWarnings(vmService, nil)
+	warnings = append(warnings, v.selectorWarnings(ctx, vmService, nil)...)
+
+	return common.BuildValidationResponse(ctx, validationErrs, warnings, nil)
 }
 
 func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
@@ -125,7 +136,10 @@ func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	warnings := v.specWarnings(vmService, oldVMService)
+	warnings = append(warnings, v.selectorWarnings(ctx, vmService, oldVMService)...)
+
+	return common.BuildValidationResponse(ctx, validationErrs, warnings, nil)
 }
 
 func (v validator) validateMetadata(ctx *context.WebhookRequestContext, vmService *vmopv1.VirtualMachineService) field.ErrorList {
@@ -205,6 +219,54 @@ func (v validator) validateSpec(ctx *context.WebhookRequestContext, vmService *v
 	return allErrs
 }
 
+// specWarnings returns a warning when a LoadBalancer service does not restrict its source ranges. On update, the
+// warning is only returned when the source ranges were removed.
+func (v validator) specWarnings(vmService, oldVMService *vmopv1.VirtualMachineService) []string {
+	if vmService.Spec.Type != vmopv1.VirtualMachineServiceTypeLoadBalancer || len(vmService.Spec.LoadBalancerSourceRanges) > 0 {
+		return nil
+	}
+	if oldVMService != nil && len(oldVMService.Spec.LoadBalancerSourceRanges) == 0 {
+		return nil
+	}
+
+	fldPath := field.NewPath("spec", "loadBalancerSourceRanges")
+	return []string{fmt.Sprintf("%s: %s", fldPath, noSourceRangesWarning)}
+}
+
+// selectorWarnings returns a warning when the selector matches no VirtualMachines of the namespace of the service,
+// whose Endpoints then stay empty. On update, the warning is only returned when the selector was changed. A failure
+// to list the VirtualMachines is logged, since warnings never deny the request.
+func (v validator) selectorWarnings(
+	ctx *context.WebhookRequestContext,
+	vmService, oldVMService *vmopv1.VirtualMachineService) []string {
+
+	if vmService.Spec.Type == vmopv1.VirtualMachineServiceTypeExternalName || len(vmService.Spec.Selector) == 0 {
+		return nil
+	}
+	if oldVMService != nil && reflect.DeepEqual(vmService.Spec.Selector, oldVMService.Spec.Selector) {
+		return nil
+	}
+
+	vmList := &vmopv1.VirtualMachineList{}
+	if err := v.client.List(ctx, vmList, client.MatchingLabels(vmService.Spec.Selector)); err != nil {
+		ctx.Logger.Error(err, "Failed to list the VirtualMachines selected by the VirtualMachineService")
+		return nil
+	}
+
+	for _, vm := range vmList.Items {
+		if vm.Namespace == vmService.Namespace {
+			return nil
+		}
+	}
+
+	// The other namespaces are not named, since the user may not have access to them.
+	fldPath := field.NewPath("spec", "selector")
+	if len(vmList.Items) > 0 {
+		return []string{fmt.Sprintf("%s: "+otherNamespaceVMsFmt, fldPath, vmService.Namespace)}
+	}
+	return []string{fmt.Sprintf("%s: "+noSelectedVMsFmt, fldPath, vmService.Namespace)}
+}
+
 func validatePorts(vmService *vmopv1.VirtualMachineService, specPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 	portsPath := specPath.Child("ports")
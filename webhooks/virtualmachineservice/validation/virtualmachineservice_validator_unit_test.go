@@ -31,6 +31,7 @@ type unitValidatingWebhookContext struct {
 
 func newUnitTestContextForValidatingWebhook(isUpdate bool) *unitValidatingWebhookContext {
 	vmService := builder.DummyVirtualMachineService()
+	vmService.Namespace = "dummy-ns"
 	obj, err := builder.ToUnstructured(vmService)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -44,12 +45,24 @@ func newUnitTestContextForValidatingWebhook(isUpdate bool) *unitValidatingWebhoo
 	}
 
 	return &unitValidatingWebhookContext{
-		UnitTestContextForValidatingWebhook: *suite.NewUnitTestContextForValidatingWebhook(obj, oldObj),
+		UnitTestContextForValidatingWebhook: *suite.NewUnitTestContextForValidatingWebhook(obj, oldObj, selectedVM(vmService, "dummy-vm")),
 		vmService:                           vmService,
 		oldVMService:                        oldVMService,
 	}
 }
 
+// selectedVM returns a VirtualMachine, in the namespace of the service, that the selector of the service matches.
+func selectedVM(vmService *vmopv1.VirtualMachineService, name string) *vmopv1.VirtualMachine {
+	vm := builder.DummyVirtualMachine()
+	vm.Name = name
+	vm.Namespace = vmService.Namespace
+	vm.Labels = map[string]string{}
+	for k, v := range vmService.Spec.Selector {
+		vm.Labels[k] = v
+	}
+	return vm
+}
+
 func unitTestsValidateCreate() {
 	var (
 		ctx *unitValidatingWebhookContext
@@ -65,6 +78,9 @@ func unitTestsValidateCreate() {
 		invalidLoadBalancerIP bool
 		invalidLBSourceRanges bool
 		invalidExternalName   bool
+		noLBSourceRanges      bool
+		noSelectedVMs         bool
+		otherNamespaceVMs     bool
 	}
 
 	validateCreate := func(args createArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
@@ -101,6 +117,18 @@ func unitTestsValidateCreate() {
 			ctx.vmService.Spec.Type = vmopv1.VirtualMachineServiceTypeExternalName
 			ctx.vmService.Spec.ExternalName = "InValid!"
 		}
+		if args.noLBSourceRanges {
+			ctx.vmService.Spec.Type = vmopv1.VirtualMachineServiceTypeLoadBalancer
+			ctx.vmService.Spec.LoadBalancerSourceRanges = nil
+		}
+		if args.noSelectedVMs || args.otherNamespaceVMs {
+			ctx.vmService.Spec.Selector = map[string]string{"app": "other"}
+		}
+		if args.otherNamespaceVMs {
+			vm := selectedVM(ctx.vmService, "other-vm")
+			vm.Namespace = "other-ns"
+			Expect(ctx.Client.Create(ctx, vm)).To(Succeed())
+		}
 
 		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.vmService)
 		Expect(err).ToNot(HaveOccurred())
@@ -113,6 +141,18 @@ func unitTestsValidateCreate() {
 		if expectedErr != nil {
 			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
 		}
+		switch {
+		case args.noLBSourceRanges:
+			Expect(response.Warnings).To(ConsistOf(
+				"spec.loadBalancerSourceRanges: is not set, so the load balancer is reachable from any address"))
+		case args.noSelectedVMs:
+			Expect(response.Warnings).To(ContainElement(
+				"spec.selector: matches no VirtualMachines in namespace dummy-ns"))
+		case args.otherNamespaceVMs:
+			Expect(response.Warnings).To(ContainElement(
+				"spec.selector: matches no VirtualMachines in namespace dummy-ns but matches VirtualMachines in other namespaces, " +
+					"and a VirtualMachineService only selects the VirtualMachines of its namespace"))
+		}
 	}
 
 	BeforeEach(func() {
@@ -133,6 +173,9 @@ func unitTestsValidateCreate() {
 		Entry("should deny invalid LoadBalancerIP", createArgs{invalidLoadBalancerIP: true}, false, "spec.loadBalancerIP: Invalid value: \"500.1.1.1\": must be a valid IP address", nil),
 		Entry("should deny invalid LoadBalancerSourceRanges", createArgs{invalidLBSourceRanges: true}, false, "spec.loadBalancerSourceRanges: Invalid value: \"[10.1.1.1/42]", nil),
 		Entry("should deny invalid ExternalName", createArgs{invalidExternalName: true}, false, "spec.externalName: Invalid value: \"InValid!\": a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters", nil),
+		Entry("should allow a LoadBalancer without LoadBalancerSourceRanges with a warning", createArgs{noLBSourceRanges: true}, true, nil, nil),
+		Entry("should allow a selector that matches no VMs with a warning", createArgs{noSelectedVMs: true}, true, nil, nil),
+		Entry("should allow a selector that matches VMs of other namespaces with a warning", createArgs{otherNamespaceVMs: true}, true, nil, nil),
 	)
 
 	validatePortCreate := func(expectedReason string, ports []vmopv1.VirtualMachineServicePort) {
@@ -235,6 +278,8 @@ func unitTestsValidateUpdate() {
 	type updateArgs struct {
 		updateType      bool
 		updateClusterIP bool
+		updateSelector  bool
+		unselectVMs     bool
 	}
 
 	validateUpdate := func(args updateArgs, expectedAllowed bool, expectedReason string, expectedErr error) {
@@ -246,6 +291,13 @@ func unitTestsValidateUpdate() {
 		if args.updateClusterIP {
 			ctx.vmService.Spec.ClusterIP = "9.9.9.9"
 		}
+		if args.updateSelector {
+			ctx.vmService.Spec.Selector = map[string]string{"app": "other"}
+		}
+		if args.unselectVMs {
+			vm := selectedVM(ctx.vmService, "dummy-vm")
+			Expect(ctx.Client.Delete(ctx, vm)).To(Succeed())
+		}
 
 		ctx.WebhookRequestContext.Obj, err = builder.ToUnstructured(ctx.vmService)
 		Expect(err).ToNot(HaveOccurred())
@@ -258,6 +310,12 @@ func unitTestsValidateUpdate() {
 		if expectedErr != nil {
 			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
 		}
+		if args.updateSelector {
+			Expect(response.Warnings).To(ConsistOf(
+				"spec.selector: matches no VirtualMachines in namespace dummy-ns"))
+		} else {
+			Expect(response.Warnings).To(BeEmpty())
+		}
 	}
 
 	BeforeEach(func() {
@@ -271,6 +329,8 @@ func unitTestsValidateUpdate() {
 		Entry("should allow", updateArgs{}, true, nil, nil),
 		Entry("should deny Type change", updateArgs{updateType: true}, false, "spec.type: Forbidden: field is immutable", nil),
 		Entry("should deny ClusterIP change", updateArgs{updateClusterIP: true}, false, "spec.clusterIP: Forbidden: field is immutable", nil),
+		Entry("should allow a selector change that matches no VMs with a warning", updateArgs{updateSelector: true}, true, nil, nil),
+		Entry("should allow an unchanged selector that matches no VMs without a warning", updateArgs{unselectVMs: true}, true, nil, nil),
 	)
 
 	When("the update is performed while object deletion", func() {
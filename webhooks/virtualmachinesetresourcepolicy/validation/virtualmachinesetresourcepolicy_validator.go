@@ -4,6 +4,7 @@
 package validation
 
 import (
+	"fmt"
 	"net/http"
 	"reflect"
 
@@ -29,6 +30,8 @@ import (
 
 const (
 	webHookName = "default"
+
+	noLimitsWarning = "neither CPU nor memory is limited, so VMs of the resource pool can use all the resources of the namespace"
 )
 
 // +kubebuilder:webhook:verbs=create;update,path=/default-validate-vmoperator-vmware-com-v1alpha1-virtualmachinesetresourcepolicy,mutating=false,failurePolicy=fail,groups=vmoperator.vmware.com,resources=virtualmachinesetresourcepolicies,versions=v1alpha1,name=default.validating.virtualmachinesetresourcepolicy.vmoperator.vmware.com,sideEffects=None,admissionReviewVersions=v1;v1beta1
@@ -75,7 +78,9 @@ func (v validator) ValidateCreate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	warnings := v.resourcePoolWarnings(field.NewPath("spec", "resourcepool"), vmRP.Spec.ResourcePool)
+
+	return common.BuildValidationResponse(ctx, validationErrs, warnings, nil)
 }
 
 func (v validator) ValidateDelete(*context.WebhookRequestContext) admission.Response {
@@ -101,7 +106,8 @@ func (v validator) ValidateUpdate(ctx *context.WebhookRequestContext) admission.
 		validationErrs = append(validationErrs, fieldErr.Error())
 	}
 
-	return common.BuildValidationResponse(ctx, validationErrs, nil)
+	// The spec is immutable, so its warnings were already returned on create.
+	return common.BuildValidationResponse(ctx, validationErrs, nil, nil)
 }
 
 func (v validator) validateSpec(ctx *context.WebhookRequestContext, vmRP *vmopv1.VirtualMachineSetResourcePolicy) field.ErrorList {
@@ -127,6 +133,15 @@ func (v validator) validateResourcePool(ctx *context.WebhookRequestContext, fldP
 	return fieldErrs
 }
 
+// resourcePoolWarnings returns a warning when the resource pool limits neither CPU nor memory.
+func (v validator) resourcePoolWarnings(fldPath *field.Path, rp vmopv1.ResourcePoolSpec) []string {
+	if rp.Name == "" || !rp.Limits.Cpu.IsZero() || !rp.Limits.Memory.IsZero() {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s: %s", fldPath.Child("limits"), noLimitsWarning)}
+}
+
 func (v validator) validateFolder(ctx *context.WebhookRequestContext, specPath *field.Path, folder vmopv1.FolderSpec) field.ErrorList {
 	var fieldErrs field.ErrorList
 	return fieldErrs
@@ -94,6 +94,12 @@ func unitTestsValidateCreate() {
 		if expectedErr != nil {
 			Expect(response.Result.Message).To(Equal(expectedErr.Error()))
 		}
+		if args.noCPULimit && args.noMemoryLimit {
+			Expect(response.Warnings).To(ConsistOf("spec.resourcepool.limits: neither CPU nor memory is limited, " +
+				"so VMs of the resource pool can use all the resources of the namespace"))
+		} else {
+			Expect(response.Warnings).To(BeEmpty())
+		}
 	}
 
 	BeforeEach(func() {
@@ -109,6 +115,7 @@ func unitTestsValidateCreate() {
 		Entry("should allow valid", createArgs{}, true, nil, nil),
 		Entry("should allow no cpu limit", createArgs{noCPULimit: true}, true, nil, nil),
 		Entry("should allow no memory limit", createArgs{noMemoryLimit: true}, true, nil, nil),
+		Entry("should allow no limits with a warning", createArgs{noCPULimit: true, noMemoryLimit: true}, true, nil, nil),
 		Entry("should deny invalid cpu reservation", createArgs{invalidCPURequest: true}, false,
 			field.Invalid(reservationsPath.Child("cpu"), "2Gi", detailMsg).Error(), nil),
 		Entry("should deny invalid memory reservation", createArgs{invalidMemoryRequest: true}, false,
@@ -8,8 +8,13 @@ import (
 	ctrlmgr "sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/vmware-tanzu/vm-operator/pkg/context"
+	"github.com/vmware-tanzu/vm-operator/webhooks/contentlibraryprovider"
+	"github.com/vmware-tanzu/vm-operator/webhooks/contentsource"
 	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachine"
 	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachineclass"
+	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachinedefaults"
+	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachineimage"
+	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachinereplicaset"
 	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachineservice"
 	"github.com/vmware-tanzu/vm-operator/webhooks/virtualmachinesetresourcepolicy"
 )
@@ -28,5 +33,20 @@ func AddToManager(ctx *context.ControllerManagerContext, mgr ctrlmgr.Manager) er
 	if err := virtualmachinesetresourcepolicy.AddToManager(ctx, mgr); err != nil {
 		return errors.Wrap(err, "failed to initialize VirtualMachineSetResourcePolicy webhooks")
 	}
+	if err := contentsource.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize ContentSource webhooks")
+	}
+	if err := contentlibraryprovider.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize ContentLibraryProvider webhooks")
+	}
+	if err := virtualmachineimage.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize VirtualMachineImage webhooks")
+	}
+	if err := virtualmachinedefaults.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize VirtualMachineDefaults webhooks")
+	}
+	if err := virtualmachinereplicaset.AddToManager(ctx, mgr); err != nil {
+		return errors.Wrap(err, "failed to initialize VirtualMachineReplicaSet webhooks")
+	}
 	return nil
 }